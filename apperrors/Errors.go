@@ -0,0 +1,113 @@
+// Package apperrors - the errors of the domain, which the repositories and services return for the failures
+// the clients can act on. Each has a kind that the controllers map to a status code and a stable code the
+// clients can rely on, the message is meant to be shown to them. Any other error is internal and its message
+// is never sent.
+package apperrors
+
+import "errors"
+
+// Kind - the class of a failure, it decides the status code of the response
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindNotFound
+	KindConflict
+	KindUnauthorized
+	KindForbidden
+	KindValidation
+	KindTooManyRequests
+	KindTooLarge
+)
+
+// FieldError - a field of the request that failed validation, by its JSON path, the rule it broke and a message
+// to show the users
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+// Error - a failure of the domain. Errors with the same kind and code are the same failure for errors.Is, so
+// a sentinel still matches once details or a cause are added to it.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+	// Err - cause of the failure, for the logs only
+	Err error
+}
+
+// Error - the message followed by the cause, which is only meant for the logs
+func (e *Error) Error() string {
+	if e.Err != nil {
+		return e.Message + ": " + e.Err.Error()
+	}
+	return e.Message
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Kind == e.Kind && t.Code == e.Code
+}
+
+// WithFields - a copy of the error with the fields that failed validation
+func (e *Error) WithFields(fields ...FieldError) *Error {
+	copied := *e
+	copied.Fields = append(append([]FieldError{}, e.Fields...), fields...)
+	return &copied
+}
+
+// Wrap - a copy of the error caused by err
+func (e *Error) Wrap(err error) *Error {
+	copied := *e
+	copied.Err = err
+	return &copied
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func NotFound(code, message string) *Error {
+	return New(KindNotFound, code, message)
+}
+
+func Conflict(code, message string) *Error {
+	return New(KindConflict, code, message)
+}
+
+func Unauthorized(code, message string) *Error {
+	return New(KindUnauthorized, code, message)
+}
+
+func Forbidden(code, message string) *Error {
+	return New(KindForbidden, code, message)
+}
+
+// Validation - the request is invalid, fields lists the fields at fault when known
+func Validation(code, message string, fields ...FieldError) *Error {
+	return New(KindValidation, code, message).WithFields(fields...)
+}
+
+// As - the domain error in the chain of err, nil for an internal error
+func As(err error) *Error {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr
+	}
+	return nil
+}
+
+// KindOf - the kind of err, KindInternal when it is not a domain error
+func KindOf(err error) Kind {
+	if domainErr := As(err); domainErr != nil {
+		return domainErr.Kind
+	}
+	return KindInternal
+}
@@ -0,0 +1,100 @@
+package apperrors
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestError_Is(t *testing.T) {
+	notFound := NotFound("note_not_found", "note not found")
+	tests := []struct {
+		name   string
+		err    error
+		target error
+		want   bool
+	}{
+		{
+			name:   "same error",
+			err:    notFound,
+			target: notFound,
+			want:   true,
+		},
+		{
+			name:   "wrapped with a cause",
+			err:    fmt.Errorf("deleting: %w", notFound.Wrap(errors.New("db error"))),
+			target: notFound,
+			want:   true,
+		},
+		{
+			name:   "with fields",
+			err:    notFound.WithFields(FieldError{Field: "id", Rule: "exists"}),
+			target: notFound,
+			want:   true,
+		},
+		{
+			name:   "other code",
+			err:    NotFound("user_not_found", "user not found"),
+			target: notFound,
+			want:   false,
+		},
+		{
+			name:   "other kind",
+			err:    Conflict("note_not_found", "note not found"),
+			target: notFound,
+			want:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := errors.Is(tt.err, tt.target); got != tt.want {
+				t.Errorf("errors.Is() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKindOf(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want Kind
+	}{
+		{
+			name: "domain error",
+			err:  Validation("invalid_request", "invalid request"),
+			want: KindValidation,
+		},
+		{
+			name: "wrapped domain error",
+			err:  fmt.Errorf("signing up: %w", Conflict("email_taken", "email address is already registered")),
+			want: KindConflict,
+		},
+		{
+			name: "internal error",
+			err:  errors.New("db error"),
+			want: KindInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := KindOf(tt.err); got != tt.want {
+				t.Errorf("KindOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestError_WithFields(t *testing.T) {
+	invalid := Validation("invalid_request", "invalid request")
+	withFields := invalid.WithFields(FieldError{Field: "email", Rule: "required"})
+	if len(invalid.Fields) != 0 {
+		t.Errorf("sentinel was modified, fields %v", invalid.Fields)
+	}
+	if len(withFields.Fields) != 1 || withFields.Fields[0].Field != "email" {
+		t.Errorf("fields = %v", withFields.Fields)
+	}
+	if got := invalid.Wrap(errors.New("EOF")).Error(); got != "invalid request: EOF" {
+		t.Errorf("Error() = %q", got)
+	}
+}
@@ -1,8 +1,44 @@
 package config
 
-import "github.com/spf13/viper"
+import (
+	"notes-server/constants"
 
+	"github.com/spf13/viper"
+)
+
+// Load - reads the configuration from the environment and the .env file of the working directory, over the
+// defaults
 func Load() {
+	viper.SetDefault(constants.JwtSigningAlgEnvKey, "EdDSA")
+	viper.SetDefault(constants.JwtKeyRotationIntervalEnvKey, "24h")
+	viper.SetDefault(constants.JwtKeyGracePeriodEnvKey, "1h")
+	viper.SetDefault(constants.ReminderPollIntervalEnvKey, "30s")
+	viper.SetDefault(constants.ReminderNotifiersEnvKey, "inapp")
+	viper.SetDefault(constants.SMTPPortEnvKey, 25)
+	viper.SetDefault(constants.AppBaseURLEnvKey, "http://localhost:8080")
+	viper.SetDefault(constants.MailerOutboxDirEnvKey, "outbox")
+	viper.SetDefault(constants.EmailVerificationTTLEnvKey, "24h")
+	viper.SetDefault(constants.PasswordResetTTLEnvKey, "1h")
+	viper.SetDefault(constants.WorkspaceInvitationTTLEnvKey, "168h")
+	viper.SetDefault(constants.MFAIssuerEnvKey, "Notes")
+	viper.SetDefault(constants.LoginBackoffAfterEnvKey, 3)
+	viper.SetDefault(constants.LoginBackoffBaseEnvKey, "1s")
+	viper.SetDefault(constants.LoginBackoffMaxEnvKey, "1m")
+	viper.SetDefault(constants.LoginLockoutAfterEnvKey, 10)
+	viper.SetDefault(constants.LoginLockoutDurationEnvKey, "15m")
+	viper.SetDefault(constants.LoginIPBackoffAfterEnvKey, 20)
+	viper.SetDefault(constants.LoginIPLockoutAfterEnvKey, 100)
+	viper.SetDefault(constants.LoginFailureWindowEnvKey, "1h")
+	viper.SetDefault(constants.AdminNameEnvKey, "Admin")
+	viper.SetDefault(constants.PasswordMinLengthEnvKey, 8)
+	viper.SetDefault(constants.PasswordForbidPersonalInfoEnvKey, true)
+	viper.SetDefault(constants.AuditRetentionEnvKey, "2160h")
+	viper.SetDefault(constants.AuditPurgeIntervalEnvKey, "1h")
+	viper.SetDefault(constants.QuotaMaxNoteSizeEnvKey, 256<<10)
+	viper.SetDefault(constants.QuotaMaxNotesEnvKey, 10000)
+	viper.SetDefault(constants.QuotaMaxStorageEnvKey, 100<<20)
+	// the variables of the environment take precedence over the ones of .env
+	viper.AutomaticEnv()
 	viper.SetConfigFile(".env")
 	viper.ReadInConfig()
 }
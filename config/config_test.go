@@ -0,0 +1,40 @@
+package config
+
+import (
+	"io/ioutil"
+	"notes-server/constants"
+	"os"
+	"testing"
+
+	"github.com/spf13/viper"
+)
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	env := "ADMIN_EMAIL=\"file@example.com\"\nADMIN_NAME=\"From File\"\n"
+	if err := ioutil.WriteFile(dir+"/.env", []byte(env), 0600); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd() error = %v", err)
+	}
+	if err = os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir() error = %v", err)
+	}
+	t.Cleanup(func() { os.Chdir(wd) })
+	t.Setenv(constants.AdminEmailEnvKey, "admin@example.com")
+	t.Setenv(constants.AdminPasswordEnvKey, "Initial-Admin-Password-1")
+
+	Load()
+	for key, want := range map[string]string{
+		constants.AdminEmailEnvKey:    "admin@example.com",
+		constants.AdminPasswordEnvKey: "Initial-Admin-Password-1",
+		constants.AdminNameEnvKey:     "From File",
+		constants.MFAIssuerEnvKey:     "Notes",
+	} {
+		if got := viper.GetString(key); got != want {
+			t.Errorf("viper.GetString(%s) = %q, want %q", key, got, want)
+		}
+	}
+}
@@ -1,15 +1,148 @@
 package constants
 
 const (
+	// JwtSecretEnvKey - shared secret of at least 32 bytes, signs the session tokens with HS256 and the email
+	// verification, password reset and two-factor challenge tokens whatever the algorithm
 	JwtSecretEnvKey = "JWT_SECRET"
+	// JwtSigningAlgEnvKey - HS256, RS256 or EdDSA
+	JwtSigningAlgEnvKey = "JWT_SIGNING_ALG"
+	// JwtSigningKeyFileEnvKey - PEM file with the private keys for RS256 or EdDSA, the current one first. Keys
+	// are generated in memory when it is not set, which only works for a single instance.
+	JwtSigningKeyFileEnvKey = "JWT_SIGNING_KEY_FILE"
+	// JwtKeyRotationIntervalEnvKey - a new signing key is generated this often, 0 disables the rotation. The keys
+	// of JWT_SIGNING_KEY_FILE are never rotated.
+	JwtKeyRotationIntervalEnvKey = "JWT_KEY_ROTATION_INTERVAL"
+	// JwtKeyGracePeriodEnvKey - a rotated key keeps verifying the tokens it signed this long
+	JwtKeyGracePeriodEnvKey = "JWT_KEY_GRACE_PERIOD"
+)
+
+const (
+	ReminderPollIntervalEnvKey = "REMINDER_POLL_INTERVAL"
+	ReminderNotifiersEnvKey    = "REMINDER_NOTIFIERS"
+	ReminderWebhookURLEnvKey   = "REMINDER_WEBHOOK_URL"
+	SMTPHostEnvKey             = "SMTP_HOST"
+	SMTPPortEnvKey             = "SMTP_PORT"
+	SMTPUsernameEnvKey         = "SMTP_USERNAME"
+	SMTPPasswordEnvKey         = "SMTP_PASSWORD"
+	SMTPFromEnvKey             = "SMTP_FROM"
+)
+
+const (
+	// AppBaseURLEnvKey - URL the service is reached at, used in the links sent by email
+	AppBaseURLEnvKey = "APP_BASE_URL"
+	// MailerEnvKey - smtp to send emails through the SMTP_* server, outbox to write them to MAILER_OUTBOX_DIR.
+	// Defaults to smtp when SMTP_HOST is set.
+	MailerEnvKey               = "MAILER"
+	MailerOutboxDirEnvKey      = "MAILER_OUTBOX_DIR"
+	EmailVerificationTTLEnvKey = "EMAIL_VERIFICATION_TTL"
+	PasswordResetTTLEnvKey     = "PASSWORD_RESET_TTL"
+	// WorkspaceInvitationTTLEnvKey - how long an invitation to join a workspace can be accepted
+	WorkspaceInvitationTTLEnvKey = "WORKSPACE_INVITATION_TTL"
+	// MFAIssuerEnvKey - name the authenticator apps show next to the TOTP codes
+	MFAIssuerEnvKey = "MFA_ISSUER"
+)
+
+const (
+	// OIDCIssuerEnvKey - issuer URL of the OpenID Connect provider the users can sign in with, signing in with a
+	// provider is disabled when it is not set
+	OIDCIssuerEnvKey       = "OIDC_ISSUER"
+	OIDCClientIDEnvKey     = "OIDC_CLIENT_ID"
+	OIDCClientSecretEnvKey = "OIDC_CLIENT_SECRET"
+	// OIDCRedirectURLEnvKey - defaults to the callback route under APP_BASE_URL
+	OIDCRedirectURLEnvKey = "OIDC_REDIRECT_URL"
+)
+
+const (
+	// LoginBackoffAfterEnvKey - failed logins of an account before each further one delays the next attempt,
+	// starting at LOGIN_BACKOFF_BASE and doubling up to LOGIN_BACKOFF_MAX
+	LoginBackoffAfterEnvKey = "LOGIN_BACKOFF_AFTER"
+	LoginBackoffBaseEnvKey  = "LOGIN_BACKOFF_BASE"
+	LoginBackoffMaxEnvKey   = "LOGIN_BACKOFF_MAX"
+	// LoginLockoutAfterEnvKey - failed logins of an account that lock it out for LOGIN_LOCKOUT_DURATION
+	LoginLockoutAfterEnvKey    = "LOGIN_LOCKOUT_AFTER"
+	LoginLockoutDurationEnvKey = "LOGIN_LOCKOUT_DURATION"
+	// LoginIPBackoffAfterEnvKey, LoginIPLockoutAfterEnvKey - the same for the failed logins from a client IP,
+	// which can be shared by many users
+	LoginIPBackoffAfterEnvKey = "LOGIN_IP_BACKOFF_AFTER"
+	LoginIPLockoutAfterEnvKey = "LOGIN_IP_LOCKOUT_AFTER"
+	// LoginFailureWindowEnvKey - failed logins are forgotten once there was none for this long
+	LoginFailureWindowEnvKey = "LOGIN_FAILURE_WINDOW"
+	// TrustedProxiesEnvKey - comma separated addresses of the reverse proxies whose X-Forwarded-For header gives
+	// the address of the client
+	TrustedProxiesEnvKey = "TRUSTED_PROXIES"
+)
+
+const (
+	// PasswordMinLengthEnvKey - characters a new password must have at least
+	PasswordMinLengthEnvKey = "PASSWORD_MIN_LENGTH"
+	// PasswordRequiredClassesEnvKey - comma separated classes of characters a new password must contain, any of
+	// lower, upper, digit and symbol
+	PasswordRequiredClassesEnvKey = "PASSWORD_REQUIRED_CLASSES"
+	// PasswordForbidPersonalInfoEnvKey - whether a new password can not contain the email address or the name of
+	// the user
+	PasswordForbidPersonalInfoEnvKey = "PASSWORD_FORBID_PERSONAL_INFO"
+	// PasswordBreachedDirEnvKey - directory of the SHA-1 hashes of breached passwords split in files by prefix, the
+	// passwords are not checked against breaches when it is not set
+	PasswordBreachedDirEnvKey = "PASSWORD_BREACHED_DIR"
+)
+
+const (
+	// AuditRetentionEnvKey - the audit log entries are deleted once they are older than this, 0 keeps them forever
+	AuditRetentionEnvKey = "AUDIT_RETENTION"
+	// AuditPurgeIntervalEnvKey - how often the entries older than the retention are deleted
+	AuditPurgeIntervalEnvKey = "AUDIT_PURGE_INTERVAL"
+)
+
+const (
+	QuotaMaxNoteSizeEnvKey = "QUOTA_MAX_NOTE_SIZE"
+	QuotaMaxNotesEnvKey    = "QUOTA_MAX_NOTES"
+	QuotaMaxStorageEnvKey  = "QUOTA_MAX_STORAGE"
+)
+
+const (
+	// EncryptionMasterKeyEnvKey - current master key, as <id>:<base64 key>
+	EncryptionMasterKeyEnvKey = "ENCRYPTION_MASTER_KEY"
+	// EncryptionMasterKeyFileEnvKey - file with one master key per line, the current one first, read instead
+	// of ENCRYPTION_MASTER_KEY
+	EncryptionMasterKeyFileEnvKey = "ENCRYPTION_MASTER_KEY_FILE"
+	// EncryptionPreviousMasterKeysEnvKey - comma separated master keys that were rotated out
+	EncryptionPreviousMasterKeysEnvKey = "ENCRYPTION_PREVIOUS_MASTER_KEYS"
+)
+
+const (
+	// AdminEmailEnvKey, AdminPasswordEnvKey - the admin created at startup when there is none, they must change
+	// the password on first login. Without them a one-time setup token is printed instead.
+	AdminEmailEnvKey    = "ADMIN_EMAIL"
+	AdminPasswordEnvKey = "ADMIN_PASSWORD"
+	AdminNameEnvKey     = "ADMIN_NAME"
 )
 
 const (
 	RequestIDKey = "X-Request-Id"
 	EmailKey     = "Email"
+	NameKey      = "Name"
 )
 
 type ContextKey string
 
 var RequestIDCtxKey = ContextKey("X-Request-Id")
 var EmailCtxKey = ContextKey("Email")
+var NameCtxKey = ContextKey("Name")
+
+// ScopesCtxKey - scopes of the personal access token a request was authenticated with, not set for sessions
+var ScopesCtxKey = ContextKey("Scopes")
+
+// RoleCtxKey - role of the user a request was authenticated for
+var RoleCtxKey = ContextKey("Role")
+
+// PasswordChangeRequiredCtxKey - set when the user a request was authenticated for must change their password
+var PasswordChangeRequiredCtxKey = ContextKey("PasswordChangeRequired")
+
+// WorkspaceCtxKey - workspace a request operates in, the one of the session or of the personal access token
+var WorkspaceCtxKey = ContextKey("Workspace")
+
+// ClientIPCtxKey - address of the client a request comes from
+var ClientIPCtxKey = ContextKey("ClientIP")
+
+// UserAgentCtxKey - User-Agent header of the request, kept in the audit log
+var UserAgentCtxKey = ContextKey("UserAgent")
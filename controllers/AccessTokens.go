@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+func (c *AccessTokensController) GetAccessTokens(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response, err := c.service.GetAccessTokens(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetAccessTokens()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *AccessTokensController) CreateAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.CreateAccessTokenRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.CreateAccessToken(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.CreateAccessToken()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusCreated, response)
+}
+
+func (c *AccessTokensController) RevokeAccessToken(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.RevokeAccessTokenRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.RevokeAccessToken(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.RevokeAccessToken()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "access token revoked")
+}
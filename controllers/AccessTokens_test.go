@@ -0,0 +1,140 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAccessTokensController_CreateAccessToken(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockIAccessTokensService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"name":"cli","scopes":["notes:read"]}`),
+			},
+			given: func(s *interfaces.MockIAccessTokensService) {
+				s.EXPECT().CreateAccessToken(mock.Anything, mock.Anything).Return(models.CreateAccessTokenResponse{Token: "pat_token"}, nil)
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "failure case - unknown scope",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"name":"cli","scopes":["keys:write"]}`),
+			},
+			given: func(s *interfaces.MockIAccessTokensService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - expiry in the past",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"name":"cli","scopes":["notes:read"],"expires_at":"2001-01-01T00:00:00Z"}`),
+			},
+			given: func(s *interfaces.MockIAccessTokensService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.CreateAccessToken()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"name":"cli","scopes":["notes:write"]}`),
+			},
+			given: func(s *interfaces.MockIAccessTokensService) {
+				s.EXPECT().CreateAccessToken(mock.Anything, mock.Anything).Return(models.CreateAccessTokenResponse{}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIAccessTokensService{}
+			tt.given(&mockService)
+			c := &AccessTokensController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.CreateAccessToken(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestAccessTokensController_RevokeAccessToken(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockIAccessTokensService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":7}`),
+			},
+			given: func(s *interfaces.MockIAccessTokensService) {
+				s.EXPECT().RevokeAccessToken(mock.Anything, models.RevokeAccessTokenRequest{Id: 7}).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - id missing",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{}`),
+			},
+			given: func(s *interfaces.MockIAccessTokensService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.RevokeAccessToken()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":7}`),
+			},
+			given: func(s *interfaces.MockIAccessTokensService) {
+				s.EXPECT().RevokeAccessToken(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIAccessTokensService{}
+			tt.given(&mockService)
+			c := &AccessTokensController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.RevokeAccessToken(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
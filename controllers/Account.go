@@ -0,0 +1,192 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+func (c *AccountController) GetAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response, err := c.service.GetAccount(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetAccount()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *AccountController) UpdateAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.UpdateAccountRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.UpdateAccount(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.UpdateAccount()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+// ConfirmEmailChange - GET route opened from the link sent to the new address, the token is in the query
+func (c *AccountController) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	request := models.VerifyEmailRequest{Token: r.URL.Query().Get("token")}
+	if request.Token == "" {
+		c.logger.Warn(ctx, "invalid request, token missing")
+		utils.WriteHttpError(w, models.ErrInvalidVerificationLink)
+		return
+	}
+	err := c.service.ConfirmEmailChange(ctx, request)
+	if errors.Is(err, models.ErrEmailTaken) {
+		c.logger.Warn(ctx, "error in c.service.ConfirmEmailChange()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ConfirmEmailChange()", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, models.ErrInvalidVerificationLink)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "email address changed, sign in with the new address")
+}
+
+// ChangePassword - responds with a new session, the ones issued before are revoked
+func (c *AccountController) ChangePassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ChangePasswordRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.ChangePassword(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ChangePassword()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *AccountController) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.DeleteAccountRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.DeleteAccount(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.DeleteAccount()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "account deleted")
+}
+
+// EnrollMFA - returns the TOTP secret to add to an authenticator app, a code of the current app is required when
+// two-factor authentication is already enabled
+func (c *AccountController) EnrollMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.EnrollMFARequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.EnrollMFA(ctx, request)
+	if errors.Is(err, models.ErrTooManyLoginAttempts) {
+		c.logger.Warn(ctx, "error in c.service.EnrollMFA()", err)
+		writeTooManyLoginAttempts(w, err)
+		return
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.EnrollMFA()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+// ConfirmMFA - enables two-factor authentication and responds with the recovery codes
+func (c *AccountController) ConfirmMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.MFACodeRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.ConfirmMFA(ctx, request)
+	if errors.Is(err, models.ErrTooManyLoginAttempts) {
+		c.logger.Warn(ctx, "error in c.service.ConfirmMFA()", err)
+		writeTooManyLoginAttempts(w, err)
+		return
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ConfirmMFA()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *AccountController) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.MFACodeRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.RegenerateRecoveryCodes(ctx, request)
+	if errors.Is(err, models.ErrTooManyLoginAttempts) {
+		c.logger.Warn(ctx, "error in c.service.RegenerateRecoveryCodes()", err)
+		writeTooManyLoginAttempts(w, err)
+		return
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.RegenerateRecoveryCodes()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *AccountController) DisableMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.DisableMFARequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.DisableMFA(ctx, request)
+	if errors.Is(err, models.ErrTooManyLoginAttempts) {
+		c.logger.Warn(ctx, "error in c.service.DisableMFA()", err)
+		writeTooManyLoginAttempts(w, err)
+		return
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.DisableMFA()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "two-factor authentication disabled")
+}
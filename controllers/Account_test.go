@@ -0,0 +1,271 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAccountController_UpdateAccount(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockIAccountService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"name":"renamed", "new_email":"new@gmail.com"}`,
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().UpdateAccount(mock.Anything, models.UpdateAccountRequest{Name: "renamed", NewEmail: "new@gmail.com"}).Return(models.Account{}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - invalid email",
+			body:  `{"new_email":"not an email"}`,
+			given: func(s *interfaces.MockIAccountService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - email taken",
+			body: `{"new_email":"new@gmail.com"}`,
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().UpdateAccount(mock.Anything, mock.Anything).Return(models.Account{}, models.ErrEmailTaken)
+			},
+			want: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIAccountService{}
+			tt.given(&mockService)
+			c := &AccountController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.UpdateAccount(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestAccountController_ConfirmEmailChange(t *testing.T) {
+	tests := []struct {
+		name   string
+		target string
+		given  func(*interfaces.MockIAccountService)
+		want   int
+	}{
+		{
+			name:   "success case",
+			target: "/v1/api/account/verify-email?token=abc",
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().ConfirmEmailChange(mock.Anything, models.VerifyEmailRequest{Token: "abc"}).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:   "failure case - token missing",
+			target: "/v1/api/account/verify-email",
+			given:  func(s *interfaces.MockIAccountService) {},
+			want:   http.StatusBadRequest,
+		},
+		{
+			name:   "failure case - invalid token",
+			target: "/v1/api/account/verify-email?token=abc",
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().ConfirmEmailChange(mock.Anything, mock.Anything).Return(errors.New("token is expired"))
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name:   "failure case - email taken",
+			target: "/v1/api/account/verify-email?token=abc",
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().ConfirmEmailChange(mock.Anything, mock.Anything).Return(models.ErrEmailTaken)
+			},
+			want: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIAccountService{}
+			tt.given(&mockService)
+			c := &AccountController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.ConfirmEmailChange(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestAccountController_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockIAccountService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"current_password":"old", "new_password":"new password"}`,
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().ChangePassword(mock.Anything, models.ChangePasswordRequest{CurrentPassword: "old", NewPassword: "new password"}).Return(models.LoginResponse{SID: "sid"}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - missing current password",
+			body:  `{"new_password":"new password"}`,
+			given: func(s *interfaces.MockIAccountService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "failure case - new password only spaces",
+			body:  `{"current_password":"old", "new_password":"        "}`,
+			given: func(s *interfaces.MockIAccountService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - wrong password",
+			body: `{"current_password":"old", "new_password":"new password"}`,
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().ChangePassword(mock.Anything, mock.Anything).Return(models.LoginResponse{}, models.ErrWrongPassword)
+			},
+			want: http.StatusForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIAccountService{}
+			tt.given(&mockService)
+			c := &AccountController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.ChangePassword(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestAccountController_DeleteAccount(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockIAccountService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"password":"password"}`,
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().DeleteAccount(mock.Anything, models.DeleteAccountRequest{Password: "password"}).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - missing password",
+			body:  `{}`,
+			given: func(s *interfaces.MockIAccountService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.DeleteAccount()",
+			body: `{"password":"password"}`,
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().DeleteAccount(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIAccountService{}
+			tt.given(&mockService)
+			c := &AccountController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.DeleteAccount(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestAccountController_DisableMFA(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockIAccountService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"password":"password", "code":"123456"}`,
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().DisableMFA(mock.Anything, models.DisableMFARequest{Password: "password", Code: "123456"}).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - missing code",
+			body:  `{"password":"password"}`,
+			given: func(s *interfaces.MockIAccountService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - invalid code",
+			body: `{"password":"password", "code":"123456"}`,
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().DisableMFA(mock.Anything, mock.Anything).Return(models.ErrInvalidMFACode)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "failure case - too many wrong codes",
+			body: `{"password":"password", "code":"123456"}`,
+			given: func(s *interfaces.MockIAccountService) {
+				s.EXPECT().DisableMFA(mock.Anything, mock.Anything).Return(&models.LoginThrottledError{RetryAt: time.Now().Add(time.Minute)})
+			},
+			want: http.StatusTooManyRequests,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIAccountService{}
+			tt.given(&mockService)
+			c := &AccountController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.DisableMFA(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
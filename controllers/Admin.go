@@ -0,0 +1,98 @@
+package controllers
+
+import (
+	"net/http"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+func (c *AdminController) ListUsers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ListUsersRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.ListUsers(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ListUsers()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *AdminController) DisableUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ManageUserRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.DisableUser(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.DisableUser()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *AdminController) EnableUser(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ManageUserRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.EnableUser(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.EnableUser()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *AdminController) ForcePasswordReset(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ManageUserRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.ForcePasswordReset(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ForcePasswordReset()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "password removed and a reset code sent to the user")
+}
+
+// Setup - creates the first admin with the setup token printed at startup, they log in afterwards
+func (c *AdminController) Setup(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.SetupRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.Setup(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.Setup()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusCreated, "admin created")
+}
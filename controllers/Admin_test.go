@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAdminController_DisableUser(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockIAdminService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockIAdminService) {
+				s.EXPECT().DisableUser(mock.Anything, models.ManageUserRequest{Email: "test@gmail.com"}).Return(models.UserSummary{}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - invalid email",
+			body:  `{"email":"test"}`,
+			given: func(s *interfaces.MockIAdminService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - unknown user",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockIAdminService) {
+				s.EXPECT().DisableUser(mock.Anything, mock.Anything).Return(models.UserSummary{}, models.ErrUserNotFound)
+			},
+			want: http.StatusNotFound,
+		},
+		{
+			name: "failure case - own account",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockIAdminService) {
+				s.EXPECT().DisableUser(mock.Anything, mock.Anything).Return(models.UserSummary{}, models.ErrManageOwnAccount)
+			},
+			want: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIAdminService{}
+			tt.given(&mockService)
+			c := &AdminController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.DisableUser(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,26 @@
+package controllers
+
+import (
+	"net/http"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+// GetAuditLog - the entries of the audit log matching the filters of the request, for the admins
+func (c *AuditController) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.GetAuditLogRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.GetAuditLog(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetAuditLog()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
@@ -0,0 +1,67 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestAuditController_GetAuditLog(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockIAuditService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"actor":"test@gmail.com","action":"note.deleted","limit":10}`,
+			given: func(s *interfaces.MockIAuditService) {
+				s.EXPECT().GetAuditLog(mock.Anything, models.GetAuditLogRequest{Actor: "test@gmail.com", Action: "note.deleted", Limit: 10}).
+					Return(models.GetAuditLogResponse{Entries: []models.AuditEntry{{Id: 1, Action: models.AuditNoteDeleted}}}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - limit too high",
+			body:  `{"limit":5000}`,
+			given: func(s *interfaces.MockIAuditService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name:  "failure case - unknown outcome",
+			body:  `{"outcome":"maybe"}`,
+			given: func(s *interfaces.MockIAuditService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.GetAuditLog()",
+			body: `{}`,
+			given: func(s *interfaces.MockIAuditService) {
+				s.EXPECT().GetAuditLog(mock.Anything, mock.Anything).Return(models.GetAuditLogResponse{}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIAuditService{}
+			tt.given(&mockService)
+			c := &AuditController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.GetAuditLog(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
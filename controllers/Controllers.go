@@ -3,6 +3,7 @@ package controllers
 import (
 	"notes-server/interfaces"
 	"notes-server/loggers"
+	"notes-server/signing"
 )
 
 type NotesController struct {
@@ -15,6 +16,51 @@ type LoginController struct {
 	logger  *loggers.Logger
 }
 
+type RemindersController struct {
+	service interfaces.IRemindersService
+	logger  *loggers.Logger
+}
+
+type TemplatesController struct {
+	service interfaces.ITemplatesService
+	logger  *loggers.Logger
+}
+
+type KeysController struct {
+	service interfaces.IKeysService
+	logger  *loggers.Logger
+}
+
+type AccountController struct {
+	service interfaces.IAccountService
+	logger  *loggers.Logger
+}
+
+type SigningKeysController struct {
+	keys   *signing.Keyring
+	logger *loggers.Logger
+}
+
+type AccessTokensController struct {
+	service interfaces.IAccessTokensService
+	logger  *loggers.Logger
+}
+
+type AdminController struct {
+	service interfaces.IAdminService
+	logger  *loggers.Logger
+}
+
+type AuditController struct {
+	service interfaces.IAuditService
+	logger  *loggers.Logger
+}
+
+type WorkspacesController struct {
+	service interfaces.IWorkspaceService
+	logger  *loggers.Logger
+}
+
 func NewLoginController(logger *loggers.Logger, service interfaces.ILoginService) LoginController {
 	return LoginController{
 		service: service,
@@ -28,3 +74,66 @@ func NewNotesController(logger *loggers.Logger, service interfaces.INotesService
 		logger:  logger,
 	}
 }
+
+func NewRemindersController(logger *loggers.Logger, service interfaces.IRemindersService) RemindersController {
+	return RemindersController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func NewTemplatesController(logger *loggers.Logger, service interfaces.ITemplatesService) TemplatesController {
+	return TemplatesController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func NewKeysController(logger *loggers.Logger, service interfaces.IKeysService) KeysController {
+	return KeysController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func NewAccountController(logger *loggers.Logger, service interfaces.IAccountService) AccountController {
+	return AccountController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func NewAccessTokensController(logger *loggers.Logger, service interfaces.IAccessTokensService) AccessTokensController {
+	return AccessTokensController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func NewAdminController(logger *loggers.Logger, service interfaces.IAdminService) AdminController {
+	return AdminController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func NewAuditController(logger *loggers.Logger, service interfaces.IAuditService) AuditController {
+	return AuditController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func NewWorkspacesController(logger *loggers.Logger, service interfaces.IWorkspaceService) WorkspacesController {
+	return WorkspacesController{
+		service: service,
+		logger:  logger,
+	}
+}
+
+func NewSigningKeysController(logger *loggers.Logger, keys *signing.Keyring) SigningKeysController {
+	return SigningKeysController{
+		keys:   keys,
+		logger: logger,
+	}
+}
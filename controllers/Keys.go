@@ -0,0 +1,36 @@
+package controllers
+
+import (
+	"net/http"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+func (c *KeysController) GetKeyMaterial(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response, err := c.service.GetKeyMaterial(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetKeyMaterial()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *KeysController) SetKeyMaterial(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.KeyMaterial
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.SetKeyMaterial(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.SetKeyMaterial()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
@@ -0,0 +1,133 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestKeysController_GetKeyMaterial(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockIKeysService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(``),
+			},
+			given: func(s *interfaces.MockIKeysService) {
+				s.EXPECT().GetKeyMaterial(mock.Anything).Return(models.KeyMaterial{Version: 1}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - error in service.GetKeyMaterial()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(``),
+			},
+			given: func(s *interfaces.MockIKeysService) {
+				s.EXPECT().GetKeyMaterial(mock.Anything).Return(models.KeyMaterial{}, errors.New("no key material stored"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIKeysService{}
+			tt.given(&mockService)
+			c := &KeysController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.GetKeyMaterial(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestKeysController_SetKeyMaterial(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockIKeysService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"scheme":"pbkdf2-sha256+aes-256-gcm","salt":"AAECAwQFBgcICQoLDA0ODw==","iterations":600000,"nonce":"AAECAwQFBgcICQoL","wrapped_key":"AAECAwQFBgcICQoLDA0ODw=="}`),
+			},
+			given: func(s *interfaces.MockIKeysService) {
+				s.EXPECT().SetKeyMaterial(mock.Anything, mock.Anything).Return(models.SetKeyMaterialResponse{Version: 1}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"scheme": pbkdf2"}`),
+			},
+			given: func(s *interfaces.MockIKeysService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - too few iterations",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"scheme":"pbkdf2-sha256+aes-256-gcm","salt":"AAECAwQFBgcICQoLDA0ODw==","iterations":1000,"nonce":"AAECAwQFBgcICQoL","wrapped_key":"AAECAwQFBgcICQoLDA0ODw=="}`),
+			},
+			given: func(s *interfaces.MockIKeysService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.SetKeyMaterial()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"scheme":"pbkdf2-sha256+aes-256-gcm","salt":"AAECAwQFBgcICQoLDA0ODw==","iterations":600000,"nonce":"AAECAwQFBgcICQoL","wrapped_key":"AAECAwQFBgcICQoLDA0ODw=="}`),
+			},
+			given: func(s *interfaces.MockIKeysService) {
+				s.EXPECT().SetKeyMaterial(mock.Anything, mock.Anything).Return(models.SetKeyMaterialResponse{Version: 1}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIKeysService{}
+			tt.given(&mockService)
+			c := &KeysController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.SetKeyMaterial(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -1,9 +1,19 @@
 package controllers
 
 import (
+	"crypto/subtle"
+	"errors"
+	"math"
 	"net/http"
+	"notes-server/apperrors"
+	"notes-server/constants"
 	"notes-server/models"
 	"notes-server/utils"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
 )
 
 func (c *LoginController) Login(w http.ResponseWriter, r *http.Request) {
@@ -16,14 +26,62 @@ func (c *LoginController) Login(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	response, err := c.service.Login(ctx, request)
+	if errors.Is(err, models.ErrTooManyLoginAttempts) {
+		c.logger.Warn(ctx, "error in c.service.Login()", err)
+		writeTooManyLoginAttempts(w, err)
+		return
+	}
 	if err != nil {
 		c.logger.Warn(ctx, "error in c.service.Login()", err)
-		utils.WriteHttpFailure(w, http.StatusInternalServerError, err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+// LoginMFA - second step of the login of a user with two-factor authentication
+func (c *LoginController) LoginMFA(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.LoginMFARequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.LoginMFA(ctx, request)
+	if errors.Is(err, models.ErrTooManyLoginAttempts) {
+		c.logger.Warn(ctx, "error in c.service.LoginMFA()", err)
+		writeTooManyLoginAttempts(w, err)
+		return
+	}
+	// a wrong code is a failed login here, while it only refuses a change to a signed in account
+	if errors.Is(err, models.ErrInvalidMFACode) {
+		c.logger.Warn(ctx, "error in c.service.LoginMFA()", err)
+		utils.WriteHttpFailure(w, http.StatusUnauthorized, err)
+		return
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.LoginMFA()", err)
+		utils.WriteHttpError(w, err)
 		return
 	}
 	utils.WriteHttpSuccess(w, http.StatusOK, response)
 }
 
+// writeTooManyLoginAttempts - 429 with the seconds before the next attempt is accepted in Retry-After
+func writeTooManyLoginAttempts(w http.ResponseWriter, err error) {
+	var throttled *models.LoginThrottledError
+	if errors.As(err, &throttled) {
+		seconds := int(math.Ceil(time.Until(throttled.RetryAt).Seconds()))
+		if seconds < 1 {
+			seconds = 1
+		}
+		w.Header().Set("Retry-After", strconv.Itoa(seconds))
+	}
+	utils.WriteHttpFailure(w, http.StatusTooManyRequests, models.ErrTooManyLoginAttempts)
+}
+
 func (c *LoginController) SignUp(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var request models.SignUpRequest
@@ -36,8 +94,151 @@ func (c *LoginController) SignUp(w http.ResponseWriter, r *http.Request) {
 	err = c.service.SignUp(ctx, request)
 	if err != nil {
 		c.logger.Warn(ctx, "error in c.service.SignUp()", err)
-		utils.WriteHttpFailure(w, http.StatusInternalServerError, err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusCreated, "user created, follow the link sent to your email address to verify it")
+}
+
+// VerifyEmail - GET route opened from the link of the verification email, the token is in the query
+func (c *LoginController) VerifyEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	request := models.VerifyEmailRequest{Token: r.URL.Query().Get("token")}
+	if request.Token == "" {
+		c.logger.Warn(ctx, "invalid request, token missing")
+		utils.WriteHttpError(w, models.ErrInvalidVerificationLink)
+		return
+	}
+	err := c.service.VerifyEmail(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.VerifyEmail()", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, models.ErrInvalidVerificationLink)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "email address verified")
+}
+
+func (c *LoginController) ResendVerificationEmail(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ResendVerificationRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.ResendVerificationEmail(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ResendVerificationEmail()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "a verification email was sent if the address is registered and not verified yet")
+}
+
+// ForgotPassword - responds the same whether the address is registered or not
+func (c *LoginController) ForgotPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ForgotPasswordRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.ForgotPassword(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ForgotPassword()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "a password reset code was sent if the address is registered")
+}
+
+func (c *LoginController) ResetPassword(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ResetPasswordRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
 		return
 	}
-	utils.WriteHttpSuccess(w, http.StatusCreated, "user created")
+	// invalid_reset_token and weak_password are told apart, any other failure is a server error
+	err = c.service.ResetPassword(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ResetPassword()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "password reset, sign in with the new password")
+}
+
+// oidcStateCookie - holds the hash of the state of the sign in started by the browser, the callback is only
+// accepted from the same browser so that nobody can sign a victim in to the account of the attacker
+const oidcStateCookie = "oidc_state"
+
+// StartOIDCLogin - returns the URL of the identity provider the user signs in at
+func (c *LoginController) StartOIDCLogin(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response, err := c.service.StartOIDCLogin(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.StartOIDCLogin()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	http.SetCookie(w, newOIDCStateCookie(utils.HashToken(response.State), int(models.OIDCLoginTTL/time.Second)))
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+// OIDCCallback - GET route the identity provider redirects back to, the code and state are in the query
+func (c *LoginController) OIDCCallback(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	query := r.URL.Query()
+	if providerError := query.Get("error"); providerError != "" {
+		// the error is only logged, it comes from the query and is not echoed back
+		c.logger.Warn(ctx, "error in OIDCCallback(), identity provider returned", providerError)
+		utils.WriteHttpError(w, models.ErrOIDCLoginFailed)
+		return
+	}
+	request := models.OIDCCallbackRequest{Code: query.Get("code"), State: query.Get("state")}
+	if request.Code == "" || request.State == "" {
+		c.logger.Warn(ctx, "invalid request, code or state missing")
+		utils.WriteHttpError(w, models.ErrInvalidOIDCCallback)
+		return
+	}
+	cookie, err := r.Cookie(oidcStateCookie)
+	http.SetCookie(w, newOIDCStateCookie("", -1))
+	if err != nil || subtle.ConstantTimeCompare([]byte(cookie.Value), []byte(utils.HashToken(request.State))) != 1 {
+		c.logger.Warn(ctx, "error in OIDCCallback(), state does not match the cookie of the browser")
+		utils.WriteHttpFailure(w, http.StatusUnauthorized, models.ErrOIDCLoginFailed)
+		return
+	}
+	response, err := c.service.FinishOIDCLogin(ctx, request)
+	// failures of the exchange with the identity provider are not told apart
+	if err != nil && apperrors.As(err) == nil {
+		c.logger.Warn(ctx, "error in c.service.FinishOIDCLogin()", err)
+		utils.WriteHttpFailure(w, http.StatusUnauthorized, models.ErrOIDCLoginFailed)
+		return
+	}
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.FinishOIDCLogin()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+// newOIDCStateCookie - the cookie is sent back on the redirect from the identity provider, a top level navigation,
+// and only to the callback. A maxAge below 0 deletes it.
+func newOIDCStateCookie(value string, maxAge int) *http.Cookie {
+	return &http.Cookie{
+		Name:     oidcStateCookie,
+		Value:    value,
+		Path:     "/v1/api/oidc/callback",
+		MaxAge:   maxAge,
+		HttpOnly: true,
+		Secure:   strings.HasPrefix(viper.GetString(constants.AppBaseURLEnvKey), "https://"),
+		SameSite: http.SameSiteLaxMode,
+	}
 }
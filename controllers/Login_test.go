@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"io"
 	"net/http"
@@ -9,7 +10,11 @@ import (
 	"notes-server/interfaces"
 	"notes-server/loggers"
 	"notes-server/models"
+	"notes-server/passwords"
+	"notes-server/utils"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -24,6 +29,10 @@ func TestLoginController_Login(t *testing.T) {
 		given func(*interfaces.MockILoginService)
 		args  args
 		want  int
+		// wantRetryAfter - seconds in the Retry-After header
+		wantRetryAfter string
+		// wantCode - error code in the response
+		wantCode string
 	}{
 		{
 			name: "success case",
@@ -46,7 +55,8 @@ func TestLoginController_Login(t *testing.T) {
 			},
 			given: func(s *interfaces.MockILoginService) {
 			},
-			want: http.StatusBadRequest,
+			want:     http.StatusBadRequest,
+			wantCode: "malformed_request",
 		},
 		{
 			name: "failure case - missing email",
@@ -56,7 +66,8 @@ func TestLoginController_Login(t *testing.T) {
 			},
 			given: func(s *interfaces.MockILoginService) {
 			},
-			want: http.StatusBadRequest,
+			want:     http.StatusBadRequest,
+			wantCode: "invalid_request",
 		},
 		{
 			name: "failure case - missing password",
@@ -77,7 +88,45 @@ func TestLoginController_Login(t *testing.T) {
 			given: func(s *interfaces.MockILoginService) {
 				s.EXPECT().Login(mock.Anything, mock.Anything).Return(models.LoginResponse{}, errors.New("db errpr"))
 			},
-			want: http.StatusInternalServerError,
+			want:     http.StatusInternalServerError,
+			wantCode: "internal_error",
+		},
+		{
+			name: "failure case - email address not verified",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"email":"test@gmail.com", "password":"testpassword"}`),
+			},
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().Login(mock.Anything, mock.Anything).Return(models.LoginResponse{}, models.ErrEmailNotVerified)
+			},
+			want:     http.StatusForbidden,
+			wantCode: "email_not_verified",
+		},
+		{
+			name: "failure case - invalid credentials",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"email":"test@gmail.com", "password":"testpassword"}`),
+			},
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().Login(mock.Anything, mock.Anything).Return(models.LoginResponse{}, models.ErrInvalidCredentials)
+			},
+			want:     http.StatusUnauthorized,
+			wantCode: "invalid_credentials",
+		},
+		{
+			name: "failure case - too many failed attempts",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"email":"test@gmail.com", "password":"testpassword"}`),
+			},
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().Login(mock.Anything, mock.Anything).Return(models.LoginResponse{}, &models.LoginThrottledError{RetryAt: time.Now().Add(90 * time.Second)})
+			},
+			want:           http.StatusTooManyRequests,
+			wantRetryAfter: "90",
+			wantCode:       "too_many_login_attempts",
 		},
 	}
 	for _, tt := range tests {
@@ -89,13 +138,34 @@ func TestLoginController_Login(t *testing.T) {
 				logger:  loggers.NewLogger(),
 			}
 			c.Login(tt.args.w, tt.args.r)
+			if got := tt.args.w.Result().Header.Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
 			if tt.args.w.Result().StatusCode != tt.want {
 				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
 			}
+			if tt.wantCode != "" {
+				checkErrorCode(t, tt.args.w, tt.wantCode)
+			}
 		})
 	}
 }
 
+// checkErrorCode - the response carries the error code, and only a generic description for a server error
+func checkErrorCode(t *testing.T, w *httptest.ResponseRecorder, wantCode string) {
+	t.Helper()
+	var response utils.Response
+	if err := json.Unmarshal(w.Body.Bytes(), &response); err != nil || response.Error == nil {
+		t.Fatalf("response %s has no error", w.Body.String())
+	}
+	if response.Error.Code != wantCode {
+		t.Errorf("error code = %q, want %q", response.Error.Code, wantCode)
+	}
+	if w.Code >= http.StatusInternalServerError && response.Error.Description != "internal server error" {
+		t.Errorf("server error description %q is sent", response.Error.Description)
+	}
+}
+
 func CreateReq(req string) *http.Request {
 	return &http.Request{
 		Method: http.MethodPost,
@@ -147,6 +217,16 @@ func TestLoginController_SignUp(t *testing.T) {
 			},
 			want: http.StatusBadRequest,
 		},
+		{
+			name: "failure case - malformed email",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"email":"test@", "password":"testpassword", "name":"test"}`),
+			},
+			given: func(s *interfaces.MockILoginService) {
+			},
+			want: http.StatusBadRequest,
+		},
 		{
 			name: "failure case - missing password",
 			args: args{
@@ -180,6 +260,17 @@ func TestLoginController_SignUp(t *testing.T) {
 			},
 			want: http.StatusInternalServerError,
 		},
+		{
+			name: "failure case - email taken",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"email":"test@gmail.com", "password":"testpassword", "name":"test"}`),
+			},
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().SignUp(mock.Anything, mock.Anything).Return(models.ErrEmailTaken)
+			},
+			want: http.StatusConflict,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -196,3 +287,382 @@ func TestLoginController_SignUp(t *testing.T) {
 		})
 	}
 }
+
+func TestLoginController_VerifyEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		given    func(*interfaces.MockILoginService)
+		want     int
+		wantCode string
+	}{
+		{
+			name:   "success case",
+			target: "/v1/api/verify-email?token=abc",
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().VerifyEmail(mock.Anything, models.VerifyEmailRequest{Token: "abc"}).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:     "failure case - token missing",
+			target:   "/v1/api/verify-email",
+			given:    func(s *interfaces.MockILoginService) {},
+			want:     http.StatusBadRequest,
+			wantCode: "invalid_verification_link",
+		},
+		{
+			name:   "failure case - invalid token",
+			target: "/v1/api/verify-email?token=abc",
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().VerifyEmail(mock.Anything, mock.Anything).Return(errors.New("token is expired"))
+			},
+			want:     http.StatusBadRequest,
+			wantCode: "invalid_verification_link",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockILoginService{}
+			tt.given(&mockService)
+			c := &LoginController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.VerifyEmail(w, httptest.NewRequest(http.MethodGet, tt.target, nil))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+			if tt.wantCode != "" {
+				checkErrorCode(t, w, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestLoginController_ResendVerificationEmail(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockILoginService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().ResendVerificationEmail(mock.Anything, models.ResendVerificationRequest{Email: "test@gmail.com"}).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - invalid email",
+			body:  `{"email":"not an email"}`,
+			given: func(s *interfaces.MockILoginService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.ResendVerificationEmail()",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().ResendVerificationEmail(mock.Anything, mock.Anything).Return(errors.New("smtp down"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockILoginService{}
+			tt.given(&mockService)
+			c := &LoginController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.ResendVerificationEmail(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestLoginController_ForgotPassword(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockILoginService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().ForgotPassword(mock.Anything, models.ForgotPasswordRequest{Email: "test@gmail.com"}).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - invalid email",
+			body:  `{"email":"not an email"}`,
+			given: func(s *interfaces.MockILoginService) {},
+			want:  http.StatusBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockILoginService{}
+			tt.given(&mockService)
+			c := &LoginController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.ForgotPassword(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestLoginController_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		given    func(*interfaces.MockILoginService)
+		want     int
+		wantCode string
+	}{
+		{
+			name: "success case",
+			body: `{"token":"abc", "password":"new password"}`,
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().ResetPassword(mock.Anything, models.ResetPasswordRequest{Token: "abc", Password: "new password"}).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - missing password",
+			body:  `{"token":"abc", "password":""}`,
+			given: func(s *interfaces.MockILoginService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - invalid token",
+			body: `{"token":"abc", "password":"new password"}`,
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().ResetPassword(mock.Anything, mock.Anything).Return(models.ErrInvalidResetToken)
+			},
+			want:     http.StatusBadRequest,
+			wantCode: "invalid_reset_token",
+		},
+		{
+			name: "failure case - error in service.ResetPassword()",
+			body: `{"token":"abc", "password":"new password"}`,
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().ResetPassword(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want:     http.StatusInternalServerError,
+			wantCode: "internal_error",
+		},
+		{
+			name: "failure case - weak password",
+			body: `{"token":"abc", "password":"new password"}`,
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().ResetPassword(mock.Anything, mock.Anything).Return(passwords.ErrWeakPassword)
+			},
+			want:     http.StatusBadRequest,
+			wantCode: "weak_password",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockILoginService{}
+			tt.given(&mockService)
+			c := &LoginController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.ResetPassword(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+			if tt.wantCode != "" {
+				checkErrorCode(t, w, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestLoginController_LoginMFA(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockILoginService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"mfa_token":"abc", "code":"123456"}`,
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().LoginMFA(mock.Anything, models.LoginMFARequest{MFAToken: "abc", Code: "123456"}).Return(models.LoginResponse{SID: "sid"}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - missing code",
+			body:  `{"mfa_token":"abc"}`,
+			given: func(s *interfaces.MockILoginService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - invalid code",
+			body: `{"mfa_token":"abc", "code":"123456"}`,
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().LoginMFA(mock.Anything, mock.Anything).Return(models.LoginResponse{}, models.ErrInvalidMFACode)
+			},
+			want: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockILoginService{}
+			tt.given(&mockService)
+			c := &LoginController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.LoginMFA(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestLoginController_StartOIDCLogin(t *testing.T) {
+	mockService := interfaces.MockILoginService{}
+	mockService.EXPECT().StartOIDCLogin(mock.Anything).Return(models.OIDCLoginResponse{AuthorizationURL: "https://idp.example.com/authorize", State: "xyz"}, nil)
+	c := &LoginController{
+		service: &mockService,
+		logger:  loggers.NewLogger(),
+	}
+	w := httptest.NewRecorder()
+	c.StartOIDCLogin(w, httptest.NewRequest(http.MethodPost, "/v1/api/oidc/login", nil))
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Result().StatusCode)
+	}
+	if strings.Contains(w.Body.String(), "xyz") {
+		t.Errorf("the state is in the body: %s", w.Body.String())
+	}
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 || cookies[0].Name != oidcStateCookie || cookies[0].Value != utils.HashToken("xyz") || !cookies[0].HttpOnly || cookies[0].SameSite != http.SameSiteLaxMode {
+		t.Errorf("cookies = %+v, want the HttpOnly SameSite=Lax state cookie", cookies)
+	}
+}
+
+func TestLoginController_OIDCCallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		target   string
+		cookie   string
+		given    func(*interfaces.MockILoginService)
+		want     int
+		wantCode string
+	}{
+		{
+			name:   "success case",
+			target: "/v1/api/oidc/callback?code=abc&state=xyz",
+			cookie: utils.HashToken("xyz"),
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().FinishOIDCLogin(mock.Anything, models.OIDCCallbackRequest{Code: "abc", State: "xyz"}).Return(models.LoginResponse{SID: "token"}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:   "failure case - no state cookie, the sign in was started by another browser",
+			target: "/v1/api/oidc/callback?code=abc&state=xyz",
+			given:  func(s *interfaces.MockILoginService) {},
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:   "failure case - state cookie of another sign in",
+			target: "/v1/api/oidc/callback?code=abc&state=xyz",
+			cookie: utils.HashToken("other"),
+			given:  func(s *interfaces.MockILoginService) {},
+			want:   http.StatusUnauthorized,
+		},
+		{
+			name:     "failure case - state missing",
+			target:   "/v1/api/oidc/callback?code=abc",
+			given:    func(s *interfaces.MockILoginService) {},
+			want:     http.StatusBadRequest,
+			wantCode: "invalid_oidc_callback",
+		},
+		{
+			name:     "failure case - sign in denied at the provider",
+			target:   "/v1/api/oidc/callback?error=access_denied_%3Cb%3Eechoed%3C%2Fb%3E&state=xyz",
+			given:    func(s *interfaces.MockILoginService) {},
+			want:     http.StatusUnauthorized,
+			wantCode: "oidc_login_failed",
+		},
+		{
+			name:   "failure case - not configured",
+			target: "/v1/api/oidc/callback?code=abc&state=xyz",
+			cookie: utils.HashToken("xyz"),
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().FinishOIDCLogin(mock.Anything, mock.Anything).Return(models.LoginResponse{}, models.ErrOIDCNotConfigured)
+			},
+			want: http.StatusNotFound,
+		},
+		{
+			name:   "failure case - email not verified by the provider",
+			target: "/v1/api/oidc/callback?code=abc&state=xyz",
+			cookie: utils.HashToken("xyz"),
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().FinishOIDCLogin(mock.Anything, mock.Anything).Return(models.LoginResponse{}, models.ErrEmailNotVerified)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name:   "failure case - invalid ID token",
+			target: "/v1/api/oidc/callback?code=abc&state=xyz",
+			cookie: utils.HashToken("xyz"),
+			given: func(s *interfaces.MockILoginService) {
+				s.EXPECT().FinishOIDCLogin(mock.Anything, mock.Anything).Return(models.LoginResponse{}, errors.New("ID token nonce does not match"))
+			},
+			want:     http.StatusUnauthorized,
+			wantCode: "oidc_login_failed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockILoginService{}
+			tt.given(&mockService)
+			c := &LoginController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodGet, tt.target, nil)
+			if tt.cookie != "" {
+				r.AddCookie(&http.Cookie{Name: oidcStateCookie, Value: tt.cookie})
+			}
+			c.OIDCCallback(w, r)
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+			if tt.wantCode != "" {
+				checkErrorCode(t, w, tt.wantCode)
+			}
+			// neither the query nor the message of a non-domain error is sent back
+			if body := w.Body.String(); strings.Contains(body, "echoed") || strings.Contains(body, "nonce") {
+				t.Errorf("response %s echoes the error", body)
+			}
+		})
+	}
+}
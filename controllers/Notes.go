@@ -8,10 +8,35 @@ import (
 
 func (c *NotesController) GetNotes(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	response, err := c.service.GetNotes(ctx)
+	var request models.GetNotesRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.GetNotes(ctx, request)
 	if err != nil {
 		c.logger.Warn(ctx, "error in c.service.Login()", err)
-		utils.WriteHttpFailure(w, http.StatusInternalServerError, err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *NotesController) SearchNotes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.SearchNotesRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.SearchNotes(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.SearchNotes()", err)
+		utils.WriteHttpError(w, err)
 		return
 	}
 	utils.WriteHttpSuccess(w, http.StatusOK, response)
@@ -29,12 +54,96 @@ func (c *NotesController) AddNote(w http.ResponseWriter, r *http.Request) {
 	reponse, err := c.service.AddNote(ctx, request)
 	if err != nil {
 		c.logger.Warn(ctx, "error in c.service.AddNote()", err)
-		utils.WriteHttpFailure(w, http.StatusInternalServerError, err)
+		utils.WriteHttpError(w, err)
 		return
 	}
 	utils.WriteHttpSuccess(w, http.StatusCreated, reponse)
 }
 
+func (c *NotesController) CreateNoteFromTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.CreateNoteFromTemplateRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.CreateNoteFromTemplate(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.CreateNoteFromTemplate()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusCreated, response)
+}
+
+func (c *NotesController) UpdateNote(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.UpdateNoteRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.UpdateNote(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.UpdateNote()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "succesfully updated")
+}
+
+func (c *NotesController) PinNote(w http.ResponseWriter, r *http.Request) {
+	c.setNoteFlag(w, r, models.NoteFlagPinned)
+}
+
+func (c *NotesController) ArchiveNote(w http.ResponseWriter, r *http.Request) {
+	c.setNoteFlag(w, r, models.NoteFlagArchived)
+}
+
+func (c *NotesController) StarNote(w http.ResponseWriter, r *http.Request) {
+	c.setNoteFlag(w, r, models.NoteFlagStarred)
+}
+
+func (c *NotesController) setNoteFlag(w http.ResponseWriter, r *http.Request, flag string) {
+	ctx := r.Context()
+	var request models.SetNoteFlagRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.SetNoteFlag(ctx, flag, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.SetNoteFlag()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "succesfully updated")
+}
+
+func (c *NotesController) SetNoteColor(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.SetNoteColorRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.SetNoteColor(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.SetNoteColor()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "succesfully updated")
+}
+
 func (c *NotesController) DeleteNote(w http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 	var request models.DeleteNoteRequest
@@ -47,8 +156,154 @@ func (c *NotesController) DeleteNote(w http.ResponseWriter, r *http.Request) {
 	err = c.service.DeleteNote(ctx, request)
 	if err != nil {
 		c.logger.Warn(ctx, "error in c.service.DeleteNote()", err)
-		utils.WriteHttpFailure(w, http.StatusInternalServerError, err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "succesfully deleted")
+}
+
+func (c *NotesController) GetNoteLinks(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.GetNoteLinksRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.GetNoteLinks(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetNoteLinks()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *NotesController) ExportNotes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/zip")
+	w.Header().Set("Content-Disposition", `attachment; filename="notes-export.zip"`)
+	stream := &streamWriter{w: w}
+	err := c.service.ExportNotes(ctx, stream)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ExportNotes()", err)
+		if !stream.started {
+			w.Header().Del("Content-Disposition")
+			utils.WriteHttpError(w, err)
+		}
+		return
+	}
+}
+
+func (c *NotesController) ImportNotes(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ImportNotesRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.ImportNotes(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ImportNotes()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusCreated, response)
+}
+
+func (c *NotesController) AddChecklistItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.AddChecklistItemRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.AddChecklistItem(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.AddChecklistItem()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusCreated, response)
+}
+
+func (c *NotesController) ReorderChecklistItems(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ReorderChecklistItemsRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.ReorderChecklistItems(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ReorderChecklistItems()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "succesfully reordered")
+}
+
+func (c *NotesController) ToggleChecklistItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.ToggleChecklistItemRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.ToggleChecklistItem(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.ToggleChecklistItem()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "succesfully updated")
+}
+
+func (c *NotesController) RemoveChecklistItem(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.RemoveChecklistItemRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.RemoveChecklistItem(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.RemoveChecklistItem()", err)
+		utils.WriteHttpError(w, err)
 		return
 	}
 	utils.WriteHttpSuccess(w, http.StatusOK, "succesfully deleted")
 }
+
+// streamWriter - remembers whether the response body has been started so errors can still be reported before that
+type streamWriter struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+func (s *streamWriter) Write(p []byte) (int, error) {
+	s.started = true
+	return s.w.Write(p)
+}
+
+func (c *NotesController) GetUsage(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response, err := c.service.GetUsage(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetUsage()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
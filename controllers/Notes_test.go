@@ -1,7 +1,9 @@
 package controllers
 
 import (
+	"context"
 	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"notes-server/interfaces"
@@ -27,10 +29,10 @@ func TestNotesController_GetNotes(t *testing.T) {
 			name: "success case",
 			args: args{
 				w: httptest.NewRecorder(),
-				r: CreateReq(``),
+				r: CreateReq(`{}`),
 			},
 			given: func(s *interfaces.MockINotesService) {
-				s.EXPECT().GetNotes(mock.Anything).Return([]models.Note{{
+				s.EXPECT().GetNotes(mock.Anything, mock.Anything).Return([]models.Note{{
 					Id:        1,
 					Note:      "test note",
 					CreatedBy: "test@gmail.com",
@@ -38,14 +40,35 @@ func TestNotesController_GetNotes(t *testing.T) {
 			},
 			want: http.StatusOK,
 		},
+		{
+			name: "success case - archived notes",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"archived":true}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().GetNotes(mock.Anything, models.GetNotesRequest{Archived: true}).Return([]models.Note{}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"starred":"yes"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
 		{
 			name: "failure case - error in service.GetNotes()",
 			args: args{
 				w: httptest.NewRecorder(),
-				r: CreateReq(``),
+				r: CreateReq(`{}`),
 			},
 			given: func(s *interfaces.MockINotesService) {
-				s.EXPECT().GetNotes(mock.Anything).Return([]models.Note{}, errors.New("db error"))
+				s.EXPECT().GetNotes(mock.Anything, mock.Anything).Return([]models.Note{}, errors.New("db error"))
 			},
 			want: http.StatusInternalServerError,
 		},
@@ -66,7 +89,7 @@ func TestNotesController_GetNotes(t *testing.T) {
 	}
 }
 
-func TestNotesController_AddNote(t *testing.T) {
+func TestNotesController_SearchNotes(t *testing.T) {
 	type args struct {
 		w *httptest.ResponseRecorder
 		r *http.Request
@@ -76,6 +99,82 @@ func TestNotesController_AddNote(t *testing.T) {
 		given func(*interfaces.MockINotesService)
 		args  args
 		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"query":"test"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().SearchNotes(mock.Anything, mock.Anything).Return([]models.Note{{
+					Id:        1,
+					Note:      "test note",
+					CreatedBy: "test@gmail.com",
+				}}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "success case - query",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"query":"milk"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().SearchNotes(mock.Anything, models.SearchNotesRequest{Query: "milk"}).Return([]models.Note{}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"query":""}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.SearchNotes()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"query":"test"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().SearchNotes(mock.Anything, mock.Anything).Return([]models.Note{}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.SearchNotes(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_AddNote(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name     string
+		given    func(*interfaces.MockINotesService)
+		args     args
+		want     int
+		wantCode string
 	}{
 		{
 			name: "success case",
@@ -123,6 +222,32 @@ func TestNotesController_AddNote(t *testing.T) {
 			},
 			want: http.StatusInternalServerError,
 		},
+		{
+			name: "failure case - note too large",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"note":"test note"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().AddNote(mock.Anything, mock.Anything).Return(models.AddNoteResponse{},
+					models.QuotaExceeded(models.ErrNoteTooLarge, 4))
+			},
+			want:     http.StatusRequestEntityTooLarge,
+			wantCode: "note_too_large",
+		},
+		{
+			name: "failure case - note limit reached",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"note":"test note"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().AddNote(mock.Anything, mock.Anything).Return(models.AddNoteResponse{},
+					models.QuotaExceeded(models.ErrNoteLimitReached, 10))
+			},
+			want:     http.StatusTooManyRequests,
+			wantCode: "note_limit_reached",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -136,6 +261,9 @@ func TestNotesController_AddNote(t *testing.T) {
 			if tt.args.w.Result().StatusCode != tt.want {
 				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
 			}
+			if tt.wantCode != "" {
+				checkErrorCode(t, tt.args.w, tt.wantCode)
+			}
 		})
 	}
 }
@@ -183,6 +311,17 @@ func TestNotesController_DeleteNote(t *testing.T) {
 			},
 			want: http.StatusInternalServerError,
 		},
+		{
+			name: "failure case - note not found",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().DeleteNote(mock.Anything, mock.Anything).Return(models.ErrNoteNotFound)
+			},
+			want: http.StatusNotFound,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -199,3 +338,746 @@ func TestNotesController_DeleteNote(t *testing.T) {
 		})
 	}
 }
+
+func TestNotesController_PinNote(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().SetNoteFlag(mock.Anything, models.NoteFlagPinned, mock.Anything).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":"123"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.SetNoteFlag()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().SetNoteFlag(mock.Anything, models.NoteFlagPinned, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.PinNote(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_SetNoteColor(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123,"color":"green"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().SetNoteColor(mock.Anything, mock.Anything).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123,"color":"pink"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.SetNoteColor()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123,"color":"green"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().SetNoteColor(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.SetNoteColor(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_CreateNoteFromTemplate(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"template_id":1,"variables":{"topic":"planning"}}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().CreateNoteFromTemplate(mock.Anything, mock.Anything).Return(models.AddNoteResponse{
+					Id: 123,
+				}, nil)
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"template_id": "1"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - template missing",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"variables":{"topic":"planning"}}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.CreateNoteFromTemplate()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"template_id":1,"variables":{"topic":"planning"}}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().CreateNoteFromTemplate(mock.Anything, mock.Anything).Return(models.AddNoteResponse{
+					Id: 123,
+				}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.CreateNoteFromTemplate(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_UpdateNote(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123,"title":"Groceries"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().UpdateNote(mock.Anything, mock.Anything).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":"123"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.UpdateNote()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123,"title":"Groceries"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().UpdateNote(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+		{
+			name: "failure case - invalid title",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123,"title":"[[Groceries]]"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().UpdateNote(mock.Anything, mock.Anything).Return(models.ErrInvalidTitle)
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - title taken",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123,"title":"Groceries"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().UpdateNote(mock.Anything, mock.Anything).Return(models.ErrNoteTitleTaken)
+			},
+			want: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.UpdateNote(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_GetNoteLinks(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().GetNoteLinks(mock.Anything, mock.Anything).Return(models.NoteLinksResponse{}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":"123"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.GetNoteLinks()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().GetNoteLinks(mock.Anything, mock.Anything).Return(models.NoteLinksResponse{}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.GetNoteLinks(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_ExportNotes(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(``),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().ExportNotes(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, w io.Writer) error {
+					_, err := w.Write([]byte("PK"))
+					return err
+				})
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - error in service.ExportNotes() before streaming",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(``),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().ExportNotes(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+		{
+			name: "failure case - error in service.ExportNotes() while streaming",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(``),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().ExportNotes(mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, w io.Writer) error {
+					w.Write([]byte("PK"))
+					return errors.New("db error")
+				})
+			},
+			want: http.StatusOK,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.ExportNotes(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_ImportNotes(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"archive":"UEsFBgAAAAAAAAAAAAAAAAAAAAAAAA=="}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().ImportNotes(mock.Anything, mock.Anything).Return(models.ImportNotesResponse{
+					Ids: []int32{123},
+				}, nil)
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "failure case - archive missing",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"archive":""}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.ImportNotes()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"archive":"UEsFBgAAAAAAAAAAAAAAAAAAAAAAAA=="}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().ImportNotes(mock.Anything, mock.Anything).Return(models.ImportNotesResponse{}, errors.New("invalid archive"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.ImportNotes(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_AddChecklistItem(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "text":"buy milk", "position":0}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().AddChecklistItem(mock.Anything, mock.Anything).Return(models.AddChecklistItemResponse{ItemId: 1}, nil)
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "failure case - text missing",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "text":""}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.AddChecklistItem()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "text":"buy milk"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().AddChecklistItem(mock.Anything, mock.Anything).Return(models.AddChecklistItemResponse{}, errors.New("note is not a checklist"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.AddChecklistItem(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_ReorderChecklistItems(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "item_ids":[2, 1]}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().ReorderChecklistItems(mock.Anything, mock.Anything).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - item_ids missing",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.ReorderChecklistItems()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "item_ids":[2, 1]}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().ReorderChecklistItems(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.ReorderChecklistItems(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_ToggleChecklistItem(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "item_id":1, "done":true}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().ToggleChecklistItem(mock.Anything, mock.Anything).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - item_id missing",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.ToggleChecklistItem()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "item_id":1}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().ToggleChecklistItem(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.ToggleChecklistItem(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_RemoveChecklistItem(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "item_id":1}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().RemoveChecklistItem(mock.Anything, mock.Anything).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "item_id":"1"}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.RemoveChecklistItem()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "item_id":1}`),
+			},
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().RemoveChecklistItem(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.RemoveChecklistItem(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestNotesController_GetUsage(t *testing.T) {
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockINotesService)
+		want  int
+	}{
+		{
+			name: "success case",
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().GetUsage(mock.Anything).Return(models.UsageResponse{Usage: models.Usage{Notes: 1, Bytes: 10}}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - error in service.GetUsage()",
+			given: func(s *interfaces.MockINotesService) {
+				s.EXPECT().GetUsage(mock.Anything).Return(models.UsageResponse{}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockINotesService{}
+			tt.given(&mockService)
+			c := &NotesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.GetUsage(w, CreateReq(`{}`))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+func (c *RemindersController) SetReminder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.SetReminderRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.SetReminder(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.SetReminder()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "reminder updated")
+}
+
+func (c *RemindersController) SnoozeReminder(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.SnoozeReminderRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.SnoozeReminder(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.SnoozeReminder()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "reminder snoozed")
+}
+
+func (c *RemindersController) GetNotifications(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response, err := c.service.GetNotifications(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetNotifications()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
@@ -0,0 +1,185 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestRemindersController_SetReminder(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockIRemindersService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "remind_at":"2030-01-01T09:00:00Z", "recurrence":"daily"}`),
+			},
+			given: func(s *interfaces.MockIRemindersService) {
+				s.EXPECT().SetReminder(mock.Anything, mock.Anything).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid remind_at",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "remind_at":"tomorrow"}`),
+			},
+			given: func(s *interfaces.MockIRemindersService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.SetReminder()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "remind_at":"2030-01-01T09:00:00Z"}`),
+			},
+			given: func(s *interfaces.MockIRemindersService) {
+				s.EXPECT().SetReminder(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIRemindersService{}
+			tt.given(&mockService)
+			c := &RemindersController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.SetReminder(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestRemindersController_SnoozeReminder(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockIRemindersService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "minutes":10}`),
+			},
+			given: func(s *interfaces.MockIRemindersService) {
+				s.EXPECT().SnoozeReminder(mock.Anything, mock.Anything).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - minutes missing",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockIRemindersService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.SnoozeReminder()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123, "minutes":10}`),
+			},
+			given: func(s *interfaces.MockIRemindersService) {
+				s.EXPECT().SnoozeReminder(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIRemindersService{}
+			tt.given(&mockService)
+			c := &RemindersController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.SnoozeReminder(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestRemindersController_GetNotifications(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockIRemindersService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(``),
+			},
+			given: func(s *interfaces.MockIRemindersService) {
+				s.EXPECT().GetNotifications(mock.Anything).Return([]models.Notification{{
+					Id: 1, NoteId: 123, Message: "Reminder: test",
+				}}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - error in service.GetNotifications()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(``),
+			},
+			given: func(s *interfaces.MockIRemindersService) {
+				s.EXPECT().GetNotifications(mock.Anything).Return([]models.Notification{}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIRemindersService{}
+			tt.given(&mockService)
+			c := &RemindersController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.GetNotifications(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,18 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// GetJWKS - publishes the public keys the session tokens are verified with, as a standard JWK set rather than in
+// the usual response envelope so that other services can read it
+func (c *SigningKeysController) GetJWKS(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	w.Header().Set("Content-Type", "application/json")
+	// short enough for the verifiers to see a rotated key before the tokens it signs reach them
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	if err := json.NewEncoder(w).Encode(c.keys.JWKS()); err != nil {
+		c.logger.Warn(ctx, "error in GetJWKS(), error from json.Encode()", err)
+	}
+}
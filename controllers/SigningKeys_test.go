@@ -0,0 +1,31 @@
+package controllers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"notes-server/loggers"
+	"notes-server/signing"
+	"testing"
+	"time"
+)
+
+func TestSigningKeysController_GetJWKS(t *testing.T) {
+	keys, err := signing.NewKeyring(signing.EdDSA, "", time.Hour)
+	if err != nil {
+		t.Fatalf("signing.NewKeyring() error = %v", err)
+	}
+	c := &SigningKeysController{keys: keys, logger: loggers.NewLogger()}
+	w := httptest.NewRecorder()
+	c.GetJWKS(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+	if w.Result().StatusCode != http.StatusOK {
+		t.Fatalf("expected status code %d, got %d", http.StatusOK, w.Result().StatusCode)
+	}
+	var set signing.JSONWebKeySet
+	if err = json.NewDecoder(w.Body).Decode(&set); err != nil {
+		t.Fatalf("invalid JWK set: %v", err)
+	}
+	if len(set.Keys) != 1 || set.Keys[0].Kid != keys.CurrentKeyID() || set.Keys[0].Kty != "OKP" || set.Keys[0].X == "" {
+		t.Errorf("GetJWKS() = %+v", set)
+	}
+}
@@ -0,0 +1,54 @@
+package controllers
+
+import (
+	"net/http"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+func (c *TemplatesController) GetTemplates(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response, err := c.service.GetTemplates(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetTemplates()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *TemplatesController) AddTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.AddTemplateRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.AddTemplate(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.AddTemplate()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusCreated, response)
+}
+
+func (c *TemplatesController) DeleteTemplate(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.DeleteTemplateRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.DeleteTemplate(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.DeleteTemplate()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "succesfully deleted")
+}
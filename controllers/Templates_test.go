@@ -0,0 +1,202 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestTemplatesController_GetTemplates(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockITemplatesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(``),
+			},
+			given: func(s *interfaces.MockITemplatesService) {
+				s.EXPECT().GetTemplates(mock.Anything).Return([]models.Template{{
+					Id:     1,
+					Name:   "Meeting notes",
+					Note:   "{{date}}",
+					Global: true,
+				}}, nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - error in service.GetTemplates()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(``),
+			},
+			given: func(s *interfaces.MockITemplatesService) {
+				s.EXPECT().GetTemplates(mock.Anything).Return([]models.Template{}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockITemplatesService{}
+			tt.given(&mockService)
+			c := &TemplatesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.GetTemplates(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestTemplatesController_AddTemplate(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockITemplatesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"name":"Standup","note":"{{date}}"}`),
+			},
+			given: func(s *interfaces.MockITemplatesService) {
+				s.EXPECT().AddTemplate(mock.Anything, mock.Anything).Return(models.AddTemplateResponse{
+					Id: 123,
+				}, nil)
+			},
+			want: http.StatusCreated,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"name": Standup"}`),
+			},
+			given: func(s *interfaces.MockITemplatesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - name missing",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"name": "", "note": "{{date}}"}`),
+			},
+			given: func(s *interfaces.MockITemplatesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.AddTemplate()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"name":"Standup","note":"{{date}}"}`),
+			},
+			given: func(s *interfaces.MockITemplatesService) {
+				s.EXPECT().AddTemplate(mock.Anything, mock.Anything).Return(models.AddTemplateResponse{
+					Id: 123,
+				}, errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockITemplatesService{}
+			tt.given(&mockService)
+			c := &TemplatesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.AddTemplate(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
+
+func TestTemplatesController_DeleteTemplate(t *testing.T) {
+	type args struct {
+		w *httptest.ResponseRecorder
+		r *http.Request
+	}
+	tests := []struct {
+		name  string
+		given func(*interfaces.MockITemplatesService)
+		args  args
+		want  int
+	}{
+		{
+			name: "success case",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockITemplatesService) {
+				s.EXPECT().DeleteTemplate(mock.Anything, mock.Anything).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name: "failure case - invalid request",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":"123"}`),
+			},
+			given: func(s *interfaces.MockITemplatesService) {
+			},
+			want: http.StatusBadRequest,
+		},
+		{
+			name: "failure case - error in service.DeleteTemplate()",
+			args: args{
+				w: httptest.NewRecorder(),
+				r: CreateReq(`{"id":123}`),
+			},
+			given: func(s *interfaces.MockITemplatesService) {
+				s.EXPECT().DeleteTemplate(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			want: http.StatusInternalServerError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockITemplatesService{}
+			tt.given(&mockService)
+			c := &TemplatesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			c.DeleteTemplate(tt.args.w, tt.args.r)
+			if tt.args.w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, tt.args.w.Result().StatusCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package controllers
+
+import (
+	"net/http"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+func (c *WorkspacesController) GetWorkspaces(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response, err := c.service.GetWorkspaces(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetWorkspaces()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *WorkspacesController) CreateWorkspace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.CreateWorkspaceRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.CreateWorkspace(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.CreateWorkspace()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusCreated, response)
+}
+
+func (c *WorkspacesController) SwitchWorkspace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.SwitchWorkspaceRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.SwitchWorkspace(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.SwitchWorkspace()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *WorkspacesController) GetMembers(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	response, err := c.service.GetMembers(ctx)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.GetMembers()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *WorkspacesController) InviteMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.InviteMemberRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.InviteMember(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.InviteMember()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "invitation sent")
+}
+
+func (c *WorkspacesController) JoinWorkspace(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.JoinWorkspaceRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	response, err := c.service.JoinWorkspace(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.JoinWorkspace()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, response)
+}
+
+func (c *WorkspacesController) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	var request models.RemoveMemberRequest
+	err := utils.GetBodyParams(r, &request)
+	if err != nil {
+		c.logger.Warn(ctx, "invalid request", err)
+		utils.WriteHttpFailure(w, http.StatusBadRequest, err)
+		return
+	}
+	err = c.service.RemoveMember(ctx, request)
+	if err != nil {
+		c.logger.Warn(ctx, "error in c.service.RemoveMember()", err)
+		utils.WriteHttpError(w, err)
+		return
+	}
+	utils.WriteHttpSuccess(w, http.StatusOK, "member removed")
+}
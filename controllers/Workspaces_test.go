@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWorkspacesController_RemoveMember(t *testing.T) {
+	tests := []struct {
+		name  string
+		body  string
+		given func(*interfaces.MockIWorkspaceService)
+		want  int
+	}{
+		{
+			name: "success case",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockIWorkspaceService) {
+				s.EXPECT().RemoveMember(mock.Anything, models.RemoveMemberRequest{Email: "test@gmail.com"}).Return(nil)
+			},
+			want: http.StatusOK,
+		},
+		{
+			name:  "failure case - invalid email",
+			body:  `{"email":"test"}`,
+			given: func(s *interfaces.MockIWorkspaceService) {},
+			want:  http.StatusBadRequest,
+		},
+		{
+			name: "failure case - not a member",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockIWorkspaceService) {
+				s.EXPECT().RemoveMember(mock.Anything, mock.Anything).Return(models.ErrNotWorkspaceMember)
+			},
+			want: http.StatusNotFound,
+		},
+		{
+			name: "failure case - not allowed",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockIWorkspaceService) {
+				s.EXPECT().RemoveMember(mock.Anything, mock.Anything).Return(models.ErrWorkspaceForbidden)
+			},
+			want: http.StatusForbidden,
+		},
+		{
+			name: "failure case - owner",
+			body: `{"email":"test@gmail.com"}`,
+			given: func(s *interfaces.MockIWorkspaceService) {
+				s.EXPECT().RemoveMember(mock.Anything, mock.Anything).Return(models.ErrRemoveOwner)
+			},
+			want: http.StatusConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockService := interfaces.MockIWorkspaceService{}
+			tt.given(&mockService)
+			c := &WorkspacesController{
+				service: &mockService,
+				logger:  loggers.NewLogger(),
+			}
+			w := httptest.NewRecorder()
+			c.RemoveMember(w, CreateReq(tt.body))
+			if w.Result().StatusCode != tt.want {
+				t.Errorf("expected status code %d, got %d", tt.want, w.Result().StatusCode)
+			}
+		})
+	}
+}
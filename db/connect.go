@@ -37,15 +37,18 @@ func init() {
 						Unique:  false,
 						Indexer: &memdb.StringFieldIndex{Field: "Name"},
 					},
+					// the addresses are stored normalized, looking them up ignores their case too
 					"email": {
 						Name:    "email",
 						Unique:  true,
-						Indexer: &memdb.StringFieldIndex{Field: "Email"},
+						Indexer: &memdb.StringFieldIndex{Field: "Email", Lowercase: true},
 					},
+					// the users provisioned by an identity provider have no password
 					"password": {
-						Name:    "password",
-						Unique:  false,
-						Indexer: &memdb.StringFieldIndex{Field: "Password"},
+						Name:         "password",
+						Unique:       false,
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "Password"},
 					},
 				},
 			},
@@ -58,15 +61,335 @@ func init() {
 						Indexer: &memdb.IntFieldIndex{Field: "Id"},
 					},
 					"note": {
-						Name:    "note",
-						Unique:  false,
-						Indexer: &memdb.StringFieldIndex{Field: "Note"},
+						Name:         "note",
+						Unique:       false,
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "Note"},
 					},
 					"created_by": {
 						Name:    "created_by",
 						Unique:  false,
 						Indexer: &memdb.StringFieldIndex{Field: "CreatedBy"},
 					},
+					// the notes of a user in one workspace, every query of the notes is scoped by workspace_owner
+					// or one of the owner_ indexes below
+					"workspace_owner": {
+						Name:   "workspace_owner",
+						Unique: false,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.IntFieldIndex{Field: "Workspace"},
+								&memdb.StringFieldIndex{Field: "CreatedBy"},
+							},
+						},
+					},
+					"owner_title": {
+						Name:         "owner_title",
+						Unique:       false,
+						AllowMissing: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.IntFieldIndex{Field: "Workspace"},
+								&memdb.StringFieldIndex{Field: "CreatedBy"},
+								&memdb.StringFieldIndex{Field: "Title", Lowercase: true},
+							},
+						},
+					},
+					"owner_state": {
+						Name:   "owner_state",
+						Unique: false,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.IntFieldIndex{Field: "Workspace"},
+								&memdb.StringFieldIndex{Field: "CreatedBy"},
+								&memdb.BoolFieldIndex{Field: "Archived"},
+								&memdb.BoolFieldIndex{Field: "Pinned"},
+							},
+						},
+					},
+					"owner_starred": {
+						Name:   "owner_starred",
+						Unique: false,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.IntFieldIndex{Field: "Workspace"},
+								&memdb.StringFieldIndex{Field: "CreatedBy"},
+								&memdb.BoolFieldIndex{Field: "Starred"},
+								&memdb.BoolFieldIndex{Field: "Archived"},
+								&memdb.BoolFieldIndex{Field: "Pinned"},
+							},
+						},
+					},
+					"reminder": {
+						Name:   "reminder",
+						Unique: false,
+						Indexer: &memdb.ConditionalIndex{Conditional: func(obj interface{}) (bool, error) {
+							return obj.(*models.Note).NextReminderAt() != nil, nil
+						}},
+					},
+				},
+			},
+			"links": {
+				Name: "links",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.IntFieldIndex{Field: "Id"},
+					},
+					"source": {
+						Name:    "source",
+						Unique:  false,
+						Indexer: &memdb.IntFieldIndex{Field: "SourceId"},
+					},
+					"target": {
+						Name:    "target",
+						Unique:  false,
+						Indexer: &memdb.IntFieldIndex{Field: "TargetId"},
+					},
+					"owner": {
+						Name:    "owner",
+						Unique:  false,
+						Indexer: &memdb.StringFieldIndex{Field: "Owner"},
+					},
+					"owner_key": {
+						Name:   "owner_key",
+						Unique: false,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.IntFieldIndex{Field: "Workspace"},
+								&memdb.StringFieldIndex{Field: "Owner"},
+								&memdb.StringFieldIndex{Field: "Key"},
+							},
+						},
+					},
+				},
+			},
+			"templates": {
+				Name: "templates",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.IntFieldIndex{Field: "Id"},
+					},
+					"owner": {
+						Name:         "owner",
+						Unique:       false,
+						AllowMissing: true,
+						Indexer:      &memdb.StringFieldIndex{Field: "Owner"},
+					},
+					"global": {
+						Name:    "global",
+						Unique:  false,
+						Indexer: &memdb.BoolFieldIndex{Field: "Global"},
+					},
+				},
+			},
+			"keys": {
+				Name: "keys",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "Email"},
+					},
+				},
+			},
+			"data_keys": {
+				Name: "data_keys",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "Email"},
+					},
+					"master_key": {
+						Name:    "master_key",
+						Unique:  false,
+						Indexer: &memdb.StringFieldIndex{Field: "MasterKeyId"},
+					},
+				},
+			},
+			"usage": {
+				Name: "usage",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "Email"},
+					},
+				},
+			},
+			"password_resets": {
+				Name: "password_resets",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "TokenHash"},
+					},
+					"email": {
+						Name:    "email",
+						Unique:  false,
+						Indexer: &memdb.StringFieldIndex{Field: "Email"},
+					},
+				},
+			},
+			"oidc_logins": {
+				Name: "oidc_logins",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "StateHash"},
+					},
+				},
+			},
+			"mfa_challenges": {
+				Name: "mfa_challenges",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "ID"},
+					},
+				},
+			},
+			"oidc_links": {
+				Name: "oidc_links",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.StringFieldIndex{Field: "Issuer"},
+								&memdb.StringFieldIndex{Field: "Subject"},
+							},
+						},
+					},
+					"email": {
+						Name:    "email",
+						Unique:  false,
+						Indexer: &memdb.StringFieldIndex{Field: "Email"},
+					},
+				},
+			},
+			"access_tokens": {
+				Name: "access_tokens",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.IntFieldIndex{Field: "Id"},
+					},
+					"hash": {
+						Name:    "hash",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "TokenHash"},
+					},
+					"email": {
+						Name:    "email",
+						Unique:  false,
+						Indexer: &memdb.StringFieldIndex{Field: "Email"},
+					},
+				},
+			},
+			"login_failures": {
+				Name: "login_failures",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "Key"},
+					},
+				},
+			},
+			"workspaces": {
+				Name: "workspaces",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.IntFieldIndex{Field: "Id"},
+					},
+				},
+			},
+			"memberships": {
+				Name: "memberships",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:   "id",
+						Unique: true,
+						Indexer: &memdb.CompoundIndex{
+							Indexes: []memdb.Indexer{
+								&memdb.IntFieldIndex{Field: "WorkspaceId"},
+								&memdb.StringFieldIndex{Field: "Email"},
+							},
+						},
+					},
+					"workspace": {
+						Name:    "workspace",
+						Unique:  false,
+						Indexer: &memdb.IntFieldIndex{Field: "WorkspaceId"},
+					},
+					"email": {
+						Name:    "email",
+						Unique:  false,
+						Indexer: &memdb.StringFieldIndex{Field: "Email"},
+					},
+				},
+			},
+			"invitations": {
+				Name: "invitations",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "TokenHash"},
+					},
+					"workspace": {
+						Name:    "workspace",
+						Unique:  false,
+						Indexer: &memdb.IntFieldIndex{Field: "WorkspaceId"},
+					},
+				},
+			},
+			"setup_tokens": {
+				Name: "setup_tokens",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.StringFieldIndex{Field: "TokenHash"},
+					},
+				},
+			},
+			// the audit log is append-only, entries are only deleted once they are older than the retention
+			"audit_log": {
+				Name: "audit_log",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.IntFieldIndex{Field: "Id"},
+					},
+				},
+			},
+			"notifications": {
+				Name: "notifications",
+				Indexes: map[string]*memdb.IndexSchema{
+					"id": {
+						Name:    "id",
+						Unique:  true,
+						Indexer: &memdb.IntFieldIndex{Field: "Id"},
+					},
+					"email": {
+						Name:    "email",
+						Unique:  false,
+						Indexer: &memdb.StringFieldIndex{Field: "Email"},
+					},
 				},
 			},
 		},
@@ -75,12 +398,26 @@ func init() {
 	if err != nil {
 		panic(err)
 	}
+	// there are no default users, the first admin is bootstrapped at startup
 	txn := db.Txn(true)
-	users := []*models.User{
-		{Name: "Admin", Email: "admin@accuknox.com", Password: "admin"},
+	templates := []*models.Template{
+		{
+			Id:     1,
+			Name:   "Meeting notes",
+			Title:  "Meeting {{date}} - {{topic}}",
+			Note:   "Date: {{date}}\nAuthor: {{user.name}}\n\n## Attendees\n\n## Agenda\n\n## Decisions\n\n## Action items\n",
+			Global: true,
+		},
+		{
+			Id:     2,
+			Name:   "Incident report",
+			Title:  "Incident {{date}} - {{summary}}",
+			Note:   "Reported: {{datetime}} by {{user.name}}\nSeverity: {{severity}}\n\n## Summary\n{{summary}}\n\n## Timeline\n\n## Impact\n\n## Root cause\n\n## Follow-up\n",
+			Global: true,
+		},
 	}
-	for _, user := range users {
-		if err := txn.Insert("user", user); err != nil {
+	for _, template := range templates {
+		if err := txn.Insert("templates", template); err != nil {
 			panic(err)
 		}
 	}
@@ -31,12 +31,14 @@ func (m *memDb) Delete(table string, obj interface{}) error {
 	return m.txn.Delete(table, obj)
 }
 
+// Get - args are spread into the values of the index, passed as a single slice they would be taken as one value
 func (m *memDb) Get(table string, index string, args ...interface{}) (memdb.ResultIterator, error) {
-	return m.txn.Get(table, index, args)
+	return m.txn.Get(table, index, args...)
 }
 
+// First - args are spread like those of Get
 func (m *memDb) First(table string, index string, args ...interface{}) (interface{}, error) {
-	return m.txn.First(table, index, args)
+	return m.txn.First(table, index, args...)
 }
 
 func (m *memDb) Abort() {
@@ -0,0 +1,154 @@
+// Package e2ee is the reference implementation of the client side of end-to-end encrypted notes. The note key
+// of a user is generated on the client and only ever sent to the server wrapped with a key derived from a
+// passphrase, note bodies are sent as AES-256-GCM ciphertext.
+package e2ee
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"errors"
+	"notes-server/models"
+
+	"golang.org/x/crypto/pbkdf2"
+)
+
+const (
+	// KeySize - size of the note key and of the key wrapping it
+	KeySize = 32
+	// DefaultIterations - PBKDF2 iterations used to derive the wrapping key from the passphrase
+	DefaultIterations = 600000
+
+	saltSize = 16
+)
+
+// Client - encrypts and decrypts the notes of a user with the unwrapped note key
+type Client struct {
+	aead       cipher.AEAD
+	keyVersion int
+}
+
+// NewKey - generates a random note key
+func NewKey() ([]byte, error) {
+	return randomBytes(KeySize)
+}
+
+// WrapKey - encrypts the note key with a key derived from the passphrase, the result is what the server stores
+func WrapKey(key []byte, passphrase string, iterations int) (models.KeyMaterial, error) {
+	salt, err := randomBytes(saltSize)
+	if err != nil {
+		return models.KeyMaterial{}, err
+	}
+	aead, err := newAEAD(deriveKey(passphrase, salt, iterations))
+	if err != nil {
+		return models.KeyMaterial{}, err
+	}
+	nonce, err := randomBytes(aead.NonceSize())
+	if err != nil {
+		return models.KeyMaterial{}, err
+	}
+	return models.KeyMaterial{
+		Scheme:     models.KeyWrapSchemePBKDF2AESGCM,
+		Salt:       salt,
+		Iterations: iterations,
+		Nonce:      nonce,
+		WrappedKey: aead.Seal(nil, nonce, key, []byte(models.KeyWrapSchemePBKDF2AESGCM)),
+	}, nil
+}
+
+// UnwrapKey - decrypts the note key stored by the server, it fails when the passphrase is wrong
+func UnwrapKey(material models.KeyMaterial, passphrase string) ([]byte, error) {
+	if material.Scheme != models.KeyWrapSchemePBKDF2AESGCM {
+		return nil, errors.New("unsupported key wrapping scheme " + material.Scheme)
+	}
+	aead, err := newAEAD(deriveKey(passphrase, material.Salt, material.Iterations))
+	if err != nil {
+		return nil, err
+	}
+	if len(material.Nonce) != aead.NonceSize() {
+		return nil, errors.New("invalid key material nonce")
+	}
+	key, err := aead.Open(nil, material.Nonce, material.WrappedKey, []byte(material.Scheme))
+	if err != nil {
+		return nil, errors.New("wrong passphrase or corrupted key material")
+	}
+	return key, nil
+}
+
+// NewClient - keyVersion is the version of the key material the key was unwrapped from
+func NewClient(key []byte, keyVersion int) (*Client, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{aead: aead, keyVersion: keyVersion}, nil
+}
+
+// EncryptNote - returns the request adding an encrypted note with the given body
+func (c *Client) EncryptNote(body string) (models.AddNoteRequest, error) {
+	ciphertext, encryption, err := c.encrypt(body)
+	if err != nil {
+		return models.AddNoteRequest{}, err
+	}
+	return models.AddNoteRequest{Ciphertext: ciphertext, Encryption: encryption}, nil
+}
+
+// EncryptUpdate - returns the request replacing the body of a note with an encrypted one
+func (c *Client) EncryptUpdate(noteID int32, body string) (models.UpdateNoteRequest, error) {
+	ciphertext, encryption, err := c.encrypt(body)
+	if err != nil {
+		return models.UpdateNoteRequest{}, err
+	}
+	return models.UpdateNoteRequest{Id: noteID, Ciphertext: ciphertext, Encryption: encryption}, nil
+}
+
+// DecryptNote - returns the body of a note, notes that are not encrypted are returned as they are
+func (c *Client) DecryptNote(note models.Note) (string, error) {
+	if !note.IsEncrypted() {
+		return note.Note, nil
+	}
+	if note.Encryption.Scheme != models.EncryptionSchemeAESGCM {
+		return "", errors.New("unsupported encryption scheme " + note.Encryption.Scheme)
+	}
+	if len(note.Encryption.Nonce) != c.aead.NonceSize() {
+		return "", errors.New("invalid note nonce")
+	}
+	body, err := c.aead.Open(nil, note.Encryption.Nonce, note.Ciphertext, []byte(note.Encryption.Scheme))
+	if err != nil {
+		return "", errors.New("note can not be decrypted with this key")
+	}
+	return string(body), nil
+}
+
+func (c *Client) encrypt(body string) ([]byte, *models.Encryption, error) {
+	nonce, err := randomBytes(c.aead.NonceSize())
+	if err != nil {
+		return nil, nil, err
+	}
+	encryption := &models.Encryption{Scheme: models.EncryptionSchemeAESGCM, Nonce: nonce, KeyVersion: c.keyVersion}
+	return c.aead.Seal(nil, nonce, []byte(body), []byte(encryption.Scheme)), encryption, nil
+}
+
+func deriveKey(passphrase string, salt []byte, iterations int) []byte {
+	return pbkdf2.Key([]byte(passphrase), salt, iterations, KeySize, sha256.New)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, errors.New("keys must be 32 bytes long")
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func randomBytes(n int) ([]byte, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
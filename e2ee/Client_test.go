@@ -0,0 +1,137 @@
+package e2ee
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"notes-server/constants"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/repositories"
+	"notes-server/services"
+	"strings"
+	"testing"
+)
+
+// testIterations - keeps the key derivation fast in tests
+const testIterations = 1000
+
+func TestWrapKey(t *testing.T) {
+	key, err := NewKey()
+	if err != nil {
+		t.Fatalf("NewKey() error = %v", err)
+	}
+	material, err := WrapKey(key, "correct horse", testIterations)
+	if err != nil {
+		t.Fatalf("WrapKey() error = %v", err)
+	}
+	if bytes.Contains(material.WrappedKey, key) {
+		t.Errorf("WrapKey() stored the key in clear")
+	}
+	got, err := UnwrapKey(material, "correct horse")
+	if err != nil {
+		t.Fatalf("UnwrapKey() error = %v", err)
+	}
+	if !bytes.Equal(got, key) {
+		t.Errorf("UnwrapKey() = %x, want %x", got, key)
+	}
+	if _, err = UnwrapKey(material, "wrong horse"); err == nil {
+		t.Errorf("UnwrapKey() accepted a wrong passphrase")
+	}
+}
+
+func TestClient_DecryptNote(t *testing.T) {
+	key, _ := NewKey()
+	client, err := NewClient(key, 1)
+	if err != nil {
+		t.Fatalf("NewClient() error = %v", err)
+	}
+	request, err := client.EncryptNote("secret")
+	if err != nil {
+		t.Fatalf("Client.EncryptNote() error = %v", err)
+	}
+	note := models.Note{Ciphertext: request.Ciphertext, Encryption: request.Encryption}
+	if got, err := client.DecryptNote(note); err != nil || got != "secret" {
+		t.Errorf("Client.DecryptNote() = %q, %v, want %q", got, err, "secret")
+	}
+
+	otherKey, _ := NewKey()
+	other, _ := NewClient(otherKey, 1)
+	if _, err = other.DecryptNote(note); err == nil {
+		t.Errorf("Client.DecryptNote() decrypted a note with another key")
+	}
+	note.Ciphertext[0] ^= 1
+	if _, err = client.DecryptNote(note); err == nil {
+		t.Errorf("Client.DecryptNote() accepted a modified ciphertext")
+	}
+}
+
+// TestRoundTrip - a note encrypted by the client goes through the notes service and the repository and can only
+// be read back by a client holding the key
+func TestRoundTrip(t *testing.T) {
+	const email = "e2ee@gmail.com"
+	const secret = "the database password is hunter2"
+	ctx := context.WithValue(context.Background(), constants.EmailCtxKey, email)
+	logger := loggers.NewLogger()
+	notesService := services.NewNotesService(logger, repositories.NewNotesRepository(db.NewDB(), logger, nil), repositories.NewTemplatesRepository(db.NewDB(), logger), models.Quota{})
+	keysService := services.NewKeysService(logger, repositories.NewKeysRepository(db.NewDB(), logger))
+
+	// the first device creates the key and stores it wrapped
+	key, _ := NewKey()
+	material, _ := WrapKey(key, "passphrase", testIterations)
+	response, err := keysService.SetKeyMaterial(ctx, material)
+	if err != nil {
+		t.Fatalf("keysService.SetKeyMaterial() error = %v", err)
+	}
+	client, _ := NewClient(key, response.Version)
+	request, _ := client.EncryptNote(secret)
+	if _, err = notesService.AddNote(ctx, request); err != nil {
+		t.Fatalf("notesService.AddNote() error = %v", err)
+	}
+
+	// a second device unwraps the stored key and reads the note
+	stored, err := keysService.GetKeyMaterial(ctx)
+	if err != nil {
+		t.Fatalf("keysService.GetKeyMaterial() error = %v", err)
+	}
+	key, err = UnwrapKey(stored, "passphrase")
+	if err != nil {
+		t.Fatalf("UnwrapKey() error = %v", err)
+	}
+	client, _ = NewClient(key, stored.Version)
+	notes, err := notesService.GetNotes(ctx, models.GetNotesRequest{})
+	if err != nil || len(notes) != 1 {
+		t.Fatalf("notesService.GetNotes() = %v, %v", notes, err)
+	}
+	if notes[0].Note != "" || bytes.Contains(notes[0].Ciphertext, []byte(secret)) {
+		t.Errorf("the server stored the note in clear")
+	}
+	if got, err := client.DecryptNote(notes[0]); err != nil || got != secret {
+		t.Errorf("Client.DecryptNote() = %q, %v, want %q", got, err, secret)
+	}
+
+	// search never matches encrypted notes
+	found, err := notesService.SearchNotes(ctx, models.SearchNotesRequest{Query: "hunter2"})
+	if err != nil || len(found) != 0 {
+		t.Errorf("notesService.SearchNotes() = %v, %v, want no notes", found, err)
+	}
+
+	// an exported note stays encrypted and can be decrypted once imported again
+	var archive bytes.Buffer
+	if err = notesService.ExportNotes(ctx, &archive); err != nil {
+		t.Fatalf("notesService.ExportNotes() error = %v", err)
+	}
+	if strings.Contains(archive.String(), secret) {
+		t.Errorf("the export contains the note in clear")
+	}
+	importCtx := context.WithValue(context.Background(), constants.EmailCtxKey, "e2ee-import@gmail.com")
+	imported, err := notesService.ImportNotes(importCtx, models.ImportNotesRequest{Archive: base64.StdEncoding.EncodeToString(archive.Bytes())})
+	if err != nil || len(imported.Ids) != 1 {
+		t.Fatalf("notesService.ImportNotes() = %v, %v", imported, err)
+	}
+	notes, _ = notesService.GetNotes(importCtx, models.GetNotesRequest{})
+	if got, err := client.DecryptNote(notes[0]); err != nil || got != secret {
+		t.Errorf("Client.DecryptNote() of the imported note = %q, %v, want %q", got, err, secret)
+	}
+}
@@ -0,0 +1,146 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeySize - size of the master keys and of the data keys they wrap
+const KeySize = 32
+
+// Keyring - the master keys wrapping the data keys of the users. New data keys are always wrapped with the
+// current master key, the previous ones are only kept to unwrap the data keys that were not re-wrapped yet.
+type Keyring struct {
+	current string
+	keys    map[string]cipher.AEAD
+}
+
+// NewKeyring - keys are "<id>:<base64 key>" entries, the first one is the current master key
+func NewKeyring(keys ...string) (*Keyring, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("no master key given")
+	}
+	keyring := &Keyring{keys: make(map[string]cipher.AEAD, len(keys))}
+	for i, entry := range keys {
+		id, aead, err := parseMasterKey(entry)
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := keyring.keys[id]; ok {
+			return nil, fmt.Errorf("master key %q is listed twice", id)
+		}
+		if i == 0 {
+			keyring.current = id
+		}
+		keyring.keys[id] = aead
+	}
+	return keyring, nil
+}
+
+// LoadKeyring - builds the keyring from a key file holding one entry per line, or from the entries given
+// directly when there is no file. It returns nil when no master key is configured.
+func LoadKeyring(keyFile, masterKey string, previousKeys []string) (*Keyring, error) {
+	keys := make([]string, 0)
+	if keyFile != "" {
+		content, err := os.ReadFile(keyFile)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, strings.Fields(string(content))...)
+	} else if masterKey != "" {
+		keys = append(keys, masterKey)
+	}
+	for _, key := range previousKeys {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) == 0 {
+		return nil, nil
+	}
+	return NewKeyring(keys...)
+}
+
+// CurrentKeyID - id of the master key new data keys are wrapped with
+func (k *Keyring) CurrentKeyID() string {
+	return k.current
+}
+
+// NewDataKey - generates a data key and returns it along with its wrapped form
+func (k *Keyring) NewDataKey() ([]byte, []byte, error) {
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return nil, nil, err
+	}
+	wrapped, err := k.Wrap(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return key, wrapped, nil
+}
+
+// Wrap - encrypts a data key with the current master key
+func (k *Keyring) Wrap(key []byte) ([]byte, error) {
+	return Seal(k.keys[k.current], key, []byte(k.current))
+}
+
+// Unwrap - decrypts a data key wrapped with the master key keyID
+func (k *Keyring) Unwrap(keyID string, wrapped []byte) ([]byte, error) {
+	aead, ok := k.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown master key %q", keyID)
+	}
+	return Open(aead, wrapped, []byte(keyID))
+}
+
+// NewAEAD - AES-256-GCM with the given key
+func NewAEAD(key []byte) (cipher.AEAD, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("keys must be %d bytes long", KeySize)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal - encrypts plaintext with a random nonce, which is prepended to the ciphertext
+func Seal(aead cipher.AEAD, plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open - decrypts a ciphertext produced by Seal
+func Open(aead cipher.AEAD, sealed, additionalData []byte) ([]byte, error) {
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	return aead.Open(nil, nonce, ciphertext, additionalData)
+}
+
+func parseMasterKey(entry string) (string, cipher.AEAD, error) {
+	parts := strings.SplitN(strings.TrimSpace(entry), ":", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return "", nil, errors.New("master keys must be given as <id>:<base64 key>")
+	}
+	key, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", nil, fmt.Errorf("master key %q is not base64 encoded", parts[0])
+	}
+	aead, err := NewAEAD(key)
+	if err != nil {
+		return "", nil, fmt.Errorf("master key %q: %w", parts[0], err)
+	}
+	return parts[0], aead, nil
+}
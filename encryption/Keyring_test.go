@@ -0,0 +1,83 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func masterKey(id string, b byte) string {
+	return id + ":" + base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{b}, KeySize))
+}
+
+func TestNewKeyring(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    []string
+		wantErr bool
+	}{
+		{name: "valid keys", keys: []string{masterKey("k2", 2), masterKey("k1", 1)}},
+		{name: "no keys", keys: []string{}, wantErr: true},
+		{name: "missing id", keys: []string{masterKey("", 1)}, wantErr: true},
+		{name: "not base64", keys: []string{"k1:not base64!"}, wantErr: true},
+		{name: "short key", keys: []string{"k1:" + base64.StdEncoding.EncodeToString([]byte("short"))}, wantErr: true},
+		{name: "duplicate id", keys: []string{masterKey("k1", 1), masterKey("k1", 2)}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewKeyring(tt.keys...)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("NewKeyring() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadKeyring(t *testing.T) {
+	keyFile := filepath.Join(t.TempDir(), "master.keys")
+	if err := os.WriteFile(keyFile, []byte(masterKey("k2", 2)+"\n"+masterKey("k1", 1)+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	keyring, err := LoadKeyring(keyFile, masterKey("ignored", 3), nil)
+	if err != nil || keyring.CurrentKeyID() != "k2" {
+		t.Fatalf("LoadKeyring() from file = %v, %v", keyring, err)
+	}
+	keyring, err = LoadKeyring("", masterKey("k3", 3), []string{masterKey("k2", 2), " "})
+	if err != nil || keyring.CurrentKeyID() != "k3" {
+		t.Fatalf("LoadKeyring() = %v, %v", keyring, err)
+	}
+	if keyring, err = LoadKeyring("", "", nil); keyring != nil || err != nil {
+		t.Errorf("LoadKeyring() without keys = %v, %v", keyring, err)
+	}
+}
+
+func TestKeyring_Rotation(t *testing.T) {
+	old, _ := NewKeyring(masterKey("k1", 1))
+	key, wrapped, err := old.NewDataKey()
+	if err != nil {
+		t.Fatalf("Keyring.NewDataKey() error = %v", err)
+	}
+
+	rotated, _ := NewKeyring(masterKey("k2", 2), masterKey("k1", 1))
+	unwrapped, err := rotated.Unwrap("k1", wrapped)
+	if err != nil || !bytes.Equal(unwrapped, key) {
+		t.Fatalf("Keyring.Unwrap() with a previous key = %v, %v", unwrapped, err)
+	}
+	rewrapped, err := rotated.Wrap(unwrapped)
+	if err != nil {
+		t.Fatalf("Keyring.Wrap() error = %v", err)
+	}
+	if _, err = rotated.Unwrap("k1", rewrapped); err == nil {
+		t.Errorf("Keyring.Unwrap() accepted a key wrapped by another master key")
+	}
+	if unwrapped, err = rotated.Unwrap("k2", rewrapped); err != nil || !bytes.Equal(unwrapped, key) {
+		t.Errorf("Keyring.Unwrap() with the current key = %v, %v", unwrapped, err)
+	}
+
+	current, _ := NewKeyring(masterKey("k2", 2))
+	if _, err = current.Unwrap("k1", wrapped); err == nil {
+		t.Errorf("Keyring.Unwrap() accepted an unknown master key")
+	}
+}
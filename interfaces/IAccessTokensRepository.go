@@ -0,0 +1,14 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+	"time"
+)
+
+type IAccessTokensRepository interface {
+	GetAccessTokens(ctx context.Context, email string) ([]models.AccessToken, error)
+	AddAccessToken(ctx context.Context, token models.AccessToken) error
+	RevokeAccessToken(ctx context.Context, email string, tokenID int32) error
+	UseAccessToken(ctx context.Context, tokenHash string, now time.Time) (models.AccessToken, error)
+}
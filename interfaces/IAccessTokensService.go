@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IAccessTokensService interface {
+	GetAccessTokens(ctx context.Context) ([]models.AccessToken, error)
+	CreateAccessToken(ctx context.Context, request models.CreateAccessTokenRequest) (models.CreateAccessTokenResponse, error)
+	RevokeAccessToken(ctx context.Context, request models.RevokeAccessTokenRequest) error
+}
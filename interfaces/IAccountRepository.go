@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IAccountRepository interface {
+	UpdateAccount(ctx context.Context, request models.UpdateAccountRequest) (models.User, error)
+	ChangeEmail(ctx context.Context, email, newEmail string) error
+	ChangePassword(ctx context.Context, request models.ChangePasswordRequest) (models.User, error)
+	DeleteAccount(ctx context.Context, request models.DeleteAccountRequest) error
+}
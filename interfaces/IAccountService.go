@@ -0,0 +1,18 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IAccountService interface {
+	GetAccount(ctx context.Context) (models.Account, error)
+	UpdateAccount(ctx context.Context, request models.UpdateAccountRequest) (models.Account, error)
+	ConfirmEmailChange(ctx context.Context, request models.VerifyEmailRequest) error
+	ChangePassword(ctx context.Context, request models.ChangePasswordRequest) (models.LoginResponse, error)
+	DeleteAccount(ctx context.Context, request models.DeleteAccountRequest) error
+	EnrollMFA(ctx context.Context, request models.EnrollMFARequest) (models.MFAEnrollment, error)
+	ConfirmMFA(ctx context.Context, request models.MFACodeRequest) (models.RecoveryCodesResponse, error)
+	RegenerateRecoveryCodes(ctx context.Context, request models.MFACodeRequest) (models.RecoveryCodesResponse, error)
+	DisableMFA(ctx context.Context, request models.DisableMFARequest) error
+}
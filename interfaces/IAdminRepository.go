@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IAdminRepository interface {
+	ListUsers(ctx context.Context, request models.ListUsersRequest) (models.ListUsersResponse, error)
+	SetUserDisabled(ctx context.Context, email string, disabled bool) (models.UserSummary, error)
+	ForcePasswordReset(ctx context.Context, reset models.PasswordReset) (models.User, error)
+	CreateAdmin(ctx context.Context, user models.User) error
+	AddSetupToken(ctx context.Context, token models.SetupToken) error
+	SetupAdmin(ctx context.Context, tokenHash string, user models.User) error
+}
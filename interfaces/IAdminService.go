@@ -0,0 +1,15 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IAdminService interface {
+	ListUsers(ctx context.Context, request models.ListUsersRequest) (models.ListUsersResponse, error)
+	DisableUser(ctx context.Context, request models.ManageUserRequest) (models.UserSummary, error)
+	EnableUser(ctx context.Context, request models.ManageUserRequest) (models.UserSummary, error)
+	ForcePasswordReset(ctx context.Context, request models.ManageUserRequest) error
+	Bootstrap(ctx context.Context) (string, error)
+	Setup(ctx context.Context, request models.SetupRequest) error
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+	"time"
+)
+
+type IAuditRepository interface {
+	GetAuditLog(ctx context.Context, request models.GetAuditLogRequest) (models.GetAuditLogResponse, error)
+	DeleteAuditEntries(ctx context.Context, cutoff time.Time) (int, error)
+}
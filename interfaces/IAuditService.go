@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IAuditService interface {
+	GetAuditLog(ctx context.Context, request models.GetAuditLogRequest) (models.GetAuditLogResponse, error)
+	PurgeAuditLog(ctx context.Context) (int, error)
+}
@@ -0,0 +1,7 @@
+package interfaces
+
+import "context"
+
+type IDataKeysRepository interface {
+	RewrapDataKeys(ctx context.Context) (int, error)
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IKeysRepository interface {
+	GetKeyMaterial(ctx context.Context, email string) (models.KeyMaterial, error)
+	SetKeyMaterial(ctx context.Context, material models.KeyMaterial) (int, error)
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IKeysService interface {
+	GetKeyMaterial(ctx context.Context) (models.KeyMaterial, error)
+	SetKeyMaterial(ctx context.Context, material models.KeyMaterial) (models.SetKeyMaterialResponse, error)
+}
@@ -3,11 +3,25 @@ package interfaces
 import (
 	"context"
 	"notes-server/models"
+	"time"
 )
 
 type ILoginRepository interface {
-	Login(ctx context.Context, request models.LoginRequest) (models.LoginRepoResponse, error)
-	CheckIfUserExists(ctx context.Context, email string) (bool, error)
+	Login(ctx context.Context, request models.LoginRequest, attempt models.LoginAttempt) (models.LoginRepoResponse, error)
 	SignUp(ctx context.Context, request models.SignUpRequest) error
-	ValidateUser(ctx context.Context, email, name string) error
+	ValidateUser(ctx context.Context, email string, workspace int32, sessionVersion int) (models.User, error)
+	GetMember(ctx context.Context, workspace int32, email string) (models.User, models.Membership, error)
+	GetUser(ctx context.Context, email string) (models.User, error)
+	VerifyEmail(ctx context.Context, email string) error
+	AddPasswordReset(ctx context.Context, reset models.PasswordReset) error
+	GetPasswordReset(ctx context.Context, tokenHash string, now time.Time) (models.User, error)
+	ResetPassword(ctx context.Context, tokenHash, password string, now time.Time) error
+	StartMFAEnrollment(ctx context.Context, email, code, secret string, attempt models.LoginAttempt) error
+	ConfirmMFAEnrollment(ctx context.Context, email, code string, recoveryCodes []string, attempt models.LoginAttempt) error
+	UseMFACode(ctx context.Context, email, code string, challenge models.MFAChallenge, attempt models.LoginAttempt) (models.User, error)
+	ReplaceRecoveryCodes(ctx context.Context, email, code string, recoveryCodes []string, attempt models.LoginAttempt) error
+	DisableMFA(ctx context.Context, request models.DisableMFARequest, attempt models.LoginAttempt) error
+	AddOIDCLogin(ctx context.Context, login models.OIDCLogin) error
+	TakeOIDCLogin(ctx context.Context, stateHash string, now time.Time) (models.OIDCLogin, error)
+	LoginOIDC(ctx context.Context, identity models.OIDCIdentity) (models.User, error)
 }
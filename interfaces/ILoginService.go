@@ -7,5 +7,12 @@ import (
 
 type ILoginService interface {
 	Login(ctx context.Context, request models.LoginRequest) (models.LoginResponse, error)
+	LoginMFA(ctx context.Context, request models.LoginMFARequest) (models.LoginResponse, error)
 	SignUp(ctx context.Context, request models.SignUpRequest) error
+	VerifyEmail(ctx context.Context, request models.VerifyEmailRequest) error
+	ResendVerificationEmail(ctx context.Context, request models.ResendVerificationRequest) error
+	ForgotPassword(ctx context.Context, request models.ForgotPasswordRequest) error
+	ResetPassword(ctx context.Context, request models.ResetPasswordRequest) error
+	StartOIDCLogin(ctx context.Context) (models.OIDCLoginResponse, error)
+	FinishOIDCLogin(ctx context.Context, request models.OIDCCallbackRequest) (models.LoginResponse, error)
 }
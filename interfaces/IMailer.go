@@ -0,0 +1,10 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IMailer interface {
+	Send(ctx context.Context, mail models.Mail) error
+}
@@ -3,10 +3,28 @@ package interfaces
 import (
 	"context"
 	"notes-server/models"
+	"time"
 )
 
 type INotesRepository interface {
-	GetNotes(ctx context.Context, email string) ([]models.Note, error)
+	GetNotes(ctx context.Context, request models.GetNotesRequest) ([]models.Note, error)
+	StreamNotes(ctx context.Context, workspace int32, email string, fn func(models.Note) error) error
 	AddNote(ctx context.Context, request models.AddNoteRequest) (int32, error)
-	DeleteNote(ctx context.Context, noteID int32) error
+	AddNotes(ctx context.Context, requests []models.AddNoteRequest) ([]int32, error)
+	SearchNotes(ctx context.Context, request models.SearchNotesRequest) ([]models.Note, error)
+	UpdateNote(ctx context.Context, request models.UpdateNoteRequest) error
+	DeleteNote(ctx context.Context, workspace int32, email string, noteID int32) error
+	GetNote(ctx context.Context, workspace int32, email string, noteID int32) (models.Note, error)
+	GetUsage(ctx context.Context, email string) (models.Usage, error)
+	GetNoteLinks(ctx context.Context, workspace int32, email string, noteID int32) (models.NoteLinksResponse, error)
+	SetNoteFlag(ctx context.Context, flag string, request models.SetNoteFlagRequest) error
+	SetNoteColor(ctx context.Context, request models.SetNoteColorRequest) error
+	SetReminder(ctx context.Context, request models.SetReminderRequest) error
+	SnoozeReminder(ctx context.Context, workspace int32, email string, noteID int32, until time.Time) error
+	GetDueReminders(ctx context.Context, now time.Time) ([]models.Note, error)
+	UpdateReminder(ctx context.Context, firedAt time.Time, note models.Note) error
+	AddChecklistItem(ctx context.Context, request models.AddChecklistItemRequest) (int32, error)
+	ReorderChecklistItems(ctx context.Context, request models.ReorderChecklistItemsRequest) error
+	ToggleChecklistItem(ctx context.Context, request models.ToggleChecklistItemRequest) error
+	RemoveChecklistItem(ctx context.Context, request models.RemoveChecklistItemRequest) error
 }
@@ -2,11 +2,25 @@ package interfaces
 
 import (
 	"context"
+	"io"
 	"notes-server/models"
 )
 
 type INotesService interface {
-	GetNotes(ctx context.Context) ([]models.Note, error)
+	GetNotes(ctx context.Context, request models.GetNotesRequest) ([]models.Note, error)
+	SearchNotes(ctx context.Context, request models.SearchNotesRequest) ([]models.Note, error)
 	AddNote(ctx context.Context, request models.AddNoteRequest) (models.AddNoteResponse, error)
+	CreateNoteFromTemplate(ctx context.Context, request models.CreateNoteFromTemplateRequest) (models.AddNoteResponse, error)
+	UpdateNote(ctx context.Context, request models.UpdateNoteRequest) error
 	DeleteNote(ctx context.Context, request models.DeleteNoteRequest) error
+	SetNoteFlag(ctx context.Context, flag string, request models.SetNoteFlagRequest) error
+	SetNoteColor(ctx context.Context, request models.SetNoteColorRequest) error
+	GetNoteLinks(ctx context.Context, request models.GetNoteLinksRequest) (models.NoteLinksResponse, error)
+	ExportNotes(ctx context.Context, w io.Writer) error
+	ImportNotes(ctx context.Context, request models.ImportNotesRequest) (models.ImportNotesResponse, error)
+	AddChecklistItem(ctx context.Context, request models.AddChecklistItemRequest) (models.AddChecklistItemResponse, error)
+	ReorderChecklistItems(ctx context.Context, request models.ReorderChecklistItemsRequest) error
+	ToggleChecklistItem(ctx context.Context, request models.ToggleChecklistItemRequest) error
+	RemoveChecklistItem(ctx context.Context, request models.RemoveChecklistItemRequest) error
+	GetUsage(ctx context.Context) (models.UsageResponse, error)
 }
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type INotificationsRepository interface {
+	AddNotification(ctx context.Context, notification models.Notification) error
+	GetNotifications(ctx context.Context, email string) ([]models.Notification, error)
+}
@@ -0,0 +1,10 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type INotifier interface {
+	Notify(ctx context.Context, reminder models.Reminder) error
+}
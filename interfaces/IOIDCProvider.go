@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+// IOIDCProvider - an OpenID Connect provider the users sign in with
+type IOIDCProvider interface {
+	AuthCodeURL(ctx context.Context, state, nonce, codeChallenge string) (string, error)
+	Exchange(ctx context.Context, code, codeVerifier, nonce string) (models.OIDCIdentity, error)
+}
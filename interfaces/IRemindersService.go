@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IRemindersService interface {
+	SetReminder(ctx context.Context, request models.SetReminderRequest) error
+	SnoozeReminder(ctx context.Context, request models.SnoozeReminderRequest) error
+	GetNotifications(ctx context.Context) ([]models.Notification, error)
+}
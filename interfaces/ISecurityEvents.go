@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+// ISecurityEvents - where the security events are sent, they are never allowed to fail the request that raised them
+type ISecurityEvents interface {
+	Emit(ctx context.Context, event models.SecurityEvent)
+}
@@ -0,0 +1,13 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type ITemplatesRepository interface {
+	GetTemplates(ctx context.Context, email string) ([]models.Template, error)
+	GetTemplate(ctx context.Context, email string, templateID int32) (models.Template, error)
+	AddTemplate(ctx context.Context, request models.AddTemplateRequest) (int32, error)
+	DeleteTemplate(ctx context.Context, email string, templateID int32) error
+}
@@ -0,0 +1,12 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type ITemplatesService interface {
+	GetTemplates(ctx context.Context) ([]models.Template, error)
+	AddTemplate(ctx context.Context, request models.AddTemplateRequest) (models.AddTemplateResponse, error)
+	DeleteTemplate(ctx context.Context, request models.DeleteTemplateRequest) error
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+	"time"
+)
+
+type IWorkspaceRepository interface {
+	CreateWorkspace(ctx context.Context, email string, workspace models.Workspace) error
+	GetWorkspaces(ctx context.Context, email string) ([]models.UserWorkspace, error)
+	GetMembers(ctx context.Context, workspace int32, email string) ([]models.Membership, error)
+	AddInvitation(ctx context.Context, inviter string, invitation models.Invitation, now time.Time) (models.Workspace, error)
+	AcceptInvitation(ctx context.Context, tokenHash, email string, now time.Time) (models.UserWorkspace, error)
+	RemoveMember(ctx context.Context, workspace int32, actor, email string) error
+}
@@ -0,0 +1,16 @@
+package interfaces
+
+import (
+	"context"
+	"notes-server/models"
+)
+
+type IWorkspaceService interface {
+	GetWorkspaces(ctx context.Context) ([]models.UserWorkspace, error)
+	CreateWorkspace(ctx context.Context, request models.CreateWorkspaceRequest) (models.Workspace, error)
+	SwitchWorkspace(ctx context.Context, request models.SwitchWorkspaceRequest) (models.LoginResponse, error)
+	GetMembers(ctx context.Context) ([]models.Membership, error)
+	InviteMember(ctx context.Context, request models.InviteMemberRequest) error
+	JoinWorkspace(ctx context.Context, request models.JoinWorkspaceRequest) (models.UserWorkspace, error)
+	RemoveMember(ctx context.Context, request models.RemoveMemberRequest) error
+}
@@ -101,3 +101,13 @@ func (l *Logger) Fatal(ctx context.Context, args ...interface{}) {
 	}
 	l.logger.Fatal(args...)
 }
+
+// WarnWithFields - logs args at the warn level with fields, for the entries meant to be searched and alerted on
+func (l *Logger) WarnWithFields(ctx context.Context, fields map[string]interface{}, args ...interface{}) {
+	entry := l.logger.WithFields(fields)
+	requestID := utils.GetRequestIDFromCtx(ctx)
+	if requestID != "" {
+		entry = entry.WithField(constants.RequestIDKey, requestID)
+	}
+	entry.Warn(args...)
+}
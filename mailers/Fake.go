@@ -0,0 +1,36 @@
+package mailers
+
+import (
+	"context"
+	"notes-server/models"
+	"sync"
+)
+
+// FakeMailer - keeps the emails in memory instead of sending them, for tests
+type FakeMailer struct {
+	mu    sync.Mutex
+	mails []models.Mail
+	// Err - returned from Send when set, to simulate a delivery failure
+	Err error
+}
+
+func NewFakeMailer() *FakeMailer {
+	return &FakeMailer{}
+}
+
+func (m *FakeMailer) Send(ctx context.Context, mail models.Mail) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.Err != nil {
+		return m.Err
+	}
+	m.mails = append(m.mails, mail)
+	return nil
+}
+
+// Mails - the emails sent so far
+func (m *FakeMailer) Mails() []models.Mail {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]models.Mail{}, m.mails...)
+}
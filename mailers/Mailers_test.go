@@ -0,0 +1,47 @@
+package mailers
+
+import (
+	"context"
+	"net/smtp"
+	"notes-server/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSMTPMailer_Send(t *testing.T) {
+	var to []string
+	var msg string
+	m := NewSMTPMailer("localhost", 25, "", "", "notes@example.com").(*smtpMailer)
+	m.send = func(addr string, a smtp.Auth, from string, recipients []string, body []byte) error {
+		to = recipients
+		msg = string(body)
+		return nil
+	}
+	err := m.Send(context.Background(), models.Mail{To: "test@gmail.com", Subject: "Hello", Body: "line one\nline two"})
+	if err != nil {
+		t.Fatalf("smtpMailer.Send() error = %v", err)
+	}
+	if len(to) != 1 || to[0] != "test@gmail.com" || !strings.Contains(msg, "Subject: Hello\r\n") || !strings.Contains(msg, "line one\r\nline two") {
+		t.Errorf("smtpMailer.Send() sent %q to %v", msg, to)
+	}
+}
+
+func TestOutboxMailer_Send(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m := NewOutboxMailer(dir, "notes@example.com").(*outboxMailer)
+	m.now = func() time.Time { return time.Unix(1700000000, 0) }
+	err := m.Send(context.Background(), models.Mail{To: "Test User <test@gmail.com>", Subject: "Hello", Body: "hi"})
+	if err != nil {
+		t.Fatalf("outboxMailer.Send() error = %v", err)
+	}
+	content, err := os.ReadFile(filepath.Join(dir, "1700000000000000000-Test_User__test@gmail.com_.eml"))
+	if err != nil {
+		t.Fatalf("outbox file not written: %v", err)
+	}
+	if !strings.Contains(string(content), "To: Test User <test@gmail.com>\r\n") || !strings.HasSuffix(string(content), "\r\n\r\nhi\r\n") {
+		t.Errorf("outbox file = %q", content)
+	}
+}
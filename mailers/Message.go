@@ -0,0 +1,22 @@
+package mailers
+
+import (
+	"bytes"
+	"fmt"
+	"notes-server/models"
+	"strings"
+	"time"
+)
+
+// formatMessage - the RFC 5322 message for mail, line breaks of the body are normalized to CRLF
+func formatMessage(from string, mail models.Mail, date time.Time) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", from)
+	fmt.Fprintf(&msg, "To: %s\r\n", mail.To)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mail.Subject)
+	fmt.Fprintf(&msg, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=UTF-8\r\n\r\n")
+	body := strings.ReplaceAll(mail.Body, "\r\n", "\n")
+	fmt.Fprintf(&msg, "%s\r\n", strings.ReplaceAll(body, "\n", "\r\n"))
+	return msg.Bytes()
+}
@@ -0,0 +1,36 @@
+package mailers
+
+import (
+	"context"
+	"fmt"
+	"notes-server/interfaces"
+	"notes-server/models"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// unsafeFileChars - characters of a recipient that are not kept in the name of its outbox file
+var unsafeFileChars = regexp.MustCompile(`[^A-Za-z0-9@._-]`)
+
+type outboxMailer struct {
+	dir  string
+	from string
+	now  func() time.Time
+}
+
+// NewOutboxMailer - writes every email as an .eml file in dir instead of sending it, for local runs without an
+// SMTP server
+func NewOutboxMailer(dir, from string) interfaces.IMailer {
+	return &outboxMailer{dir: dir, from: from, now: time.Now}
+}
+
+func (m *outboxMailer) Send(ctx context.Context, mail models.Mail) error {
+	if err := os.MkdirAll(m.dir, 0700); err != nil {
+		return err
+	}
+	now := m.now()
+	name := fmt.Sprintf("%d-%s.eml", now.UnixNano(), unsafeFileChars.ReplaceAllString(mail.To, "_"))
+	return os.WriteFile(filepath.Join(m.dir, name), formatMessage(m.from, mail, now), 0600)
+}
@@ -0,0 +1,36 @@
+package mailers
+
+import (
+	"context"
+	"net"
+	"net/smtp"
+	"notes-server/interfaces"
+	"notes-server/models"
+	"strconv"
+	"time"
+)
+
+type smtpMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewSMTPMailer - sends emails through an SMTP server, authenticating only when a username is given
+func NewSMTPMailer(host string, port int, username, password, from string) interfaces.IMailer {
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+	return &smtpMailer{
+		addr: net.JoinHostPort(host, strconv.Itoa(port)),
+		from: from,
+		auth: auth,
+		send: smtp.SendMail,
+	}
+}
+
+func (m *smtpMailer) Send(ctx context.Context, mail models.Mail) error {
+	return m.send(m.addr, m.auth, m.from, []string{mail.To}, formatMessage(m.from, mail, time.Now()))
+}
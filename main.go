@@ -2,8 +2,12 @@
 package main
 
 import (
+	"context"
 	"net/http"
 	"notes-server/config"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"time"
 
 	logrus "github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
@@ -12,9 +16,62 @@ import (
 func main() {
 	config.Load()
 	port := viper.GetString("PORT")
+	signingKeys := ServiceContainer().InjectSigningKeyring()
+	signingKeys.StartRotation(viper.GetDuration(constants.JwtKeyRotationIntervalEnvKey), func(err error) {
+		logrus.Warnf("failed to rotate the signing keys: %v", err)
+	})
+	defer signingKeys.StopRotation()
+	logrus.Infof("Signing session tokens with %s, current key %q", signingKeys.Algorithm(), signingKeys.CurrentKeyID())
+	rewrapped, err := ServiceContainer().InjectDataKeysRepository().RewrapDataKeys(context.Background())
+	if err != nil {
+		logrus.Fatalf("failed to re-wrap the data keys: %v", err)
+	}
+	if rewrapped > 0 {
+		logrus.Infof("Re-wrapped %d data keys with the current master key", rewrapped)
+	}
+	setupToken, err := ServiceContainer().InjectAdminService().Bootstrap(context.Background())
+	if err != nil {
+		logrus.Fatalf("failed to bootstrap the admin: %v", err)
+	}
+	if setupToken != "" {
+		logrus.Warnf("There is no admin, create one with POST /v1/api/setup and the one-time setup token %s", setupToken)
+	}
+	stopAuditPurge := purgeAuditLog(ServiceContainer().InjectAuditService(), viper.GetDuration(constants.AuditPurgeIntervalEnvKey))
+	defer stopAuditPurge()
+	reminderScheduler := ServiceContainer().InjectReminderScheduler()
+	reminderScheduler.Start(context.Background())
+	defer reminderScheduler.Stop()
 	logrus.Infof("Service running on port: %s", port)
-	err := http.ListenAndServe(":"+port, ChiRouter().InitRouter())
+	err = http.ListenAndServe(":"+port, ChiRouter().InitRouter())
 	if err != nil {
 		logrus.Warn("failed to setup service", err)
 	}
 }
+
+// purgeAuditLog - deletes the audit log entries older than the retention now and every interval, until the
+// returned function is called
+func purgeAuditLog(service interfaces.IAuditService, interval time.Duration) func() {
+	if interval <= 0 {
+		logrus.Fatalf("invalid %s: must be positive", constants.AuditPurgeIntervalEnvKey)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			deleted, err := service.PurgeAuditLog(ctx)
+			if err != nil {
+				logrus.Warnf("failed to purge the audit log: %v", err)
+			}
+			if deleted > 0 {
+				logrus.Infof("Deleted %d audit log entries older than the retention", deleted)
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return cancel
+}
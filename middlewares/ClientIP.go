@@ -0,0 +1,44 @@
+package middlewares
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"notes-server/constants"
+	"strings"
+)
+
+// ClientIP - puts the address of the client in the context. It is the address of the connection unless that is
+// one of the trusted proxies, the last address of X-Forwarded-For that is not a trusted proxy is used then. The
+// addresses added by the client itself are never trusted.
+func ClientIP(trustedProxies []string) func(http.Handler) http.Handler {
+	trusted := make(map[string]bool)
+	for _, proxy := range trustedProxies {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			trusted[proxy] = true
+		}
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			ip, _, err := net.SplitHostPort(r.RemoteAddr)
+			if err != nil {
+				ip = r.RemoteAddr
+			}
+			if trusted[ip] {
+				forwarded := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+				for i := len(forwarded) - 1; i >= 0; i-- {
+					hop := strings.TrimSpace(forwarded[i])
+					if net.ParseIP(hop) == nil {
+						break
+					}
+					ip = hop
+					if !trusted[hop] {
+						break
+					}
+				}
+			}
+			ctx := context.WithValue(r.Context(), constants.ClientIPCtxKey, ip)
+			next.ServeHTTP(rw, r.WithContext(ctx))
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package middlewares
+
+import (
+	"net/http"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+// RequirePasswordChanged - refuses the requests of a user who must change their password first, like the admin
+// bootstrapped from the configuration, it goes after TokenValidation
+func RequirePasswordChanged(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		if utils.GetPasswordChangeRequiredFromCtx(r.Context()) {
+			utils.WriteHttpFailure(rw, http.StatusForbidden, models.ErrPasswordChangeRequired)
+			return
+		}
+		next.ServeHTTP(rw, r)
+	})
+}
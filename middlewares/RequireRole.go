@@ -0,0 +1,23 @@
+package middlewares
+
+import (
+	"net/http"
+	"notes-server/apperrors"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+// RequireRole - lets a request through only when the user it was authenticated for has the role, it goes after
+// TokenValidation
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			user := models.User{Role: utils.GetRoleFromCtx(r.Context())}
+			if !user.HasRole(role) {
+				utils.WriteHttpFailure(rw, http.StatusForbidden, apperrors.Forbidden("role_required", "the "+role+" role is required"))
+				return
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package middlewares
+
+import (
+	"net/http"
+	"notes-server/apperrors"
+	"notes-server/constants"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+// RequireScope - lets a request authenticated with a personal access token through only when the token has all
+// the scopes. Sessions are not limited by scopes, with no scopes the routes are only reachable with a session.
+func RequireScope(scopes ...string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			scopesGranted, ok := r.Context().Value(constants.ScopesCtxKey).([]string)
+			if !ok {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			if len(scopes) == 0 {
+				utils.WriteHttpFailure(rw, http.StatusForbidden, apperrors.Forbidden("session_required", "not allowed with an access token"))
+				return
+			}
+			token := models.AccessToken{Scopes: scopesGranted}
+			for _, scope := range scopes {
+				if !token.HasScope(scope) {
+					utils.WriteHttpFailure(rw, http.StatusForbidden, apperrors.Forbidden("scope_required", "access token is missing the "+scope+" scope"))
+					return
+				}
+			}
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
@@ -7,21 +7,27 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"notes-server/apperrors"
 	"notes-server/constants"
 	"notes-server/interfaces"
 	"notes-server/loggers"
 	"notes-server/models"
+	"notes-server/signing"
 	"notes-server/utils"
-
-	"github.com/golang-jwt/jwt/v4"
-	"github.com/spf13/viper"
+	"strings"
+	"time"
 )
 
 type sessionID struct {
 	SID string `json:"sid" validate:"required"`
 }
 
-func TokenValidation(db interfaces.ILoginRepository, logger *loggers.Logger) func(http.Handler) http.Handler {
+// TokenValidation - authenticates the request with the sid in its body, which is either a session or a personal
+// access token. The scopes of an access token are put in the context for RequireScope, the role of the user as
+// stored for RequireRole, so that a role taken away applies to the sessions already issued. Whether the user must
+// change their password is put in the context for RequirePasswordChanged. The workspace of the session or of the
+// access token is put in the context too, the user must still be a member of it.
+func TokenValidation(db interfaces.ILoginRepository, tokens interfaces.IAccessTokensRepository, keys *signing.Keyring, logger *loggers.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
 			ctx := r.Context()
@@ -29,47 +35,92 @@ func TokenValidation(db interfaces.ILoginRepository, logger *loggers.Logger) fun
 			err := json.NewDecoder(r.Body).Decode(&request)
 			if err != nil {
 				logger.Warn(ctx, "error in TokenValidation(), error from utils.GetBodyParams()", err)
-				utils.WriteHttpFailure(rw, http.StatusUnauthorized, err)
+				utils.WriteHttpFailure(rw, http.StatusUnauthorized, sessionFailure(err))
 				return
 			}
 			if _, ok := request["sid"]; !ok {
 				err = errors.New("sid missing")
 				logger.Warn(ctx, "error in TokenValidation(), sid missing", err)
-				utils.WriteHttpFailure(rw, http.StatusUnauthorized, err)
+				utils.WriteHttpFailure(rw, http.StatusUnauthorized, sessionFailure(err))
 				return
 			}
 			if _, ok := request["sid"].(string); !ok {
 				err = errors.New("sid invalid")
 				logger.Warn(ctx, "error in TokenValidation(), sid invalid", err)
-				utils.WriteHttpFailure(rw, http.StatusUnauthorized, err)
-				return
-			}
-			claims := &models.Claims{}
-			token, err := jwt.ParseWithClaims(request["sid"].(string), claims, func(token *jwt.Token) (interface{}, error) {
-				return []byte(viper.GetString(constants.JwtSecretEnvKey)), nil
-			})
-			if err != nil {
-				logger.Warn(ctx, "error in TokenValidation(), error from jwt.ParseWithClaims()", err)
-				utils.WriteHttpFailure(rw, http.StatusUnauthorized, err)
-				return
-			}
-			if !token.Valid {
-				err = errors.New("invalid token")
-				logger.Warn(ctx, "error in TokenValidation(), invalid token", err)
-				utils.WriteHttpFailure(rw, http.StatusUnauthorized, err)
+				utils.WriteHttpFailure(rw, http.StatusUnauthorized, sessionFailure(err))
 				return
 			}
-			err = db.ValidateUser(ctx, claims.Email, claims.Name)
-			if err != nil {
-				logger.Warn(ctx, "error in TokenValidation(), error from db.ValidateUser()", err)
-				utils.WriteHttpFailure(rw, http.StatusUnauthorized, err)
-				return
+			sid := request["sid"].(string)
+			var email, name, role string
+			var workspace int32
+			var mustChangePassword bool
+			var scopes []string
+			if strings.HasPrefix(sid, models.AccessTokenPrefix) {
+				token, err := tokens.UseAccessToken(ctx, utils.HashToken(sid), time.Now())
+				if err != nil {
+					logger.Warn(ctx, "error in TokenValidation(), error from tokens.UseAccessToken()", err)
+					utils.WriteHttpFailure(rw, http.StatusUnauthorized, sessionFailure(err))
+					return
+				}
+				user, _, err := db.GetMember(ctx, token.Workspace, token.Email)
+				if err != nil {
+					logger.Warn(ctx, "error in TokenValidation(), error from db.GetMember()", err)
+					utils.WriteHttpFailure(rw, http.StatusUnauthorized, sessionFailure(err))
+					return
+				}
+				if user.Disabled {
+					err = models.ErrAccountDisabled
+					logger.Warn(ctx, "error in TokenValidation(), account disabled", err)
+					utils.WriteHttpFailure(rw, http.StatusUnauthorized, sessionFailure(err))
+					return
+				}
+				email, name, role, scopes = user.Email, user.Name, user.Role, token.Scopes
+				workspace = token.Workspace
+				mustChangePassword = user.MustChangePassword
+			} else {
+				claims := &models.Claims{}
+				token, err := keys.Parse(sid, claims)
+				if err != nil {
+					logger.Warn(ctx, "error in TokenValidation(), error from keys.Parse()", err)
+					utils.WriteHttpFailure(rw, http.StatusUnauthorized, sessionFailure(err))
+					return
+				}
+				if !token.Valid {
+					err = errors.New("invalid token")
+					logger.Warn(ctx, "error in TokenValidation(), invalid token", err)
+					utils.WriteHttpFailure(rw, http.StatusUnauthorized, sessionFailure(err))
+					return
+				}
+				user, err := db.ValidateUser(ctx, claims.Email, claims.Workspace, claims.SessionVersion)
+				if err != nil {
+					logger.Warn(ctx, "error in TokenValidation(), error from db.ValidateUser()", err)
+					utils.WriteHttpFailure(rw, http.StatusUnauthorized, sessionFailure(err))
+					return
+				}
+				email, name, role, workspace = claims.Email, user.Name, user.Role, claims.Workspace
+				mustChangePassword = user.MustChangePassword
 			}
 			delete(request, "sid")
 			req, _ := json.Marshal(request)
 			r.Body = io.NopCloser(bytes.NewBuffer(req))
-			ctx = context.WithValue(r.Context(), constants.EmailCtxKey, claims.Email)
+			ctx = context.WithValue(r.Context(), constants.EmailCtxKey, email)
+			ctx = context.WithValue(ctx, constants.NameCtxKey, name)
+			ctx = context.WithValue(ctx, constants.RoleCtxKey, role)
+			ctx = context.WithValue(ctx, constants.WorkspaceCtxKey, workspace)
+			ctx = context.WithValue(ctx, constants.PasswordChangeRequiredCtxKey, mustChangePassword)
+			if scopes != nil {
+				ctx = context.WithValue(ctx, constants.ScopesCtxKey, scopes)
+			}
 			next.ServeHTTP(rw, r.WithContext(ctx))
 		})
 	}
 }
+
+// sessionFailure - the error sent for a refused session or access token, why it was refused stays in the logs
+// unless it is a domain error
+func sessionFailure(err error) error {
+	if apperrors.As(err) != nil {
+		return err
+	}
+	return models.ErrInvalidSession
+}
@@ -0,0 +1,15 @@
+package middlewares
+
+import (
+	"context"
+	"net/http"
+	"notes-server/constants"
+)
+
+// UserAgent - puts the User-Agent header of the request in the context, for the audit log
+func UserAgent(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		ctx := context.WithValue(r.Context(), constants.UserAgentCtxKey, r.UserAgent())
+		next.ServeHTTP(rw, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"notes-server/apperrors"
+	"time"
+)
+
+var (
+	// ErrAccessTokenNotFound - the user has no access token with the id
+	ErrAccessTokenNotFound = apperrors.NotFound("access_token_not_found", "access token not found")
+	// ErrInvalidAccessToken - the access token is unknown, revoked or expired
+	ErrInvalidAccessToken = apperrors.Unauthorized("invalid_access_token", "invalid or expired access token")
+)
+
+// Scopes of the personal access tokens, a session has all of them
+const (
+	ScopeNotesRead  = "notes:read"
+	ScopeNotesWrite = "notes:write"
+)
+
+// AccessTokenPrefix - starts every personal access token, which tells them apart from the session tokens
+const AccessTokenPrefix = "pat_"
+
+// AccessToken - a personal access token used by scripts instead of a session, only the hash of the token is
+// stored
+type AccessToken struct {
+	Id        int32  `json:"id"`
+	TokenHash string `json:"-"`
+	Email     string `json:"-"`
+	// Workspace - the workspace the token was created in, it only gives access to the notes of this workspace
+	Workspace  int32      `json:"workspace_id"`
+	Name       string     `json:"name"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+}
+
+// AuditSummary - what the audit log keeps of the token
+func (t AccessToken) AuditSummary() AuditSummary {
+	return AuditSummary{"name": t.Name, "workspace": t.Workspace, "scopes": t.Scopes}
+}
+
+// HasScope - tells whether the token grants scope
+func (t AccessToken) HasScope(scope string) bool {
+	for _, s := range t.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateAccessTokenRequest - the token never expires when ExpiresAt is not set
+type CreateAccessTokenRequest struct {
+	Email     string
+	Workspace int32      `json:"-"`
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes" validate:"required,min=1,dive,oneof=notes:read notes:write"`
+	ExpiresAt *time.Time `json:"expires_at" validate:"omitempty,gt"`
+}
+
+// CreateAccessTokenResponse - Token is only ever returned here
+type CreateAccessTokenResponse struct {
+	Token       string      `json:"token"`
+	AccessToken AccessToken `json:"access_token"`
+}
+
+type RevokeAccessTokenRequest struct {
+	Id int32 `json:"id" validate:"required"`
+}
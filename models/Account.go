@@ -0,0 +1,28 @@
+package models
+
+// Account - the profile of the user, PendingEmail is the address waiting to be verified before replacing Email
+type Account struct {
+	Email        string `json:"email"`
+	Name         string `json:"name"`
+	Verified     bool   `json:"verified"`
+	PendingEmail string `json:"pending_email,omitempty"`
+	MFAEnabled   bool   `json:"mfa_enabled"`
+}
+
+// UpdateAccountRequest - empty fields are left as they are, a new email is only applied once verified
+type UpdateAccountRequest struct {
+	Email    string
+	Name     string `json:"name" validate:"max=100"`
+	NewEmail string `json:"new_email" validate:"omitempty,email,max=254"`
+}
+
+type ChangePasswordRequest struct {
+	Email           string
+	CurrentPassword string `json:"current_password" validate:"required"`
+	NewPassword     string `json:"new_password" validate:"required,password"`
+}
+
+type DeleteAccountRequest struct {
+	Email    string
+	Password string `json:"password" validate:"required"`
+}
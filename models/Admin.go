@@ -0,0 +1,62 @@
+package models
+
+import "notes-server/apperrors"
+
+var (
+	// ErrUserNotFound - no user is registered with the email address
+	ErrUserNotFound = apperrors.NotFound("user_not_found", "user not found")
+	// ErrManageOwnAccount - admins can not disable their own account, which could leave no admin to enable it
+	ErrManageOwnAccount = apperrors.Conflict("manage_own_account", "admins can not disable their own account")
+	// ErrAdminExists - the service is already set up, admins are only bootstrapped while there is none
+	ErrAdminExists = apperrors.Conflict("admin_exists", "an admin account already exists")
+	// ErrInvalidSetupToken - the setup token is wrong or was already used
+	ErrInvalidSetupToken = apperrors.Unauthorized("invalid_setup_token", "invalid setup token")
+)
+
+// DefaultUsersLimit - users listed when the request sets no limit
+const DefaultUsersLimit = 50
+
+// UserSummary - a user as listed to the admins, with the number of notes they own and the bytes they take
+type UserSummary struct {
+	Id         int32  `json:"id"`
+	Email      string `json:"email"`
+	Name       string `json:"name"`
+	Role       string `json:"role"`
+	Verified   bool   `json:"verified"`
+	Disabled   bool   `json:"disabled"`
+	MFAEnabled bool   `json:"mfa_enabled"`
+	Usage      Usage  `json:"usage"`
+}
+
+// ListUsersRequest - Query is matched against the email and the name of the users ignoring case, the users are
+// ordered by email
+type ListUsersRequest struct {
+	Query  string `json:"query"`
+	Role   string `json:"role" validate:"omitempty,oneof=user admin"`
+	Offset int    `json:"offset" validate:"min=0"`
+	Limit  int    `json:"limit" validate:"min=0,max=100"`
+}
+
+// ListUsersResponse - Total is the number of users matching the request, whatever the offset and limit
+type ListUsersResponse struct {
+	Users []UserSummary `json:"users"`
+	Total int           `json:"total"`
+}
+
+// ManageUserRequest - the user an admin acts on
+type ManageUserRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+// SetupToken - the one-time token printed at startup while there is no admin, only its hash is stored
+type SetupToken struct {
+	TokenHash string
+}
+
+// SetupRequest - creates the first admin with the setup token printed at startup
+type SetupRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Name     string `json:"name" validate:"required,max=100"`
+	Password string `json:"password" validate:"required,password"`
+}
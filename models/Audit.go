@@ -0,0 +1,90 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Actions recorded in the audit log
+const (
+	AuditLogin              = "login"
+	AuditLoginMFA           = "login.mfa"
+	AuditLoginOIDC          = "login.oidc"
+	AuditSignUp             = "signup"
+	AuditAccessTokenRevoked = "access_token.revoked"
+	// the changes to a user, all but the enabling revoke their sessions
+	AuditEmailChanged        = "user.email_changed"
+	AuditPasswordChanged     = "user.password_changed"
+	AuditPasswordReset       = "user.password_reset"
+	AuditPasswordResetForced = "user.password_reset_forced"
+	AuditUserDisabled        = "user.disabled"
+	AuditUserEnabled         = "user.enabled"
+	AuditOIDCLinked          = "user.oidc_linked"
+	AuditNoteCreated         = "note.created"
+	AuditNoteUpdated         = "note.updated"
+	AuditNoteDeleted         = "note.deleted"
+)
+
+// Outcomes of the audited actions, only the logins are recorded when they fail. AuditMFARequired - the password
+// or the identity provider was accepted and the login waits for the second factor.
+const (
+	AuditSuccess     = "success"
+	AuditFailure     = "failure"
+	AuditMFARequired = "mfa_required"
+)
+
+// DefaultAuditLimit, MaxAuditLimit - entries returned when the request sets no limit, and at most
+const (
+	DefaultAuditLimit = 100
+	MaxAuditLimit     = 1000
+)
+
+// AuditSummary - the state of the target of an audit entry before or after the change. It never holds secrets or
+// the content of the notes.
+type AuditSummary map[string]interface{}
+
+// AuditEntry - a security-relevant or data-changing event, written in the transaction of the change and never
+// modified. The entries are only deleted once they are older than the retention.
+type AuditEntry struct {
+	// Id - the time the entry was written in nanoseconds, moved forward on a collision, so the entries are
+	// ordered by it
+	Id     int64     `json:"id"`
+	At     time.Time `json:"at"`
+	Action string    `json:"action"`
+	// Outcome - AuditSuccess, AuditFailure with the code of the error in Reason, or AuditMFARequired
+	Outcome string `json:"outcome"`
+	Reason  string `json:"reason,omitempty"`
+	// Actor - email address of the user who did it, or tried to for a failed login
+	Actor     string       `json:"actor,omitempty"`
+	Target    string       `json:"target,omitempty"`
+	RequestID string       `json:"request_id,omitempty"`
+	IP        string       `json:"ip,omitempty"`
+	UserAgent string       `json:"user_agent,omitempty"`
+	Before    AuditSummary `json:"before,omitempty"`
+	After     AuditSummary `json:"after,omitempty"`
+}
+
+// AuditTarget - the target of an audit entry, as kind:id
+func AuditTarget(kind string, id interface{}) string {
+	return fmt.Sprintf("%s:%v", kind, id)
+}
+
+// GetAuditLogRequest - every filter that is set must match, the entries are returned newest first. Before is the
+// Next of the previous page.
+type GetAuditLogRequest struct {
+	Actor     string     `json:"actor" validate:"max=254"`
+	Action    string     `json:"action" validate:"max=100"`
+	Target    string     `json:"target" validate:"max=200"`
+	RequestID string     `json:"request_id" validate:"max=100"`
+	Outcome   string     `json:"outcome" validate:"omitempty,oneof=success failure mfa_required"`
+	Since     *time.Time `json:"since"`
+	Until     *time.Time `json:"until"`
+	Before    int64      `json:"before" validate:"min=0"`
+	Limit     int        `json:"limit" validate:"min=0,max=1000"`
+}
+
+// GetAuditLogResponse - Next is set when there are older matching entries, pass it as Before to get them
+type GetAuditLogResponse struct {
+	Entries []AuditEntry `json:"entries"`
+	Next    int64        `json:"next,omitempty"`
+}
@@ -0,0 +1,60 @@
+package models
+
+import "notes-server/apperrors"
+
+var (
+	// ErrNotChecklist - items can only be changed on a checklist note
+	ErrNotChecklist = apperrors.Conflict("not_checklist", "note is not a checklist")
+	// ErrChecklistItemNotFound - the checklist has no item with the id
+	ErrChecklistItemNotFound = apperrors.NotFound("checklist_item_not_found", "checklist item not found")
+	// ErrInvalidItemOrder - the order of a checklist does not list every item exactly once
+	ErrInvalidItemOrder = apperrors.Validation("invalid_item_order", "item_ids must list every item of the checklist",
+		apperrors.FieldError{Field: "item_ids", Rule: "permutation", Message: "item_ids must list every item of the checklist once"})
+)
+
+type ChecklistItem struct {
+	Id   int32  `json:"id"`
+	Text string `json:"text" validate:"required,max=1000"`
+	Done bool   `json:"done"`
+}
+
+type ChecklistProgress struct {
+	Done  int `json:"done"`
+	Total int `json:"total"`
+}
+
+type AddChecklistItemRequest struct {
+	Email     string
+	Workspace int32  `json:"-"`
+	Id        int32  `json:"id" validate:"required"`
+	Text      string `json:"text" validate:"required,max=1000"`
+	// Position - index at which the item is inserted, the item is appended when it is missing or out of range
+	Position *int `json:"position" validate:"omitempty,min=0"`
+}
+
+type AddChecklistItemResponse struct {
+	ItemId int32 `json:"item_id"`
+}
+
+type ReorderChecklistItemsRequest struct {
+	Email     string
+	Workspace int32   `json:"-"`
+	Id        int32   `json:"id" validate:"required"`
+	ItemIds   []int32 `json:"item_ids" validate:"required"`
+}
+
+type ToggleChecklistItemRequest struct {
+	Email     string
+	Workspace int32 `json:"-"`
+	Id        int32 `json:"id" validate:"required"`
+	ItemId    int32 `json:"item_id" validate:"required"`
+	// Done - state to set, the item is flipped when it is missing
+	Done *bool `json:"done"`
+}
+
+type RemoveChecklistItemRequest struct {
+	Email     string
+	Workspace int32 `json:"-"`
+	Id        int32 `json:"id" validate:"required"`
+	ItemId    int32 `json:"item_id" validate:"required"`
+}
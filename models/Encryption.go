@@ -0,0 +1,55 @@
+package models
+
+import "notes-server/apperrors"
+
+var (
+	// ErrKeyMaterialNotFound - the user did not store a note key yet
+	ErrKeyMaterialNotFound = apperrors.NotFound("key_material_not_found", "no key material stored")
+	// ErrKeyMaterialChanged - the key material was replaced since the version the client read
+	ErrKeyMaterialChanged = apperrors.Conflict("key_material_changed", "key material was changed by another client")
+	// ErrIncompleteEncryption - an encrypted body needs both the ciphertext and the parameters to decrypt it
+	ErrIncompleteEncryption = apperrors.Validation("incomplete_encryption", "ciphertext and encryption must be given together",
+		apperrors.FieldError{Field: "ciphertext", Rule: "required_with", Message: "ciphertext is required with encryption"},
+		apperrors.FieldError{Field: "encryption", Rule: "required_with", Message: "encryption is required with ciphertext"})
+	// ErrInvalidEncryptedNote - the title and the items of an encrypted note would be readable by the server
+	ErrInvalidEncryptedNote = apperrors.Validation("invalid_encrypted_note", "encrypted notes can not have a title, a plaintext body or items")
+)
+
+const (
+	// EncryptionSchemeAESGCM - note bodies encrypted with AES-256-GCM under the note key of the user
+	EncryptionSchemeAESGCM = "aes-256-gcm"
+	// KeyWrapSchemePBKDF2AESGCM - note key encrypted with AES-256-GCM under a key derived from a passphrase
+	// with PBKDF2-SHA256
+	KeyWrapSchemePBKDF2AESGCM = "pbkdf2-sha256+aes-256-gcm"
+)
+
+// KeyMaterial - the note key of a user, wrapped on the client by a key the server never sees. Byte fields are
+// base64 encoded in JSON.
+type KeyMaterial struct {
+	Email      string `json:"-"`
+	Scheme     string `json:"scheme" validate:"required,oneof=pbkdf2-sha256+aes-256-gcm"`
+	Salt       []byte `json:"salt" validate:"required,min=16"`
+	Iterations int    `json:"iterations" validate:"required,min=100000"`
+	Nonce      []byte `json:"nonce" validate:"required"`
+	WrappedKey []byte `json:"wrapped_key" validate:"required"`
+	// Version - incremented on every change, a change must name the version it replaces
+	Version int `json:"version"`
+}
+
+// Encryption - how the ciphertext of an end-to-end encrypted note was produced
+type Encryption struct {
+	Scheme     string `json:"scheme" validate:"required,oneof=aes-256-gcm"`
+	Nonce      []byte `json:"nonce" validate:"required"`
+	KeyVersion int    `json:"key_version"`
+}
+
+type SetKeyMaterialResponse struct {
+	Version int `json:"version"`
+}
+
+// DataKey - the key the notes of a user are encrypted at rest with, wrapped by a master key of the server
+type DataKey struct {
+	Email       string
+	MasterKeyId string
+	WrappedKey  []byte
+}
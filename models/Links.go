@@ -0,0 +1,31 @@
+package models
+
+// NoteLink - a [[Title]] link found in the body of a note, stored in the link graph
+type NoteLink struct {
+	Id        int32
+	SourceId  int32
+	Workspace int32
+	Owner     string
+	// Key - the lowercased title the link is resolved with
+	Key   string
+	Title string
+	// Position - order of the link in the body of the source note
+	Position int
+	// TargetId - the linked note, 0 while no note of the owner has the title
+	TargetId int32
+}
+
+type GetNoteLinksRequest struct {
+	Id int32 `json:"id" validate:"required"`
+}
+
+type LinkedNote struct {
+	Id       int32  `json:"id,omitempty"`
+	Title    string `json:"title"`
+	Dangling bool   `json:"dangling,omitempty"`
+}
+
+type NoteLinksResponse struct {
+	Links     []LinkedNote `json:"links"`
+	Backlinks []LinkedNote `json:"backlinks"`
+}
@@ -1,21 +1,60 @@
 package models
 
+import "time"
+
 type LoginRequest struct {
-	Email    string `json:"email" validate:"required"`
+	Email    string `json:"email" validate:"required,max=254"`
 	Password string `json:"password" validate:"required"`
 }
 
+// LoginResponse - when MFARequired is set there is no SID yet, MFAToken is exchanged for it with a TOTP or
+// recovery code. When PasswordChangeRequired is set the session can only be used to change the password.
 type LoginResponse struct {
-	SID string `json:"sid"`
+	SID                    string `json:"sid,omitempty"`
+	MFARequired            bool   `json:"mfa_required,omitempty"`
+	MFAToken               string `json:"mfa_token,omitempty"`
+	PasswordChangeRequired bool   `json:"password_change_required,omitempty"`
 }
 
 type LoginRepoResponse struct {
-	Email string
-	Name  string
+	Email          string
+	Name           string
+	Verified       bool
+	SessionVersion int
+	MFAEnabled     bool
+	Role           string
+	Disabled       bool
+	// MustChangePassword - see User.MustChangePassword
+	MustChangePassword bool
+	Workspace          int32
 }
 
 type SignUpRequest struct {
-	Email    string `json:"email" validate:"required"`
-	Password string `json:"password" validate:"required"`
-	Name     string `json:"name" validate:"required"`
+	Email    string `json:"email" validate:"required,email,max=254"`
+	Password string `json:"password" validate:"required,password"`
+	Name     string `json:"name" validate:"required,max=100"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+type ResendVerificationRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
+
+type ResetPasswordRequest struct {
+	Token    string `json:"token" validate:"required"`
+	Password string `json:"password" validate:"required,password"`
+}
+
+// PasswordReset - a pending password reset, only the hash of its token is stored
+type PasswordReset struct {
+	TokenHash string
+	Email     string
+	ExpiresAt time.Time
 }
@@ -0,0 +1,98 @@
+package models
+
+import (
+	"notes-server/apperrors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrInvalidCredentials - returned whether the email or the password is wrong, so that the response does not
+	// tell which addresses are registered
+	ErrInvalidCredentials = apperrors.Unauthorized("invalid_credentials", "invalid email or password")
+	// ErrTooManyLoginAttempts - the account or the client is backed off or locked out after failed logins
+	ErrTooManyLoginAttempts = apperrors.New(apperrors.KindTooManyRequests, "too_many_login_attempts", "too many failed login attempts, try again later")
+)
+
+// LoginThrottledError - a login refused until RetryAt. Lockouts lists the failures that locked an account or a
+// client out with the attempt, it is empty when they were locked out before.
+type LoginThrottledError struct {
+	RetryAt  time.Time
+	Lockouts []LoginFailures
+}
+
+func (e *LoginThrottledError) Error() string {
+	return ErrTooManyLoginAttempts.Error()
+}
+
+func (e *LoginThrottledError) Unwrap() error {
+	return ErrTooManyLoginAttempts
+}
+
+// LoginThrottlePolicy - how the failed logins of an account or of a client IP are slowed down
+type LoginThrottlePolicy struct {
+	// BackoffAfter - failures accepted before the backoff starts
+	BackoffAfter int
+	// BaseDelay - delay after the first failure over BackoffAfter, it doubles with every failure up to MaxDelay
+	BaseDelay time.Duration
+	MaxDelay  time.Duration
+	// LockoutAfter - failures after which no attempt is accepted for LockoutDuration
+	LockoutAfter    int
+	LockoutDuration time.Duration
+	// Window - failures are forgotten once there was none for this long
+	Window time.Duration
+}
+
+// Delay - how long no attempt is accepted after the failure number failures, and whether it is a lockout
+func (p LoginThrottlePolicy) Delay(failures int) (time.Duration, bool) {
+	if p.LockoutAfter > 0 && failures >= p.LockoutAfter {
+		return p.LockoutDuration, true
+	}
+	if failures <= p.BackoffAfter {
+		return 0, false
+	}
+	delay := p.BaseDelay
+	for i := p.BackoffAfter + 1; i < failures && delay < p.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	return delay, false
+}
+
+// LoginThrottle - the policies failed logins are counted with, per account and per client IP
+type LoginThrottle struct {
+	Account LoginThrottlePolicy
+	Client  LoginThrottlePolicy
+}
+
+// LoginAttempt - where and when a login is attempted, and how it is throttled
+type LoginAttempt struct {
+	// IP - address of the client, the client is not throttled when it is unknown
+	IP       string
+	At       time.Time
+	Throttle LoginThrottle
+}
+
+// LoginFailures - the recent failed logins of an account or of a client IP
+type LoginFailures struct {
+	// Key - AccountThrottleKey or ClientThrottleKey
+	Key           string
+	Failures      int
+	LastFailureAt time.Time
+	// RetryAt - no attempt is accepted before, set by the backoff or the lockout
+	RetryAt   time.Time
+	LockedOut bool
+	// ExpiresAt - the failures are forgotten then, once the window and the delay are over
+	ExpiresAt time.Time
+}
+
+// AccountThrottleKey - failures are counted per email address whether it is registered or not
+func AccountThrottleKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ClientThrottleKey(ip string) string {
+	return "ip:" + ip
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"notes-server/apperrors"
+	"time"
+)
+
+// ErrNoMFAEnrollment - a code was confirmed while no two-factor authentication enrollment is waiting for one
+var ErrNoMFAEnrollment = apperrors.Conflict("no_mfa_enrollment", "no two-factor authentication enrollment in progress")
+
+// MFA - the TOTP two-factor authentication settings of a user
+type MFA struct {
+	Enabled bool
+	Secret  string
+	// PendingSecret - the secret of an enrollment waiting for its first code, it replaces Secret once confirmed
+	PendingSecret string
+	// LastStep - time step of the last TOTP code accepted, a code is never accepted twice
+	LastStep int64
+	// RecoveryCodes - hashes of the one time codes that are accepted instead of a TOTP code
+	RecoveryCodes []string
+}
+
+// MFAEnrollment - the secret to add to an authenticator app, URI is the otpauth URI shown as a QR code
+type MFAEnrollment struct {
+	Secret string `json:"secret"`
+	URI    string `json:"otpauth_uri"`
+}
+
+// EnrollMFARequest - Code is required when two-factor authentication is already enabled, the enrollment then
+// moves it to a new device
+type EnrollMFARequest struct {
+	Email string
+	Code  string `json:"code"`
+}
+
+type MFACodeRequest struct {
+	Email string
+	Code  string `json:"code" validate:"required"`
+}
+
+type DisableMFARequest struct {
+	Email    string
+	Password string `json:"password" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
+
+type RecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallenge - the challenge token of a login exchanged for a session, kept until it expires so that it is not
+// exchanged again
+type MFAChallenge struct {
+	ID        string
+	ExpiresAt time.Time
+}
+
+// LoginMFARequest - second step of the login of a user with two-factor authentication
+type LoginMFARequest struct {
+	MFAToken string `json:"mfa_token" validate:"required"`
+	Code     string `json:"code" validate:"required"`
+}
@@ -0,0 +1,8 @@
+package models
+
+// Mail - a plain text email to a single recipient
+type Mail struct {
+	To      string
+	Subject string
+	Body    string
+}
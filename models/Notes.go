@@ -1,20 +1,195 @@
 package models
 
+import (
+	"notes-server/apperrors"
+	"time"
+)
+
+var (
+	// ErrNoteNotFound - the note does not exist, or belongs to another user or another workspace
+	ErrNoteNotFound = apperrors.NotFound("note_not_found", "note not found")
+	// ErrNoteTitleTaken - titles are the targets of [[Title]] links, so they are unique among the notes of a user
+	ErrNoteTitleTaken = apperrors.Conflict("note_title_taken", "a note with this title already exists")
+	// ErrItemsNotChecklist - items were given for a note that is not a checklist
+	ErrItemsNotChecklist = apperrors.Validation("items_not_checklist", "only checklist notes can have items",
+		apperrors.FieldError{Field: "items", Rule: "checklist", Message: "items are only allowed on checklist notes"})
+	// ErrInvalidTitle - the title contains the syntax of the [[Title]] links
+	ErrInvalidTitle = apperrors.Validation("invalid_title", "title can not contain '[', ']', '|' or line breaks",
+		apperrors.FieldError{Field: "title", Rule: "link_syntax", Message: "title can not contain '[', ']', '|' or line breaks"})
+	// ErrInvalidArchive - the archive to import is not an export of notes that can be read, the reason is in the
+	// cause
+	ErrInvalidArchive = apperrors.Validation("invalid_archive", "the archive is not a valid export of notes")
+)
+
+const (
+	NoteTypeText      = "text"
+	NoteTypeChecklist = "checklist"
+)
+
+const (
+	NoteFlagPinned   = "pinned"
+	NoteFlagArchived = "archived"
+	NoteFlagStarred  = "starred"
+)
+
 type Note struct {
-	Id        int32  `json:"id"`
-	Note      string `json:"note"`
-	CreatedBy string `json:"-"`
+	Id    int32  `json:"id"`
+	Type  string `json:"type"`
+	Title string `json:"title,omitempty"`
+	Note  string `json:"note"`
+	// Ciphertext - the body of an end-to-end encrypted note, which has an empty Note
+	Ciphertext []byte      `json:"ciphertext,omitempty"`
+	Encryption *Encryption `json:"encryption,omitempty"`
+	CreatedBy  string      `json:"-"`
+	// Workspace - the notes of a user are only seen in the workspace they were created in
+	Workspace    int32      `json:"-"`
+	Pinned       bool       `json:"pinned"`
+	Archived     bool       `json:"archived"`
+	Starred      bool       `json:"starred"`
+	Color        string     `json:"color,omitempty"`
+	DueAt        *time.Time `json:"due_at,omitempty"`
+	RemindAt     *time.Time `json:"remind_at,omitempty"`
+	SnoozedUntil *time.Time `json:"snoozed_until,omitempty"`
+	Recurrence   string     `json:"recurrence,omitempty"`
+	// RemindersSent - number of reminders fired so far, used for the COUNT part of a recurrence
+	RemindersSent int `json:"-"`
+	// Items - the ordered items of a checklist note
+	Items []ChecklistItem `json:"items,omitempty"`
+	// Progress - completion counts of a checklist note, computed when listing notes and never stored
+	Progress *ChecklistProgress `json:"progress,omitempty"`
+	// Size - bytes counted against the storage quota of the user, see ContentSize
+	Size int `json:"size"`
+	// SealedBody - the body and items encrypted at rest with the data key of the user, which leaves them empty
+	SealedBody []byte `json:"-"`
+}
+
+// IsEncrypted - the body of an end-to-end encrypted note can only be read by the clients of the user
+func (n Note) IsEncrypted() bool {
+	return n.Encryption != nil
+}
+
+// ContentSize - bytes taken by the title, body and items of the note, its metadata is not counted
+func (n Note) ContentSize() int {
+	size := len(n.Title) + len(n.Note) + len(n.Ciphertext)
+	for _, item := range n.Items {
+		size += len(item.Text)
+	}
+	return size
+}
+
+// AuditSummary - what the audit log keeps of the note, the body and the items are left out
+func (n Note) AuditSummary() AuditSummary {
+	return AuditSummary{
+		"type":      n.Type,
+		"title":     n.Title,
+		"workspace": n.Workspace,
+		"size":      n.Size,
+		"items":     len(n.Items),
+		"encrypted": n.IsEncrypted(),
+		"pinned":    n.Pinned,
+		"archived":  n.Archived,
+		"starred":   n.Starred,
+		"color":     n.Color,
+	}
+}
+
+// NextReminderAt - time at which the next reminder of the note has to be fired, nil if none is scheduled
+func (n Note) NextReminderAt() *time.Time {
+	if n.SnoozedUntil != nil {
+		return n.SnoozedUntil
+	}
+	return n.RemindAt
 }
 
 type AddNoteRequest struct {
-	Email string
-	Note  string `json:"note" validate:"required"`
+	Email      string
+	Workspace  int32           `json:"-"`
+	Type       string          `json:"type" validate:"omitempty,oneof=text checklist"`
+	Title      string          `json:"title" validate:"max=200"`
+	Note       string          `json:"note" validate:"required_without_all=Items Ciphertext"`
+	Ciphertext []byte          `json:"ciphertext"`
+	Encryption *Encryption     `json:"encryption"`
+	Items      []ChecklistItem `json:"items" validate:"dive"`
+	Pinned     bool            `json:"pinned"`
+	Archived   bool            `json:"archived"`
+	Starred    bool            `json:"starred"`
+	Color      string          `json:"color" validate:"omitempty,oneof=red orange yellow green blue purple gray"`
+	DueAt      *time.Time      `json:"due_at"`
+	RemindAt   *time.Time      `json:"remind_at"`
+	Recurrence string          `json:"recurrence"`
+}
+
+// GetNotesRequest - notes are listed pinned first, Archived lists the archived notes instead of the others and
+// Starred lists only the starred ones
+type GetNotesRequest struct {
+	Email     string
+	Workspace int32 `json:"-"`
+	Archived  bool  `json:"archived"`
+	Starred   bool  `json:"starred"`
 }
 
 type AddNoteResponse struct {
 	Id int32 `json:"id"`
 }
 
+type UpdateNoteRequest struct {
+	Email     string
+	Workspace int32   `json:"-"`
+	Id        int32   `json:"id" validate:"required"`
+	Title     *string `json:"title" validate:"omitempty,max=200"`
+	Note      *string `json:"note"`
+	// Ciphertext and Encryption replace the body of an encrypted note, or encrypt a note that was not
+	Ciphertext []byte      `json:"ciphertext"`
+	Encryption *Encryption `json:"encryption"`
+}
+
+// Apply - changes note as requested, encrypting a note drops its plaintext body
+func (r UpdateNoteRequest) Apply(note *Note) {
+	if r.Note != nil {
+		note.Note = *r.Note
+	}
+	if r.Title != nil {
+		note.Title = *r.Title
+	}
+	if r.Encryption != nil {
+		note.Ciphertext = append([]byte{}, r.Ciphertext...)
+		note.Encryption = r.Encryption
+		note.Note = ""
+	}
+}
+
+type SearchNotesRequest struct {
+	Email     string
+	Workspace int32  `json:"-"`
+	Query     string `json:"query" validate:"required,max=200"`
+}
+
+// SetNoteFlagRequest - sets the pinned, archived or starred flag of a note, or flips it when no value is given
+type SetNoteFlagRequest struct {
+	Email     string
+	Workspace int32 `json:"-"`
+	Id        int32 `json:"id" validate:"required"`
+	Value     *bool `json:"value"`
+}
+
+// SetNoteColorRequest - an empty color removes the color label
+type SetNoteColorRequest struct {
+	Email     string
+	Workspace int32  `json:"-"`
+	Id        int32  `json:"id" validate:"required"`
+	Color     string `json:"color" validate:"omitempty,oneof=red orange yellow green blue purple gray"`
+}
+
 type DeleteNoteRequest struct {
 	Id int32 `json:"id" validate:"required"`
 }
+
+type ImportNotesRequest struct {
+	Email     string
+	Workspace int32  `json:"-"`
+	Archive   string `json:"archive" validate:"required"`
+}
+
+type ImportNotesResponse struct {
+	Ids []int32 `json:"ids"`
+}
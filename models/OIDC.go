@@ -0,0 +1,55 @@
+package models
+
+import (
+	"notes-server/apperrors"
+	"time"
+)
+
+var (
+	// ErrOIDCNotConfigured - no OpenID Connect provider is configured to sign in with
+	ErrOIDCNotConfigured = apperrors.NotFound("oidc_not_configured", "sign in with an identity provider is not configured")
+	// ErrOIDCLoginFailed - the sign in at the identity provider was refused, expired or could not be verified
+	ErrOIDCLoginFailed = apperrors.Unauthorized("oidc_login_failed", "sign in with the identity provider failed")
+	// ErrInvalidOIDCCallback - the identity provider redirected back without the code or the state of the sign in
+	ErrInvalidOIDCCallback = apperrors.Validation("invalid_oidc_callback", "the identity provider callback has no code or state")
+)
+
+// OIDCIdentity - the user an OpenID Connect provider signed in, from a validated ID token
+type OIDCIdentity struct {
+	Issuer        string
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// OIDCLink - links the account with Email to the subject of an OpenID Connect provider
+type OIDCLink struct {
+	Issuer  string
+	Subject string
+	Email   string
+}
+
+// OIDCLogin - a sign in started with an OpenID Connect provider, until the provider redirects back with the
+// state. Only the hash of the state is stored.
+type OIDCLogin struct {
+	StateHash    string
+	Nonce        string
+	CodeVerifier string
+	ExpiresAt    time.Time
+}
+
+// OIDCLoginTTL - time the user has to sign in at the identity provider
+const OIDCLoginTTL = 10 * time.Minute
+
+// OIDCLoginResponse - State is not sent in the body, the controller binds it to the browser starting the sign in
+type OIDCLoginResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+	State            string `json:"-"`
+}
+
+// OIDCCallbackRequest - query of the redirect back from the provider
+type OIDCCallbackRequest struct {
+	Code  string
+	State string
+}
@@ -0,0 +1,47 @@
+package models
+
+import (
+	"fmt"
+	"notes-server/apperrors"
+)
+
+// the notes over the size or storage quota are too large, a user at the note limit sends too many requests
+var (
+	ErrNoteTooLarge        = apperrors.New(apperrors.KindTooLarge, "note_too_large", "note is larger than the size limit")
+	ErrNoteLimitReached    = apperrors.New(apperrors.KindTooManyRequests, "note_limit_reached", "note limit reached")
+	ErrStorageLimitReached = apperrors.New(apperrors.KindTooLarge, "storage_limit_reached", "storage limit reached")
+)
+
+// Quota - limits on the notes of every user, a limit of 0 disables it. Sizes are in bytes.
+type Quota struct {
+	MaxNoteSize int   `json:"max_note_size"`
+	MaxNotes    int   `json:"max_notes"`
+	MaxStorage  int64 `json:"max_storage"`
+}
+
+// Usage - number of notes of a user and the bytes they take, as counted by Note.ContentSize
+type Usage struct {
+	Email string `json:"-"`
+	Notes int    `json:"notes"`
+	Bytes int64  `json:"bytes"`
+}
+
+type UsageResponse struct {
+	Usage Usage `json:"usage"`
+	Quota Quota `json:"quota"`
+}
+
+// QuotaExceeded - quota, one of ErrNoteTooLarge, ErrNoteLimitReached or ErrStorageLimitReached, with the limit
+// in its message
+func QuotaExceeded(quota *apperrors.Error, limit int64) *apperrors.Error {
+	exceeded := *quota
+	switch quota {
+	case ErrNoteTooLarge:
+		exceeded.Message = fmt.Sprintf("note is larger than the limit of %d bytes", limit)
+	case ErrNoteLimitReached:
+		exceeded.Message = fmt.Sprintf("note limit of %d notes reached", limit)
+	default:
+		exceeded.Message = fmt.Sprintf("storage limit of %d bytes reached", limit)
+	}
+	return &exceeded
+}
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"notes-server/apperrors"
+	"time"
+)
+
+// ErrRecurrenceWithoutReminder - a recurrence repeats a reminder, so it needs remind_at
+var ErrRecurrenceWithoutReminder = apperrors.Validation("recurrence_without_reminder", "recurrence requires remind_at",
+	apperrors.FieldError{Field: "remind_at", Rule: "required_with", Message: "remind_at is required with recurrence"})
+
+type SetReminderRequest struct {
+	Email      string
+	Workspace  int32      `json:"-"`
+	Id         int32      `json:"id" validate:"required"`
+	DueAt      *time.Time `json:"due_at"`
+	RemindAt   *time.Time `json:"remind_at"`
+	Recurrence string     `json:"recurrence"`
+}
+
+type SnoozeReminderRequest struct {
+	Email     string
+	Workspace int32 `json:"-"`
+	Id        int32 `json:"id" validate:"required"`
+	Minutes   int   `json:"minutes" validate:"required,min=1"`
+}
+
+// Reminder - a reminder that is due, handed over to the notifiers. It names the note by its title, the body is
+// encrypted at rest and never leaves the server with a reminder.
+type Reminder struct {
+	NoteId   int32      `json:"note_id"`
+	Email    string     `json:"email"`
+	Title    string     `json:"title,omitempty"`
+	DueAt    *time.Time `json:"due_at,omitempty"`
+	RemindAt time.Time  `json:"remind_at"`
+}
+
+type Notification struct {
+	Id        int32     `json:"id"`
+	Email     string    `json:"-"`
+	NoteId    int32     `json:"note_id"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Types of the security events
+const (
+	// SecurityEventLoginLockout - an account or a client IP was locked out after failed logins
+	SecurityEventLoginLockout = "login.lockout"
+	// SecurityEventUserDisabled, SecurityEventUserEnabled, SecurityEventPasswordResetForced - an admin acted on
+	// a user, Email is the admin and Subject the email of the user
+	SecurityEventUserDisabled        = "admin.user_disabled"
+	SecurityEventUserEnabled         = "admin.user_enabled"
+	SecurityEventPasswordResetForced = "admin.password_reset_forced"
+	// SecurityEventAdminBootstrapped - the first admin was created at startup or with the setup token, Subject
+	// is the email of the admin
+	SecurityEventAdminBootstrapped = "admin.bootstrapped"
+)
+
+// SecurityEvent - something the operators of the service should know about, like an account under attack
+type SecurityEvent struct {
+	Type      string    `json:"type"`
+	At        time.Time `json:"at"`
+	RequestID string    `json:"request_id,omitempty"`
+	// Email - the account behind the event, as given by the client
+	Email string `json:"email,omitempty"`
+	IP    string `json:"ip,omitempty"`
+	// Subject - what the event applies to, like the throttle key that was locked out
+	Subject string `json:"subject,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+}
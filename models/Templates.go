@@ -0,0 +1,37 @@
+package models
+
+import "notes-server/apperrors"
+
+// ErrTemplateNotFound - no template with the id is global or owned by the user
+var ErrTemplateNotFound = apperrors.NotFound("template_not_found", "template not found")
+
+// Template - a reusable layout for new notes, a template without an owner is global and offered to every user
+type Template struct {
+	Id     int32  `json:"id"`
+	Name   string `json:"name"`
+	Title  string `json:"title,omitempty"`
+	Note   string `json:"note"`
+	Owner  string `json:"-"`
+	Global bool   `json:"global"`
+}
+
+type AddTemplateRequest struct {
+	Email string
+	Name  string `json:"name" validate:"required,max=100"`
+	Title string `json:"title" validate:"max=200"`
+	Note  string `json:"note" validate:"required"`
+}
+
+type AddTemplateResponse struct {
+	Id int32 `json:"id"`
+}
+
+type DeleteTemplateRequest struct {
+	Id int32 `json:"id" validate:"required"`
+}
+
+// CreateNoteFromTemplateRequest - Variables fill the custom {{name}} placeholders of the template
+type CreateNoteFromTemplateRequest struct {
+	TemplateId int32             `json:"template_id" validate:"required"`
+	Variables  map[string]string `json:"variables"`
+}
@@ -1,16 +1,97 @@
 package models
 
-import "github.com/golang-jwt/jwt/v4"
+import (
+	"notes-server/apperrors"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+var (
+	// ErrEmailNotVerified - the user can not log in before following the link of the verification email
+	ErrEmailNotVerified = apperrors.Forbidden("email_not_verified", "email address is not verified")
+	// ErrEmailTaken - another account is registered with the email address
+	ErrEmailTaken = apperrors.Conflict("email_taken", "email address is already registered")
+	// ErrWrongPassword - the current password given to confirm a change does not match
+	ErrWrongPassword = apperrors.Forbidden("wrong_password", "wrong password")
+	// ErrInvalidMFACode - the TOTP or recovery code is wrong, expired or already used
+	ErrInvalidMFACode = apperrors.Forbidden("invalid_mfa_code", "invalid two-factor authentication code")
+	// ErrAccountDisabled - an admin disabled the account, the user can not log in until it is enabled again
+	ErrAccountDisabled = apperrors.Forbidden("account_disabled", "account is disabled")
+	// ErrPasswordChangeRequired - the user was given their password, like a bootstrapped admin, and must choose
+	// their own before using the account
+	ErrPasswordChangeRequired = apperrors.Forbidden("password_change_required", "the password must be changed before using the account")
+	// ErrInvalidSession - the session can not be verified, or the user is gone or signed out of every session
+	// since it was issued
+	ErrInvalidSession = apperrors.Unauthorized("invalid_session", "invalid or expired session")
+	// ErrInvalidResetToken - the password reset code is wrong, used or expired
+	ErrInvalidResetToken = apperrors.Validation("invalid_reset_token", "invalid or expired token")
+	// ErrInvalidVerificationLink - the token of a verification link is wrong, expired or superseded
+	ErrInvalidVerificationLink = apperrors.Validation("invalid_verification_link", "invalid or expired verification link")
+)
+
+// Roles of the users, what an admin can do on top of a user is guarded with RequireRole
+const (
+	RoleUser  = "user"
+	RoleAdmin = "admin"
+)
 
 type User struct {
 	Id       int32
 	Name     string
 	Email    string
 	Password string
+	// Verified - the user followed the link sent to Email
+	Verified bool
+	// PendingEmail - the address the user asked to change Email to, until they follow the link sent to it
+	PendingEmail string
+	// SessionVersion - incremented to revoke all the sessions issued before, sessions carry the version they
+	// were issued with
+	SessionVersion int
+	MFA            MFA
+	// Role - RoleUser or RoleAdmin, the users stored without one are users
+	Role string
+	// Disabled - set by an admin, the user can not log in and their sessions and access tokens are refused
+	Disabled bool
+	// MustChangePassword - the sessions of the user only give access to ChangePassword until it is cleared by a
+	// password change or reset
+	MustChangePassword bool
+	// DefaultWorkspace - the personal workspace created with the user, their sessions start in it
+	DefaultWorkspace int32
+}
+
+// AuditSummary - what the audit log keeps of the user, the password and the two-factor secrets are left out
+func (u User) AuditSummary() AuditSummary {
+	return AuditSummary{
+		"email":                u.Email,
+		"role":                 u.Role,
+		"verified":             u.Verified,
+		"disabled":             u.Disabled,
+		"has_password":         u.Password != "",
+		"mfa_enabled":          u.MFA.Enabled,
+		"must_change_password": u.MustChangePassword,
+		"session_version":      u.SessionVersion,
+	}
+}
+
+// HasRole - whether the user has the role, admins have every role
+func (u User) HasRole(role string) bool {
+	return u.Role == RoleAdmin || role == RoleUser || u.Role == role
 }
 
 type Claims struct {
-	Email string `json:"email"`
-	Name  string `json:"name"`
+	Email          string `json:"email"`
+	Name           string `json:"name"`
+	SessionVersion int    `json:"sv"`
+	Role           string `json:"role"`
+	// Workspace - every query of the notes made with the session is scoped to this workspace
+	Workspace int32 `json:"ws"`
+	jwt.RegisteredClaims
+}
+
+// VerificationClaims - claims of the token in the link of a verification email, NewEmail is set when the user
+// asked to change their address to it
+type VerificationClaims struct {
+	Email    string `json:"email"`
+	NewEmail string `json:"new_email,omitempty"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,103 @@
+package models
+
+import (
+	"notes-server/apperrors"
+	"time"
+)
+
+var (
+	// ErrWorkspaceNotFound - the workspace does not exist or the user is not a member, which are not told apart
+	ErrWorkspaceNotFound = apperrors.NotFound("workspace_not_found", "workspace not found")
+	// ErrNotWorkspaceMember - the user of a session or access token is no longer a member of its workspace
+	ErrNotWorkspaceMember = apperrors.NotFound("not_workspace_member", "not a member of the workspace")
+	// ErrWorkspaceForbidden - the member is not allowed to manage the members of the workspace
+	ErrWorkspaceForbidden = apperrors.Forbidden("workspace_forbidden", "only the owner and the admins of the workspace can manage its members")
+	// ErrInvalidInvitation - the invitation token is wrong, expired, used or sent to another address
+	ErrInvalidInvitation = apperrors.NotFound("invalid_invitation", "invalid or expired invitation")
+	// ErrAlreadyMember - the invited user is a member of the workspace already
+	ErrAlreadyMember = apperrors.Conflict("already_member", "already a member of the workspace")
+	// ErrRemoveOwner - the owner can not be removed from the workspace, nor leave it
+	ErrRemoveOwner = apperrors.Conflict("remove_owner", "the owner of the workspace can not be removed")
+	// ErrPersonalWorkspace - the personal workspace of a user can not be shared
+	ErrPersonalWorkspace = apperrors.Conflict("personal_workspace", "a personal workspace can not be shared")
+)
+
+// Roles of the members of a workspace. The owner and the admins invite and remove members, only the owner
+// removes admins.
+const (
+	WorkspaceRoleOwner  = "owner"
+	WorkspaceRoleAdmin  = "admin"
+	WorkspaceRoleMember = "member"
+)
+
+// PersonalWorkspaceName - name of the workspace created with every user
+const PersonalWorkspaceName = "Personal"
+
+// Workspace - a tenant. The notes are owned by a user within a workspace, a user of several workspaces only sees
+// the notes of the workspace their session is for.
+type Workspace struct {
+	Id   int32  `json:"id"`
+	Name string `json:"name"`
+	// Personal - the workspace created with the user, it can not be shared
+	Personal bool `json:"personal"`
+}
+
+// Membership - the role of a user in a workspace
+type Membership struct {
+	WorkspaceId int32     `json:"workspace_id"`
+	Email       string    `json:"email"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `json:"joined_at"`
+}
+
+// CanManage - whether the member can invite or remove a member with the role
+func (m Membership) CanManage(role string) bool {
+	switch m.Role {
+	case WorkspaceRoleOwner:
+		return role != WorkspaceRoleOwner
+	case WorkspaceRoleAdmin:
+		return role == WorkspaceRoleMember
+	}
+	return false
+}
+
+// Invitation - a pending invitation to join a workspace, only the hash of its token is stored
+type Invitation struct {
+	TokenHash   string
+	WorkspaceId int32
+	Email       string
+	Role        string
+	InvitedBy   string
+	ExpiresAt   time.Time
+}
+
+// UserWorkspace - a workspace as listed to one of its members, Current is set for the workspace of the session
+type UserWorkspace struct {
+	Workspace
+	Role    string `json:"role"`
+	Current bool   `json:"current"`
+}
+
+type CreateWorkspaceRequest struct {
+	Name string `json:"name" validate:"required,max=100"`
+}
+
+// SwitchWorkspaceRequest - responds with a session for the workspace
+type SwitchWorkspaceRequest struct {
+	Id int32 `json:"id" validate:"required"`
+}
+
+type InviteMemberRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+	Role  string `json:"role" validate:"required,oneof=admin member"`
+}
+
+// JoinWorkspaceRequest - accepts an invitation sent to the email address of the user
+type JoinWorkspaceRequest struct {
+	Token string `json:"token" validate:"required"`
+}
+
+// RemoveMemberRequest - members remove themselves to leave the workspace
+type RemoveMemberRequest struct {
+	Email string `json:"email" validate:"required,email,max=254"`
+}
@@ -0,0 +1,25 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"notes-server/interfaces"
+	"notes-server/models"
+)
+
+type emailNotifier struct {
+	mailer interfaces.IMailer
+}
+
+// NewEmailNotifier - delivers reminders as emails to the owner of the note
+func NewEmailNotifier(mailer interfaces.IMailer) interfaces.INotifier {
+	return &emailNotifier{mailer: mailer}
+}
+
+func (n *emailNotifier) Notify(ctx context.Context, reminder models.Reminder) error {
+	return n.mailer.Send(ctx, models.Mail{
+		To:      reminder.Email,
+		Subject: fmt.Sprintf("Reminder for note %d", reminder.NoteId),
+		Body:    message(reminder),
+	})
+}
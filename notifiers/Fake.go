@@ -0,0 +1,36 @@
+package notifiers
+
+import (
+	"context"
+	"notes-server/models"
+	"sync"
+)
+
+// FakeNotifier - keeps the reminders in memory instead of delivering them, for tests and local runs
+type FakeNotifier struct {
+	mu        sync.Mutex
+	reminders []models.Reminder
+	// Err - returned from Notify when set, to simulate a delivery failure
+	Err error
+}
+
+func NewFakeNotifier() *FakeNotifier {
+	return &FakeNotifier{}
+}
+
+func (n *FakeNotifier) Notify(ctx context.Context, reminder models.Reminder) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.Err != nil {
+		return n.Err
+	}
+	n.reminders = append(n.reminders, reminder)
+	return nil
+}
+
+// Reminders - the reminders notified so far
+func (n *FakeNotifier) Reminders() []models.Reminder {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return append([]models.Reminder{}, n.reminders...)
+}
@@ -0,0 +1,46 @@
+package notifiers
+
+import (
+	"context"
+	"fmt"
+	"notes-server/interfaces"
+	"notes-server/models"
+)
+
+// maxMessageTitleLength - number of characters of the title of the note copied into a notification message
+const maxMessageTitleLength = 100
+
+type inAppNotifier struct {
+	repo interfaces.INotificationsRepository
+}
+
+// NewInAppNotifier - delivers reminders as notifications stored for the user and listed through the API
+func NewInAppNotifier(repo interfaces.INotificationsRepository) interfaces.INotifier {
+	return &inAppNotifier{repo: repo}
+}
+
+func (n *inAppNotifier) Notify(ctx context.Context, reminder models.Reminder) error {
+	return n.repo.AddNotification(ctx, models.Notification{
+		Email:     reminder.Email,
+		NoteId:    reminder.NoteId,
+		Message:   message(reminder),
+		CreatedAt: reminder.RemindAt,
+	})
+}
+
+// message - short human readable text of a reminder
+func message(reminder models.Reminder) string {
+	title := []rune(reminder.Title)
+	text := string(title)
+	if len(title) > maxMessageTitleLength {
+		text = string(title[:maxMessageTitleLength]) + "..."
+	}
+	if text == "" {
+		// the notes without a title, and the encrypted ones, are named by their id
+		text = fmt.Sprintf("note %d", reminder.NoteId)
+	}
+	if reminder.DueAt != nil {
+		return fmt.Sprintf("Reminder: %s (due %s)", text, reminder.DueAt.Format("Mon, 02 Jan 2006 15:04 MST"))
+	}
+	return fmt.Sprintf("Reminder: %s", text)
+}
@@ -0,0 +1,37 @@
+package notifiers
+
+import (
+	"context"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+)
+
+type multiNotifier struct {
+	notifiers []interfaces.INotifier
+	logger    *loggers.Logger
+}
+
+// NewMultiNotifier - delivers reminders through all the notifiers. It only fails when none of them succeeded,
+// so that a reminder is not delivered twice by the others when one of them is down.
+func NewMultiNotifier(logger *loggers.Logger, notifiers ...interfaces.INotifier) interfaces.INotifier {
+	return &multiNotifier{notifiers: notifiers, logger: logger}
+}
+
+func (n *multiNotifier) Notify(ctx context.Context, reminder models.Reminder) error {
+	var lastErr error
+	delivered := false
+	for _, notifier := range n.notifiers {
+		err := notifier.Notify(ctx, reminder)
+		if err != nil {
+			n.logger.Warn(ctx, "error in multiNotifier.Notify(), error from notifier.Notify()", err)
+			lastErr = err
+			continue
+		}
+		delivered = true
+	}
+	if !delivered {
+		return lastErr
+	}
+	return nil
+}
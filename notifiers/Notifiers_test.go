@@ -0,0 +1,83 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"notes-server/loggers"
+	"notes-server/mailers"
+	"notes-server/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWebhookNotifier_Notify(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "success case", status: http.StatusNoContent, wantErr: false},
+		{name: "failure case - webhook error", status: http.StatusBadGateway, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got models.Reminder
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				json.NewDecoder(r.Body).Decode(&got)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+			n := NewWebhookNotifier(server.URL, time.Second)
+			err := n.Notify(context.Background(), models.Reminder{NoteId: 123, Email: "test@gmail.com", Title: "test"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("webhookNotifier.Notify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.NoteId != 123 {
+				t.Errorf("webhook received %v", got)
+			}
+		})
+	}
+}
+
+func TestEmailNotifier_Notify(t *testing.T) {
+	mailer := mailers.NewFakeMailer()
+	err := NewEmailNotifier(mailer).Notify(context.Background(), models.Reminder{NoteId: 123, Email: "test@gmail.com", Title: "buy milk"})
+	if err != nil {
+		t.Fatalf("emailNotifier.Notify() error = %v", err)
+	}
+	mails := mailer.Mails()
+	if len(mails) != 1 || mails[0].To != "test@gmail.com" || !strings.Contains(mails[0].Body, "Reminder: buy milk") {
+		t.Errorf("emailNotifier.Notify() sent %v", mails)
+	}
+}
+
+func TestMultiNotifier_Notify(t *testing.T) {
+	failing := NewFakeNotifier()
+	failing.Err = errors.New("down")
+	working := NewFakeNotifier()
+	tests := []struct {
+		name    string
+		n       []*FakeNotifier
+		wantErr bool
+	}{
+		{name: "success case - one notifier failed", n: []*FakeNotifier{failing, working}, wantErr: false},
+		{name: "failure case - all notifiers failed", n: []*FakeNotifier{failing}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &multiNotifier{logger: loggers.NewLogger()}
+			for _, n := range tt.n {
+				m.notifiers = append(m.notifiers, n)
+			}
+			err := m.Notify(context.Background(), models.Reminder{NoteId: 123})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("multiNotifier.Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
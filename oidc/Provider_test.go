@@ -0,0 +1,89 @@
+package oidc
+
+import (
+	"context"
+	"net/url"
+	"notes-server/oidc/oidctest"
+	"strings"
+	"testing"
+	"time"
+)
+
+const testRedirectURL = "http://localhost:8080/v1/api/oidc/callback"
+
+// signIn - goes through the authorization of the fake provider, returns the code
+func signIn(t *testing.T, fake *oidctest.FakeProvider, p *Provider, verifier, nonce string) string {
+	t.Helper()
+	authorizationURL, err := p.AuthCodeURL(context.Background(), "state-1", nonce, CodeChallenge(verifier))
+	if err != nil {
+		t.Fatalf("Provider.AuthCodeURL() error = %v", err)
+	}
+	query, _ := url.ParseQuery(authorizationURL[len(fake.Issuer()+"/authorize?"):])
+	if query.Get("redirect_uri") != testRedirectURL || query.Get("scope") != Scopes {
+		t.Fatalf("Provider.AuthCodeURL() = %s", authorizationURL)
+	}
+	code, state, err := fake.Authorize(authorizationURL)
+	if err != nil || state != "state-1" {
+		t.Fatalf("FakeProvider.Authorize() state = %s, error = %v", state, err)
+	}
+	return code
+}
+
+func TestCodeChallenge(t *testing.T) {
+	// RFC 7636 appendix B
+	got := CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk")
+	if got != "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM" {
+		t.Errorf("CodeChallenge() = %s", got)
+	}
+}
+
+func TestProvider_Exchange(t *testing.T) {
+	fake := oidctest.NewFakeProvider("notes")
+	defer fake.Close()
+	fake.SetUser(oidctest.FakeUser{Subject: "248289761001", Email: "jane@example.com", EmailVerified: true, Name: "Jane"})
+	p := NewProvider(fake.Issuer()+"/", "notes", "secret", testRedirectURL)
+	ctx := context.Background()
+
+	code := signIn(t, fake, p, "verifier-1", "nonce-1")
+	got, err := p.Exchange(ctx, code, "verifier-1", "nonce-1")
+	if err != nil {
+		t.Fatalf("Provider.Exchange() error = %v", err)
+	}
+	if got.Issuer != fake.Issuer() || got.Subject != "248289761001" || got.Email != "jane@example.com" || !got.EmailVerified || got.Name != "Jane" {
+		t.Errorf("Provider.Exchange() = %+v", got)
+	}
+	if _, err = p.Exchange(ctx, code, "verifier-1", "nonce-1"); err == nil {
+		t.Errorf("Provider.Exchange() redeemed a code twice")
+	}
+
+	code = signIn(t, fake, p, "verifier-2", "nonce-2")
+	if _, err = p.Exchange(ctx, code, "another-verifier", "nonce-2"); err == nil {
+		t.Errorf("Provider.Exchange() succeeded with the wrong PKCE verifier")
+	}
+
+	code = signIn(t, fake, p, "verifier-3", "nonce-3")
+	if _, err = p.Exchange(ctx, code, "verifier-3", "another-nonce"); err == nil {
+		t.Errorf("Provider.Exchange() accepted an ID token with another nonce")
+	}
+
+	fake.RotateKey("fake-2")
+	code = signIn(t, fake, p, "verifier-4", "nonce-4")
+	if _, err = p.Exchange(ctx, code, "verifier-4", "nonce-4"); err == nil {
+		t.Errorf("Provider.Exchange() fetched the keys again within keysRefreshInterval")
+	}
+	p.keysFetchedAt = time.Now().Add(-keysRefreshInterval)
+	code = signIn(t, fake, p, "verifier-5", "nonce-5")
+	if _, err = p.Exchange(ctx, code, "verifier-5", "nonce-5"); err != nil {
+		t.Errorf("Provider.Exchange() error = %v after the keys were rotated", err)
+	}
+}
+
+func TestProvider_discover(t *testing.T) {
+	fake := oidctest.NewFakeProvider("notes")
+	defer fake.Close()
+	// the same server under another name, its discovery document is for 127.0.0.1
+	p := NewProvider(strings.Replace(fake.Issuer(), "127.0.0.1", "localhost", 1), "notes", "", testRedirectURL)
+	if _, err := p.AuthCodeURL(context.Background(), "state", "nonce", "challenge"); err == nil {
+		t.Errorf("Provider.AuthCodeURL() accepted the discovery document of another issuer")
+	}
+}
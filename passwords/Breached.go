@@ -0,0 +1,67 @@
+package passwords
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PrefixLength - hex characters of the SHA-1 hash of a password that name the file listing it
+const PrefixLength = 5
+
+// BreachedList - the passwords known from data breaches, by the uppercase hex SHA-1 hash of the password. The
+// hashes are split by their first PrefixLength characters into files named after the prefix, with or without a
+// .txt extension, as served by the k-anonymity range API of Have I Been Pwned: one SUFFIX:COUNT line per hash
+// with the remaining characters of the hash. Only the file of the prefix of a password is read to check it.
+type BreachedList struct {
+	dir string
+}
+
+// NewBreachedList - dir is the directory of the prefix files
+func NewBreachedList(dir string) (*BreachedList, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+	return &BreachedList{dir: dir}, nil
+}
+
+// Contains - whether password is in the list
+func (l *BreachedList) Contains(password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	prefix, suffix := hash[:PrefixLength], hash[PrefixLength:]
+	file, err := l.open(prefix)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.EqualFold(strings.SplitN(line, ":", 2)[0], suffix) {
+			return true, nil
+		}
+	}
+	return false, scanner.Err()
+}
+
+// open - the file of the hashes starting with prefix
+func (l *BreachedList) open(prefix string) (*os.File, error) {
+	file, err := os.Open(filepath.Join(l.dir, prefix+".txt"))
+	if errors.Is(err, os.ErrNotExist) {
+		return os.Open(filepath.Join(l.dir, prefix))
+	}
+	return file, err
+}
@@ -0,0 +1,126 @@
+// Package passwords - the policy new passwords are checked against when they are chosen, at signup, on a change
+// and on a reset
+package passwords
+
+import (
+	"fmt"
+	"notes-server/apperrors"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Classes of characters a policy can require
+const (
+	ClassLower  = "lower"
+	ClassUpper  = "upper"
+	ClassDigit  = "digit"
+	ClassSymbol = "symbol"
+)
+
+// Rules a password can fail, a missing class of characters is reported with the name of the class as the rule
+const (
+	RuleMinLength    = "min_length"
+	RulePersonalInfo = "personal_info"
+	RuleBreached     = "breached"
+)
+
+// ErrWeakPassword - the password does not follow the policy, the rules it failed are listed in the fields
+var ErrWeakPassword = apperrors.Validation("weak_password", "password does not follow the password policy")
+
+// minPersonalInfoLength - parts of the email address and of the name shorter than this may be in a password
+const minPersonalInfoLength = 3
+
+var classMessages = map[string]string{
+	ClassLower:  "a lowercase letter",
+	ClassUpper:  "an uppercase letter",
+	ClassDigit:  "a digit",
+	ClassSymbol: "a symbol",
+}
+
+// Policy - the rules of the new passwords, the zero Policy accepts any password
+type Policy struct {
+	MinLength       int
+	RequiredClasses []string
+	// ForbidPersonalInfo - the password can not contain the email address, its local part or a part of the name
+	ForbidPersonalInfo bool
+	// Breached - the passwords known from data breaches, which are refused, nil to not check them
+	Breached *BreachedList
+}
+
+// NewPolicy - returns an error for a class of characters that is not known
+func NewPolicy(minLength int, requiredClasses []string, forbidPersonalInfo bool, breached *BreachedList) (Policy, error) {
+	classes := make([]string, 0, len(requiredClasses))
+	for _, class := range requiredClasses {
+		class = strings.TrimSpace(class)
+		if class == "" {
+			continue
+		}
+		if _, ok := classMessages[class]; !ok {
+			return Policy{}, fmt.Errorf("unknown class of characters %q", class)
+		}
+		classes = append(classes, class)
+	}
+	return Policy{MinLength: minLength, RequiredClasses: classes, ForbidPersonalInfo: forbidPersonalInfo, Breached: breached}, nil
+}
+
+// Check - returns ErrWeakPassword with a field error named field for every rule password fails, email and name
+// are those of the user choosing it. An error reading the breached passwords is returned as is.
+func (p Policy) Check(field, password, email, name string) error {
+	var failed []apperrors.FieldError
+	fail := func(rule, message string) {
+		failed = append(failed, apperrors.FieldError{Field: field, Rule: rule, Message: field + " must " + message})
+	}
+	if utf8.RuneCountInString(password) < p.MinLength {
+		fail(RuleMinLength, "be at least "+strconv.Itoa(p.MinLength)+" characters long")
+	}
+	for _, class := range p.RequiredClasses {
+		if !hasClass(password, class) {
+			fail(class, "contain "+classMessages[class])
+		}
+	}
+	if p.ForbidPersonalInfo && containsPersonalInfo(password, email, name) {
+		fail(RulePersonalInfo, "not contain the email address or the name")
+	}
+	if p.Breached != nil {
+		breached, err := p.Breached.Contains(password)
+		if err != nil {
+			return err
+		}
+		if breached {
+			fail(RuleBreached, "not be a password known from a data breach")
+		}
+	}
+	if len(failed) > 0 {
+		return ErrWeakPassword.WithFields(failed...)
+	}
+	return nil
+}
+
+func hasClass(password, class string) bool {
+	for _, r := range password {
+		switch {
+		case class == ClassLower && unicode.IsLower(r),
+			class == ClassUpper && unicode.IsUpper(r),
+			class == ClassDigit && unicode.IsDigit(r),
+			class == ClassSymbol && !unicode.IsLetter(r) && !unicode.IsDigit(r) && !unicode.IsSpace(r):
+			return true
+		}
+	}
+	return false
+}
+
+// containsPersonalInfo - whether the password contains, ignoring the case, the email address, its local part or a
+// word of the name
+func containsPersonalInfo(password, email, name string) bool {
+	password = strings.ToLower(password)
+	email = strings.ToLower(email)
+	parts := append([]string{email, strings.SplitN(email, "@", 2)[0]}, strings.Fields(strings.ToLower(name))...)
+	for _, part := range parts {
+		if utf8.RuneCountInString(part) >= minPersonalInfoLength && strings.Contains(password, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package passwords
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"io/ioutil"
+	"notes-server/apperrors"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// testBreachedList - a list with the given passwords, in prefix files as served by the range API
+func testBreachedList(t *testing.T, passwords ...string) *BreachedList {
+	t.Helper()
+	dir := t.TempDir()
+	for _, password := range passwords {
+		sum := sha1.Sum([]byte(password))
+		hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+		lines := "0000000000000000000000000000000000A:1\n" + hash[PrefixLength:] + ":42\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, hash[:PrefixLength]+".txt"), []byte(lines), 0600); err != nil {
+			t.Fatalf("WriteFile() error = %v", err)
+		}
+	}
+	list, err := NewBreachedList(dir)
+	if err != nil {
+		t.Fatalf("NewBreachedList() error = %v", err)
+	}
+	return list
+}
+
+func TestPolicy_Check(t *testing.T) {
+	breached := testBreachedList(t, "password123")
+	tests := []struct {
+		name      string
+		policy    Policy
+		password  string
+		wantRules []string
+	}{
+		{
+			name:     "success case - zero policy",
+			password: "a",
+		},
+		{
+			name:     "success case - every rule followed",
+			policy:   Policy{MinLength: 8, RequiredClasses: []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol}, ForbidPersonalInfo: true, Breached: breached},
+			password: "Correct-Horse-9",
+		},
+		{
+			name:      "failure case - too short",
+			policy:    Policy{MinLength: 8},
+			password:  "short",
+			wantRules: []string{RuleMinLength},
+		},
+		{
+			name:      "failure case - missing classes",
+			policy:    Policy{RequiredClasses: []string{ClassLower, ClassUpper, ClassDigit, ClassSymbol}},
+			password:  "lowercase",
+			wantRules: []string{ClassUpper, ClassDigit, ClassSymbol},
+		},
+		{
+			name:      "failure case - contains the local part of the email",
+			policy:    Policy{ForbidPersonalInfo: true},
+			password:  "my-JDoe-password",
+			wantRules: []string{RulePersonalInfo},
+		},
+		{
+			name:      "failure case - contains a word of the name",
+			policy:    Policy{ForbidPersonalInfo: true},
+			password:  "janet1987",
+			wantRules: []string{RulePersonalInfo},
+		},
+		{
+			name:      "failure case - breached",
+			policy:    Policy{MinLength: 12, Breached: breached},
+			password:  "password123",
+			wantRules: []string{RuleMinLength, RuleBreached},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check("password", tt.password, "jdoe@gmail.com", "Janet Doe")
+			if len(tt.wantRules) == 0 {
+				if err != nil {
+					t.Errorf("Policy.Check() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrWeakPassword) {
+				t.Fatalf("Policy.Check() error = %v, want ErrWeakPassword", err)
+			}
+			var rules []string
+			for _, field := range apperrors.As(err).Fields {
+				if field.Field != "password" || field.Message == "" {
+					t.Errorf("Policy.Check() field = %+v, want the password field with a message", field)
+				}
+				rules = append(rules, field.Rule)
+			}
+			if !reflect.DeepEqual(rules, tt.wantRules) {
+				t.Errorf("Policy.Check() rules = %v, want %v", rules, tt.wantRules)
+			}
+		})
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	policy, err := NewPolicy(10, []string{" upper", "", "digit "}, true, nil)
+	if err != nil {
+		t.Fatalf("NewPolicy() error = %v", err)
+	}
+	if !reflect.DeepEqual(policy.RequiredClasses, []string{ClassUpper, ClassDigit}) || policy.MinLength != 10 || !policy.ForbidPersonalInfo {
+		t.Errorf("NewPolicy() = %+v", policy)
+	}
+	if _, err := NewPolicy(10, []string{"emoji"}, false, nil); err == nil {
+		t.Errorf("NewPolicy() accepted an unknown class")
+	}
+}
+
+func TestBreachedList_Contains(t *testing.T) {
+	list := testBreachedList(t, "hunter2")
+	for password, want := range map[string]bool{"hunter2": true, "hunter3": false} {
+		got, err := list.Contains(password)
+		if err != nil || got != want {
+			t.Errorf("BreachedList.Contains(%q) = %v, %v, want %v", password, got, err, want)
+		}
+	}
+	if _, err := NewBreachedList(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Errorf("NewBreachedList() accepted a missing directory")
+	}
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"time"
+)
+
+// lastUsedResolution - the last use of a token is recorded at most this often, so that every request does not
+// write to the db
+const lastUsedResolution = time.Minute
+
+type accessTokensRepository struct {
+	db     db.DB
+	logger *loggers.Logger
+}
+
+func NewAccessTokensRepository(db db.DB, logger *loggers.Logger) interfaces.IAccessTokensRepository {
+	return &accessTokensRepository{db: db, logger: logger}
+}
+
+// GetAccessTokens - retrieves the personal access tokens of the user, expired ones included
+func (r *accessTokensRepository) GetAccessTokens(ctx context.Context, email string) ([]models.AccessToken, error) {
+	r.logger.Info(ctx, "Entering accessTokensRepository.GetAccessTokens()")
+	defer r.logger.Info(ctx, "Exiting accessTokensRepository.GetAccessTokens()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	tokens, err := getAccessTokens(txn, email)
+	if err != nil {
+		r.logger.Warn(ctx, "error in accessTokensRepository.GetAccessTokens(), error from getAccessTokens()", err)
+		return nil, err
+	}
+	return tokens, nil
+}
+
+// AddAccessToken - stores a personal access token
+func (r *accessTokensRepository) AddAccessToken(ctx context.Context, token models.AccessToken) error {
+	r.logger.Info(ctx, "Entering accessTokensRepository.AddAccessToken()")
+	defer r.logger.Info(ctx, "Exiting accessTokensRepository.AddAccessToken()")
+	txn := r.db.Txn(ctx, true)
+	err := txn.Insert("access_tokens", &token)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accessTokensRepository.AddAccessToken(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// RevokeAccessToken - deletes a personal access token of the user and writes the revocation to the audit log
+func (r *accessTokensRepository) RevokeAccessToken(ctx context.Context, email string, tokenID int32) error {
+	r.logger.Info(ctx, "Entering accessTokensRepository.RevokeAccessToken()")
+	defer r.logger.Info(ctx, "Exiting accessTokensRepository.RevokeAccessToken()")
+	txn := r.db.Txn(ctx, true)
+	row, err := txn.First("access_tokens", "id", tokenID)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accessTokensRepository.RevokeAccessToken(), error from txn.First()", err)
+		return err
+	}
+	token, ok := row.(*models.AccessToken)
+	if !ok || token.Email != email {
+		txn.Abort()
+		return models.ErrAccessTokenNotFound
+	}
+	err = txn.Delete("access_tokens", token)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accessTokensRepository.RevokeAccessToken(), error from txn.Delete()", err)
+		return err
+	}
+	err = appendAudit(ctx, txn, models.AuditEntry{
+		Action: models.AuditAccessTokenRevoked,
+		Actor:  email,
+		Target: models.AuditTarget("access_token", token.Id),
+		Before: token.AuditSummary(),
+	})
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accessTokensRepository.RevokeAccessToken(), error from appendAudit()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// UseAccessToken - returns the unexpired token with the hash and records that it was used at now
+func (r *accessTokensRepository) UseAccessToken(ctx context.Context, tokenHash string, now time.Time) (models.AccessToken, error) {
+	r.logger.Info(ctx, "Entering accessTokensRepository.UseAccessToken()")
+	defer r.logger.Info(ctx, "Exiting accessTokensRepository.UseAccessToken()")
+	txn := r.db.Txn(ctx, true)
+	row, err := txn.First("access_tokens", "hash", tokenHash)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accessTokensRepository.UseAccessToken(), error from txn.First()", err)
+		return models.AccessToken{}, err
+	}
+	stored, ok := row.(*models.AccessToken)
+	if !ok || (stored.ExpiresAt != nil && !now.Before(*stored.ExpiresAt)) {
+		txn.Abort()
+		return models.AccessToken{}, models.ErrInvalidAccessToken
+	}
+	token := *stored
+	if token.LastUsedAt != nil && now.Sub(*token.LastUsedAt) < lastUsedResolution {
+		txn.Abort()
+		return token, nil
+	}
+	token.LastUsedAt = &now
+	err = txn.Insert("access_tokens", &token)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accessTokensRepository.UseAccessToken(), error from txn.Insert()", err)
+		return models.AccessToken{}, err
+	}
+	txn.Commit()
+	return token, nil
+}
+
+// getAccessTokens - returns copies of the access tokens of the user
+func getAccessTokens(txn db.MemDbTxn, email string) ([]models.AccessToken, error) {
+	rows, err := txn.Get("access_tokens", "email", email)
+	if err != nil {
+		return nil, err
+	}
+	tokens := make([]models.AccessToken, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		tokens = append(tokens, *obj.(*models.AccessToken))
+	}
+	return tokens, nil
+}
+
+// deleteAccessTokens - revokes all the access tokens of the user
+func deleteAccessTokens(txn db.MemDbTxn, email string) error {
+	tokens, err := getAccessTokens(txn, email)
+	if err != nil {
+		return err
+	}
+	for i := range tokens {
+		if err = txn.Delete("access_tokens", &tokens[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"testing"
+	"time"
+)
+
+func Test_accessTokensRepository_UseAccessToken(t *testing.T) {
+	const email = "access-tokens@gmail.com"
+	ctx := context.Background()
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	r := NewAccessTokensRepository(db.NewDB(), loggers.NewLogger())
+	active := models.AccessToken{Id: utils.NewID(), TokenHash: utils.HashToken("pat_active"), Email: email, Scopes: []string{models.ScopeNotesRead}, ExpiresAt: &expiresAt}
+	expired := models.AccessToken{Id: utils.NewID(), TokenHash: utils.HashToken("pat_expired"), Email: email, ExpiresAt: &now}
+	for _, token := range []models.AccessToken{active, expired} {
+		if err := r.AddAccessToken(ctx, token); err != nil {
+			t.Fatalf("accessTokensRepository.AddAccessToken() error = %v", err)
+		}
+	}
+
+	got, err := r.UseAccessToken(ctx, active.TokenHash, now)
+	if err != nil {
+		t.Fatalf("accessTokensRepository.UseAccessToken() error = %v", err)
+	}
+	if got.Id != active.Id || got.LastUsedAt == nil || !got.LastUsedAt.Equal(now) {
+		t.Errorf("accessTokensRepository.UseAccessToken() = %+v, want token %d last used at %v", got, active.Id, now)
+	}
+	got, err = r.UseAccessToken(ctx, active.TokenHash, now.Add(lastUsedResolution/2))
+	if err != nil || !got.LastUsedAt.Equal(now) {
+		t.Errorf("accessTokensRepository.UseAccessToken() recorded a use within lastUsedResolution, got %v, error = %v", got.LastUsedAt, err)
+	}
+	if _, err = r.UseAccessToken(ctx, expired.TokenHash, now); err == nil {
+		t.Errorf("accessTokensRepository.UseAccessToken() accepted an expired token")
+	}
+	if _, err = r.UseAccessToken(ctx, utils.HashToken("pat_unknown"), now); err == nil {
+		t.Errorf("accessTokensRepository.UseAccessToken() accepted an unknown token")
+	}
+
+	if err = r.RevokeAccessToken(ctx, "someone-else@gmail.com", active.Id); err == nil {
+		t.Errorf("accessTokensRepository.RevokeAccessToken() revoked the token of another user")
+	}
+	if err = r.RevokeAccessToken(ctx, email, active.Id); err != nil {
+		t.Fatalf("accessTokensRepository.RevokeAccessToken() error = %v", err)
+	}
+	if _, err = r.UseAccessToken(ctx, active.TokenHash, now); err == nil {
+		t.Errorf("accessTokensRepository.UseAccessToken() accepted a revoked token")
+	}
+	tokens, err := r.GetAccessTokens(ctx, email)
+	if err != nil || len(tokens) != 1 || tokens[0].Id != expired.Id {
+		t.Errorf("accessTokensRepository.GetAccessTokens() = %v, error = %v, want only the expired token", tokens, err)
+	}
+}
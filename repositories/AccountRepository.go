@@ -0,0 +1,434 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/encryption"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+)
+
+type accountRepository struct {
+	db     db.DB
+	logger *loggers.Logger
+	cipher *noteCipher
+}
+
+// NewAccountRepository - the data of a user is owned by their email address, keyring is needed to seal their
+// notes again when it changes
+func NewAccountRepository(db db.DB, logger *loggers.Logger, keyring *encryption.Keyring) interfaces.IAccountRepository {
+	return &accountRepository{db: db, logger: logger, cipher: newNoteCipher(keyring)}
+}
+
+// UpdateAccount - renames the user and records the address they asked to change their email to, which must not
+// be registered already
+func (r *accountRepository) UpdateAccount(ctx context.Context, request models.UpdateAccountRequest) (models.User, error) {
+	r.logger.Info(ctx, "Entering accountRepository.UpdateAccount()")
+	defer r.logger.Info(ctx, "Exiting accountRepository.UpdateAccount()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, request.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.UpdateAccount(), error from getUser()", err)
+		return models.User{}, err
+	}
+	if request.Name != "" {
+		user.Name = request.Name
+	}
+	if request.NewEmail != "" && request.NewEmail != user.Email {
+		taken, err := emailTaken(txn, request.NewEmail)
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in accountRepository.UpdateAccount(), error from emailTaken()", err)
+			return models.User{}, err
+		}
+		if taken {
+			txn.Abort()
+			return models.User{}, models.ErrEmailTaken
+		}
+		user.PendingEmail = request.NewEmail
+	}
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.UpdateAccount(), error from txn.Insert()", err)
+		return models.User{}, err
+	}
+	txn.Commit()
+	return user, nil
+}
+
+// ChangeEmail - moves the user and everything they own to newEmail, which must still be the pending address
+// of the user. The sessions issued for the old address stop working.
+func (r *accountRepository) ChangeEmail(ctx context.Context, email, newEmail string) error {
+	r.logger.Info(ctx, "Entering accountRepository.ChangeEmail()")
+	defer r.logger.Info(ctx, "Exiting accountRepository.ChangeEmail()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.ChangeEmail(), error from getUser()", err)
+		return err
+	}
+	if user.PendingEmail == "" || user.PendingEmail != newEmail {
+		txn.Abort()
+		return models.ErrInvalidVerificationLink
+	}
+	taken, err := emailTaken(txn, newEmail)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.ChangeEmail(), error from emailTaken()", err)
+		return err
+	}
+	if taken {
+		txn.Abort()
+		return models.ErrEmailTaken
+	}
+	err = r.moveOwnedData(txn, email, newEmail)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.ChangeEmail(), error from moveOwnedData()", err)
+		return err
+	}
+	before := user
+	user.Email = newEmail
+	user.PendingEmail = ""
+	user.Verified = true
+	user.SessionVersion++
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.ChangeEmail(), error from txn.Insert()", err)
+		return err
+	}
+	entry := userAuditEntry(models.AuditEmailChanged, before, user)
+	entry.Actor = newEmail
+	err = appendAudit(ctx, txn, entry)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.ChangeEmail(), error from appendAudit()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// ChangePassword - sets a new password once the current one is confirmed, all the sessions and access tokens of
+// the user are revoked. Returns the updated user.
+func (r *accountRepository) ChangePassword(ctx context.Context, request models.ChangePasswordRequest) (models.User, error) {
+	r.logger.Info(ctx, "Entering accountRepository.ChangePassword()")
+	defer r.logger.Info(ctx, "Exiting accountRepository.ChangePassword()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, request.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.ChangePassword(), error from getUser()", err)
+		return models.User{}, err
+	}
+	if user.Password != request.CurrentPassword {
+		txn.Abort()
+		return models.User{}, models.ErrWrongPassword
+	}
+	before := user
+	user.Password = request.NewPassword
+	user.MustChangePassword = false
+	user.SessionVersion++
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.ChangePassword(), error from txn.Insert()", err)
+		return models.User{}, err
+	}
+	// the access tokens were created with the old password, they are revoked with the sessions
+	err = deleteAccessTokens(txn, user.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.ChangePassword(), error from deleteAccessTokens()", err)
+		return models.User{}, err
+	}
+	entry := userAuditEntry(models.AuditPasswordChanged, before, user)
+	entry.Actor = user.Email
+	err = appendAudit(ctx, txn, entry)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.ChangePassword(), error from appendAudit()", err)
+		return models.User{}, err
+	}
+	txn.Commit()
+	return user, nil
+}
+
+// DeleteAccount - deletes the user and everything they own once their password is confirmed
+func (r *accountRepository) DeleteAccount(ctx context.Context, request models.DeleteAccountRequest) error {
+	r.logger.Info(ctx, "Entering accountRepository.DeleteAccount()")
+	defer r.logger.Info(ctx, "Exiting accountRepository.DeleteAccount()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, request.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.DeleteAccount(), error from getUser()", err)
+		return err
+	}
+	if user.Password != request.Password {
+		txn.Abort()
+		return models.ErrWrongPassword
+	}
+	err = deleteOwnedData(ctx, txn, user.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.DeleteAccount(), error from deleteOwnedData()", err)
+		return err
+	}
+	err = txn.Delete("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in accountRepository.DeleteAccount(), error from txn.Delete()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// getUser - returns a copy of the user with the email, which can be modified and inserted back
+func getUser(txn db.MemDbTxn, email string) (models.User, error) {
+	row, err := txn.First("user", "email", email)
+	if err != nil {
+		return models.User{}, err
+	}
+	user, ok := row.(*models.User)
+	if !ok {
+		return models.User{}, models.ErrUserNotFound
+	}
+	return *user, nil
+}
+
+func emailTaken(txn db.MemDbTxn, email string) (bool, error) {
+	row, err := txn.First("user", "email", email)
+	if err != nil {
+		return false, err
+	}
+	return row != nil, nil
+}
+
+// moveOwnedData - gives everything owned by email to newEmail. The notes encrypted at rest are bound to their
+// owner, so they are opened before their data key moves and sealed again for the new owner.
+func (r *accountRepository) moveOwnedData(txn db.MemDbTxn, email, newEmail string) error {
+	notes, err := getOwnedNotes(txn, email)
+	if err != nil {
+		return err
+	}
+	for i := range notes {
+		if err = r.cipher.open(txn, &notes[i]); err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"data_keys", "keys", "usage"} {
+		if err = moveRow(txn, table, email, newEmail); err != nil {
+			return err
+		}
+	}
+	for i := range notes {
+		note := notes[i]
+		note.CreatedBy = newEmail
+		if err = r.cipher.seal(txn, &note); err != nil {
+			return err
+		}
+		if err = txn.Insert("notes", &note); err != nil {
+			return err
+		}
+	}
+	links, err := getOwnedLinks(txn, notes)
+	if err != nil {
+		return err
+	}
+	for i := range links {
+		links[i].Owner = newEmail
+		if err = txn.Insert("links", &links[i]); err != nil {
+			return err
+		}
+	}
+	templates, err := getOwnedTemplates(txn, email)
+	if err != nil {
+		return err
+	}
+	for i := range templates {
+		templates[i].Owner = newEmail
+		if err = txn.Insert("templates", &templates[i]); err != nil {
+			return err
+		}
+	}
+	notifications, err := getOwnedNotifications(txn, email)
+	if err != nil {
+		return err
+	}
+	for i := range notifications {
+		notifications[i].Email = newEmail
+		if err = txn.Insert("notifications", &notifications[i]); err != nil {
+			return err
+		}
+	}
+	tokens, err := getAccessTokens(txn, email)
+	if err != nil {
+		return err
+	}
+	for i := range tokens {
+		tokens[i].Email = newEmail
+		if err = txn.Insert("access_tokens", &tokens[i]); err != nil {
+			return err
+		}
+	}
+	if err = moveMemberships(txn, email, newEmail); err != nil {
+		return err
+	}
+	oidcLinks, err := getOIDCLinks(txn, email)
+	if err != nil {
+		return err
+	}
+	for i := range oidcLinks {
+		oidcLinks[i].Email = newEmail
+		if err = txn.Insert("oidc_links", &oidcLinks[i]); err != nil {
+			return err
+		}
+	}
+	return deletePasswordResets(txn, email)
+}
+
+// deleteOwnedData - deletes everything owned by email, the deletion of the notes is written to the audit log
+func deleteOwnedData(ctx context.Context, txn db.MemDbTxn, email string) error {
+	notes, err := getOwnedNotes(txn, email)
+	if err != nil {
+		return err
+	}
+	links, err := getOwnedLinks(txn, notes)
+	if err != nil {
+		return err
+	}
+	for i := range links {
+		if err = txn.Delete("links", &links[i]); err != nil {
+			return err
+		}
+	}
+	if err = deleteNotes(ctx, txn, notes); err != nil {
+		return err
+	}
+	templates, err := getOwnedTemplates(txn, email)
+	if err != nil {
+		return err
+	}
+	for i := range templates {
+		if err = txn.Delete("templates", &templates[i]); err != nil {
+			return err
+		}
+	}
+	notifications, err := getOwnedNotifications(txn, email)
+	if err != nil {
+		return err
+	}
+	for i := range notifications {
+		if err = txn.Delete("notifications", &notifications[i]); err != nil {
+			return err
+		}
+	}
+	if err = deleteAccessTokens(txn, email); err != nil {
+		return err
+	}
+	if err = deleteMemberships(txn, email); err != nil {
+		return err
+	}
+	oidcLinks, err := getOIDCLinks(txn, email)
+	if err != nil {
+		return err
+	}
+	for i := range oidcLinks {
+		if err = txn.Delete("oidc_links", &oidcLinks[i]); err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"data_keys", "keys", "usage"} {
+		if err = moveRow(txn, table, email, ""); err != nil {
+			return err
+		}
+	}
+	return deletePasswordResets(txn, email)
+}
+
+// moveRow - moves the row of the user in a table indexed by email to newEmail, or deletes it when newEmail is
+// empty
+func moveRow(txn db.MemDbTxn, table, email, newEmail string) error {
+	row, err := txn.First(table, "id", email)
+	if err != nil || row == nil {
+		return err
+	}
+	if err = txn.Delete(table, row); err != nil {
+		return err
+	}
+	if newEmail == "" {
+		return nil
+	}
+	switch row := row.(type) {
+	case *models.DataKey:
+		moved := *row
+		moved.Email = newEmail
+		return txn.Insert(table, &moved)
+	case *models.KeyMaterial:
+		moved := *row
+		moved.Email = newEmail
+		return txn.Insert(table, &moved)
+	case *models.Usage:
+		moved := *row
+		moved.Email = newEmail
+		return txn.Insert(table, &moved)
+	}
+	return errors.New("unexpected row in table " + table)
+}
+
+func getOwnedNotes(txn db.MemDbTxn, email string) ([]models.Note, error) {
+	rows, err := txn.Get("notes", "created_by", email)
+	if err != nil {
+		return nil, err
+	}
+	notes := make([]models.Note, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		notes = append(notes, *obj.(*models.Note))
+	}
+	return notes, nil
+}
+
+// getOwnedLinks - the links of a user all start from one of their notes
+func getOwnedLinks(txn db.MemDbTxn, notes []models.Note) ([]models.NoteLink, error) {
+	links := make([]models.NoteLink, 0)
+	for _, note := range notes {
+		noteLinks, err := getLinks(txn, "source", note.Id)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, noteLinks...)
+	}
+	return links, nil
+}
+
+func getOwnedTemplates(txn db.MemDbTxn, email string) ([]models.Template, error) {
+	rows, err := txn.Get("templates", "owner", email)
+	if err != nil {
+		return nil, err
+	}
+	templates := make([]models.Template, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		templates = append(templates, *obj.(*models.Template))
+	}
+	return templates, nil
+}
+
+func getOwnedNotifications(txn db.MemDbTxn, email string) ([]models.Notification, error) {
+	rows, err := txn.Get("notifications", "email", email)
+	if err != nil {
+		return nil, err
+	}
+	notifications := make([]models.Notification, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		notifications = append(notifications, *obj.(*models.Notification))
+	}
+	return notifications, nil
+}
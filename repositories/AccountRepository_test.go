@@ -0,0 +1,213 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"testing"
+	"time"
+)
+
+// seedAccount - signs up a verified user owning a note linking to another, a template, a notification, key
+// material, an access token and an identity provider link. Returns the ids of the notes.
+func seedAccount(t *testing.T, email string) []int32 {
+	t.Helper()
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	loginRepository := NewLoginRepository(db.NewDB(), logger)
+	if err := loginRepository.SignUp(ctx, models.SignUpRequest{Email: email, Name: "owner", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	if err := loginRepository.VerifyEmail(ctx, email); err != nil {
+		t.Fatalf("loginRepository.VerifyEmail() error = %v", err)
+	}
+	notesRepository := NewNotesRepository(db.NewDB(), logger, testKeyring(t, "k1"))
+	ids := make([]int32, 0)
+	for _, request := range []models.AddNoteRequest{
+		{Email: email, Title: "Index", Note: "see [[Todo]]"},
+		{Email: email, Title: "Todo", Type: models.NoteTypeChecklist, Items: []models.ChecklistItem{{Text: "milk"}}},
+	} {
+		id, err := notesRepository.AddNote(ctx, request)
+		if err != nil {
+			t.Fatalf("notesRepository.AddNote() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+	if _, err := NewTemplatesRepository(db.NewDB(), logger).AddTemplate(ctx, models.AddTemplateRequest{Email: email, Name: "mine", Note: "body"}); err != nil {
+		t.Fatalf("templatesRepository.AddTemplate() error = %v", err)
+	}
+	if err := NewNotificationsRepository(db.NewDB(), logger).AddNotification(ctx, models.Notification{Email: email, NoteId: ids[0], Message: "due", CreatedAt: time.Now()}); err != nil {
+		t.Fatalf("notificationsRepository.AddNotification() error = %v", err)
+	}
+	if _, err := NewKeysRepository(db.NewDB(), logger).SetKeyMaterial(ctx, models.KeyMaterial{Email: email, WrappedKey: []byte("wrapped")}); err != nil {
+		t.Fatalf("keysRepository.SetKeyMaterial() error = %v", err)
+	}
+	token := models.AccessToken{Id: utils.NewID(), TokenHash: utils.HashToken(email), Email: email, Scopes: []string{models.ScopeNotesRead}}
+	if err := NewAccessTokensRepository(db.NewDB(), logger).AddAccessToken(ctx, token); err != nil {
+		t.Fatalf("accessTokensRepository.AddAccessToken() error = %v", err)
+	}
+	if _, err := loginRepository.LoginOIDC(ctx, models.OIDCIdentity{Issuer: "https://idp.example.com", Subject: email, Email: email, EmailVerified: true}); err != nil {
+		t.Fatalf("loginRepository.LoginOIDC() error = %v", err)
+	}
+	return ids
+}
+
+// ownedRows - number of rows owned by email in each table
+func ownedRows(t *testing.T, email string) map[string]int {
+	t.Helper()
+	txn := db.NewDB().Txn(context.Background(), false)
+	defer txn.Abort()
+	counts := make(map[string]int)
+	for _, query := range []struct{ table, index string }{
+		{"user", "email"}, {"notes", "created_by"}, {"templates", "owner"}, {"notifications", "email"},
+		{"keys", "id"}, {"data_keys", "id"}, {"usage", "id"}, {"password_resets", "email"},
+		{"access_tokens", "email"}, {"oidc_links", "email"}, {"memberships", "email"},
+	} {
+		rows, err := txn.Get(query.table, query.index, email)
+		if err != nil {
+			t.Fatalf("txn.Get(%s) error = %v", query.table, err)
+		}
+		for obj := rows.Next(); obj != nil; obj = rows.Next() {
+			counts[query.table]++
+		}
+	}
+	links, err := getLinks(txn, "owner", email)
+	if err != nil {
+		t.Fatalf("getLinks() error = %v", err)
+	}
+	counts["links"] = len(links)
+	return counts
+}
+
+func Test_accountRepository_ChangeEmail(t *testing.T) {
+	const (
+		email    = "old-address@gmail.com"
+		newEmail = "new-address@gmail.com"
+	)
+	ctx := context.Background()
+	ids := seedAccount(t, email)
+	seedAccount(t, "taken-address@gmail.com")
+	before := ownedRows(t, email)
+	r := NewAccountRepository(db.NewDB(), loggers.NewLogger(), testKeyring(t, "k1"))
+
+	if err := r.ChangeEmail(ctx, email, newEmail); err == nil {
+		t.Errorf("accountRepository.ChangeEmail() changed an address that was not requested")
+	}
+	if _, err := r.UpdateAccount(ctx, models.UpdateAccountRequest{Email: email, Name: "renamed", NewEmail: "taken-address@gmail.com"}); !errors.Is(err, models.ErrEmailTaken) {
+		t.Errorf("accountRepository.UpdateAccount() error = %v, want %v", err, models.ErrEmailTaken)
+	}
+	user, err := r.UpdateAccount(ctx, models.UpdateAccountRequest{Email: email, Name: "renamed", NewEmail: newEmail})
+	if err != nil {
+		t.Fatalf("accountRepository.UpdateAccount() error = %v", err)
+	}
+	if user.Email != email || user.PendingEmail != newEmail || user.Name != "renamed" {
+		t.Errorf("accountRepository.UpdateAccount() = %+v", user)
+	}
+	if err = r.ChangeEmail(ctx, email, newEmail); err != nil {
+		t.Fatalf("accountRepository.ChangeEmail() error = %v", err)
+	}
+
+	if after := ownedRows(t, newEmail); len(after) != len(before) {
+		t.Errorf("rows owned after the change = %v, want %v", after, before)
+	} else {
+		for table, count := range before {
+			if after[table] != count {
+				t.Errorf("%s rows owned after the change = %d, want %d", table, after[table], count)
+			}
+		}
+	}
+	if left := ownedRows(t, email); len(left) != 1 || left["links"] != 0 {
+		t.Errorf("rows left to the old address = %v", left)
+	}
+	// the notes sealed for the old address are sealed again for the new one
+	notesRepository := NewNotesRepository(db.NewDB(), loggers.NewLogger(), testKeyring(t, "k1"))
+	note, err := notesRepository.GetNote(ctx, 0, newEmail, ids[0])
+	if err != nil || note.Note != "see [[Todo]]" {
+		t.Errorf("notesRepository.GetNote() = %q, %v", note.Note, err)
+	}
+	if storedNote(t, ids[1]).SealedBody == nil {
+		t.Errorf("note %d is stored in plaintext after the change", ids[1])
+	}
+	links, err := notesRepository.GetNoteLinks(ctx, 0, newEmail, ids[0])
+	if err != nil || len(links.Links) != 1 || links.Links[0].Id != ids[1] {
+		t.Errorf("notesRepository.GetNoteLinks() = %+v, %v", links, err)
+	}
+
+	loginRepository := NewLoginRepository(db.NewDB(), loggers.NewLogger())
+	if _, err = loginRepository.ValidateUser(ctx, email, 0, 0); err == nil {
+		t.Errorf("loginRepository.ValidateUser() accepted a session of the old address")
+	}
+	if user, err = loginRepository.GetUser(ctx, newEmail); err != nil || user.PendingEmail != "" || !user.Verified || user.SessionVersion != 1 {
+		t.Errorf("loginRepository.GetUser() = %+v, %v", user, err)
+	}
+}
+
+func Test_accountRepository_ChangePassword(t *testing.T) {
+	const email = "change-password@gmail.com"
+	ctx := context.Background()
+	seedAccount(t, email)
+	tokens := NewAccessTokensRepository(db.NewDB(), loggers.NewLogger())
+	token := models.AccessToken{Id: utils.NewID(), TokenHash: utils.HashToken("pat_change_password"), Email: email}
+	if err := tokens.AddAccessToken(ctx, token); err != nil {
+		t.Fatalf("accessTokensRepository.AddAccessToken() error = %v", err)
+	}
+	r := NewAccountRepository(db.NewDB(), loggers.NewLogger(), nil)
+
+	_, err := r.ChangePassword(ctx, models.ChangePasswordRequest{Email: email, CurrentPassword: "wrong", NewPassword: "new"})
+	if !errors.Is(err, models.ErrWrongPassword) {
+		t.Errorf("accountRepository.ChangePassword() error = %v, want %v", err, models.ErrWrongPassword)
+	}
+	user, err := r.ChangePassword(ctx, models.ChangePasswordRequest{Email: email, CurrentPassword: "password", NewPassword: "new"})
+	if err != nil {
+		t.Fatalf("accountRepository.ChangePassword() error = %v", err)
+	}
+	if user.Password != "new" || user.SessionVersion != 1 {
+		t.Errorf("accountRepository.ChangePassword() = %+v", user)
+	}
+	if remaining, err := tokens.GetAccessTokens(ctx, email); err != nil || len(remaining) != 0 {
+		t.Errorf("accessTokensRepository.GetAccessTokens() = %+v, %v, want the tokens revoked", remaining, err)
+	}
+	entry := lastAuditEntry(t, models.AuditPasswordChanged, models.AuditTarget("user", email))
+	if entry.Actor != email || entry.Before["session_version"] != 0 || entry.After["session_version"] != 1 {
+		t.Errorf("password change entry = %+v", entry)
+	}
+	if _, ok := entry.After["password"]; ok {
+		t.Errorf("password change entry kept the password: %+v", entry.After)
+	}
+}
+
+func Test_accountRepository_DeleteAccount(t *testing.T) {
+	const (
+		email = "deleted@gmail.com"
+		other = "kept@gmail.com"
+	)
+	ctx := context.Background()
+	ids := seedAccount(t, email)
+	seedAccount(t, other)
+	kept := ownedRows(t, other)
+	r := NewAccountRepository(db.NewDB(), loggers.NewLogger(), testKeyring(t, "k1"))
+
+	if err := r.DeleteAccount(ctx, models.DeleteAccountRequest{Email: email, Password: "wrong"}); !errors.Is(err, models.ErrWrongPassword) {
+		t.Errorf("accountRepository.DeleteAccount() error = %v, want %v", err, models.ErrWrongPassword)
+	}
+	if err := r.DeleteAccount(ctx, models.DeleteAccountRequest{Email: email, Password: "password"}); err != nil {
+		t.Fatalf("accountRepository.DeleteAccount() error = %v", err)
+	}
+	if left := ownedRows(t, email); len(left) != 1 || left["links"] != 0 {
+		t.Errorf("rows left after the deletion = %v", left)
+	}
+	for _, id := range ids {
+		if entry := lastAuditEntry(t, models.AuditNoteDeleted, models.AuditTarget("note", id)); entry.Before == nil || entry.After != nil {
+			t.Errorf("deletion entry of note %d = %+v", id, entry)
+		}
+	}
+	after := ownedRows(t, other)
+	for table, count := range kept {
+		if after[table] != count {
+			t.Errorf("%s rows of another user after the deletion = %d, want %d", table, after[table], count)
+		}
+	}
+}
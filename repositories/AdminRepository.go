@@ -0,0 +1,312 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"sort"
+	"strings"
+)
+
+type adminRepository struct {
+	db     db.DB
+	logger *loggers.Logger
+}
+
+func NewAdminRepository(db db.DB, logger *loggers.Logger) interfaces.IAdminRepository {
+	return &adminRepository{db: db, logger: logger}
+}
+
+// ListUsers - retrieves the users matching the request ordered by email, with the usage of each
+func (r *adminRepository) ListUsers(ctx context.Context, request models.ListUsersRequest) (models.ListUsersResponse, error) {
+	r.logger.Info(ctx, "Entering adminRepository.ListUsers()")
+	defer r.logger.Info(ctx, "Exiting adminRepository.ListUsers()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	rows, err := txn.Get("user", "email")
+	if err != nil {
+		r.logger.Warn(ctx, "error in adminRepository.ListUsers(), error from txn.Get()", err)
+		return models.ListUsersResponse{}, err
+	}
+	query := strings.ToLower(request.Query)
+	users := make([]models.User, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		user := obj.(*models.User)
+		if request.Role != "" && userRole(*user) != request.Role {
+			continue
+		}
+		if query != "" && !strings.Contains(strings.ToLower(user.Email), query) && !strings.Contains(strings.ToLower(user.Name), query) {
+			continue
+		}
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Email < users[j].Email })
+
+	response := models.ListUsersResponse{Users: make([]models.UserSummary, 0), Total: len(users)}
+	limit := request.Limit
+	if limit == 0 {
+		limit = models.DefaultUsersLimit
+	}
+	for i := request.Offset; i < len(users) && i < request.Offset+limit; i++ {
+		summary, err := summarizeUser(txn, users[i])
+		if err != nil {
+			r.logger.Warn(ctx, "error in adminRepository.ListUsers(), error from summarizeUser()", err)
+			return models.ListUsersResponse{}, err
+		}
+		response.Users = append(response.Users, summary)
+	}
+	return response, nil
+}
+
+// SetUserDisabled - disables or enables the user. Disabling revokes the sessions of the user, their access tokens
+// are refused as long as they are disabled.
+func (r *adminRepository) SetUserDisabled(ctx context.Context, email string, disabled bool) (models.UserSummary, error) {
+	r.logger.Info(ctx, "Entering adminRepository.SetUserDisabled()")
+	defer r.logger.Info(ctx, "Exiting adminRepository.SetUserDisabled()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.SetUserDisabled(), error from getUser()", err)
+		return models.UserSummary{}, err
+	}
+	before := user
+	if disabled && !user.Disabled {
+		user.SessionVersion++
+	}
+	user.Disabled = disabled
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.SetUserDisabled(), error from txn.Insert()", err)
+		return models.UserSummary{}, err
+	}
+	if before.Disabled != disabled {
+		action := models.AuditUserEnabled
+		if disabled {
+			action = models.AuditUserDisabled
+		}
+		err = appendAudit(ctx, txn, userAuditEntry(action, before, user))
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in adminRepository.SetUserDisabled(), error from appendAudit()", err)
+			return models.UserSummary{}, err
+		}
+	}
+	summary, err := summarizeUser(txn, user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.SetUserDisabled(), error from summarizeUser()", err)
+		return models.UserSummary{}, err
+	}
+	txn.Commit()
+	return summary, nil
+}
+
+// ForcePasswordReset - removes the password of the user, who can only log in again with the password reset token,
+// and revokes all their sessions and access tokens. The reset tokens issued to the user before are dropped.
+func (r *adminRepository) ForcePasswordReset(ctx context.Context, reset models.PasswordReset) (models.User, error) {
+	r.logger.Info(ctx, "Entering adminRepository.ForcePasswordReset()")
+	defer r.logger.Info(ctx, "Exiting adminRepository.ForcePasswordReset()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, reset.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.ForcePasswordReset(), error from getUser()", err)
+		return models.User{}, err
+	}
+	err = deletePasswordResets(txn, user.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.ForcePasswordReset(), error from deletePasswordResets()", err)
+		return models.User{}, err
+	}
+	err = deleteAccessTokens(txn, user.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.ForcePasswordReset(), error from deleteAccessTokens()", err)
+		return models.User{}, err
+	}
+	err = txn.Insert("password_resets", &reset)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.ForcePasswordReset(), error from txn.Insert()", err)
+		return models.User{}, err
+	}
+	before := user
+	user.Password = ""
+	user.SessionVersion++
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.ForcePasswordReset(), error from txn.Insert()", err)
+		return models.User{}, err
+	}
+	err = appendAudit(ctx, txn, userAuditEntry(models.AuditPasswordResetForced, before, user))
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.ForcePasswordReset(), error from appendAudit()", err)
+		return models.User{}, err
+	}
+	txn.Commit()
+	return user, nil
+}
+
+// CreateAdmin - inserts the admin configured at startup, unless there is an admin already
+func (r *adminRepository) CreateAdmin(ctx context.Context, user models.User) error {
+	r.logger.Info(ctx, "Entering adminRepository.CreateAdmin()")
+	defer r.logger.Info(ctx, "Exiting adminRepository.CreateAdmin()")
+	txn := r.db.Txn(ctx, true)
+	err := insertAdmin(txn, user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.CreateAdmin(), error from insertAdmin()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// AddSetupToken - stores the setup token printed at startup while there is no admin, replacing the one printed
+// before
+func (r *adminRepository) AddSetupToken(ctx context.Context, token models.SetupToken) error {
+	r.logger.Info(ctx, "Entering adminRepository.AddSetupToken()")
+	defer r.logger.Info(ctx, "Exiting adminRepository.AddSetupToken()")
+	txn := r.db.Txn(ctx, true)
+	exists, err := adminExists(txn)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.AddSetupToken(), error from adminExists()", err)
+		return err
+	}
+	if exists {
+		txn.Abort()
+		return models.ErrAdminExists
+	}
+	err = deleteSetupTokens(txn)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.AddSetupToken(), error from deleteSetupTokens()", err)
+		return err
+	}
+	err = txn.Insert("setup_tokens", &token)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.AddSetupToken(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// SetupAdmin - consumes the setup token and inserts the first admin
+func (r *adminRepository) SetupAdmin(ctx context.Context, tokenHash string, user models.User) error {
+	r.logger.Info(ctx, "Entering adminRepository.SetupAdmin()")
+	defer r.logger.Info(ctx, "Exiting adminRepository.SetupAdmin()")
+	txn := r.db.Txn(ctx, true)
+	row, err := txn.First("setup_tokens", "id", tokenHash)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.SetupAdmin(), error from txn.First()", err)
+		return err
+	}
+	if row == nil {
+		txn.Abort()
+		return models.ErrInvalidSetupToken
+	}
+	err = deleteSetupTokens(txn)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.SetupAdmin(), error from deleteSetupTokens()", err)
+		return err
+	}
+	err = insertAdmin(txn, user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in adminRepository.SetupAdmin(), error from insertAdmin()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// insertAdmin - inserts the user as the first admin, fails when there is an admin or the email is registered
+func insertAdmin(txn db.MemDbTxn, user models.User) error {
+	exists, err := adminExists(txn)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return models.ErrAdminExists
+	}
+	taken, err := emailTaken(txn, user.Email)
+	if err != nil {
+		return err
+	}
+	if taken {
+		return models.ErrEmailTaken
+	}
+	user.Role = models.RoleAdmin
+	if err = addPersonalWorkspace(txn, &user); err != nil {
+		return err
+	}
+	return txn.Insert("user", &user)
+}
+
+func deleteSetupTokens(txn db.MemDbTxn) error {
+	rows, err := txn.Get("setup_tokens", "id")
+	if err != nil {
+		return err
+	}
+	tokens := make([]models.SetupToken, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		tokens = append(tokens, *obj.(*models.SetupToken))
+	}
+	for i := range tokens {
+		if err = txn.Delete("setup_tokens", &tokens[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func adminExists(txn db.MemDbTxn) (bool, error) {
+	rows, err := txn.Get("user", "id")
+	if err != nil {
+		return false, err
+	}
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		if obj.(*models.User).Role == models.RoleAdmin {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// summarizeUser - the user as listed to the admins, with their usage
+func summarizeUser(txn db.MemDbTxn, user models.User) (models.UserSummary, error) {
+	usage, err := getUsage(txn, user.Email)
+	if err != nil {
+		return models.UserSummary{}, err
+	}
+	return models.UserSummary{
+		Id:         user.Id,
+		Email:      user.Email,
+		Name:       user.Name,
+		Role:       userRole(user),
+		Verified:   user.Verified,
+		Disabled:   user.Disabled,
+		MFAEnabled: user.MFA.Enabled,
+		Usage:      usage,
+	}, nil
+}
+
+// userRole - the users stored without a role are users
+func userRole(user models.User) string {
+	if user.Role == "" {
+		return models.RoleUser
+	}
+	return user.Role
+}
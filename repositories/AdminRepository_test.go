@@ -0,0 +1,180 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"testing"
+	"time"
+)
+
+func Test_adminRepository_ListUsers(t *testing.T) {
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	seedAccount(t, "carol@admin-list.io")
+	for _, request := range []models.SignUpRequest{
+		{Email: "alice@admin-list.io", Name: "Alice", Password: "password"},
+		{Email: "bob@admin-list.io", Name: "Bobby Tables", Password: "password"},
+	} {
+		if err := NewLoginRepository(db.NewDB(), logger).SignUp(ctx, request); err != nil {
+			t.Fatalf("loginRepository.SignUp() error = %v", err)
+		}
+	}
+	r := NewAdminRepository(db.NewDB(), logger)
+
+	tests := []struct {
+		name      string
+		request   models.ListUsersRequest
+		want      []string
+		wantTotal int
+	}{
+		{name: "search by email", request: models.ListUsersRequest{Query: "@ADMIN-LIST.io"}, want: []string{"alice@admin-list.io", "bob@admin-list.io", "carol@admin-list.io"}, wantTotal: 3},
+		{name: "search by name", request: models.ListUsersRequest{Query: "tables"}, want: []string{"bob@admin-list.io"}, wantTotal: 1},
+		{name: "page", request: models.ListUsersRequest{Query: "admin-list.io", Offset: 1, Limit: 1}, want: []string{"bob@admin-list.io"}, wantTotal: 3},
+		{name: "past the last page", request: models.ListUsersRequest{Query: "admin-list.io", Offset: 5}, want: []string{}, wantTotal: 3},
+		{name: "by role", request: models.ListUsersRequest{Query: "admin-list.io", Role: models.RoleAdmin}, want: []string{}, wantTotal: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := r.ListUsers(ctx, tt.request)
+			if err != nil {
+				t.Fatalf("adminRepository.ListUsers() error = %v", err)
+			}
+			emails := make([]string, 0)
+			for _, user := range got.Users {
+				emails = append(emails, user.Email)
+			}
+			if got.Total != tt.wantTotal || len(emails) != len(tt.want) {
+				t.Fatalf("adminRepository.ListUsers() = %v of %d, want %v of %d", emails, got.Total, tt.want, tt.wantTotal)
+			}
+			for i := range emails {
+				if emails[i] != tt.want[i] {
+					t.Errorf("adminRepository.ListUsers() = %v, want %v", emails, tt.want)
+				}
+			}
+		})
+	}
+
+	got, _ := r.ListUsers(ctx, models.ListUsersRequest{Query: "carol@admin-list.io"})
+	if len(got.Users) != 1 || got.Users[0].Usage.Notes != 2 || got.Users[0].Role != models.RoleUser {
+		t.Errorf("adminRepository.ListUsers() = %+v, want carol with 2 notes", got.Users)
+	}
+}
+
+func Test_adminRepository_SetUserDisabled(t *testing.T) {
+	const email = "disabled@admin-test.io"
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	loginRepository := NewLoginRepository(db.NewDB(), logger)
+	if err := loginRepository.SignUp(ctx, models.SignUpRequest{Email: email, Name: "test", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	user, err := loginRepository.GetUser(ctx, email)
+	if err != nil {
+		t.Fatalf("loginRepository.GetUser() error = %v", err)
+	}
+	r := NewAdminRepository(db.NewDB(), logger)
+
+	got, err := r.SetUserDisabled(ctx, email, true)
+	if err != nil || !got.Disabled {
+		t.Fatalf("adminRepository.SetUserDisabled() = %+v, error = %v", got, err)
+	}
+	// the sessions issued before are revoked, the new ones refused while disabled
+	if _, err = loginRepository.ValidateUser(ctx, email, 0, 0); err == nil {
+		t.Errorf("loginRepository.ValidateUser() accepted a session issued before the user was disabled")
+	}
+	if _, err = loginRepository.ValidateUser(ctx, email, user.DefaultWorkspace, 1); !errors.Is(err, models.ErrAccountDisabled) {
+		t.Errorf("loginRepository.ValidateUser() of a disabled user error = %v", err)
+	}
+	if entry := lastAuditEntry(t, models.AuditUserDisabled, models.AuditTarget("user", email)); entry.Before["disabled"] != false || entry.After["disabled"] != true || entry.After["session_version"] != 1 {
+		t.Errorf("disable entry = %+v", entry)
+	}
+	if got, err = r.SetUserDisabled(ctx, email, false); err != nil || got.Disabled {
+		t.Fatalf("adminRepository.SetUserDisabled() = %+v, error = %v", got, err)
+	}
+	if _, err = loginRepository.ValidateUser(ctx, email, user.DefaultWorkspace, 1); err != nil {
+		t.Errorf("loginRepository.ValidateUser() once enabled error = %v", err)
+	}
+	if _, err = r.SetUserDisabled(ctx, "nobody@admin-test.io", true); !errors.Is(err, models.ErrUserNotFound) {
+		t.Errorf("adminRepository.SetUserDisabled() of an unknown user error = %v", err)
+	}
+}
+
+func Test_adminRepository_ForcePasswordReset(t *testing.T) {
+	const email = "reset@admin-test.io"
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	loginRepository := NewLoginRepository(db.NewDB(), logger)
+	if err := loginRepository.SignUp(ctx, models.SignUpRequest{Email: email, Name: "test", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	tokens := NewAccessTokensRepository(db.NewDB(), logger)
+	if err := tokens.AddAccessToken(ctx, models.AccessToken{Id: utils.NewID(), TokenHash: utils.HashToken("pat_reset"), Email: email}); err != nil {
+		t.Fatalf("accessTokensRepository.AddAccessToken() error = %v", err)
+	}
+	now := time.Now()
+	r := NewAdminRepository(db.NewDB(), logger)
+	if _, err := r.ForcePasswordReset(ctx, models.PasswordReset{TokenHash: utils.HashToken("reset-token"), Email: email, ExpiresAt: now.Add(time.Hour)}); err != nil {
+		t.Fatalf("adminRepository.ForcePasswordReset() error = %v", err)
+	}
+
+	if _, err := loginRepository.Login(ctx, models.LoginRequest{Email: email, Password: "password"}, models.LoginAttempt{At: now}); !errors.Is(err, models.ErrInvalidCredentials) {
+		t.Errorf("loginRepository.Login() with the password removed error = %v", err)
+	}
+	if _, err := loginRepository.ValidateUser(ctx, email, 0, 0); err == nil {
+		t.Errorf("loginRepository.ValidateUser() accepted a session issued before the reset")
+	}
+	if _, err := tokens.UseAccessToken(ctx, utils.HashToken("pat_reset"), now); err == nil {
+		t.Errorf("accessTokensRepository.UseAccessToken() accepted a token issued before the reset")
+	}
+	if err := loginRepository.ResetPassword(ctx, utils.HashToken("reset-token"), "new password", now); err != nil {
+		t.Fatalf("loginRepository.ResetPassword() error = %v", err)
+	}
+	if _, err := loginRepository.Login(ctx, models.LoginRequest{Email: email, Password: "new password"}, models.LoginAttempt{At: now}); err != nil {
+		t.Errorf("loginRepository.Login() with the new password error = %v", err)
+	}
+	if entry := lastAuditEntry(t, models.AuditPasswordResetForced, models.AuditTarget("user", email)); entry.Before["has_password"] != true || entry.After["has_password"] != false || entry.After["session_version"] != 1 {
+		t.Errorf("forced reset entry = %+v", entry)
+	}
+	if entry := lastAuditEntry(t, models.AuditPasswordReset, models.AuditTarget("user", email)); entry.Actor != email || entry.After["has_password"] != true || entry.After["session_version"] != 2 {
+		t.Errorf("reset entry = %+v", entry)
+	}
+}
+
+func Test_adminRepository_bootstrap(t *testing.T) {
+	ctx := context.Background()
+	r := NewAdminRepository(db.NewDB(), loggers.NewLogger())
+	admin := models.User{Id: utils.NewID(), Name: "Admin", Email: "first-admin@admin-test.io", Password: "password", Verified: true}
+
+	if err := r.AddSetupToken(ctx, models.SetupToken{TokenHash: utils.HashToken("first")}); err != nil {
+		t.Fatalf("adminRepository.AddSetupToken() error = %v", err)
+	}
+	// a restart prints another token, the one printed before is replaced
+	if err := r.AddSetupToken(ctx, models.SetupToken{TokenHash: utils.HashToken("second")}); err != nil {
+		t.Fatalf("adminRepository.AddSetupToken() error = %v", err)
+	}
+	if err := r.SetupAdmin(ctx, utils.HashToken("first"), admin); !errors.Is(err, models.ErrInvalidSetupToken) {
+		t.Errorf("adminRepository.SetupAdmin() with a replaced token error = %v", err)
+	}
+	if err := r.SetupAdmin(ctx, utils.HashToken("second"), admin); err != nil {
+		t.Fatalf("adminRepository.SetupAdmin() error = %v", err)
+	}
+	if err := r.SetupAdmin(ctx, utils.HashToken("second"), admin); !errors.Is(err, models.ErrInvalidSetupToken) {
+		t.Errorf("adminRepository.SetupAdmin() with a used token error = %v", err)
+	}
+	if err := r.AddSetupToken(ctx, models.SetupToken{TokenHash: utils.HashToken("third")}); !errors.Is(err, models.ErrAdminExists) {
+		t.Errorf("adminRepository.AddSetupToken() once set up error = %v", err)
+	}
+	admin.Email = "second-admin@admin-test.io"
+	if err := r.CreateAdmin(ctx, admin); !errors.Is(err, models.ErrAdminExists) {
+		t.Errorf("adminRepository.CreateAdmin() once set up error = %v", err)
+	}
+
+	got, err := r.ListUsers(ctx, models.ListUsersRequest{Role: models.RoleAdmin})
+	if err != nil || got.Total != 1 || got.Users[0].Email != "first-admin@admin-test.io" {
+		t.Errorf("adminRepository.ListUsers() = %+v, error = %v, want the admin set up", got, err)
+	}
+}
@@ -0,0 +1,168 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"time"
+)
+
+type auditRepository struct {
+	db     db.DB
+	logger *loggers.Logger
+}
+
+func NewAuditRepository(db db.DB, logger *loggers.Logger) interfaces.IAuditRepository {
+	return &auditRepository{db: db, logger: logger}
+}
+
+// GetAuditLog - retrieves the entries matching the request, newest first
+func (r *auditRepository) GetAuditLog(ctx context.Context, request models.GetAuditLogRequest) (models.GetAuditLogResponse, error) {
+	r.logger.Info(ctx, "Entering auditRepository.GetAuditLog()")
+	defer r.logger.Info(ctx, "Exiting auditRepository.GetAuditLog()")
+	limit := request.Limit
+	if limit == 0 {
+		limit = models.DefaultAuditLimit
+	}
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	rows, err := txn.Get("audit_log", "id")
+	if err != nil {
+		r.logger.Warn(ctx, "error in auditRepository.GetAuditLog(), error from txn.Get()", err)
+		return models.GetAuditLogResponse{}, err
+	}
+	// the ids grow with time, the entries are read oldest first and the last ones kept
+	matching := make([]models.AuditEntry, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		entry := obj.(*models.AuditEntry)
+		if request.Before != 0 && entry.Id >= request.Before {
+			break
+		}
+		if auditEntryMatches(entry, request) {
+			matching = append(matching, *entry)
+		}
+	}
+	response := models.GetAuditLogResponse{Entries: make([]models.AuditEntry, 0, limit)}
+	for i := len(matching) - 1; i >= 0 && len(response.Entries) < limit; i-- {
+		response.Entries = append(response.Entries, matching[i])
+	}
+	if len(matching) > limit {
+		response.Next = response.Entries[limit-1].Id
+	}
+	return response, nil
+}
+
+// DeleteAuditEntries - deletes the entries written before cutoff, returns how many were deleted
+func (r *auditRepository) DeleteAuditEntries(ctx context.Context, cutoff time.Time) (int, error) {
+	r.logger.Info(ctx, "Entering auditRepository.DeleteAuditEntries()")
+	defer r.logger.Info(ctx, "Exiting auditRepository.DeleteAuditEntries()")
+	txn := r.db.Txn(ctx, true)
+	rows, err := txn.Get("audit_log", "id")
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in auditRepository.DeleteAuditEntries(), error from txn.Get()", err)
+		return 0, err
+	}
+	expired := make([]*models.AuditEntry, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		entry := obj.(*models.AuditEntry)
+		if !entry.At.Before(cutoff) {
+			break
+		}
+		expired = append(expired, entry)
+	}
+	for _, entry := range expired {
+		if err = txn.Delete("audit_log", entry); err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in auditRepository.DeleteAuditEntries(), error from txn.Delete()", err)
+			return 0, err
+		}
+	}
+	txn.Commit()
+	return len(expired), nil
+}
+
+func auditEntryMatches(entry *models.AuditEntry, request models.GetAuditLogRequest) bool {
+	switch {
+	case request.Actor != "" && entry.Actor != utils.NormalizeEmail(request.Actor),
+		request.Action != "" && entry.Action != request.Action,
+		request.Target != "" && entry.Target != request.Target,
+		request.RequestID != "" && entry.RequestID != request.RequestID,
+		request.Outcome != "" && entry.Outcome != request.Outcome,
+		request.Since != nil && entry.At.Before(*request.Since),
+		request.Until != nil && !entry.At.Before(*request.Until):
+		return false
+	}
+	return true
+}
+
+// appendAudit - writes entry to the audit log in txn, so that it is only kept if the change it records is
+// committed. The request ID, client IP and user agent come from ctx, and so does the actor unless it is set.
+func appendAudit(ctx context.Context, txn db.MemDbTxn, entry models.AuditEntry) error {
+	if entry.At.IsZero() {
+		entry.At = time.Now()
+	}
+	if entry.Outcome == "" {
+		entry.Outcome = models.AuditSuccess
+	}
+	if entry.Actor == "" {
+		entry.Actor = utils.GetEmailFromCtx(ctx)
+	}
+	entry.RequestID = utils.GetRequestIDFromCtx(ctx)
+	entry.IP = utils.GetClientIPFromCtx(ctx)
+	entry.UserAgent = utils.GetUserAgentFromCtx(ctx)
+	entry.Id = entry.At.UnixNano()
+	for {
+		row, err := txn.First("audit_log", "id", entry.Id)
+		if err != nil {
+			return err
+		}
+		if row == nil {
+			break
+		}
+		entry.Id++
+	}
+	return txn.Insert("audit_log", &entry)
+}
+
+// loginAuditEntry - the entry of a login attempt as email, reason is the code of the error it failed with or
+// empty when it succeeded
+func loginAuditEntry(action, email, reason string) models.AuditEntry {
+	entry := models.AuditEntry{Action: action, Actor: email, Target: models.AuditTarget("user", email)}
+	if reason != "" {
+		entry.Outcome = models.AuditFailure
+		entry.Reason = reason
+	}
+	return entry
+}
+
+// userAuditEntry - the entry of a change to a user, a newer session version after the change means that their
+// sessions were revoked
+func userAuditEntry(action string, before, after models.User) models.AuditEntry {
+	return models.AuditEntry{
+		Action: action,
+		Target: models.AuditTarget("user", after.Email),
+		Before: before.AuditSummary(),
+		After:  after.AuditSummary(),
+	}
+}
+
+// userLoginAuditEntry - the entry of a login of user with valid credentials. It fails when the services refuse
+// the login for the state of the user, and waits for the second factor when mfaPending is set and the user has
+// two-factor authentication enabled.
+func userLoginAuditEntry(action string, user models.User, mfaPending bool) models.AuditEntry {
+	reason := ""
+	if !user.Verified {
+		reason = models.ErrEmailNotVerified.Code
+	} else if user.Disabled {
+		reason = models.ErrAccountDisabled.Code
+	}
+	entry := loginAuditEntry(action, user.Email, reason)
+	if reason == "" && mfaPending && user.MFA.Enabled {
+		entry.Outcome = models.AuditMFARequired
+	}
+	return entry
+}
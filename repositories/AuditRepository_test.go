@@ -0,0 +1,248 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/constants"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/totp"
+	"testing"
+	"time"
+)
+
+// lastAuditEntry - the newest entry of the audit log with action on target
+func lastAuditEntry(t *testing.T, action, target string) models.AuditEntry {
+	t.Helper()
+	response, err := NewAuditRepository(db.NewDB(), loggers.NewLogger()).GetAuditLog(context.Background(), models.GetAuditLogRequest{Action: action, Target: target, Limit: 1})
+	if err != nil || len(response.Entries) != 1 {
+		t.Fatalf("auditRepository.GetAuditLog(%s, %s) = %+v, %v", action, target, response, err)
+	}
+	return response.Entries[0]
+}
+
+func Test_auditRepository_GetAuditLog(t *testing.T) {
+	const email = "audit@audit-log.io"
+	logger := loggers.NewLogger()
+	ctx := context.WithValue(context.Background(), constants.RequestIDCtxKey, "request-1")
+	ctx = context.WithValue(ctx, constants.ClientIPCtxKey, "203.0.113.7")
+	ctx = context.WithValue(ctx, constants.UserAgentCtxKey, "curl/8.0")
+	loginRepository := NewLoginRepository(db.NewDB(), logger)
+	if err := loginRepository.SignUp(ctx, models.SignUpRequest{Email: email, Name: "Audit", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	if err := loginRepository.VerifyEmail(ctx, email); err != nil {
+		t.Fatalf("loginRepository.VerifyEmail() error = %v", err)
+	}
+	attempt := models.LoginAttempt{At: time.Now()}
+	if _, err := loginRepository.Login(ctx, models.LoginRequest{Email: email, Password: "wrong"}, attempt); err == nil {
+		t.Fatalf("loginRepository.Login() accepted a wrong password")
+	}
+	if _, err := loginRepository.Login(ctx, models.LoginRequest{Email: email, Password: "password"}, attempt); err != nil {
+		t.Fatalf("loginRepository.Login() error = %v", err)
+	}
+	user, err := loginRepository.GetUser(ctx, email)
+	if err != nil {
+		t.Fatalf("loginRepository.GetUser() error = %v", err)
+	}
+	notesCtx := context.WithValue(ctx, constants.EmailCtxKey, email)
+	notesRepository := NewNotesRepository(db.NewDB(), logger, nil)
+	noteID, err := notesRepository.AddNote(notesCtx, models.AddNoteRequest{Email: email, Workspace: user.DefaultWorkspace, Title: "Audited", Note: "secret body"})
+	if err != nil {
+		t.Fatalf("notesRepository.AddNote() error = %v", err)
+	}
+	// a change that fails leaves no entry, the entry is written in its transaction
+	if _, err := notesRepository.AddNote(notesCtx, models.AddNoteRequest{Email: email, Workspace: user.DefaultWorkspace, Title: "Audited", Note: "again"}); err == nil {
+		t.Fatalf("notesRepository.AddNote() accepted a taken title")
+	}
+	if err := notesRepository.DeleteNote(notesCtx, user.DefaultWorkspace, email, noteID); err != nil {
+		t.Fatalf("notesRepository.DeleteNote() error = %v", err)
+	}
+	r := NewAuditRepository(db.NewDB(), logger)
+
+	response, err := r.GetAuditLog(ctx, models.GetAuditLogRequest{Actor: email})
+	if err != nil {
+		t.Fatalf("auditRepository.GetAuditLog() error = %v", err)
+	}
+	var actions []string
+	for _, entry := range response.Entries {
+		actions = append(actions, entry.Action+"/"+entry.Outcome)
+		if entry.RequestID != "request-1" || entry.IP != "203.0.113.7" || entry.UserAgent != "curl/8.0" {
+			t.Errorf("auditRepository.GetAuditLog() entry %+v does not have the request details", entry)
+		}
+	}
+	want := []string{"note.deleted/success", "note.created/success", "login/success", "login/failure", "signup/success"}
+	if len(actions) != len(want) {
+		t.Fatalf("auditRepository.GetAuditLog() actions = %v, want %v", actions, want)
+	}
+	for i := range want {
+		if actions[i] != want[i] {
+			t.Fatalf("auditRepository.GetAuditLog() actions = %v, want %v", actions, want)
+		}
+	}
+	deleted := response.Entries[0]
+	if deleted.Target != models.AuditTarget("note", noteID) || deleted.Before["title"] != "Audited" || deleted.After != nil {
+		t.Errorf("auditRepository.GetAuditLog() deletion = %+v", deleted)
+	}
+	if _, ok := deleted.Before["note"]; ok {
+		t.Errorf("auditRepository.GetAuditLog() kept the body of the note: %+v", deleted.Before)
+	}
+	if response.Entries[3].Reason != models.ErrInvalidCredentials.Code {
+		t.Errorf("auditRepository.GetAuditLog() failed login reason = %q", response.Entries[3].Reason)
+	}
+
+	// pages
+	first, err := r.GetAuditLog(ctx, models.GetAuditLogRequest{Actor: email, Limit: 2})
+	if err != nil || len(first.Entries) != 2 || first.Next != first.Entries[1].Id {
+		t.Fatalf("auditRepository.GetAuditLog() first page = %+v, %v", first, err)
+	}
+	second, err := r.GetAuditLog(ctx, models.GetAuditLogRequest{Actor: email, Limit: 2, Before: first.Next})
+	if err != nil || len(second.Entries) != 2 || second.Entries[0].Action != models.AuditLogin {
+		t.Fatalf("auditRepository.GetAuditLog() second page = %+v, %v", second, err)
+	}
+	last, err := r.GetAuditLog(ctx, models.GetAuditLogRequest{Actor: email, Limit: 2, Before: second.Next})
+	if err != nil || len(last.Entries) != 1 || last.Next != 0 {
+		t.Fatalf("auditRepository.GetAuditLog() last page = %+v, %v", last, err)
+	}
+
+	// filters
+	failures, err := r.GetAuditLog(ctx, models.GetAuditLogRequest{Actor: email, Action: models.AuditLogin, Outcome: models.AuditFailure})
+	if err != nil || len(failures.Entries) != 1 {
+		t.Errorf("auditRepository.GetAuditLog() failures = %+v, %v", failures, err)
+	}
+	future := time.Now().Add(time.Hour)
+	none, err := r.GetAuditLog(ctx, models.GetAuditLogRequest{Actor: email, Since: &future})
+	if err != nil || len(none.Entries) != 0 {
+		t.Errorf("auditRepository.GetAuditLog() since the future = %+v, %v", none, err)
+	}
+}
+
+func Test_loginRepository_Login_audit(t *testing.T) {
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	now := time.Now()
+	r := NewLoginRepository(db.NewDB(), logger)
+	signUp := func(email string, verified bool) {
+		t.Helper()
+		if err := r.SignUp(ctx, models.SignUpRequest{Email: email, Name: "login", Password: "password"}); err != nil {
+			t.Fatalf("loginRepository.SignUp() error = %v", err)
+		}
+		if !verified {
+			return
+		}
+		if err := r.VerifyEmail(ctx, email); err != nil {
+			t.Fatalf("loginRepository.VerifyEmail() error = %v", err)
+		}
+	}
+	signUp("unverified@audit-log.io", false)
+	signUp("disabled@audit-log.io", true)
+	if _, err := NewAdminRepository(db.NewDB(), logger).SetUserDisabled(ctx, "disabled@audit-log.io", true); err != nil {
+		t.Fatalf("adminRepository.SetUserDisabled() error = %v", err)
+	}
+	signUp("mfa@audit-log.io", true)
+	secret, _ := totp.NewSecret()
+	if err := r.StartMFAEnrollment(ctx, "mfa@audit-log.io", "", secret, models.LoginAttempt{At: now}); err != nil {
+		t.Fatalf("loginRepository.StartMFAEnrollment() error = %v", err)
+	}
+	code, _ := totp.Code(secret, totp.Step(now))
+	if err := r.ConfirmMFAEnrollment(ctx, "mfa@audit-log.io", code, []string{"aaaaa-aaaaa"}, models.LoginAttempt{At: now}); err != nil {
+		t.Fatalf("loginRepository.ConfirmMFAEnrollment() error = %v", err)
+	}
+	signUp("verified@audit-log.io", true)
+	audit := NewAuditRepository(db.NewDB(), logger)
+
+	tests := []struct {
+		email       string
+		password    string
+		wantOutcome string
+		wantReason  string
+	}{
+		{"verified@audit-log.io", "wrong", models.AuditFailure, models.ErrInvalidCredentials.Code},
+		{"unknown@audit-log.io", "password", models.AuditFailure, models.ErrInvalidCredentials.Code},
+		{"unverified@audit-log.io", "password", models.AuditFailure, models.ErrEmailNotVerified.Code},
+		{"disabled@audit-log.io", "password", models.AuditFailure, models.ErrAccountDisabled.Code},
+		{"mfa@audit-log.io", "password", models.AuditMFARequired, ""},
+		{"verified@audit-log.io", "password", models.AuditSuccess, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.wantOutcome+"/"+tt.email, func(t *testing.T) {
+			_, _ = r.Login(ctx, models.LoginRequest{Email: tt.email, Password: tt.password}, models.LoginAttempt{At: now})
+			response, err := audit.GetAuditLog(ctx, models.GetAuditLogRequest{Actor: tt.email, Action: models.AuditLogin, Limit: 1})
+			if err != nil || len(response.Entries) != 1 {
+				t.Fatalf("auditRepository.GetAuditLog() = %+v, %v", response, err)
+			}
+			entry := response.Entries[0]
+			if entry.Outcome != tt.wantOutcome || entry.Reason != tt.wantReason || entry.Actor != tt.email || entry.Target != models.AuditTarget("user", tt.email) {
+				t.Errorf("login entry = %+v, want %s %q", entry, tt.wantOutcome, tt.wantReason)
+			}
+		})
+	}
+}
+
+func Test_notesRepository_UpdateNote_audit(t *testing.T) {
+	const email = "update@audit-log.io"
+	ctx := context.WithValue(context.Background(), constants.EmailCtxKey, email)
+	r := NewNotesRepository(db.NewDB(), loggers.NewLogger(), nil)
+	add := func(title, body string) int32 {
+		t.Helper()
+		id, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Title: title, Note: body})
+		if err != nil {
+			t.Fatalf("notesRepository.AddNote() error = %v", err)
+		}
+		return id
+	}
+	index := add("Index", "see [[Draft]]")
+	draft := add("Draft", "secret body")
+	add("Taken", "")
+
+	title := "Final"
+	if err := r.UpdateNote(ctx, models.UpdateNoteRequest{Email: email, Id: draft, Title: &title}); err != nil {
+		t.Fatalf("notesRepository.UpdateNote() error = %v", err)
+	}
+	entry := lastAuditEntry(t, models.AuditNoteUpdated, models.AuditTarget("note", draft))
+	if entry.Outcome != models.AuditSuccess || entry.Actor != email || entry.Before["title"] != "Draft" || entry.After["title"] != "Final" {
+		t.Errorf("update entry = %+v", entry)
+	}
+	if _, ok := entry.After["note"]; ok {
+		t.Errorf("update entry kept the body of the note: %+v", entry.After)
+	}
+	rewritten := lastAuditEntry(t, models.AuditNoteUpdated, models.AuditTarget("note", index))
+
+	// the rename to a taken title fails once the links to the note are rewritten, nothing of it is kept
+	title = "Taken"
+	if err := r.UpdateNote(ctx, models.UpdateNoteRequest{Email: email, Id: draft, Title: &title}); err == nil {
+		t.Fatalf("notesRepository.UpdateNote() accepted a taken title")
+	}
+	if got := lastAuditEntry(t, models.AuditNoteUpdated, models.AuditTarget("note", draft)); got.Id != entry.Id {
+		t.Errorf("notesRepository.UpdateNote() rolled back wrote %+v", got)
+	}
+	if got := lastAuditEntry(t, models.AuditNoteUpdated, models.AuditTarget("note", index)); got.Id != rewritten.Id {
+		t.Errorf("notesRepository.UpdateNote() rolled back wrote the rewrite %+v", got)
+	}
+}
+
+func Test_auditRepository_DeleteAuditEntries(t *testing.T) {
+	const email = "purge@audit-log.io"
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	if err := NewLoginRepository(db.NewDB(), logger).SignUp(ctx, models.SignUpRequest{Email: email, Name: "Purge", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	r := NewAuditRepository(db.NewDB(), logger)
+	if _, err := r.DeleteAuditEntries(ctx, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("auditRepository.DeleteAuditEntries() error = %v", err)
+	}
+	kept, err := r.GetAuditLog(ctx, models.GetAuditLogRequest{Actor: email})
+	if err != nil || len(kept.Entries) != 1 {
+		t.Fatalf("auditRepository.GetAuditLog() = %+v, %v, want the entry newer than the cutoff", kept, err)
+	}
+
+	deleted, err := r.DeleteAuditEntries(ctx, time.Now().Add(time.Second))
+	if err != nil || deleted == 0 {
+		t.Fatalf("auditRepository.DeleteAuditEntries() = %d, %v", deleted, err)
+	}
+	gone, err := r.GetAuditLog(ctx, models.GetAuditLogRequest{Actor: email})
+	if err != nil || len(gone.Entries) != 0 {
+		t.Errorf("auditRepository.GetAuditLog() = %+v, %v, want no entry older than the cutoff", gone, err)
+	}
+}
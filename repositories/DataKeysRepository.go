@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/encryption"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+)
+
+type dataKeysRepository struct {
+	db      db.DB
+	logger  *loggers.Logger
+	keyring *encryption.Keyring
+}
+
+func NewDataKeysRepository(db db.DB, logger *loggers.Logger, keyring *encryption.Keyring) interfaces.IDataKeysRepository {
+	return &dataKeysRepository{db: db, logger: logger, keyring: keyring}
+}
+
+// RewrapDataKeys - wraps the data keys still wrapped by a previous master key with the current one and returns
+// how many were re-wrapped. The notes themselves are left untouched, as their data keys do not change.
+func (r *dataKeysRepository) RewrapDataKeys(ctx context.Context) (int, error) {
+	r.logger.Info(ctx, "Entering dataKeysRepository.RewrapDataKeys()")
+	defer r.logger.Info(ctx, "Exiting dataKeysRepository.RewrapDataKeys()")
+	if r.keyring == nil {
+		return 0, nil
+	}
+	txn := r.db.Txn(ctx, true)
+	rows, err := txn.Get("data_keys", "id")
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in dataKeysRepository.RewrapDataKeys(), error from txn.Get()", err)
+		return 0, err
+	}
+	stale := make([]models.DataKey, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		if dataKey := obj.(*models.DataKey); dataKey.MasterKeyId != r.keyring.CurrentKeyID() {
+			stale = append(stale, *dataKey)
+		}
+	}
+	for i := range stale {
+		key, err := r.keyring.Unwrap(stale[i].MasterKeyId, stale[i].WrappedKey)
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in dataKeysRepository.RewrapDataKeys(), error from keyring.Unwrap()", err)
+			return 0, err
+		}
+		stale[i].WrappedKey, err = r.keyring.Wrap(key)
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in dataKeysRepository.RewrapDataKeys(), error from keyring.Wrap()", err)
+			return 0, err
+		}
+		stale[i].MasterKeyId = r.keyring.CurrentKeyID()
+		if err = txn.Insert("data_keys", &stale[i]); err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in dataKeysRepository.RewrapDataKeys(), error from txn.Insert()", err)
+			return 0, err
+		}
+	}
+	txn.Commit()
+	return len(stale), nil
+}
@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"notes-server/db"
+	"notes-server/encryption"
+	"notes-server/loggers"
+	"notes-server/models"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func testKeyring(t *testing.T, ids ...string) *encryption.Keyring {
+	t.Helper()
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, id+":"+base64.StdEncoding.EncodeToString(bytes.Repeat([]byte(id[len(id)-1:]), encryption.KeySize)))
+	}
+	keyring, err := encryption.NewKeyring(keys...)
+	if err != nil {
+		t.Fatalf("encryption.NewKeyring() error = %v", err)
+	}
+	return keyring
+}
+
+func storedNote(t *testing.T, id int32) models.Note {
+	t.Helper()
+	txn := db.NewDB().Txn(context.Background(), false)
+	defer txn.Abort()
+	row, err := txn.First("notes", "id", id)
+	if err != nil || row == nil {
+		t.Fatalf("note %d not stored: %v", id, err)
+	}
+	return *row.(*models.Note)
+}
+
+func Test_dataKeysRepository_RewrapDataKeys(t *testing.T) {
+	const email = "at-rest@gmail.com"
+	ctx := context.Background()
+	r := NewNotesRepository(db.NewDB(), loggers.NewLogger(), testKeyring(t, "k1"))
+
+	id, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Title: "Secret", Note: "the launch codes, see [[Plans]]"})
+	if err != nil {
+		t.Fatalf("notesRepository.AddNote() error = %v", err)
+	}
+	checklist, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Type: models.NoteTypeChecklist, Items: []models.ChecklistItem{{Text: "buy a safe"}}})
+	if err != nil {
+		t.Fatalf("notesRepository.AddNote() error = %v", err)
+	}
+	stored := storedNote(t, id)
+	if stored.Note != "" || stored.SealedBody == nil || bytes.Contains(stored.SealedBody, []byte("launch codes")) {
+		t.Errorf("stored note is not sealed: %+v", stored)
+	}
+	if stored := storedNote(t, checklist); stored.Items != nil || stored.SealedBody == nil {
+		t.Errorf("stored checklist is not sealed: %+v", stored)
+	}
+
+	// reads, searches and renames see the plaintext
+	notes, err := r.SearchNotes(ctx, models.SearchNotesRequest{Email: email, Query: "launch"})
+	if err != nil || len(notes) != 1 || notes[0].Note != "the launch codes, see [[Plans]]" {
+		t.Fatalf("notesRepository.SearchNotes() = %v, %v", notes, err)
+	}
+	plans, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Title: "Plans", Note: "none yet"})
+	if err != nil {
+		t.Fatalf("notesRepository.AddNote() error = %v", err)
+	}
+	title := "Schemes"
+	if err = r.UpdateNote(ctx, models.UpdateNoteRequest{Email: email, Id: plans, Title: &title}); err != nil {
+		t.Fatalf("notesRepository.UpdateNote() error = %v", err)
+	}
+	if err = r.SetNoteColor(ctx, models.SetNoteColorRequest{Email: email, Id: checklist, Color: "red"}); err != nil {
+		t.Fatalf("notesRepository.SetNoteColor() error = %v", err)
+	}
+	notes, err = r.GetNotes(ctx, models.GetNotesRequest{Email: email})
+	if err != nil {
+		t.Fatalf("notesRepository.GetNotes() error = %v", err)
+	}
+	bodies := make(map[int32]string)
+	for _, note := range notes {
+		bodies[note.Id] = note.Note
+		if note.Id == checklist && (len(note.Items) != 1 || note.Items[0].Text != "buy a safe") {
+			t.Errorf("checklist items = %v", note.Items)
+		}
+	}
+	want := map[int32]string{id: "the launch codes, see [[Schemes]]", checklist: "", plans: "none yet"}
+	if !reflect.DeepEqual(bodies, want) {
+		t.Errorf("notesRepository.GetNotes() bodies = %v, want %v", bodies, want)
+	}
+
+	// a sealed body can not be read without the master key, nor moved to another note
+	if _, err = NewNotesRepository(db.NewDB(), loggers.NewLogger(), nil).GetNotes(ctx, models.GetNotesRequest{Email: email}); err == nil {
+		t.Errorf("notesRepository.GetNotes() read sealed notes without a master key")
+	}
+	moved := storedNote(t, id)
+	moved.Id = plans
+	if err = newNoteCipher(testKeyring(t, "k1")).open(db.NewDB().Txn(ctx, false), &moved); err == nil {
+		t.Errorf("noteCipher.open() accepted a body sealed for another note")
+	}
+
+	// rotating the master key re-wraps the data key only
+	before := storedNote(t, id).SealedBody
+	rotated := testKeyring(t, "k2", "k1")
+	count, err := NewDataKeysRepository(db.NewDB(), loggers.NewLogger(), rotated).RewrapDataKeys(ctx)
+	if err != nil || count == 0 {
+		t.Fatalf("dataKeysRepository.RewrapDataKeys() = %d, %v", count, err)
+	}
+	if count, err = NewDataKeysRepository(db.NewDB(), loggers.NewLogger(), rotated).RewrapDataKeys(ctx); err != nil || count != 0 {
+		t.Errorf("dataKeysRepository.RewrapDataKeys() again = %d, %v", count, err)
+	}
+	if after := storedNote(t, id).SealedBody; !bytes.Equal(before, after) {
+		t.Errorf("rotation re-encrypted the note")
+	}
+	notes, err = NewNotesRepository(db.NewDB(), loggers.NewLogger(), testKeyring(t, "k2")).SearchNotes(ctx, models.SearchNotesRequest{Email: email, Query: "launch"})
+	if err != nil || len(notes) != 1 || !strings.HasPrefix(notes[0].Note, "the launch codes") {
+		t.Errorf("notesRepository.SearchNotes() with the new master key = %v, %v", notes, err)
+	}
+}
@@ -0,0 +1,67 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+)
+
+type keysRepository struct {
+	db     db.DB
+	logger *loggers.Logger
+}
+
+func NewKeysRepository(db db.DB, logger *loggers.Logger) interfaces.IKeysRepository {
+	return &keysRepository{db: db, logger: logger}
+}
+
+// GetKeyMaterial - retrieves the wrapped note key of the user
+func (r *keysRepository) GetKeyMaterial(ctx context.Context, email string) (models.KeyMaterial, error) {
+	r.logger.Info(ctx, "Entering keysRepository.GetKeyMaterial()")
+	defer r.logger.Info(ctx, "Exiting keysRepository.GetKeyMaterial()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	row, err := txn.First("keys", "id", email)
+	if err != nil {
+		r.logger.Warn(ctx, "error in keysRepository.GetKeyMaterial(), error from txn.First()", err)
+		return models.KeyMaterial{}, err
+	}
+	material, ok := row.(*models.KeyMaterial)
+	if !ok {
+		return models.KeyMaterial{}, models.ErrKeyMaterialNotFound
+	}
+	return *material, nil
+}
+
+// SetKeyMaterial - stores the wrapped note key of the user and returns its new version. The version of the
+// request must be the one stored, so that two clients can not overwrite each other's keys.
+func (r *keysRepository) SetKeyMaterial(ctx context.Context, material models.KeyMaterial) (int, error) {
+	r.logger.Info(ctx, "Entering keysRepository.SetKeyMaterial()")
+	defer r.logger.Info(ctx, "Exiting keysRepository.SetKeyMaterial()")
+	txn := r.db.Txn(ctx, true)
+	row, err := txn.First("keys", "id", material.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in keysRepository.SetKeyMaterial(), error from txn.First()", err)
+		return 0, err
+	}
+	version := 0
+	if existing, ok := row.(*models.KeyMaterial); ok {
+		version = existing.Version
+	}
+	if material.Version != version {
+		txn.Abort()
+		return 0, models.ErrKeyMaterialChanged
+	}
+	material.Version++
+	err = txn.Insert("keys", &material)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in keysRepository.SetKeyMaterial(), error from txn.Insert()", err)
+		return 0, err
+	}
+	txn.Commit()
+	return material.Version, nil
+}
@@ -0,0 +1,135 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_keysRepository_SetKeyMaterial(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		version int
+		want    int
+		wantErr bool
+	}{
+		{
+			name: "success case - first key",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("keys", "id", "test@gmail.com").Return(nil, nil)
+				mockTxn.EXPECT().Insert("keys", mock.Anything).Return(nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			version: 0,
+			want:    1,
+			wantErr: false,
+		},
+		{
+			name: "success case - key wrapped again",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("keys", "id", "test@gmail.com").Return(&models.KeyMaterial{Email: "test@gmail.com", Version: 3}, nil)
+				mockTxn.EXPECT().Insert("keys", mock.Anything).Return(nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			version: 3,
+			want:    4,
+			wantErr: false,
+		},
+		{
+			name: "failure case - stale version",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("keys", "id", "test@gmail.com").Return(&models.KeyMaterial{Email: "test@gmail.com", Version: 3}, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			version: 2,
+			want:    0,
+			wantErr: true,
+		},
+		{
+			name: "failure case - error in txn.Insert()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("keys", "id", "test@gmail.com").Return(nil, nil)
+				mockTxn.EXPECT().Insert("keys", mock.Anything).Return(errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			version: 0,
+			want:    0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &keysRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			got, err := r.SetKeyMaterial(context.Background(), models.KeyMaterial{Email: "test@gmail.com", Version: tt.version})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("keysRepository.SetKeyMaterial() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("keysRepository.SetKeyMaterial() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_keysRepository_GetKeyMaterial(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     interface{}
+		want    models.KeyMaterial
+		wantErr bool
+	}{
+		{
+			name:    "success case",
+			row:     &models.KeyMaterial{Email: "test@gmail.com", Version: 1},
+			want:    models.KeyMaterial{Email: "test@gmail.com", Version: 1},
+			wantErr: false,
+		},
+		{
+			name:    "failure case - no key material",
+			row:     nil,
+			want:    models.KeyMaterial{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTxn := db.MockMemDbTxn{}
+			mockTxn.EXPECT().First("keys", "id", "test@gmail.com").Return(tt.row, nil)
+			mockTxn.EXPECT().Abort()
+			mockDb := db.MockDB{}
+			mockDb.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			r := &keysRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			got, err := r.GetKeyMaterial(context.Background(), "test@gmail.com")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("keysRepository.GetKeyMaterial() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got.Version != tt.want.Version || got.Email != tt.want.Email {
+				t.Errorf("keysRepository.GetKeyMaterial() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/models"
+	"notes-server/utils"
+	"regexp"
+	"strings"
+)
+
+// linkPattern - matches [[Title]] and [[Title|label]] links
+var linkPattern = regexp.MustCompile(`\[\[([^\[\]|\n]+)(\|[^\[\]\n]*)?\]\]`)
+
+// parseLinks - returns the distinct titles linked from a note body, in the order they first appear
+func parseLinks(body string) []string {
+	titles := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range linkPattern.FindAllStringSubmatch(body, -1) {
+		title := strings.TrimSpace(match[1])
+		if title == "" || seen[linkKey(title)] {
+			continue
+		}
+		seen[linkKey(title)] = true
+		titles = append(titles, title)
+	}
+	return titles
+}
+
+func linkKey(title string) string {
+	return strings.ToLower(strings.TrimSpace(title))
+}
+
+// rewriteLinks - replaces the links to oldTitle in body with links to newTitle, keeping their labels
+func rewriteLinks(body, oldTitle, newTitle string) string {
+	return linkPattern.ReplaceAllStringFunc(body, func(link string) string {
+		match := linkPattern.FindStringSubmatch(link)
+		if linkKey(match[1]) != linkKey(oldTitle) {
+			return link
+		}
+		return "[[" + newTitle + match[2] + "]]"
+	})
+}
+
+// findNoteByTitle - returns the note of the owner in the workspace with the title, titles are compared case
+// insensitively
+func findNoteByTitle(txn db.MemDbTxn, workspace int32, owner, title string) (*models.Note, error) {
+	row, err := txn.First("notes", "owner_title", workspace, owner, strings.TrimSpace(title))
+	if err != nil {
+		return nil, err
+	}
+	note, _ := row.(*models.Note)
+	return note, nil
+}
+
+// getLinks - returns copies of the links found through index, which can be modified and inserted back
+func getLinks(txn db.MemDbTxn, index string, args ...interface{}) ([]models.NoteLink, error) {
+	rows, err := txn.Get("links", index, args...)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]models.NoteLink, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		links = append(links, *obj.(*models.NoteLink))
+	}
+	return links, nil
+}
+
+// addLinks - stores the links found in the body of note, resolving them against the notes of the same owner in
+// the same workspace
+func addLinks(txn db.MemDbTxn, note *models.Note) error {
+	for i, title := range parseLinks(note.Note) {
+		link := models.NoteLink{
+			Id:        utils.NewID(),
+			SourceId:  note.Id,
+			Workspace: note.Workspace,
+			Owner:     note.CreatedBy,
+			Key:       linkKey(title),
+			Title:     title,
+			Position:  i,
+		}
+		if linkKey(title) == linkKey(note.Title) {
+			link.TargetId = note.Id
+		} else {
+			target, err := findNoteByTitle(txn, note.Workspace, note.CreatedBy, title)
+			if err != nil {
+				return err
+			}
+			if target != nil {
+				link.TargetId = target.Id
+			}
+		}
+		if err := txn.Insert("links", &link); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteLinks - removes the outgoing links of a note
+func deleteLinks(txn db.MemDbTxn, noteID int32) error {
+	links, err := getLinks(txn, "source", noteID)
+	if err != nil {
+		return err
+	}
+	for i := range links {
+		if err = txn.Delete("links", &links[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// claimTitle - checks that no other note of the owner has the title of note, and points the dangling links
+// waiting for that title to it
+func claimTitle(txn db.MemDbTxn, note *models.Note) error {
+	if note.Title == "" {
+		return nil
+	}
+	existing, err := findNoteByTitle(txn, note.Workspace, note.CreatedBy, note.Title)
+	if err != nil {
+		return err
+	}
+	if existing != nil && existing.Id != note.Id {
+		return models.ErrNoteTitleTaken
+	}
+	links, err := getLinks(txn, "owner_key", note.Workspace, note.CreatedBy, linkKey(note.Title))
+	if err != nil {
+		return err
+	}
+	for i := range links {
+		if links[i].TargetId != 0 {
+			continue
+		}
+		links[i].TargetId = note.Id
+		if err = txn.Insert("links", &links[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// renameLinks - rewrites the links to a renamed note in the bodies of the notes linking to it, and writes their
+// update to the audit log. The body of the renamed note itself is only rewritten on note, which the caller stores.
+func renameLinks(ctx context.Context, txn db.MemDbTxn, c *noteCipher, note *models.Note, oldTitle string) error {
+	links, err := getLinks(txn, "target", note.Id)
+	if err != nil {
+		return err
+	}
+	for i := range links {
+		if links[i].SourceId == note.Id {
+			note.Note = rewriteLinks(note.Note, oldTitle, note.Title)
+			continue
+		}
+		row, err := txn.First("notes", "id", links[i].SourceId)
+		if err != nil {
+			return err
+		}
+		if source, ok := row.(*models.Note); ok {
+			updated := *source
+			if err = c.open(txn, &updated); err != nil {
+				return err
+			}
+			updated.Note = rewriteLinks(updated.Note, oldTitle, note.Title)
+			if err = resizeNote(txn, &updated); err != nil {
+				return err
+			}
+			if err = c.seal(txn, &updated); err != nil {
+				return err
+			}
+			if err = txn.Insert("notes", &updated); err != nil {
+				return err
+			}
+			if err = appendAudit(ctx, txn, noteAuditEntry(models.AuditNoteUpdated, updated.Id, source, &updated)); err != nil {
+				return err
+			}
+		}
+		links[i].Key = linkKey(note.Title)
+		links[i].Title = note.Title
+		if err = txn.Insert("links", &links[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unlinkTarget - flags the links to a note as dangling, when it is deleted or loses its title
+func unlinkTarget(txn db.MemDbTxn, noteID int32) error {
+	links, err := getLinks(txn, "target", noteID)
+	if err != nil {
+		return err
+	}
+	for i := range links {
+		links[i].TargetId = 0
+		if err = txn.Insert("links", &links[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
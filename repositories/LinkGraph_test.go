@@ -0,0 +1,131 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"reflect"
+	"testing"
+)
+
+func Test_parseLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{
+			name: "no links",
+			body: "plain [text] with [ [brackets] ]",
+			want: []string{},
+		},
+		{
+			name: "links with labels, duplicates are dropped",
+			body: "see [[Groceries]], [[ groceries |the list]] and [[Recipes|food]]",
+			want: []string{"Groceries", "Recipes"},
+		},
+		{
+			name: "links do not span lines",
+			body: "[[Multi\nline]] [[]] [[|label]]",
+			want: []string{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseLinks(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_rewriteLinks(t *testing.T) {
+	got := rewriteLinks("[[groceries]], [[Groceries|list]] and [[Recipes]]", "Groceries", "Shopping")
+	want := "[[Shopping]], [[Shopping|list]] and [[Recipes]]"
+	if got != want {
+		t.Errorf("rewriteLinks() = %q, want %q", got, want)
+	}
+}
+
+func Test_notesRepository_LinkGraph(t *testing.T) {
+	const email = "links@gmail.com"
+	ctx := context.Background()
+	r := NewNotesRepository(db.NewDB(), loggers.NewLogger(), nil)
+	links := func(id int32) models.NoteLinksResponse {
+		t.Helper()
+		response, err := r.GetNoteLinks(ctx, 0, email, id)
+		if err != nil {
+			t.Fatalf("notesRepository.GetNoteLinks() error = %v", err)
+		}
+		return response
+	}
+	note := func(id int32) models.Note {
+		t.Helper()
+		notes, err := r.GetNotes(ctx, models.GetNotesRequest{Email: email})
+		if err != nil {
+			t.Fatalf("notesRepository.GetNotes() error = %v", err)
+		}
+		for _, note := range notes {
+			if note.Id == id {
+				return note
+			}
+		}
+		t.Fatalf("note %d not found", id)
+		return models.Note{}
+	}
+
+	index, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Title: "Index", Note: "[[Groceries|list]] and [[Recipes]]"})
+	if err != nil {
+		t.Fatalf("notesRepository.AddNote() error = %v", err)
+	}
+	groceries, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Title: "groceries", Note: "back to [[index]]"})
+	if err != nil {
+		t.Fatalf("notesRepository.AddNote() error = %v", err)
+	}
+	if _, err = r.AddNote(ctx, models.AddNoteRequest{Email: email, Title: "GROCERIES", Note: "duplicate"}); err == nil {
+		t.Errorf("notesRepository.AddNote() accepted a duplicate title")
+	}
+	if _, err = r.AddNote(ctx, models.AddNoteRequest{Email: "other@gmail.com", Title: "Recipes", Note: "not linked"}); err != nil {
+		t.Fatalf("notesRepository.AddNote() error = %v", err)
+	}
+
+	// the link to groceries is resolved once the note exists, recipes of another user are not linked
+	want := models.NoteLinksResponse{
+		Links:     []models.LinkedNote{{Id: groceries, Title: "Groceries"}, {Title: "Recipes", Dangling: true}},
+		Backlinks: []models.LinkedNote{{Id: groceries, Title: "groceries"}},
+	}
+	if got := links(index); !reflect.DeepEqual(got, want) {
+		t.Errorf("notesRepository.GetNoteLinks() = %v, want %v", got, want)
+	}
+
+	// renaming a note rewrites the links to it
+	title := "Shopping"
+	if err = r.UpdateNote(ctx, models.UpdateNoteRequest{Email: email, Id: groceries, Title: &title}); err != nil {
+		t.Fatalf("notesRepository.UpdateNote() error = %v", err)
+	}
+	if got := note(index).Note; got != "[[Shopping|list]] and [[Recipes]]" {
+		t.Errorf("renamed note body = %q", got)
+	}
+	if got := links(index).Links[0]; got != (models.LinkedNote{Id: groceries, Title: "Shopping"}) {
+		t.Errorf("renamed link = %v", got)
+	}
+	if entry := lastAuditEntry(t, models.AuditNoteUpdated, models.AuditTarget("note", index)); entry.Before["size"] == entry.After["size"] {
+		t.Errorf("rewrite of the links entry = %+v, want the new size", entry)
+	}
+
+	// deleting a note leaves the links to it dangling and removes its own links
+	if err = r.DeleteNote(ctx, 0, "other@gmail.com", groceries); err == nil {
+		t.Errorf("notesRepository.DeleteNote() deleted a note of another user")
+	}
+	if err = r.DeleteNote(ctx, 0, email, groceries); err != nil {
+		t.Fatalf("notesRepository.DeleteNote() error = %v", err)
+	}
+	want = models.NoteLinksResponse{
+		Links:     []models.LinkedNote{{Title: "Shopping", Dangling: true}, {Title: "Recipes", Dangling: true}},
+		Backlinks: []models.LinkedNote{},
+	}
+	if got := links(index); !reflect.DeepEqual(got, want) {
+		t.Errorf("notesRepository.GetNoteLinks() after delete = %v, want %v", got, want)
+	}
+}
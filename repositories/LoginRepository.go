@@ -2,12 +2,12 @@ package repositories
 
 import (
 	"context"
-	"errors"
 	"notes-server/db"
 	"notes-server/interfaces"
 	"notes-server/loggers"
 	"notes-server/models"
 	"notes-server/utils"
+	"time"
 )
 
 type loginRepository struct {
@@ -19,12 +19,32 @@ func NewLoginRepository(db db.DB, logger *loggers.Logger) interfaces.ILoginRepos
 	return &loginRepository{db: db, logger: logger}
 }
 
-// Login - Checks if user exists in the db and checks if the password matches based on email
-func (r *loginRepository) Login(ctx context.Context, request models.LoginRequest) (models.LoginRepoResponse, error) {
+// Login - Checks if user exists in the db and checks if the password matches based on email. The attempt is
+// refused while the account or the client is throttled, a failure is counted whether the email or the password
+// was wrong and the same error is returned for both. Every attempt is written to the audit log.
+func (r *loginRepository) Login(ctx context.Context, request models.LoginRequest, attempt models.LoginAttempt) (models.LoginRepoResponse, error) {
 	r.logger.Info(ctx, "Entering loginRepository.Login()")
 	defer r.logger.Info(ctx, "Exiting loginRepository.Login()")
+	// the throttle is checked and updated in the same transaction so that concurrent attempts are counted
+	txn := r.db.Txn(ctx, true)
+	keys := loginThrottleKeys(request.Email, attempt)
+	retryAt, err := checkLoginThrottle(txn, keys, attempt.At)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.Login(), error from checkLoginThrottle()", err)
+		return models.LoginRepoResponse{}, err
+	}
+	if !retryAt.IsZero() {
+		err = appendAudit(ctx, txn, loginAuditEntry(models.AuditLogin, request.Email, models.ErrTooManyLoginAttempts.Code))
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.Login(), error from appendAudit()", err)
+			return models.LoginRepoResponse{}, err
+		}
+		txn.Commit()
+		return models.LoginRepoResponse{}, &models.LoginThrottledError{RetryAt: retryAt}
+	}
 	// Query DB to validate email and password
-	txn := r.db.Txn(ctx, false)
 	row, err := txn.First("user", "email", request.Email)
 	if err != nil {
 		txn.Abort()
@@ -32,79 +52,317 @@ func (r *loginRepository) Login(ctx context.Context, request models.LoginRequest
 		return models.LoginRepoResponse{}, err
 	}
 	response, ok := row.(*models.User)
-	if !ok {
+	// the users provisioned by an identity provider have no password to log in with
+	if !ok || response.Password == "" || response.Password != request.Password {
+		lockouts, retryAt, err := recordLoginFailure(txn, keys, attempt.At)
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.Login(), error from recordLoginFailure()", err)
+			return models.LoginRepoResponse{}, err
+		}
+		err = appendAudit(ctx, txn, loginAuditEntry(models.AuditLogin, request.Email, models.ErrInvalidCredentials.Code))
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.Login(), error from appendAudit()", err)
+			return models.LoginRepoResponse{}, err
+		}
+		txn.Commit()
+		r.logger.Warn(ctx, "error in loginRepository.Login(), invalid credentials")
+		if len(lockouts) > 0 {
+			return models.LoginRepoResponse{}, &models.LoginThrottledError{RetryAt: retryAt, Lockouts: lockouts}
+		}
+		return models.LoginRepoResponse{}, models.ErrInvalidCredentials
+	}
+	err = clearLoginFailures(txn, models.AccountThrottleKey(request.Email))
+	if err != nil {
 		txn.Abort()
-		r.logger.Warn(ctx, "error in loginRepository.Login(), failed to fetch user data", err)
-		return models.LoginRepoResponse{}, errors.New("failed to fetch user data, data not existing")
+		r.logger.Warn(ctx, "error in loginRepository.Login(), error from clearLoginFailures()", err)
+		return models.LoginRepoResponse{}, err
 	}
-	if response.Password != request.Password {
+	err = appendAudit(ctx, txn, userLoginAuditEntry(models.AuditLogin, *response, true))
+	if err != nil {
 		txn.Abort()
-		r.logger.Warn(ctx, "error in loginRepository.Login(), invalid credentials", err)
-		return models.LoginRepoResponse{}, errors.New("invalid credentials")
+		r.logger.Warn(ctx, "error in loginRepository.Login(), error from appendAudit()", err)
+		return models.LoginRepoResponse{}, err
 	}
 	txn.Commit()
 	return models.LoginRepoResponse{
-		Email: response.Email,
-		Name:  response.Name,
+		Email:          response.Email,
+		Name:           response.Name,
+		Verified:       response.Verified,
+		SessionVersion: response.SessionVersion,
+		MFAEnabled:     response.MFA.Enabled,
+		Role:           response.Role,
+		Disabled:       response.Disabled,
+		// the admin bootstrapped from the configuration logs in with a password the operator knows
+		MustChangePassword: response.MustChangePassword,
+		Workspace:          response.DefaultWorkspace,
 	}, nil
 }
 
-// SignUp - Creates a new user in the db
+// SignUp - Creates a new user in the db, with their personal workspace. The address is checked and the user
+// inserted in one write transaction, which memdb runs one at a time, so only one of concurrent signups with the
+// same address succeeds and the others get ErrEmailTaken.
 func (r *loginRepository) SignUp(ctx context.Context, request models.SignUpRequest) error {
 	r.logger.Info(ctx, "Entering loginRepository.SignUp()")
 	defer r.logger.Info(ctx, "Exiting loginRepository.SignUp()")
 	txn := r.db.Txn(ctx, true)
 
-	user := models.User{Name: request.Name, Email: request.Email, Password: request.Password, Id: utils.NewID()}
-	err := txn.Insert("user", &user)
+	user := models.User{Name: request.Name, Email: utils.NormalizeEmail(request.Email), Password: request.Password, Id: utils.NewID(), Role: models.RoleUser}
+	taken, err := emailTaken(txn, user.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.SignUp(), error from emailTaken()", err)
+		return err
+	}
+	if taken {
+		txn.Abort()
+		return models.ErrEmailTaken
+	}
+	err = addPersonalWorkspace(txn, &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.SignUp(), error from addPersonalWorkspace()", err)
+		return err
+	}
+	err = txn.Insert("user", &user)
 	if err != nil {
 		txn.Abort()
 		r.logger.Warn(ctx, "error in loginRepository.SignUp(), error from txn.Insert()", err)
 		return err
 	}
+	err = appendAudit(ctx, txn, models.AuditEntry{
+		Action: models.AuditSignUp,
+		Actor:  user.Email,
+		Target: models.AuditTarget("user", user.Email),
+		After:  models.AuditSummary{"name": user.Name, "role": user.Role},
+	})
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.SignUp(), error from appendAudit()", err)
+		return err
+	}
 	txn.Commit()
 	return nil
 }
 
-// CheckIfUserExists - Checks if a user exists
-func (r *loginRepository) CheckIfUserExists(ctx context.Context, email string) (bool, error) {
-	r.logger.Info(ctx, "Entering loginRepository.CheckIfUserExists()")
-	defer r.logger.Info(ctx, "Exiting loginRepository.CheckIfUserExists()")
+// ValidateUser - validate creds and returns the user, sessions issued before the last revocation, the sessions
+// of disabled users and the sessions for a workspace the user is no longer a member of are refused. The name of
+// the user can change during a session so it is not part of the check.
+func (r *loginRepository) ValidateUser(ctx context.Context, email string, workspace int32, sessionVersion int) (models.User, error) {
+	r.logger.Info(ctx, "Entering loginRepository.ValidateUser()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.ValidateUser()")
 	// Query DB to validate email
 	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
 	row, err := txn.First("user", "email", email)
 	if err != nil {
-		txn.Abort()
-		r.logger.Warn(ctx, "error in loginRepository.CheckIfUserExists(), error from txn.First()", err)
-		return false, err
+		r.logger.Warn(ctx, "error in loginRepository.ValidateUser(), error from txn.First()", err)
+		return models.User{}, err
 	}
-	txn.Commit()
-	_, ok := row.(*models.User)
-	if ok {
-		return true, nil
+	user, ok := row.(*models.User)
+	if !ok {
+		return models.User{}, models.ErrInvalidSession
+	}
+	if user.SessionVersion != sessionVersion {
+		return models.User{}, models.ErrInvalidSession
 	}
-	return false, nil
+	if user.Disabled {
+		return models.User{}, models.ErrAccountDisabled
+	}
+	if _, err = getMembership(txn, workspace, email); err != nil {
+		return models.User{}, err
+	}
+	return *user, nil
 }
 
-// ValidateUser - validate creds
-func (r *loginRepository) ValidateUser(ctx context.Context, email, name string) error {
-	r.logger.Info(ctx, "Entering loginRepository.ValidateUser()")
-	defer r.logger.Info(ctx, "Exiting loginRepository.ValidateUser()")
-	// Query DB to validate email and name
+// GetMember - retrieves the user with the email and their membership of the workspace
+func (r *loginRepository) GetMember(ctx context.Context, workspace int32, email string) (models.User, models.Membership, error) {
+	r.logger.Info(ctx, "Entering loginRepository.GetMember()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.GetMember()")
 	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	user, err := getUser(txn, email)
+	if err != nil {
+		r.logger.Warn(ctx, "error in loginRepository.GetMember(), error from getUser()", err)
+		return models.User{}, models.Membership{}, err
+	}
+	membership, err := getMembership(txn, workspace, email)
+	if err != nil {
+		r.logger.Warn(ctx, "error in loginRepository.GetMember(), error from getMembership()", err)
+		return models.User{}, models.Membership{}, err
+	}
+	return user, membership, nil
+}
+
+// GetUser - retrieves the user with the email
+func (r *loginRepository) GetUser(ctx context.Context, email string) (models.User, error) {
+	r.logger.Info(ctx, "Entering loginRepository.GetUser()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.GetUser()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	row, err := txn.First("user", "email", email)
+	if err != nil {
+		r.logger.Warn(ctx, "error in loginRepository.GetUser(), error from txn.First()", err)
+		return models.User{}, err
+	}
+	user, ok := row.(*models.User)
+	if !ok {
+		return models.User{}, models.ErrUserNotFound
+	}
+	return *user, nil
+}
+
+// VerifyEmail - marks the email address of the user as verified
+func (r *loginRepository) VerifyEmail(ctx context.Context, email string) error {
+	r.logger.Info(ctx, "Entering loginRepository.VerifyEmail()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.VerifyEmail()")
+	txn := r.db.Txn(ctx, true)
 	row, err := txn.First("user", "email", email)
 	if err != nil {
 		txn.Abort()
-		r.logger.Warn(ctx, "error in loginRepository.ValidateUser(), error from txn.First()", err)
+		r.logger.Warn(ctx, "error in loginRepository.VerifyEmail(), error from txn.First()", err)
 		return err
 	}
-	txn.Commit()
 	user, ok := row.(*models.User)
 	if !ok {
-		return errors.New("invalid user")
+		txn.Abort()
+		return models.ErrUserNotFound
+	}
+	updated := *user
+	updated.Verified = true
+	err = txn.Insert("user", &updated)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.VerifyEmail(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// AddPasswordReset - stores a password reset token, the tokens issued to the user before are dropped
+func (r *loginRepository) AddPasswordReset(ctx context.Context, reset models.PasswordReset) error {
+	r.logger.Info(ctx, "Entering loginRepository.AddPasswordReset()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.AddPasswordReset()")
+	txn := r.db.Txn(ctx, true)
+	err := deletePasswordResets(txn, reset.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.AddPasswordReset(), error from deletePasswordResets()", err)
+		return err
+	}
+	err = txn.Insert("password_resets", &reset)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.AddPasswordReset(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// GetPasswordReset - returns the user an unexpired password reset token was issued to, the token is not used
+func (r *loginRepository) GetPasswordReset(ctx context.Context, tokenHash string, now time.Time) (models.User, error) {
+	r.logger.Info(ctx, "Entering loginRepository.GetPasswordReset()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.GetPasswordReset()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	reset, err := getPasswordReset(txn, tokenHash, now)
+	if err != nil {
+		r.logger.Warn(ctx, "error in loginRepository.GetPasswordReset(), error from getPasswordReset()", err)
+		return models.User{}, err
+	}
+	user, err := getUser(txn, reset.Email)
+	if err != nil {
+		r.logger.Warn(ctx, "error in loginRepository.GetPasswordReset(), error from getUser()", err)
+		return models.User{}, models.ErrInvalidResetToken
+	}
+	return user, nil
+}
+
+// ResetPassword - sets the password of the user a reset token was issued to and drops all their reset tokens.
+// All the sessions and access tokens of the user are revoked, as the account may have been taken over, and as the
+// token was received by email the address is verified.
+func (r *loginRepository) ResetPassword(ctx context.Context, tokenHash, password string, now time.Time) error {
+	r.logger.Info(ctx, "Entering loginRepository.ResetPassword()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.ResetPassword()")
+	txn := r.db.Txn(ctx, true)
+	// checked again, the token may have been used since GetPasswordReset
+	reset, err := getPasswordReset(txn, tokenHash, now)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ResetPassword(), error from getPasswordReset()", err)
+		return err
+	}
+	user, err := getUser(txn, reset.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ResetPassword(), error from getUser()", err)
+		return models.ErrInvalidResetToken
+	}
+	err = deletePasswordResets(txn, reset.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ResetPassword(), error from deletePasswordResets()", err)
+		return err
+	}
+	err = deleteAccessTokens(txn, reset.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ResetPassword(), error from deleteAccessTokens()", err)
+		return err
+	}
+	before := user
+	user.Password = password
+	user.MustChangePassword = false
+	user.SessionVersion++
+	user.Verified = true
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ResetPassword(), error from txn.Insert()", err)
+		return err
+	}
+	entry := userAuditEntry(models.AuditPasswordReset, before, user)
+	entry.Actor = user.Email
+	err = appendAudit(ctx, txn, entry)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ResetPassword(), error from appendAudit()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// getPasswordReset - the unexpired password reset with the token hash
+func getPasswordReset(txn db.MemDbTxn, tokenHash string, now time.Time) (models.PasswordReset, error) {
+	row, err := txn.First("password_resets", "id", tokenHash)
+	if err != nil {
+		return models.PasswordReset{}, err
+	}
+	reset, ok := row.(*models.PasswordReset)
+	if !ok || !now.Before(reset.ExpiresAt) {
+		return models.PasswordReset{}, models.ErrInvalidResetToken
+	}
+	return *reset, nil
+}
+
+// deletePasswordResets - removes the pending password reset tokens of a user
+func deletePasswordResets(txn db.MemDbTxn, email string) error {
+	rows, err := txn.Get("password_resets", "email", email)
+	if err != nil {
+		return err
+	}
+	resets := make([]models.PasswordReset, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		resets = append(resets, *obj.(*models.PasswordReset))
 	}
-	if user.Name != name {
-		return errors.New("invalid user")
+	for i := range resets {
+		if err = txn.Delete("password_resets", &resets[i]); err != nil {
+			return err
+		}
 	}
 	return nil
 }
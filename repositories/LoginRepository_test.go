@@ -7,119 +7,79 @@ import (
 	"notes-server/loggers"
 	"notes-server/models"
 	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
 
 func Test_loginRepository_Login(t *testing.T) {
+	const email = "login@gmail.com"
+	r := NewLoginRepository(db.NewDB(), loggers.NewLogger())
+	if err := r.SignUp(context.Background(), models.SignUpRequest{Email: email, Name: "test", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	user, err := r.GetUser(context.Background(), email)
+	if err != nil {
+		t.Fatalf("loginRepository.GetUser() error = %v", err)
+	}
 	type args struct {
 		ctx     context.Context
 		request models.LoginRequest
 	}
 	tests := []struct {
 		name    string
-		given   func(*db.MockDB)
 		args    args
 		want    models.LoginRepoResponse
-		wantErr bool
+		wantErr error
 	}{
 		{
 			name: "success case",
-			given: func(dab *db.MockDB) {
-				mockTxn := db.MockMemDbTxn{}
-				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.User{
-					Id:       123,
-					Name:     "test",
-					Email:    "test@gmail.com",
-					Password: "password",
-				}, nil)
-				mockTxn.EXPECT().Commit()
-				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
-			},
 			args: args{
 				ctx: context.Background(),
 				request: models.LoginRequest{
-					Email:    "test@gmail.com",
+					Email:    email,
 					Password: "password",
 				},
 			},
 			want: models.LoginRepoResponse{
-				Email: "test@gmail.com",
-				Name:  "test",
+				Email:     email,
+				Name:      "test",
+				Role:      models.RoleUser,
+				Workspace: user.DefaultWorkspace,
 			},
-			wantErr: false,
-		},
-		{
-			name: "failure case - error in txn.First()",
-			given: func(dab *db.MockDB) {
-				mockTxn := db.MockMemDbTxn{}
-				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.User{}, errors.New("db error"))
-				mockTxn.EXPECT().Abort()
-				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
-			},
-			args: args{
-				ctx: context.Background(),
-				request: models.LoginRequest{
-					Email:    "test@gmail.com",
-					Password: "password",
-				},
-			},
-			want:    models.LoginRepoResponse{},
-			wantErr: true,
 		},
 		{
 			name: "failure case - data dosent exist in db",
-			given: func(dab *db.MockDB) {
-				mockTxn := db.MockMemDbTxn{}
-				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
-				mockTxn.EXPECT().Abort()
-				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
-			},
 			args: args{
 				ctx: context.Background(),
 				request: models.LoginRequest{
-					Email:    "test@gmail.com",
+					Email:    "nobody@gmail.com",
 					Password: "password",
 				},
 			},
 			want:    models.LoginRepoResponse{},
-			wantErr: true,
+			wantErr: models.ErrInvalidCredentials,
 		},
 		{
 			name: "failure case - passwords do not match",
-			given: func(dab *db.MockDB) {
-				mockTxn := db.MockMemDbTxn{}
-				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.User{
-					Id:       123,
-					Name:     "test",
-					Email:    "test@gmail.com",
-					Password: "passwordold",
-				}, nil)
-				mockTxn.EXPECT().Abort()
-				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
-			},
 			args: args{
 				ctx: context.Background(),
 				request: models.LoginRequest{
-					Email:    "test@gmail.com",
-					Password: "password",
+					Email:    email,
+					Password: "passwordold",
 				},
 			},
 			want:    models.LoginRepoResponse{},
-			wantErr: true,
+			wantErr: models.ErrInvalidCredentials,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			mockDB := db.MockDB{}
-			tt.given(&mockDB)
-			r := &loginRepository{
-				db:     &mockDB,
-				logger: loggers.NewLogger(),
-			}
-			got, err := r.Login(tt.args.ctx, tt.args.request)
-			if (err != nil) != tt.wantErr {
+			got, err := r.Login(tt.args.ctx, tt.args.request, models.LoginAttempt{At: time.Now()})
+			if err != tt.wantErr {
 				t.Errorf("loginRepository.Login() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
@@ -130,6 +90,86 @@ func Test_loginRepository_Login(t *testing.T) {
 	}
 }
 
+func Test_loginRepository_Login_throttle(t *testing.T) {
+	const email = "throttle@gmail.com"
+	ctx := context.Background()
+	r := NewLoginRepository(db.NewDB(), loggers.NewLogger())
+	if err := r.SignUp(ctx, models.SignUpRequest{Email: email, Name: "test", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	policy := models.LoginThrottlePolicy{
+		BackoffAfter:    2,
+		BaseDelay:       time.Second,
+		MaxDelay:        4 * time.Second,
+		LockoutAfter:    5,
+		LockoutDuration: time.Minute,
+		Window:          time.Hour,
+	}
+	client := policy
+	client.BackoffAfter, client.LockoutAfter = 100, 100
+	now := time.Unix(1700000000, 0)
+	login := func(email, password string) error {
+		_, err := r.Login(ctx, models.LoginRequest{Email: email, Password: password}, models.LoginAttempt{
+			IP:       "203.0.113.7",
+			At:       now,
+			Throttle: models.LoginThrottle{Account: policy, Client: client},
+		})
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := login(email, "wrong"); err != models.ErrInvalidCredentials {
+			t.Fatalf("loginRepository.Login() failure %d error = %v", i+1, err)
+		}
+	}
+	// the third failure backs off for a second, even the right password is refused meanwhile
+	if err := login(email, "wrong"); err != models.ErrInvalidCredentials {
+		t.Fatalf("loginRepository.Login() failure 3 error = %v", err)
+	}
+	var throttled *models.LoginThrottledError
+	if err := login(email, "password"); !errors.As(err, &throttled) || !throttled.RetryAt.Equal(now.Add(time.Second)) || len(throttled.Lockouts) != 0 {
+		t.Fatalf("loginRepository.Login() during the backoff error = %v", err)
+	}
+	// the address is throttled whatever its case
+	if err := login("THROTTLE@gmail.com", "password"); !errors.Is(err, models.ErrTooManyLoginAttempts) {
+		t.Errorf("loginRepository.Login() with another case error = %v", err)
+	}
+	now = now.Add(time.Second)
+	if err := login(email, "wrong"); err != models.ErrInvalidCredentials {
+		t.Fatalf("loginRepository.Login() failure 4 error = %v", err)
+	}
+	now = now.Add(2 * time.Second)
+	if err := login(email, "wrong"); !errors.As(err, &throttled) || len(throttled.Lockouts) != 1 || throttled.Lockouts[0].Key != models.AccountThrottleKey(email) {
+		t.Fatalf("loginRepository.Login() failure 5 error = %v, want a lockout", err)
+	}
+	now = now.Add(59 * time.Second)
+	if err := login(email, "password"); !errors.Is(err, models.ErrTooManyLoginAttempts) {
+		t.Errorf("loginRepository.Login() during the lockout error = %v", err)
+	}
+	// unknown addresses are throttled like the registered ones
+	for i := 0; i < 3; i++ {
+		login("nobody@gmail.com", "wrong")
+	}
+	if err := login("nobody@gmail.com", "wrong"); !errors.Is(err, models.ErrTooManyLoginAttempts) {
+		t.Errorf("loginRepository.Login() of an unknown address during the backoff error = %v", err)
+	}
+
+	now = now.Add(time.Second)
+	if err := login(email, "password"); err != nil {
+		t.Fatalf("loginRepository.Login() after the lockout error = %v", err)
+	}
+	// the success forgets the failures of the account
+	if err := login(email, "wrong"); err != models.ErrInvalidCredentials {
+		t.Errorf("loginRepository.Login() after a success error = %v", err)
+	}
+
+	// the client is locked out across accounts
+	client.LockoutAfter = 10
+	if err := login("other@gmail.com", "wrong"); !errors.As(err, &throttled) || len(throttled.Lockouts) != 1 || throttled.Lockouts[0].Key != models.ClientThrottleKey("203.0.113.7") {
+		t.Errorf("loginRepository.Login() failure 10 of the client error = %v, want a lockout", err)
+	}
+}
+
 func Test_loginRepository_SignUp(t *testing.T) {
 	type args struct {
 		ctx     context.Context
@@ -145,6 +185,8 @@ func Test_loginRepository_SignUp(t *testing.T) {
 			name: "success case",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("user", "email", "test@gmail.com").Return(nil, nil)
+				mockTxn.EXPECT().First("audit_log", "id", mock.Anything).Return(nil, nil)
 				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(nil)
 				mockTxn.EXPECT().Commit()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
@@ -163,6 +205,7 @@ func Test_loginRepository_SignUp(t *testing.T) {
 			name: "failure case - error in txn.Insert()",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("user", "email", "test@gmail.com").Return(nil, nil)
 				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(errors.New("db error"))
 				mockTxn.EXPECT().Abort()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
@@ -177,6 +220,24 @@ func Test_loginRepository_SignUp(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "failure case - email taken",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("user", "email", "test@gmail.com").Return(&models.User{Email: "test@gmail.com"}, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SignUpRequest{
+					Email:    "Test@Gmail.com ",
+					Password: "password",
+					Name:     "test",
+				},
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -195,16 +256,74 @@ func Test_loginRepository_SignUp(t *testing.T) {
 	}
 }
 
-func Test_loginRepository_CheckIfUserExists(t *testing.T) {
+// Test_loginRepository_SignUp_concurrent - concurrent signups with the same address, differing by case, create a
+// single user
+func Test_loginRepository_SignUp_concurrent(t *testing.T) {
+	const signups = 20
+	ctx := context.Background()
+	r := NewLoginRepository(db.NewDB(), loggers.NewLogger())
+	errs := make(chan error, signups)
+	var start, done sync.WaitGroup
+	start.Add(1)
+	for i := 0; i < signups; i++ {
+		email := "race@signup.io"
+		if i%2 == 1 {
+			email = " Race@SignUp.io"
+		}
+		done.Add(1)
+		go func(email string) {
+			defer done.Done()
+			start.Wait()
+			errs <- r.SignUp(ctx, models.SignUpRequest{Email: email, Name: "test", Password: "password"})
+		}(email)
+	}
+	start.Done()
+	done.Wait()
+	close(errs)
+
+	created := 0
+	for err := range errs {
+		switch {
+		case err == nil:
+			created++
+		case !errors.Is(err, models.ErrEmailTaken):
+			t.Errorf("loginRepository.SignUp() error = %v, want %v", err, models.ErrEmailTaken)
+		}
+	}
+	if created != 1 {
+		t.Errorf("loginRepository.SignUp() created %d users, want 1", created)
+	}
+	txn := db.NewDB().Txn(ctx, false)
+	defer txn.Abort()
+	rows, err := txn.Get("user", "id")
+	if err != nil {
+		t.Fatalf("txn.Get() error = %v", err)
+	}
+	users := 0
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		if user := obj.(*models.User); strings.EqualFold(user.Email, "race@signup.io") {
+			users++
+			if user.Email != "race@signup.io" {
+				t.Errorf("loginRepository.SignUp() stored the address %q, want it normalized", user.Email)
+			}
+		}
+	}
+	if users != 1 {
+		t.Errorf("%d users stored with the address, want 1", users)
+	}
+}
+
+func Test_loginRepository_ValidateUser(t *testing.T) {
 	type args struct {
-		ctx     context.Context
-		request string
+		ctx            context.Context
+		email          string
+		workspace      int32
+		sessionVersion int
 	}
 	tests := []struct {
 		name    string
 		given   func(*db.MockDB)
 		args    args
-		want    bool
 		wantErr bool
 	}{
 		{
@@ -217,14 +336,15 @@ func Test_loginRepository_CheckIfUserExists(t *testing.T) {
 					Email:    "test@gmail.com",
 					Password: "password",
 				}, nil)
-				mockTxn.EXPECT().Commit()
+				mockTxn.EXPECT().First("memberships", "id", int32(1), "test@gmail.com").Return(&models.Membership{WorkspaceId: 1, Email: "test@gmail.com"}, nil)
+				mockTxn.EXPECT().Abort()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
 			args: args{
-				ctx:     context.Background(),
-				request: "test@gmail.com",
+				ctx:       context.Background(),
+				email:     "test@gmail.com",
+				workspace: 1,
 			},
-			want:    true,
 			wantErr: false,
 		},
 		{
@@ -236,10 +356,9 @@ func Test_loginRepository_CheckIfUserExists(t *testing.T) {
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
 			args: args{
-				ctx:     context.Background(),
-				request: "test@gmail.com",
+				ctx:   context.Background(),
+				email: "test@gmail.com",
 			},
-			want:    false,
 			wantErr: true,
 		},
 		{
@@ -247,16 +366,76 @@ func Test_loginRepository_CheckIfUserExists(t *testing.T) {
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
 				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
-				mockTxn.EXPECT().Commit()
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx:   context.Background(),
+				email: "test@gmail.com",
+			},
+			wantErr: true,
+		},
+		{
+			name: "success case - renamed since the session was issued",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.User{
+					Id:       123,
+					Name:     "test2",
+					Email:    "test@gmail.com",
+					Password: "password",
+				}, nil)
+				mockTxn.EXPECT().First("memberships", "id", int32(1), "test@gmail.com").Return(&models.Membership{WorkspaceId: 1, Email: "test@gmail.com"}, nil)
+				mockTxn.EXPECT().Abort()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
 			args: args{
-				ctx:     context.Background(),
-				request: "test@gmail.com",
+				ctx:       context.Background(),
+				email:     "test@gmail.com",
+				workspace: 1,
 			},
-			want:    false,
 			wantErr: false,
 		},
+		{
+			name: "failure case - session revoked",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.User{
+					Id:             123,
+					Name:           "test",
+					Email:          "test@gmail.com",
+					SessionVersion: 2,
+				}, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx:            context.Background(),
+				email:          "test@gmail.com",
+				sessionVersion: 1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "failure case - no longer a member of the workspace",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.User{
+					Id:    123,
+					Name:  "test",
+					Email: "test@gmail.com",
+				}, nil)
+				mockTxn.EXPECT().First("memberships", "id", int32(2), "test@gmail.com").Return(nil, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx:       context.Background(),
+				email:     "test@gmail.com",
+				workspace: 2,
+			},
+			wantErr: true,
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -266,107 +445,51 @@ func Test_loginRepository_CheckIfUserExists(t *testing.T) {
 				db:     &mockDB,
 				logger: loggers.NewLogger(),
 			}
-			got, err := r.CheckIfUserExists(tt.args.ctx, tt.args.request)
+			_, err := r.ValidateUser(tt.args.ctx, tt.args.email, tt.args.workspace, tt.args.sessionVersion)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("loginRepository.CheckIfUserExists() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("loginRepository.ValidateUser() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
-			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("loginRepository.CheckIfUserExists() = %v, want %v", got, tt.want)
-			}
 		})
 	}
 }
 
-func Test_loginRepository_ValidateUser(t *testing.T) {
-	type args struct {
-		ctx     context.Context
-		request struct {
-			email, name string
-		}
-	}
+func Test_loginRepository_VerifyEmail(t *testing.T) {
 	tests := []struct {
 		name    string
 		given   func(*db.MockDB)
-		args    args
 		wantErr bool
 	}{
 		{
 			name: "success case",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
-				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.User{
-					Id:       123,
-					Name:     "test",
-					Email:    "test@gmail.com",
-					Password: "password",
-				}, nil)
+				mockTxn.EXPECT().First("user", "email", "test@gmail.com").Return(&models.User{Id: 1, Email: "test@gmail.com", Name: "test"}, nil)
+				mockTxn.EXPECT().Insert("user", &models.User{Id: 1, Email: "test@gmail.com", Name: "test", Verified: true}).Return(nil)
 				mockTxn.EXPECT().Commit()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
-			args: args{
-				ctx: context.Background(),
-				request: struct{ email, name string }{
-					email: "test@gmail.com",
-					name:  "test",
-				},
-			},
 			wantErr: false,
 		},
 		{
-			name: "failure case - error in txn.First()",
+			name: "failure case - user not found",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
-				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.User{}, errors.New("db error"))
+				mockTxn.EXPECT().First("user", "email", "test@gmail.com").Return(nil, nil)
 				mockTxn.EXPECT().Abort()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
-			args: args{
-				ctx: context.Background(),
-				request: struct{ email, name string }{
-					email: "test@gmail.com",
-					name:  "test",
-				},
-			},
 			wantErr: true,
 		},
 		{
-			name: "failure case - data dosent exist in db",
-			given: func(dab *db.MockDB) {
-				mockTxn := db.MockMemDbTxn{}
-				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
-				mockTxn.EXPECT().Commit()
-				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
-			},
-			args: args{
-				ctx: context.Background(),
-				request: struct{ email, name string }{
-					email: "test@gmail.com",
-					name:  "test",
-				},
-			},
-			wantErr: true,
-		},
-		{
-			name: "failure case - invalid user",
+			name: "failure case - error in txn.Insert()",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
-				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.User{
-					Id:       123,
-					Name:     "test2",
-					Email:    "test@gmail.com",
-					Password: "password",
-				}, nil)
-				mockTxn.EXPECT().Commit()
+				mockTxn.EXPECT().First("user", "email", "test@gmail.com").Return(&models.User{Id: 1, Email: "test@gmail.com"}, nil)
+				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(errors.New("db error"))
+				mockTxn.EXPECT().Abort()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
-			args: args{
-				ctx: context.Background(),
-				request: struct{ email, name string }{
-					email: "test@gmail.com",
-					name:  "test",
-				},
-			},
 			wantErr: true,
 		},
 	}
@@ -378,11 +501,65 @@ func Test_loginRepository_ValidateUser(t *testing.T) {
 				db:     &mockDB,
 				logger: loggers.NewLogger(),
 			}
-			err := r.ValidateUser(tt.args.ctx, tt.args.request.email, tt.args.request.name)
+			err := r.VerifyEmail(context.Background(), "test@gmail.com")
 			if (err != nil) != tt.wantErr {
-				t.Errorf("loginRepository.ValidateUser() error = %v, wantErr %v", err, tt.wantErr)
-				return
+				t.Errorf("loginRepository.VerifyEmail() error = %v, wantErr %v", err, tt.wantErr)
 			}
 		})
 	}
 }
+
+func Test_loginRepository_ResetPassword(t *testing.T) {
+	const email = "reset@gmail.com"
+	ctx := context.Background()
+	now := time.Now()
+	r := NewLoginRepository(db.NewDB(), loggers.NewLogger())
+	if err := r.SignUp(ctx, models.SignUpRequest{Email: email, Name: "reset", Password: "old"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	addReset := func(tokenHash string, expiresAt time.Time) {
+		t.Helper()
+		if err := r.AddPasswordReset(ctx, models.PasswordReset{TokenHash: tokenHash, Email: email, ExpiresAt: expiresAt}); err != nil {
+			t.Fatalf("loginRepository.AddPasswordReset() error = %v", err)
+		}
+	}
+
+	addReset("expired", now.Add(-time.Minute))
+	if _, err := r.GetPasswordReset(ctx, "expired", now); !errors.Is(err, models.ErrInvalidResetToken) {
+		t.Errorf("loginRepository.GetPasswordReset() error = %v, want %v for an expired token", err, models.ErrInvalidResetToken)
+	}
+	if err := r.ResetPassword(ctx, "expired", "new", now); !errors.Is(err, models.ErrInvalidResetToken) {
+		t.Errorf("loginRepository.ResetPassword() error = %v, want %v for an expired token", err, models.ErrInvalidResetToken)
+	}
+	addReset("first", now.Add(time.Hour))
+	addReset("second", now.Add(time.Hour))
+	if err := r.ResetPassword(ctx, "first", "new", now); err == nil {
+		t.Errorf("loginRepository.ResetPassword() accepted a token replaced by a newer one")
+	}
+	// looking the token up does not use it
+	for i := 0; i < 2; i++ {
+		if user, err := r.GetPasswordReset(ctx, "second", now); err != nil || user.Email != email || user.Name != "reset" {
+			t.Fatalf("loginRepository.GetPasswordReset() = %+v, %v, want the user of the token", user, err)
+		}
+	}
+	if err := r.ResetPassword(ctx, "second", "new", now); err != nil {
+		t.Fatalf("loginRepository.ResetPassword() error = %v", err)
+	}
+	if err := r.ResetPassword(ctx, "second", "newer", now); err == nil {
+		t.Errorf("loginRepository.ResetPassword() accepted a token twice")
+	}
+	if _, err := r.GetPasswordReset(ctx, "second", now); err == nil {
+		t.Errorf("loginRepository.GetPasswordReset() returned a used token")
+	}
+
+	user, err := r.GetUser(ctx, email)
+	if err != nil {
+		t.Fatalf("loginRepository.GetUser() error = %v", err)
+	}
+	if user.Password != "new" || user.SessionVersion != 1 || !user.Verified {
+		t.Errorf("loginRepository.ResetPassword() user = %+v, want the new password, session version 1 and verified", user)
+	}
+	if _, err := r.ValidateUser(ctx, email, 0, 0); err == nil {
+		t.Errorf("loginRepository.ValidateUser() accepted a session issued before the reset")
+	}
+}
@@ -0,0 +1,115 @@
+package repositories
+
+import (
+	"notes-server/db"
+	"notes-server/models"
+	"time"
+)
+
+// throttleKey - a key failed logins are counted under, with the policy they are throttled with
+type throttleKey struct {
+	key    string
+	policy models.LoginThrottlePolicy
+}
+
+// loginThrottleKeys - the failures of an attempt are counted for the account and for the client IP when known
+func loginThrottleKeys(email string, attempt models.LoginAttempt) []throttleKey {
+	keys := []throttleKey{{key: models.AccountThrottleKey(email), policy: attempt.Throttle.Account}}
+	if attempt.IP != "" {
+		keys = append(keys, throttleKey{key: models.ClientThrottleKey(attempt.IP), policy: attempt.Throttle.Client})
+	}
+	return keys
+}
+
+// checkLoginThrottle - returns the time before which the attempt is refused, zero when it is accepted
+func checkLoginThrottle(txn db.MemDbTxn, keys []throttleKey, now time.Time) (time.Time, error) {
+	var retryAt time.Time
+	for _, k := range keys {
+		failures, err := getLoginFailures(txn, k.key, now)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if now.Before(failures.RetryAt) && failures.RetryAt.After(retryAt) {
+			retryAt = failures.RetryAt
+		}
+	}
+	return retryAt, nil
+}
+
+// recordLoginFailure - counts a failure under each key. Returns the failures the attempt locked out and the time
+// before which the next attempt is refused.
+func recordLoginFailure(txn db.MemDbTxn, keys []throttleKey, now time.Time) ([]models.LoginFailures, time.Time, error) {
+	lockouts := make([]models.LoginFailures, 0)
+	var retryAt time.Time
+	for _, k := range keys {
+		failures, err := getLoginFailures(txn, k.key, now)
+		if err != nil {
+			return nil, time.Time{}, err
+		}
+		if failures.Failures == 0 {
+			if err = deleteExpiredLoginFailures(txn, now); err != nil {
+				return nil, time.Time{}, err
+			}
+		}
+		failures.Failures++
+		delay, lockedOut := k.policy.Delay(failures.Failures)
+		failures.LastFailureAt = now
+		failures.RetryAt = now.Add(delay)
+		failures.LockedOut = lockedOut
+		failures.ExpiresAt = now.Add(k.policy.Window)
+		if failures.RetryAt.After(failures.ExpiresAt) {
+			failures.ExpiresAt = failures.RetryAt
+		}
+		if err = txn.Insert("login_failures", &failures); err != nil {
+			return nil, time.Time{}, err
+		}
+		if lockedOut {
+			lockouts = append(lockouts, failures)
+		}
+		if failures.RetryAt.After(retryAt) {
+			retryAt = failures.RetryAt
+		}
+	}
+	return lockouts, retryAt, nil
+}
+
+// clearLoginFailures - forgets the failures counted under key, after a successful login
+func clearLoginFailures(txn db.MemDbTxn, key string) error {
+	row, err := txn.First("login_failures", "id", key)
+	if err != nil || row == nil {
+		return err
+	}
+	return txn.Delete("login_failures", row)
+}
+
+// getLoginFailures - the failures counted under key, none once they expired
+func getLoginFailures(txn db.MemDbTxn, key string, now time.Time) (models.LoginFailures, error) {
+	row, err := txn.First("login_failures", "id", key)
+	if err != nil {
+		return models.LoginFailures{}, err
+	}
+	failures, ok := row.(*models.LoginFailures)
+	if !ok || !now.Before(failures.ExpiresAt) {
+		return models.LoginFailures{Key: key}, nil
+	}
+	return *failures, nil
+}
+
+func deleteExpiredLoginFailures(txn db.MemDbTxn, now time.Time) error {
+	rows, err := txn.Get("login_failures", "id")
+	if err != nil {
+		return err
+	}
+	expired := make([]models.LoginFailures, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		if failures := obj.(*models.LoginFailures); !now.Before(failures.ExpiresAt) {
+			expired = append(expired, *failures)
+		}
+	}
+	for i := range expired {
+		if err = txn.Delete("login_failures", &expired[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
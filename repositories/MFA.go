@@ -0,0 +1,340 @@
+package repositories
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"notes-server/db"
+	"notes-server/models"
+	"notes-server/totp"
+	"strings"
+	"time"
+)
+
+// StartMFAEnrollment - records secret as the pending TOTP secret of the user. When two-factor authentication is
+// already enabled a valid code is required, the enrollment then replaces the current device once confirmed.
+// Wrong codes are throttled like wrong passwords, see Login.
+func (r *loginRepository) StartMFAEnrollment(ctx context.Context, email, code, secret string, attempt models.LoginAttempt) error {
+	r.logger.Info(ctx, "Entering loginRepository.StartMFAEnrollment()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.StartMFAEnrollment()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.StartMFAEnrollment(), error from getUser()", err)
+		return err
+	}
+	if user.MFA.Enabled {
+		refusal, err := throttleMFACode(txn, loginThrottleKeys(email, attempt), attempt.At, func() bool {
+			return useMFACode(&user, code, attempt.At)
+		})
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.StartMFAEnrollment(), error from throttleMFACode()", err)
+			return err
+		}
+		if refusal != nil {
+			txn.Commit()
+			return refusal
+		}
+	}
+	user.MFA.PendingSecret = secret
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.StartMFAEnrollment(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// ConfirmMFAEnrollment - enables two-factor authentication with the pending secret once code proves the
+// authenticator app has it, recoveryCodes replace the recovery codes of the user. Wrong codes are throttled like
+// wrong passwords.
+func (r *loginRepository) ConfirmMFAEnrollment(ctx context.Context, email, code string, recoveryCodes []string, attempt models.LoginAttempt) error {
+	r.logger.Info(ctx, "Entering loginRepository.ConfirmMFAEnrollment()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.ConfirmMFAEnrollment()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ConfirmMFAEnrollment(), error from getUser()", err)
+		return err
+	}
+	if user.MFA.PendingSecret == "" {
+		txn.Abort()
+		return models.ErrNoMFAEnrollment
+	}
+	var step int64
+	refusal, err := throttleMFACode(txn, loginThrottleKeys(email, attempt), attempt.At, func() bool {
+		var ok bool
+		step, ok = totp.Validate(user.MFA.PendingSecret, code, attempt.At, 0)
+		return ok
+	})
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ConfirmMFAEnrollment(), error from throttleMFACode()", err)
+		return err
+	}
+	if refusal != nil {
+		txn.Commit()
+		return refusal
+	}
+	user.MFA = models.MFA{
+		Enabled:       true,
+		Secret:        user.MFA.PendingSecret,
+		LastStep:      step,
+		RecoveryCodes: hashRecoveryCodes(recoveryCodes),
+	}
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ConfirmMFAEnrollment(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// UseMFACode - checks a TOTP or recovery code of the user, which can not be used again, given with challenge. The
+// challenge is consumed when the code is accepted. Returns the user. Wrong codes are throttled like wrong
+// passwords, see Login.
+func (r *loginRepository) UseMFACode(ctx context.Context, email, code string, challenge models.MFAChallenge, attempt models.LoginAttempt) (models.User, error) {
+	r.logger.Info(ctx, "Entering loginRepository.UseMFACode()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.UseMFACode()")
+	txn := r.db.Txn(ctx, true)
+	keys := loginThrottleKeys(email, attempt)
+	retryAt, err := checkLoginThrottle(txn, keys, attempt.At)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from checkLoginThrottle()", err)
+		return models.User{}, err
+	}
+	if !retryAt.IsZero() {
+		err = appendAudit(ctx, txn, loginAuditEntry(models.AuditLoginMFA, email, models.ErrTooManyLoginAttempts.Code))
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from appendAudit()", err)
+			return models.User{}, err
+		}
+		txn.Commit()
+		return models.User{}, &models.LoginThrottledError{RetryAt: retryAt}
+	}
+	user, err := getUser(txn, email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from getUser()", err)
+		return models.User{}, err
+	}
+	used, err := txn.First("mfa_challenges", "id", challenge.ID)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from txn.First()", err)
+		return models.User{}, err
+	}
+	if used != nil || !user.MFA.Enabled || !useMFACode(&user, code, attempt.At) {
+		lockouts, retryAt, err := recordLoginFailure(txn, keys, attempt.At)
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from recordLoginFailure()", err)
+			return models.User{}, err
+		}
+		err = appendAudit(ctx, txn, loginAuditEntry(models.AuditLoginMFA, email, models.ErrInvalidMFACode.Code))
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from appendAudit()", err)
+			return models.User{}, err
+		}
+		txn.Commit()
+		if len(lockouts) > 0 {
+			return models.User{}, &models.LoginThrottledError{RetryAt: retryAt, Lockouts: lockouts}
+		}
+		return models.User{}, models.ErrInvalidMFACode
+	}
+	err = clearLoginFailures(txn, models.AccountThrottleKey(email))
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from clearLoginFailures()", err)
+		return models.User{}, err
+	}
+	err = deleteExpiredMFAChallenges(txn, attempt.At)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from deleteExpiredMFAChallenges()", err)
+		return models.User{}, err
+	}
+	err = txn.Insert("mfa_challenges", &challenge)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from txn.Insert()", err)
+		return models.User{}, err
+	}
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from txn.Insert()", err)
+		return models.User{}, err
+	}
+	err = appendAudit(ctx, txn, userLoginAuditEntry(models.AuditLoginMFA, user, false))
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.UseMFACode(), error from appendAudit()", err)
+		return models.User{}, err
+	}
+	txn.Commit()
+	return user, nil
+}
+
+// ReplaceRecoveryCodes - replaces the recovery codes of the user once a valid code is given, wrong codes are
+// throttled like wrong passwords
+func (r *loginRepository) ReplaceRecoveryCodes(ctx context.Context, email, code string, recoveryCodes []string, attempt models.LoginAttempt) error {
+	r.logger.Info(ctx, "Entering loginRepository.ReplaceRecoveryCodes()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.ReplaceRecoveryCodes()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ReplaceRecoveryCodes(), error from getUser()", err)
+		return err
+	}
+	refusal, err := throttleMFACode(txn, loginThrottleKeys(email, attempt), attempt.At, func() bool {
+		return user.MFA.Enabled && useMFACode(&user, code, attempt.At)
+	})
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ReplaceRecoveryCodes(), error from throttleMFACode()", err)
+		return err
+	}
+	if refusal != nil {
+		txn.Commit()
+		return refusal
+	}
+	user.MFA.RecoveryCodes = hashRecoveryCodes(recoveryCodes)
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.ReplaceRecoveryCodes(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// DisableMFA - turns two-factor authentication off once both the password and a valid code are given, wrong
+// codes are throttled like wrong passwords
+func (r *loginRepository) DisableMFA(ctx context.Context, request models.DisableMFARequest, attempt models.LoginAttempt) error {
+	r.logger.Info(ctx, "Entering loginRepository.DisableMFA()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.DisableMFA()")
+	txn := r.db.Txn(ctx, true)
+	user, err := getUser(txn, request.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.DisableMFA(), error from getUser()", err)
+		return err
+	}
+	if user.Password != request.Password {
+		txn.Abort()
+		return models.ErrWrongPassword
+	}
+	refusal, err := throttleMFACode(txn, loginThrottleKeys(request.Email, attempt), attempt.At, func() bool {
+		return user.MFA.Enabled && useMFACode(&user, request.Code, attempt.At)
+	})
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.DisableMFA(), error from throttleMFACode()", err)
+		return err
+	}
+	if refusal != nil {
+		txn.Commit()
+		return refusal
+	}
+	user.MFA = models.MFA{}
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.DisableMFA(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// throttleMFACode - checks a code with valid unless the attempt is throttled under keys. A wrong code is counted
+// as a failed login in txn, which the caller commits when a refusal is returned: ErrInvalidMFACode, or a
+// LoginThrottledError once the failures lock the account or the client out. A valid code clears the failures of
+// the account.
+func throttleMFACode(txn db.MemDbTxn, keys []throttleKey, now time.Time, valid func() bool) (refusal error, err error) {
+	retryAt, err := checkLoginThrottle(txn, keys, now)
+	if err != nil {
+		return nil, err
+	}
+	if !retryAt.IsZero() {
+		return &models.LoginThrottledError{RetryAt: retryAt}, nil
+	}
+	if !valid() {
+		lockouts, retryAt, err := recordLoginFailure(txn, keys, now)
+		if err != nil {
+			return nil, err
+		}
+		if len(lockouts) > 0 {
+			return &models.LoginThrottledError{RetryAt: retryAt, Lockouts: lockouts}, nil
+		}
+		return models.ErrInvalidMFACode, nil
+	}
+	return nil, clearLoginFailures(txn, keys[0].key)
+}
+
+// useMFACode - checks code against the TOTP secret and then the recovery codes of user, and records that it was
+// used
+func useMFACode(user *models.User, code string, now time.Time) bool {
+	if step, ok := totp.Validate(user.MFA.Secret, code, now, user.MFA.LastStep); ok {
+		user.MFA.LastStep = step
+		return true
+	}
+	hash := hashRecoveryCode(code)
+	for i, recoveryCode := range user.MFA.RecoveryCodes {
+		if subtle.ConstantTimeCompare([]byte(recoveryCode), []byte(hash)) == 1 {
+			remaining := make([]string, 0, len(user.MFA.RecoveryCodes)-1)
+			remaining = append(remaining, user.MFA.RecoveryCodes[:i]...)
+			user.MFA.RecoveryCodes = append(remaining, user.MFA.RecoveryCodes[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func deleteExpiredMFAChallenges(txn db.MemDbTxn, now time.Time) error {
+	rows, err := txn.Get("mfa_challenges", "id")
+	if err != nil {
+		return err
+	}
+	expired := make([]models.MFAChallenge, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		if challenge := obj.(*models.MFAChallenge); !now.Before(challenge.ExpiresAt) {
+			expired = append(expired, *challenge)
+		}
+	}
+	for i := range expired {
+		if err = txn.Delete("mfa_challenges", &expired[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func hashRecoveryCodes(recoveryCodes []string) []string {
+	hashes := make([]string, 0, len(recoveryCodes))
+	for _, code := range recoveryCodes {
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return hashes
+}
+
+// hashRecoveryCode - recovery codes are compared without their case, spaces and dashes
+func hashRecoveryCode(code string) string {
+	normalized := strings.NewReplacer("-", "", " ", "").Replace(strings.ToLower(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,148 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/totp"
+	"testing"
+	"time"
+)
+
+func Test_loginRepository_MFA(t *testing.T) {
+	const email = "mfa@gmail.com"
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	r := NewLoginRepository(db.NewDB(), loggers.NewLogger())
+	if err := r.SignUp(ctx, models.SignUpRequest{Email: email, Name: "mfa", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	code := func(secret string, at time.Time) string {
+		t.Helper()
+		c, err := totp.Code(secret, totp.Step(at))
+		if err != nil {
+			t.Fatalf("totp.Code() error = %v", err)
+		}
+		return c
+	}
+	secret, _ := totp.NewSecret()
+	challenge := func(id string) models.MFAChallenge {
+		return models.MFAChallenge{ID: id, ExpiresAt: now.Add(time.Hour)}
+	}
+
+	if err := r.StartMFAEnrollment(ctx, email, "", secret, models.LoginAttempt{At: now}); err != nil {
+		t.Fatalf("loginRepository.StartMFAEnrollment() error = %v", err)
+	}
+	if _, err := r.UseMFACode(ctx, email, code(secret, now), challenge("first"), models.LoginAttempt{At: now}); !errors.Is(err, models.ErrInvalidMFACode) {
+		t.Errorf("loginRepository.UseMFACode() before the confirmation error = %v", err)
+	}
+	if err := r.ConfirmMFAEnrollment(ctx, email, "000000", []string{"aaaaa-aaaaa"}, models.LoginAttempt{At: now}); !errors.Is(err, models.ErrInvalidMFACode) {
+		t.Errorf("loginRepository.ConfirmMFAEnrollment() with a wrong code error = %v", err)
+	}
+	if err := r.ConfirmMFAEnrollment(ctx, email, code(secret, now), []string{"aaaaa-aaaaa", "bbbbb-bbbbb"}, models.LoginAttempt{At: now}); err != nil {
+		t.Fatalf("loginRepository.ConfirmMFAEnrollment() error = %v", err)
+	}
+	if response, err := r.Login(ctx, models.LoginRequest{Email: email, Password: "password"}, models.LoginAttempt{At: now}); err != nil || !response.MFAEnabled {
+		t.Errorf("loginRepository.Login() = %+v, %v, want MFAEnabled", response, err)
+	}
+
+	// every code is accepted once
+	if _, err := r.UseMFACode(ctx, email, code(secret, now), challenge("confirmation"), models.LoginAttempt{At: now}); !errors.Is(err, models.ErrInvalidMFACode) {
+		t.Errorf("loginRepository.UseMFACode() accepted the code of the confirmation again, error = %v", err)
+	}
+	later := now.Add(totp.Period * time.Second)
+	if _, err := r.UseMFACode(ctx, email, code(secret, later), challenge("second"), models.LoginAttempt{At: later}); err != nil {
+		t.Errorf("loginRepository.UseMFACode() error = %v", err)
+	}
+	if _, err := r.UseMFACode(ctx, email, "AAAAA AAAAA", challenge("recovery"), models.LoginAttempt{At: later}); err != nil {
+		t.Errorf("loginRepository.UseMFACode() with a recovery code error = %v", err)
+	}
+	// and every challenge is exchanged once
+	if _, err := r.UseMFACode(ctx, email, "bbbbb-bbbbb", challenge("second"), models.LoginAttempt{At: later}); !errors.Is(err, models.ErrInvalidMFACode) {
+		t.Errorf("loginRepository.UseMFACode() accepted a challenge twice, error = %v", err)
+	}
+	if _, err := r.UseMFACode(ctx, email, "aaaaa-aaaaa", challenge("recovery-again"), models.LoginAttempt{At: later}); !errors.Is(err, models.ErrInvalidMFACode) {
+		t.Errorf("loginRepository.UseMFACode() accepted a recovery code twice, error = %v", err)
+	}
+
+	// moving to a new device requires a code of the current one
+	newSecret, _ := totp.NewSecret()
+	if err := r.StartMFAEnrollment(ctx, email, "", newSecret, models.LoginAttempt{At: later}); !errors.Is(err, models.ErrInvalidMFACode) {
+		t.Errorf("loginRepository.StartMFAEnrollment() without a code error = %v", err)
+	}
+	if err := r.StartMFAEnrollment(ctx, email, "bbbbb-bbbbb", newSecret, models.LoginAttempt{At: later}); err != nil {
+		t.Fatalf("loginRepository.StartMFAEnrollment() error = %v", err)
+	}
+	if err := r.ConfirmMFAEnrollment(ctx, email, code(newSecret, later), []string{"ccccc-ccccc"}, models.LoginAttempt{At: later}); err != nil {
+		t.Fatalf("loginRepository.ConfirmMFAEnrollment() error = %v", err)
+	}
+	if err := r.ReplaceRecoveryCodes(ctx, email, "ccccc-ccccc", []string{"ddddd-ddddd"}, models.LoginAttempt{At: later}); err != nil {
+		t.Fatalf("loginRepository.ReplaceRecoveryCodes() error = %v", err)
+	}
+
+	disable := models.DisableMFARequest{Email: email, Password: "wrong", Code: "ddddd-ddddd"}
+	if err := r.DisableMFA(ctx, disable, models.LoginAttempt{At: later}); !errors.Is(err, models.ErrWrongPassword) {
+		t.Errorf("loginRepository.DisableMFA() with a wrong password error = %v", err)
+	}
+	disable.Password = "password"
+	if err := r.DisableMFA(ctx, disable, models.LoginAttempt{At: later}); err != nil {
+		t.Fatalf("loginRepository.DisableMFA() error = %v", err)
+	}
+	user, err := r.GetUser(ctx, email)
+	if err != nil || user.MFA.Enabled || user.MFA.Secret != "" || len(user.MFA.RecoveryCodes) != 0 {
+		t.Errorf("loginRepository.GetUser() = %+v, %v, want two-factor authentication disabled", user.MFA, err)
+	}
+}
+
+func Test_loginRepository_MFA_lockout(t *testing.T) {
+	const email = "mfa-lockout@gmail.com"
+	ctx := context.Background()
+	now := time.Unix(1700000000, 0)
+	r := NewLoginRepository(db.NewDB(), loggers.NewLogger())
+	if err := r.SignUp(ctx, models.SignUpRequest{Email: email, Name: "mfa", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	secret, _ := totp.NewSecret()
+	if err := r.StartMFAEnrollment(ctx, email, "", secret, models.LoginAttempt{At: now}); err != nil {
+		t.Fatalf("loginRepository.StartMFAEnrollment() error = %v", err)
+	}
+	code, _ := totp.Code(secret, totp.Step(now))
+	if err := r.ConfirmMFAEnrollment(ctx, email, code, []string{"aaaaa-aaaaa"}, models.LoginAttempt{At: now}); err != nil {
+		t.Fatalf("loginRepository.ConfirmMFAEnrollment() error = %v", err)
+	}
+	throttle := models.LoginThrottle{Account: models.LoginThrottlePolicy{LockoutAfter: 3, LockoutDuration: time.Hour, Window: time.Hour}}
+	attempt := models.LoginAttempt{At: now, Throttle: throttle}
+
+	// the wrong codes count together whatever the endpoint, the third one locks the account out
+	wrong := []func() error{
+		func() error { return r.StartMFAEnrollment(ctx, email, "000000", "new", attempt) },
+		func() error { return r.ReplaceRecoveryCodes(ctx, email, "000000", []string{"bbbbb-bbbbb"}, attempt) },
+		func() error {
+			return r.DisableMFA(ctx, models.DisableMFARequest{Email: email, Password: "password", Code: "000000"}, attempt)
+		},
+	}
+	for i, try := range wrong {
+		err := try()
+		if i < len(wrong)-1 && !errors.Is(err, models.ErrInvalidMFACode) {
+			t.Fatalf("wrong code %d error = %v, want %v", i+1, err, models.ErrInvalidMFACode)
+		}
+		var throttled *models.LoginThrottledError
+		if i == len(wrong)-1 && (!errors.As(err, &throttled) || len(throttled.Lockouts) != 1) {
+			t.Fatalf("wrong code %d error = %v, want the account locked out", i+1, err)
+		}
+	}
+	// the right code is refused too until the lockout ends
+	if err := r.DisableMFA(ctx, models.DisableMFARequest{Email: email, Password: "password", Code: "aaaaa-aaaaa"}, attempt); !errors.Is(err, models.ErrTooManyLoginAttempts) {
+		t.Errorf("loginRepository.DisableMFA() while locked out error = %v, want %v", err, models.ErrTooManyLoginAttempts)
+	}
+	user, err := r.GetUser(ctx, email)
+	if err != nil || !user.MFA.Enabled || len(user.MFA.RecoveryCodes) != 1 {
+		t.Errorf("loginRepository.GetUser() = %+v, %v, want two-factor authentication unchanged", user.MFA, err)
+	}
+	attempt.At = now.Add(2 * time.Hour)
+	if err := r.DisableMFA(ctx, models.DisableMFARequest{Email: email, Password: "password", Code: "aaaaa-aaaaa"}, attempt); err != nil {
+		t.Errorf("loginRepository.DisableMFA() after the lockout error = %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"crypto/cipher"
+	"encoding/json"
+	"errors"
+	"notes-server/db"
+	"notes-server/encryption"
+	"notes-server/models"
+	"strconv"
+	"sync"
+)
+
+// noteCipher - encrypts the body and items of the stored notes with the data key of their owner. A nil
+// noteCipher stores them in plaintext, but can still tell that a sealed note can not be read.
+type noteCipher struct {
+	keyring *encryption.Keyring
+	mu      sync.Mutex
+	// aeads - unwrapped data keys, by wrapped key
+	aeads map[string]cipher.AEAD
+}
+
+// sealedBody - what is encrypted in Note.SealedBody
+type sealedBody struct {
+	Note  string                 `json:"note,omitempty"`
+	Items []models.ChecklistItem `json:"items,omitempty"`
+}
+
+func newNoteCipher(keyring *encryption.Keyring) *noteCipher {
+	if keyring == nil {
+		return nil
+	}
+	return &noteCipher{keyring: keyring, aeads: make(map[string]cipher.AEAD)}
+}
+
+// seal - encrypts the body and items of note, which is about to be stored. The data key of the owner is
+// created in txn the first time it is needed.
+func (c *noteCipher) seal(txn db.MemDbTxn, note *models.Note) error {
+	if c == nil || (note.Note == "" && len(note.Items) == 0) {
+		return nil
+	}
+	aead, err := c.dataKey(txn, note.CreatedBy, true)
+	if err != nil {
+		return err
+	}
+	body, err := json.Marshal(sealedBody{Note: note.Note, Items: note.Items})
+	if err != nil {
+		return err
+	}
+	sealed, err := encryption.Seal(aead, body, noteAdditionalData(note))
+	if err != nil {
+		return err
+	}
+	note.SealedBody = sealed
+	note.Note = ""
+	note.Items = nil
+	return nil
+}
+
+// open - decrypts the body and items of a copy of a stored note
+func (c *noteCipher) open(txn db.MemDbTxn, note *models.Note) error {
+	if note.SealedBody == nil {
+		return nil
+	}
+	if c == nil {
+		return errors.New("note is encrypted at rest but no master key is configured")
+	}
+	aead, err := c.dataKey(txn, note.CreatedBy, false)
+	if err != nil {
+		return err
+	}
+	plaintext, err := encryption.Open(aead, note.SealedBody, noteAdditionalData(note))
+	if err != nil {
+		return err
+	}
+	var body sealedBody
+	if err = json.Unmarshal(plaintext, &body); err != nil {
+		return err
+	}
+	note.Note = body.Note
+	note.Items = body.Items
+	note.SealedBody = nil
+	return nil
+}
+
+// dataKey - returns the data key of the user, creating it when create is set and there is none yet
+func (c *noteCipher) dataKey(txn db.MemDbTxn, email string, create bool) (cipher.AEAD, error) {
+	row, err := txn.First("data_keys", "id", email)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, ok := row.(*models.DataKey)
+	if !ok {
+		if !create {
+			return nil, errors.New("no data key stored for the owner of the note")
+		}
+		return c.newDataKey(txn, email)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if aead, ok := c.aeads[string(dataKey.WrappedKey)]; ok {
+		return aead, nil
+	}
+	key, err := c.keyring.Unwrap(dataKey.MasterKeyId, dataKey.WrappedKey)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := encryption.NewAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	c.aeads[string(dataKey.WrappedKey)] = aead
+	return aead, nil
+}
+
+func (c *noteCipher) newDataKey(txn db.MemDbTxn, email string) (cipher.AEAD, error) {
+	key, wrapped, err := c.keyring.NewDataKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := encryption.NewAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	err = txn.Insert("data_keys", &models.DataKey{Email: email, MasterKeyId: c.keyring.CurrentKeyID(), WrappedKey: wrapped})
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.aeads[string(wrapped)] = aead
+	return aead, nil
+}
+
+// noteAdditionalData - binds a sealed body to its note, so that it can not be moved to another one
+func noteAdditionalData(note *models.Note) []byte {
+	return []byte(note.CreatedBy + "/" + strconv.Itoa(int(note.Id)))
+}
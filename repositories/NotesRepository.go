@@ -2,73 +2,762 @@ package repositories
 
 import (
 	"context"
+	"errors"
 	"notes-server/db"
+	"notes-server/encryption"
 	"notes-server/interfaces"
 	"notes-server/loggers"
 	"notes-server/models"
 	"notes-server/utils"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-memdb"
 )
 
 type notesRepository struct {
 	db     db.DB
 	logger *loggers.Logger
+	cipher *noteCipher
 }
 
-func NewNotesRepository(db db.DB, logger *loggers.Logger) interfaces.INotesRepository {
-	return &notesRepository{db: db, logger: logger}
+// NewNotesRepository - the bodies of the notes are encrypted at rest with keys wrapped by keyring, they are
+// stored in plaintext when keyring is nil
+func NewNotesRepository(db db.DB, logger *loggers.Logger, keyring *encryption.Keyring) interfaces.INotesRepository {
+	return &notesRepository{db: db, logger: logger, cipher: newNoteCipher(keyring)}
 }
 
-func (r *notesRepository) GetNotes(ctx context.Context, email string) ([]models.Note, error) {
+// GetNotes - retrieves the notes of the user in the workspace matching the request, pinned notes first. The notes are read
+// through the owner_state and owner_starred indexes, once for the pinned notes and once for the others.
+func (r *notesRepository) GetNotes(ctx context.Context, request models.GetNotesRequest) ([]models.Note, error) {
 	r.logger.Info(ctx, "Entering notesRepository.GetNotes()")
 	defer r.logger.Info(ctx, "Exiting notesRepository.GetNotes()")
 	notes := make([]models.Note, 0)
 	txn := r.db.Txn(ctx, false)
-	rows, err := txn.Get("notes", "created_by", email)
+	for _, pinned := range []bool{true, false} {
+		var rows memdb.ResultIterator
+		var err error
+		if request.Starred {
+			rows, err = txn.Get("notes", "owner_starred", request.Workspace, request.Email, true, request.Archived, pinned)
+		} else {
+			rows, err = txn.Get("notes", "owner_state", request.Workspace, request.Email, request.Archived, pinned)
+		}
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in notesRepository.GetNotes(), error from txn.Get()", err)
+			return []models.Note{}, err
+		}
+		for obj := rows.Next(); obj != nil; obj = rows.Next() {
+			note := *obj.(*models.Note)
+			if err = r.cipher.open(txn, &note); err != nil {
+				txn.Abort()
+				r.logger.Warn(ctx, "error in notesRepository.GetNotes(), error from cipher.open()", err)
+				return []models.Note{}, err
+			}
+			notes = append(notes, note)
+		}
+	}
+	txn.Commit()
+	return notes, nil
+}
+
+// StreamNotes - calls fn for every note of the user in the workspace without loading them all into memory
+func (r *notesRepository) StreamNotes(ctx context.Context, workspace int32, email string, fn func(models.Note) error) error {
+	r.logger.Info(ctx, "Entering notesRepository.StreamNotes()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.StreamNotes()")
+	txn := r.db.Txn(ctx, false)
+	rows, err := txn.Get("notes", "workspace_owner", workspace, email)
 	if err != nil {
 		txn.Abort()
-		r.logger.Warn(ctx, "error in notesRepository.GetNotes(), error from txn.Get()", err)
-		return []models.Note{}, err
+		r.logger.Warn(ctx, "error in notesRepository.StreamNotes(), error from txn.Get()", err)
+		return err
 	}
-	txn.Commit()
+	defer txn.Commit()
 	for obj := rows.Next(); obj != nil; obj = rows.Next() {
-		note := obj.(*models.Note)
-		notes = append(notes, models.Note{
-			Id:   note.Id,
-			Note: note.Note,
-		})
+		note := *obj.(*models.Note)
+		if err = r.cipher.open(txn, &note); err != nil {
+			r.logger.Warn(ctx, "error in notesRepository.StreamNotes(), error from cipher.open()", err)
+			return err
+		}
+		err = fn(note)
+		if err != nil {
+			r.logger.Warn(ctx, "error in notesRepository.StreamNotes(), error from fn()", err)
+			return err
+		}
 	}
-	return notes, nil
+	return nil
 }
 
 func (r *notesRepository) AddNote(ctx context.Context, request models.AddNoteRequest) (int32, error) {
 	r.logger.Info(ctx, "Entering notesRepository.AddNote()")
 	defer r.logger.Info(ctx, "Exiting notesRepository.AddNote()")
 	txn := r.db.Txn(ctx, true)
-	note := models.Note{
-		Note:      request.Note,
-		CreatedBy: request.Email,
-		Id:        utils.NewID(),
-	}
-	err := txn.Insert("notes", &note)
+	note := newNote(request)
+	err := insertNote(ctx, txn, r.cipher, &note)
 	if err != nil {
 		txn.Abort()
-		r.logger.Warn(ctx, "error in notesRepository.AddNote(), error from txn.Insert()", err)
+		r.logger.Warn(ctx, "error in notesRepository.AddNote(), error from insertNote()", err)
 		return 0, err
 	}
 	txn.Commit()
 	return note.Id, nil
 }
 
-func (r *notesRepository) DeleteNote(ctx context.Context, noteID int32) error {
+// AddNotes - adds all the notes in a single transaction, either all of them are added or none
+func (r *notesRepository) AddNotes(ctx context.Context, requests []models.AddNoteRequest) ([]int32, error) {
+	r.logger.Info(ctx, "Entering notesRepository.AddNotes()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.AddNotes()")
+	ids := make([]int32, 0, len(requests))
+	txn := r.db.Txn(ctx, true)
+	for _, request := range requests {
+		note := newNote(request)
+		err := insertNote(ctx, txn, r.cipher, &note)
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in notesRepository.AddNotes(), error from insertNote()", err)
+			return []int32{}, err
+		}
+		ids = append(ids, note.Id)
+	}
+	txn.Commit()
+	return ids, nil
+}
+
+// UpdateNote - changes the title and body of a note owned by the user. Renaming a note rewrites the links to it
+// in the other notes of the user, removing its title leaves them dangling.
+func (r *notesRepository) UpdateNote(ctx context.Context, request models.UpdateNoteRequest) error {
+	r.logger.Info(ctx, "Entering notesRepository.UpdateNote()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.UpdateNote()")
+	txn := r.db.Txn(ctx, true)
+	note, err := getOwnedNote(txn, request.Id, request.Workspace, request.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.UpdateNote(), error from getOwnedNote()", err)
+		return err
+	}
+	err = r.cipher.open(txn, &note)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.UpdateNote(), error from cipher.open()", err)
+		return err
+	}
+	before := note
+	oldTitle := note.Title
+	request.Apply(&note)
+	if note.IsEncrypted() && (note.Note != "" || note.Title != "" || note.Type != models.NoteTypeText) {
+		txn.Abort()
+		err = models.ErrInvalidEncryptedNote
+		r.logger.Warn(ctx, "error in notesRepository.UpdateNote(), invalid encrypted note", err)
+		return err
+	}
+	err = updateNoteLinks(ctx, txn, r.cipher, &note, oldTitle)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.UpdateNote(), error from updateNoteLinks()", err)
+		return err
+	}
+	err = resizeNote(txn, &note)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.UpdateNote(), error from resizeNote()", err)
+		return err
+	}
+	err = appendAudit(ctx, txn, noteAuditEntry(models.AuditNoteUpdated, note.Id, &before, &note))
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.UpdateNote(), error from appendAudit()", err)
+		return err
+	}
+	err = r.cipher.seal(txn, &note)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.UpdateNote(), error from cipher.seal()", err)
+		return err
+	}
+	err = txn.Insert("notes", &note)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.UpdateNote(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// DeleteNote - deletes a note owned by the user, the links to it in other notes are left dangling
+func (r *notesRepository) DeleteNote(ctx context.Context, workspace int32, email string, noteID int32) error {
 	r.logger.Info(ctx, "Entering notesRepository.DeleteNote()")
 	defer r.logger.Info(ctx, "Exiting notesRepository.DeleteNote()")
 	txn := r.db.Txn(ctx, true)
-	err := txn.Delete("notes", models.Note{Id: noteID})
+	note, err := getOwnedNote(txn, noteID, workspace, email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.DeleteNote(), error from getOwnedNote()", err)
+		return err
+	}
+	err = txn.Delete("notes", &note)
 	if err != nil {
 		txn.Abort()
 		r.logger.Warn(ctx, "error in notesRepository.DeleteNote(), error from txn.Delete()", err)
 		return err
 	}
+	err = deleteLinks(txn, noteID)
+	if err == nil {
+		err = unlinkTarget(txn, noteID)
+	}
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.DeleteNote(), error from updating links", err)
+		return err
+	}
+	err = addUsage(txn, email, -1, -int64(note.Size))
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.DeleteNote(), error from addUsage()", err)
+		return err
+	}
+	err = appendAudit(ctx, txn, noteAuditEntry(models.AuditNoteDeleted, noteID, &note, nil))
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.DeleteNote(), error from appendAudit()", err)
+		return err
+	}
 	txn.Commit()
 	return nil
 }
+
+// GetNote - retrieves a note owned by the user
+func (r *notesRepository) GetNote(ctx context.Context, workspace int32, email string, noteID int32) (models.Note, error) {
+	r.logger.Info(ctx, "Entering notesRepository.GetNote()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.GetNote()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	note, err := getOwnedNote(txn, noteID, workspace, email)
+	if err != nil {
+		r.logger.Warn(ctx, "error in notesRepository.GetNote(), error from getOwnedNote()", err)
+		return models.Note{}, err
+	}
+	err = r.cipher.open(txn, &note)
+	if err != nil {
+		r.logger.Warn(ctx, "error in notesRepository.GetNote(), error from cipher.open()", err)
+		return models.Note{}, err
+	}
+	return note, nil
+}
+
+// GetUsage - retrieves the number of notes of the user and the bytes they take
+func (r *notesRepository) GetUsage(ctx context.Context, email string) (models.Usage, error) {
+	r.logger.Info(ctx, "Entering notesRepository.GetUsage()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.GetUsage()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	usage, err := getUsage(txn, email)
+	if err != nil {
+		r.logger.Warn(ctx, "error in notesRepository.GetUsage(), error from getUsage()", err)
+		return models.Usage{}, err
+	}
+	return usage, nil
+}
+
+// GetNoteLinks - returns the notes a note of the user links to and the notes linking to it
+func (r *notesRepository) GetNoteLinks(ctx context.Context, workspace int32, email string, noteID int32) (models.NoteLinksResponse, error) {
+	r.logger.Info(ctx, "Entering notesRepository.GetNoteLinks()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.GetNoteLinks()")
+	response := models.NoteLinksResponse{Links: []models.LinkedNote{}, Backlinks: []models.LinkedNote{}}
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	if _, err := getOwnedNote(txn, noteID, workspace, email); err != nil {
+		r.logger.Warn(ctx, "error in notesRepository.GetNoteLinks(), error from getOwnedNote()", err)
+		return response, err
+	}
+	links, err := getLinks(txn, "source", noteID)
+	if err != nil {
+		r.logger.Warn(ctx, "error in notesRepository.GetNoteLinks(), error from getLinks()", err)
+		return response, err
+	}
+	sort.Slice(links, func(i, j int) bool { return links[i].Position < links[j].Position })
+	for _, link := range links {
+		response.Links = append(response.Links, models.LinkedNote{Id: link.TargetId, Title: link.Title, Dangling: link.TargetId == 0})
+	}
+	backlinks, err := getLinks(txn, "target", noteID)
+	if err != nil {
+		r.logger.Warn(ctx, "error in notesRepository.GetNoteLinks(), error from getLinks()", err)
+		return response, err
+	}
+	for _, link := range backlinks {
+		row, err := txn.First("notes", "id", link.SourceId)
+		if err != nil {
+			r.logger.Warn(ctx, "error in notesRepository.GetNoteLinks(), error from txn.First()", err)
+			return response, err
+		}
+		if source, ok := row.(*models.Note); ok {
+			response.Backlinks = append(response.Backlinks, models.LinkedNote{Id: source.Id, Title: source.Title})
+		}
+	}
+	sort.Slice(response.Backlinks, func(i, j int) bool {
+		return strings.ToLower(response.Backlinks[i].Title) < strings.ToLower(response.Backlinks[j].Title)
+	})
+	return response, nil
+}
+
+// SearchNotes - retrieves the notes of the user whose title, body or items contain the query, ignoring case.
+// The body of encrypted notes can not be read by the server, so they are never matched.
+func (r *notesRepository) SearchNotes(ctx context.Context, request models.SearchNotesRequest) ([]models.Note, error) {
+	r.logger.Info(ctx, "Entering notesRepository.SearchNotes()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.SearchNotes()")
+	notes := make([]models.Note, 0)
+	query := strings.ToLower(request.Query)
+	txn := r.db.Txn(ctx, false)
+	rows, err := txn.Get("notes", "workspace_owner", request.Workspace, request.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.SearchNotes(), error from txn.Get()", err)
+		return []models.Note{}, err
+	}
+	defer txn.Commit()
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		note := *obj.(*models.Note)
+		if note.IsEncrypted() {
+			continue
+		}
+		if err = r.cipher.open(txn, &note); err != nil {
+			r.logger.Warn(ctx, "error in notesRepository.SearchNotes(), error from cipher.open()", err)
+			return []models.Note{}, err
+		}
+		if noteMatches(&note, query) {
+			notes = append(notes, note)
+		}
+	}
+	return notes, nil
+}
+
+// SetNoteFlag - sets the pinned, archived or starred flag of a note owned by the user
+func (r *notesRepository) SetNoteFlag(ctx context.Context, flag string, request models.SetNoteFlagRequest) error {
+	r.logger.Info(ctx, "Entering notesRepository.SetNoteFlag()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.SetNoteFlag()")
+	return r.updateOwnedNote(ctx, "SetNoteFlag", request.Id, request.Workspace, request.Email, func(note *models.Note) error {
+		var value *bool
+		switch flag {
+		case models.NoteFlagPinned:
+			value = &note.Pinned
+		case models.NoteFlagArchived:
+			value = &note.Archived
+		case models.NoteFlagStarred:
+			value = &note.Starred
+		default:
+			return errors.New("unknown note flag " + flag)
+		}
+		if request.Value != nil {
+			*value = *request.Value
+		} else {
+			*value = !*value
+		}
+		return nil
+	})
+}
+
+// SetNoteColor - sets or removes the color label of a note owned by the user
+func (r *notesRepository) SetNoteColor(ctx context.Context, request models.SetNoteColorRequest) error {
+	r.logger.Info(ctx, "Entering notesRepository.SetNoteColor()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.SetNoteColor()")
+	return r.updateOwnedNote(ctx, "SetNoteColor", request.Id, request.Workspace, request.Email, func(note *models.Note) error {
+		note.Color = request.Color
+		return nil
+	})
+}
+
+// SetReminder - sets the due date, reminder time and recurrence of a note owned by the user
+func (r *notesRepository) SetReminder(ctx context.Context, request models.SetReminderRequest) error {
+	r.logger.Info(ctx, "Entering notesRepository.SetReminder()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.SetReminder()")
+	return r.updateOwnedNote(ctx, "SetReminder", request.Id, request.Workspace, request.Email, func(note *models.Note) error {
+		note.DueAt = request.DueAt
+		note.RemindAt = request.RemindAt
+		note.Recurrence = request.Recurrence
+		note.SnoozedUntil = nil
+		note.RemindersSent = 0
+		return nil
+	})
+}
+
+// SnoozeReminder - fires a reminder for the note again at until, without moving the reminders that follow
+func (r *notesRepository) SnoozeReminder(ctx context.Context, workspace int32, email string, noteID int32, until time.Time) error {
+	r.logger.Info(ctx, "Entering notesRepository.SnoozeReminder()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.SnoozeReminder()")
+	return r.updateOwnedNote(ctx, "SnoozeReminder", noteID, workspace, email, func(note *models.Note) error {
+		note.SnoozedUntil = &until
+		return nil
+	})
+}
+
+// GetDueReminders - returns the notes of all the users whose next reminder is at or before now, their body is
+// left sealed
+func (r *notesRepository) GetDueReminders(ctx context.Context, now time.Time) ([]models.Note, error) {
+	r.logger.Debug(ctx, "Entering notesRepository.GetDueReminders()")
+	defer r.logger.Debug(ctx, "Exiting notesRepository.GetDueReminders()")
+	notes := make([]models.Note, 0)
+	txn := r.db.Txn(ctx, false)
+	rows, err := txn.Get("notes", "reminder", true)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.GetDueReminders(), error from txn.Get()", err)
+		return []models.Note{}, err
+	}
+	defer txn.Commit()
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		note := *obj.(*models.Note)
+		if remindAt := note.NextReminderAt(); remindAt == nil || remindAt.After(now) {
+			continue
+		}
+		notes = append(notes, note)
+	}
+	return notes, nil
+}
+
+// UpdateReminder - stores the reminder fields of note once the reminder due at firedAt has been fired.
+// Nothing is changed if the reminder of the note was modified in the meantime.
+func (r *notesRepository) UpdateReminder(ctx context.Context, firedAt time.Time, note models.Note) error {
+	r.logger.Info(ctx, "Entering notesRepository.UpdateReminder()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.UpdateReminder()")
+	txn := r.db.Txn(ctx, true)
+	row, err := txn.First("notes", "id", note.Id)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.UpdateReminder(), error from txn.First()", err)
+		return err
+	}
+	existing, ok := row.(*models.Note)
+	if !ok || existing.NextReminderAt() == nil || !existing.NextReminderAt().Equal(firedAt) {
+		txn.Abort()
+		return nil
+	}
+	updated := *existing
+	updated.DueAt = note.DueAt
+	updated.RemindAt = note.RemindAt
+	updated.SnoozedUntil = note.SnoozedUntil
+	updated.RemindersSent = note.RemindersSent
+	err = txn.Insert("notes", &updated)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository.UpdateReminder(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// AddChecklistItem - inserts an item into a checklist note at the requested position
+func (r *notesRepository) AddChecklistItem(ctx context.Context, request models.AddChecklistItemRequest) (int32, error) {
+	r.logger.Info(ctx, "Entering notesRepository.AddChecklistItem()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.AddChecklistItem()")
+	item := models.ChecklistItem{Id: utils.NewID(), Text: request.Text}
+	err := r.updateOwnedChecklist(ctx, "AddChecklistItem", request.Id, request.Workspace, request.Email, func(items []models.ChecklistItem) ([]models.ChecklistItem, error) {
+		position := len(items)
+		if request.Position != nil && *request.Position < len(items) {
+			position = *request.Position
+		}
+		items = append(items, models.ChecklistItem{})
+		copy(items[position+1:], items[position:])
+		items[position] = item
+		return items, nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return item.Id, nil
+}
+
+// ReorderChecklistItems - orders the items of a checklist note as itemIDs, which must list every item exactly once
+func (r *notesRepository) ReorderChecklistItems(ctx context.Context, request models.ReorderChecklistItemsRequest) error {
+	r.logger.Info(ctx, "Entering notesRepository.ReorderChecklistItems()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.ReorderChecklistItems()")
+	return r.updateOwnedChecklist(ctx, "ReorderChecklistItems", request.Id, request.Workspace, request.Email, func(items []models.ChecklistItem) ([]models.ChecklistItem, error) {
+		if len(request.ItemIds) != len(items) {
+			return nil, models.ErrInvalidItemOrder
+		}
+		byID := make(map[int32]models.ChecklistItem, len(items))
+		for _, item := range items {
+			byID[item.Id] = item
+		}
+		for i, itemID := range request.ItemIds {
+			item, ok := byID[itemID]
+			if !ok {
+				return nil, models.ErrInvalidItemOrder
+			}
+			delete(byID, itemID)
+			items[i] = item
+		}
+		return items, nil
+	})
+}
+
+// ToggleChecklistItem - sets the done flag of an item, or flips it when no state is requested
+func (r *notesRepository) ToggleChecklistItem(ctx context.Context, request models.ToggleChecklistItemRequest) error {
+	r.logger.Info(ctx, "Entering notesRepository.ToggleChecklistItem()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.ToggleChecklistItem()")
+	return r.updateOwnedChecklist(ctx, "ToggleChecklistItem", request.Id, request.Workspace, request.Email, func(items []models.ChecklistItem) ([]models.ChecklistItem, error) {
+		i := checklistItemIndex(items, request.ItemId)
+		if i < 0 {
+			return nil, models.ErrChecklistItemNotFound
+		}
+		if request.Done != nil {
+			items[i].Done = *request.Done
+		} else {
+			items[i].Done = !items[i].Done
+		}
+		return items, nil
+	})
+}
+
+// RemoveChecklistItem - removes an item from a checklist note
+func (r *notesRepository) RemoveChecklistItem(ctx context.Context, request models.RemoveChecklistItemRequest) error {
+	r.logger.Info(ctx, "Entering notesRepository.RemoveChecklistItem()")
+	defer r.logger.Info(ctx, "Exiting notesRepository.RemoveChecklistItem()")
+	return r.updateOwnedChecklist(ctx, "RemoveChecklistItem", request.Id, request.Workspace, request.Email, func(items []models.ChecklistItem) ([]models.ChecklistItem, error) {
+		i := checklistItemIndex(items, request.ItemId)
+		if i < 0 {
+			return nil, models.ErrChecklistItemNotFound
+		}
+		return append(items[:i], items[i+1:]...), nil
+	})
+}
+
+// updateOwnedNote - applies update to a copy of a note owned by the user and stores it, all in one transaction
+func (r *notesRepository) updateOwnedNote(ctx context.Context, method string, noteID, workspace int32, email string, update func(*models.Note) error) error {
+	txn := r.db.Txn(ctx, true)
+	note, err := getOwnedNote(txn, noteID, workspace, email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository."+method+"(), error from getOwnedNote()", err)
+		return err
+	}
+	err = r.cipher.open(txn, &note)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository."+method+"(), error from cipher.open()", err)
+		return err
+	}
+	before := note
+	err = update(&note)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository."+method+"(), error from update()", err)
+		return err
+	}
+	err = resizeNote(txn, &note)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository."+method+"(), error from resizeNote()", err)
+		return err
+	}
+	err = appendAudit(ctx, txn, noteAuditEntry(models.AuditNoteUpdated, note.Id, &before, &note))
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository."+method+"(), error from appendAudit()", err)
+		return err
+	}
+	err = r.cipher.seal(txn, &note)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository."+method+"(), error from cipher.seal()", err)
+		return err
+	}
+	err = txn.Insert("notes", &note)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notesRepository."+method+"(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// updateOwnedChecklist - like updateOwnedNote for the items of a checklist note, update gets a copy of the items it can modify
+func (r *notesRepository) updateOwnedChecklist(ctx context.Context, method string, noteID, workspace int32, email string, update func([]models.ChecklistItem) ([]models.ChecklistItem, error)) error {
+	return r.updateOwnedNote(ctx, method, noteID, workspace, email, func(note *models.Note) error {
+		if note.Type != models.NoteTypeChecklist {
+			return models.ErrNotChecklist
+		}
+		items, err := update(append([]models.ChecklistItem{}, note.Items...))
+		if err != nil {
+			return err
+		}
+		note.Items = items
+		return nil
+	})
+}
+
+func noteMatches(note *models.Note, query string) bool {
+	if strings.Contains(strings.ToLower(note.Title), query) || strings.Contains(strings.ToLower(note.Note), query) {
+		return true
+	}
+	for _, item := range note.Items {
+		if strings.Contains(strings.ToLower(item.Text), query) {
+			return true
+		}
+	}
+	return false
+}
+
+func checklistItemIndex(items []models.ChecklistItem, itemID int32) int {
+	for i, item := range items {
+		if item.Id == itemID {
+			return i
+		}
+	}
+	return -1
+}
+
+func newNote(request models.AddNoteRequest) models.Note {
+	note := models.Note{
+		Type:       request.Type,
+		Title:      request.Title,
+		Pinned:     request.Pinned,
+		Archived:   request.Archived,
+		Starred:    request.Starred,
+		Color:      request.Color,
+		Encryption: request.Encryption,
+		Note:       request.Note,
+		CreatedBy:  request.Email,
+		Workspace:  request.Workspace,
+		Id:         utils.NewID(),
+		DueAt:      request.DueAt,
+		RemindAt:   request.RemindAt,
+		Recurrence: request.Recurrence,
+	}
+	if note.Type == "" {
+		note.Type = models.NoteTypeText
+	}
+	if request.Ciphertext != nil {
+		note.Ciphertext = append([]byte{}, request.Ciphertext...)
+	}
+	if note.Type == models.NoteTypeChecklist {
+		note.Items = make([]models.ChecklistItem, 0, len(request.Items))
+		for _, item := range request.Items {
+			note.Items = append(note.Items, models.ChecklistItem{Id: utils.NewID(), Text: item.Text, Done: item.Done})
+		}
+	}
+	return note
+}
+
+// insertNote - stores a new note along with its links, resolving the dangling links to its title, and writes
+// its creation to the audit log. The body of note is sealed on the stored copy only.
+func insertNote(ctx context.Context, txn db.MemDbTxn, c *noteCipher, note *models.Note) error {
+	if err := claimTitle(txn, note); err != nil {
+		return err
+	}
+	note.Size = note.ContentSize()
+	if err := addUsage(txn, note.CreatedBy, 1, int64(note.Size)); err != nil {
+		return err
+	}
+	stored := *note
+	if err := c.seal(txn, &stored); err != nil {
+		return err
+	}
+	if err := txn.Insert("notes", &stored); err != nil {
+		return err
+	}
+	if err := addLinks(txn, note); err != nil {
+		return err
+	}
+	return appendAudit(ctx, txn, noteAuditEntry(models.AuditNoteCreated, note.Id, nil, note))
+}
+
+// deleteNotes - deletes notes, whose links are already deleted, and writes the deletion of each to the audit log
+func deleteNotes(ctx context.Context, txn db.MemDbTxn, notes []models.Note) error {
+	for i := range notes {
+		if err := txn.Delete("notes", &notes[i]); err != nil {
+			return err
+		}
+		if err := appendAudit(ctx, txn, noteAuditEntry(models.AuditNoteDeleted, notes[i].Id, &notes[i], nil)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// noteAuditEntry - the entry of a change to a note, before is nil for a creation and after for a deletion
+func noteAuditEntry(action string, noteID int32, before, after *models.Note) models.AuditEntry {
+	entry := models.AuditEntry{Action: action, Target: models.AuditTarget("note", noteID)}
+	if before != nil {
+		entry.Before = before.AuditSummary()
+	}
+	if after != nil {
+		entry.After = after.AuditSummary()
+	}
+	return entry
+}
+
+// resizeNote - updates the size of a modified note, which is not sealed yet, and the usage of its owner
+func resizeNote(txn db.MemDbTxn, note *models.Note) error {
+	size := note.ContentSize()
+	if size == note.Size {
+		return nil
+	}
+	if err := addUsage(txn, note.CreatedBy, 0, int64(size-note.Size)); err != nil {
+		return err
+	}
+	note.Size = size
+	return nil
+}
+
+func getUsage(txn db.MemDbTxn, email string) (models.Usage, error) {
+	row, err := txn.First("usage", "id", email)
+	if err != nil {
+		return models.Usage{}, err
+	}
+	if usage, ok := row.(*models.Usage); ok {
+		return *usage, nil
+	}
+	return models.Usage{Email: email}, nil
+}
+
+// addUsage - adds to the number of notes and bytes used by the user
+func addUsage(txn db.MemDbTxn, email string, notes int, bytes int64) error {
+	usage, err := getUsage(txn, email)
+	if err != nil {
+		return err
+	}
+	usage.Notes += notes
+	usage.Bytes += bytes
+	return txn.Insert("usage", &usage)
+}
+
+// updateNoteLinks - keeps the links consistent with the new title and body of note, which is not stored yet
+func updateNoteLinks(ctx context.Context, txn db.MemDbTxn, c *noteCipher, note *models.Note, oldTitle string) error {
+	if linkKey(note.Title) != linkKey(oldTitle) {
+		if oldTitle != "" {
+			var err error
+			if note.Title != "" {
+				err = renameLinks(ctx, txn, c, note, oldTitle)
+			} else {
+				err = unlinkTarget(txn, note.Id)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		if err := claimTitle(txn, note); err != nil {
+			return err
+		}
+	}
+	if err := deleteLinks(txn, note.Id); err != nil {
+		return err
+	}
+	return addLinks(txn, note)
+}
+
+// getOwnedNote - returns a copy of the note that can be modified and inserted back, notes of other users or of
+// other workspaces are reported as not found
+func getOwnedNote(txn db.MemDbTxn, noteID, workspace int32, email string) (models.Note, error) {
+	row, err := txn.First("notes", "id", noteID)
+	if err != nil {
+		return models.Note{}, err
+	}
+	note, ok := row.(*models.Note)
+	if !ok || note.CreatedBy != email || note.Workspace != workspace {
+		return models.Note{}, models.ErrNoteNotFound
+	}
+	return *note, nil
+}
@@ -8,14 +8,15 @@ import (
 	"notes-server/models"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
 
 func Test_notesRepository_GetNotes(t *testing.T) {
 	type args struct {
-		ctx   context.Context
-		email string
+		ctx     context.Context
+		request models.GetNotesRequest
 	}
 	tests := []struct {
 		name    string
@@ -34,13 +35,13 @@ func Test_notesRepository_GetNotes(t *testing.T) {
 						Note: "test note",
 					},
 				}
-				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything).Return(&t, nil)
+				mockTxn.EXPECT().Get("notes", "owner_state", int32(0), "test@gmail.com", false, mock.Anything).Return(&t, nil)
 				mockTxn.EXPECT().Commit()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
 			args: args{
-				ctx:   context.Background(),
-				email: "test@gmail.com",
+				ctx:     context.Background(),
+				request: models.GetNotesRequest{Email: "test@gmail.com"},
 			},
 			want: []models.Note{{
 				Id:   123,
@@ -52,13 +53,13 @@ func Test_notesRepository_GetNotes(t *testing.T) {
 			name: "failure case - error in txn.Get()",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
-				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db errpr"))
+				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db errpr"))
 				mockTxn.EXPECT().Abort()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
 			args: args{
-				ctx:   context.Background(),
-				email: "test@gmail.com",
+				ctx:     context.Background(),
+				request: models.GetNotesRequest{Email: "test@gmail.com"},
 			},
 			want:    []models.Note{},
 			wantErr: true,
@@ -72,7 +73,7 @@ func Test_notesRepository_GetNotes(t *testing.T) {
 				db:     &mockDb,
 				logger: loggers.NewLogger(),
 			}
-			got, err := r.GetNotes(tt.args.ctx, tt.args.email)
+			got, err := r.GetNotes(tt.args.ctx, tt.args.request)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("notesRepository.GetNotes() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -100,7 +101,10 @@ func Test_notesRepository_AddNote(t *testing.T) {
 			name: "success case",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("audit_log", "id", mock.Anything).Return(nil, nil)
+				mockTxn.EXPECT().First("usage", "id", "test@gmail.com").Return(nil, nil)
 				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(nil)
+				mockTxn.EXPECT().Insert("audit_log", mock.Anything).Return(nil)
 				mockTxn.EXPECT().Commit()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
@@ -118,6 +122,7 @@ func Test_notesRepository_AddNote(t *testing.T) {
 			name: "failure case - error in txn.Insert()",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("usage", "id", "test@gmail.com").Return(nil, nil)
 				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(errors.New("db error"))
 				mockTxn.EXPECT().Abort()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
@@ -153,6 +158,7 @@ func Test_notesRepository_AddNote(t *testing.T) {
 func Test_notesRepository_DeleteNote(t *testing.T) {
 	type args struct {
 		ctx     context.Context
+		email   string
 		request int32
 	}
 	tests := []struct {
@@ -165,26 +171,50 @@ func Test_notesRepository_DeleteNote(t *testing.T) {
 			name: "success case",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("audit_log", "id", mock.Anything).Return(nil, nil)
+				mockTxn.EXPECT().First("notes", "id", int32(123)).Return(&models.Note{Id: 123, CreatedBy: "test@gmail.com"}, nil)
 				mockTxn.EXPECT().Delete(mock.Anything, mock.Anything).Return(nil)
+				mockTxn.EXPECT().Get("links", mock.Anything, int32(123)).Return(&mockResultIterator{}, nil)
+				mockTxn.EXPECT().First("usage", "id", "test@gmail.com").Return(nil, nil)
+				mockTxn.EXPECT().Insert("usage", &models.Usage{Email: "test@gmail.com", Notes: -1}).Return(nil)
+				mockTxn.EXPECT().Insert("audit_log", mock.Anything).Return(nil)
 				mockTxn.EXPECT().Commit()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
 			args: args{
 				ctx:     context.Background(),
+				email:   "test@gmail.com",
 				request: 123,
 			},
 			wantErr: false,
 		},
+		{
+			name: "failure case - note of another user",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("notes", "id", int32(123)).Return(&models.Note{Id: 123, CreatedBy: "other@gmail.com"}, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx:     context.Background(),
+				email:   "test@gmail.com",
+				request: 123,
+			},
+			wantErr: true,
+		},
 		{
 			name: "failure case - error in txn.Delete()",
 			given: func(dab *db.MockDB) {
 				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("notes", "id", int32(123)).Return(&models.Note{Id: 123, CreatedBy: "test@gmail.com"}, nil)
 				mockTxn.EXPECT().Delete(mock.Anything, mock.Anything).Return(errors.New("db error"))
 				mockTxn.EXPECT().Abort()
 				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
 			},
 			args: args{
 				ctx:     context.Background(),
+				email:   "test@gmail.com",
 				request: 123,
 			},
 			wantErr: true,
@@ -198,7 +228,7 @@ func Test_notesRepository_DeleteNote(t *testing.T) {
 				db:     &mockDb,
 				logger: loggers.NewLogger(),
 			}
-			err := r.DeleteNote(tt.args.ctx, tt.args.request)
+			err := r.DeleteNote(tt.args.ctx, 0, tt.args.email, tt.args.request)
 			if (err != nil) != tt.wantErr {
 				t.Errorf("notesRepository.DeleteNote() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -207,6 +237,586 @@ func Test_notesRepository_DeleteNote(t *testing.T) {
 	}
 }
 
+func Test_notesRepository_StreamNotes(t *testing.T) {
+	type args struct {
+		ctx   context.Context
+		email string
+		fn    func(models.Note) error
+	}
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				t := mockResultIterator{
+					NextResp: &models.Note{
+						Id:   123,
+						Note: "test note",
+					},
+				}
+				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&t, nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx:   context.Background(),
+				email: "test@gmail.com",
+				fn: func(note models.Note) error {
+					return nil
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in txn.Get()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx:   context.Background(),
+				email: "test@gmail.com",
+				fn: func(note models.Note) error {
+					return nil
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "failure case - error in fn()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				t := mockResultIterator{
+					NextResp: &models.Note{
+						Id:   123,
+						Note: "test note",
+					},
+				}
+				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(&t, nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx:   context.Background(),
+				email: "test@gmail.com",
+				fn: func(note models.Note) error {
+					return errors.New("write error")
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &notesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			err := r.StreamNotes(tt.args.ctx, 0, tt.args.email, tt.args.fn)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesRepository.StreamNotes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_notesRepository_AddNotes(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		requests []models.AddNoteRequest
+	}
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		args    args
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("audit_log", "id", mock.Anything).Return(nil, nil)
+				mockTxn.EXPECT().First("usage", "id", "test@gmail.com").Return(nil, nil)
+				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(nil)
+				mockTxn.EXPECT().Insert("audit_log", mock.Anything).Return(nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx: context.Background(),
+				requests: []models.AddNoteRequest{
+					{Email: "test@gmail.com", Note: "first note"},
+					{Email: "test@gmail.com", Note: "second note"},
+				},
+			},
+			wantLen: 2,
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in txn.Insert()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("usage", "id", "test@gmail.com").Return(nil, nil)
+				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx: context.Background(),
+				requests: []models.AddNoteRequest{
+					{Email: "test@gmail.com", Note: "first note"},
+				},
+			},
+			wantLen: 0,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &notesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			got, err := r.AddNotes(tt.args.ctx, tt.args.requests)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesRepository.AddNotes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("notesRepository.AddNotes() returned %d ids, want %d", len(got), tt.wantLen)
+			}
+		})
+	}
+}
+
+func Test_notesRepository_SetReminder(t *testing.T) {
+	remindAt := time.Now().Add(time.Hour)
+	type args struct {
+		ctx     context.Context
+		request models.SetReminderRequest
+	}
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("audit_log", "id", mock.Anything).Return(nil, nil)
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.Note{
+					Id:        123,
+					Note:      "test note",
+					Size:      9,
+					CreatedBy: "test@gmail.com",
+				}, nil)
+				mockTxn.EXPECT().Insert("notes", &models.Note{
+					Id:         123,
+					Note:       "test note",
+					Size:       9,
+					CreatedBy:  "test@gmail.com",
+					RemindAt:   &remindAt,
+					Recurrence: "daily",
+				}).Return(nil)
+				mockTxn.EXPECT().Insert("audit_log", mock.Anything).Return(nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetReminderRequest{
+					Email:      "test@gmail.com",
+					Id:         123,
+					RemindAt:   &remindAt,
+					Recurrence: "daily",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - note of another user",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.Note{
+					Id:        123,
+					Note:      "test note",
+					CreatedBy: "other@gmail.com",
+				}, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetReminderRequest{
+					Email:    "test@gmail.com",
+					Id:       123,
+					RemindAt: &remindAt,
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "failure case - error in txn.Insert()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.Note{
+					Id:        123,
+					Note:      "test note",
+					CreatedBy: "test@gmail.com",
+				}, nil)
+				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetReminderRequest{
+					Email:    "test@gmail.com",
+					Id:       123,
+					RemindAt: &remindAt,
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &notesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			err := r.SetReminder(tt.args.ctx, tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesRepository.SetReminder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_notesRepository_SnoozeReminder(t *testing.T) {
+	until := time.Now().Add(10 * time.Minute)
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("audit_log", "id", mock.Anything).Return(nil, nil)
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.Note{
+					Id:        123,
+					CreatedBy: "test@gmail.com",
+				}, nil)
+				mockTxn.EXPECT().Insert("notes", &models.Note{
+					Id:           123,
+					CreatedBy:    "test@gmail.com",
+					SnoozedUntil: &until,
+				}).Return(nil)
+				mockTxn.EXPECT().Insert("audit_log", mock.Anything).Return(nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - note not found",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(nil, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &notesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			err := r.SnoozeReminder(context.Background(), 0, "test@gmail.com", 123, until)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesRepository.SnoozeReminder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_notesRepository_GetDueReminders(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Minute)
+	future := now.Add(time.Minute)
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		want    []models.Note
+		wantErr bool
+	}{
+		{
+			name: "success case - due reminder",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				t := mockResultIterator{
+					NextResp: &models.Note{Id: 123, RemindAt: &past},
+				}
+				mockTxn.EXPECT().Get("notes", "reminder", true).Return(&t, nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			want:    []models.Note{{Id: 123, RemindAt: &past}},
+			wantErr: false,
+		},
+		{
+			name: "success case - reminder not due yet",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				t := mockResultIterator{
+					NextResp: &models.Note{Id: 123, RemindAt: &future},
+				}
+				mockTxn.EXPECT().Get("notes", "reminder", true).Return(&t, nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			want:    []models.Note{},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in txn.Get()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			want:    []models.Note{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &notesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			got, err := r.GetDueReminders(context.Background(), now)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesRepository.GetDueReminders() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("notesRepository.GetDueReminders() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_notesRepository_UpdateReminder(t *testing.T) {
+	firedAt := time.Now()
+	changed := firedAt.Add(time.Hour)
+	next := firedAt.AddDate(0, 0, 1)
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.Note{
+					Id: 123, RemindAt: &firedAt, Recurrence: "daily",
+				}, nil)
+				mockTxn.EXPECT().Insert("notes", &models.Note{
+					Id: 123, RemindAt: &next, Recurrence: "daily", RemindersSent: 1,
+				}).Return(nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: false,
+		},
+		{
+			name: "success case - reminder changed in the meantime",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(&models.Note{
+					Id: 123, RemindAt: &changed,
+				}, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in txn.First()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &notesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			err := r.UpdateReminder(context.Background(), firedAt, models.Note{Id: 123, RemindAt: &next, RemindersSent: 1})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesRepository.UpdateReminder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_notesRepository_ChecklistItems(t *testing.T) {
+	checklist := func(items ...models.ChecklistItem) *models.Note {
+		note := &models.Note{Id: 123, Type: models.NoteTypeChecklist, CreatedBy: "test@gmail.com", Items: items}
+		note.Size = note.ContentSize()
+		return note
+	}
+	milk := models.ChecklistItem{Id: 1, Text: "milk"}
+	eggs := models.ChecklistItem{Id: 2, Text: "eggs"}
+	doneEggs := models.ChecklistItem{Id: 2, Text: "eggs", Done: true}
+	position := 0
+	tests := []struct {
+		name       string
+		stored     *models.Note
+		wantInsert *models.Note
+		call       func(*notesRepository) error
+		wantErr    bool
+	}{
+		{
+			name:   "success case - add item at a position",
+			stored: checklist(milk),
+			call: func(r *notesRepository) error {
+				_, err := r.AddChecklistItem(context.Background(), models.AddChecklistItemRequest{Email: "test@gmail.com", Id: 123, Text: "eggs", Position: &position})
+				return err
+			},
+			wantErr: false,
+		},
+		{
+			name:       "success case - reorder items",
+			stored:     checklist(milk, eggs),
+			wantInsert: checklist(eggs, milk),
+			call: func(r *notesRepository) error {
+				return r.ReorderChecklistItems(context.Background(), models.ReorderChecklistItemsRequest{Email: "test@gmail.com", Id: 123, ItemIds: []int32{2, 1}})
+			},
+			wantErr: false,
+		},
+		{
+			name:   "failure case - reorder with a missing item",
+			stored: checklist(milk, eggs),
+			call: func(r *notesRepository) error {
+				return r.ReorderChecklistItems(context.Background(), models.ReorderChecklistItemsRequest{Email: "test@gmail.com", Id: 123, ItemIds: []int32{2, 2}})
+			},
+			wantErr: true,
+		},
+		{
+			name:       "success case - toggle item",
+			stored:     checklist(milk, eggs),
+			wantInsert: checklist(milk, doneEggs),
+			call: func(r *notesRepository) error {
+				return r.ToggleChecklistItem(context.Background(), models.ToggleChecklistItemRequest{Email: "test@gmail.com", Id: 123, ItemId: 2})
+			},
+			wantErr: false,
+		},
+		{
+			name:   "failure case - toggle unknown item",
+			stored: checklist(milk),
+			call: func(r *notesRepository) error {
+				return r.ToggleChecklistItem(context.Background(), models.ToggleChecklistItemRequest{Email: "test@gmail.com", Id: 123, ItemId: 2})
+			},
+			wantErr: true,
+		},
+		{
+			name:       "success case - remove item",
+			stored:     checklist(milk, eggs),
+			wantInsert: checklist(eggs),
+			call: func(r *notesRepository) error {
+				return r.RemoveChecklistItem(context.Background(), models.RemoveChecklistItemRequest{Email: "test@gmail.com", Id: 123, ItemId: 1})
+			},
+			wantErr: false,
+		},
+		{
+			name:   "failure case - note is not a checklist",
+			stored: &models.Note{Id: 123, Type: models.NoteTypeText, CreatedBy: "test@gmail.com"},
+			call: func(r *notesRepository) error {
+				return r.RemoveChecklistItem(context.Background(), models.RemoveChecklistItemRequest{Email: "test@gmail.com", Id: 123, ItemId: 1})
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := *tt.stored
+			if tt.stored.Items != nil {
+				stored.Items = append([]models.ChecklistItem{}, tt.stored.Items...)
+			}
+			mockTxn := db.MockMemDbTxn{}
+			mockTxn.EXPECT().First("audit_log", "id", mock.Anything).Return(nil, nil)
+			mockTxn.EXPECT().First(mock.Anything, mock.Anything, mock.Anything).Return(tt.stored, nil)
+			if tt.wantErr {
+				mockTxn.EXPECT().Abort()
+			} else {
+				if tt.wantInsert != nil {
+					mockTxn.EXPECT().Insert("notes", tt.wantInsert).Return(nil)
+				} else {
+					mockTxn.EXPECT().Insert("notes", mock.Anything).Return(nil)
+				}
+				mockTxn.EXPECT().Insert("usage", mock.Anything).Return(nil).Maybe()
+				mockTxn.EXPECT().Insert("audit_log", mock.Anything).Return(nil)
+				mockTxn.EXPECT().Commit()
+			}
+			mockDb := db.MockDB{}
+			mockDb.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			r := &notesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			err := tt.call(r)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesRepository error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(tt.stored, &stored) {
+				t.Errorf("stored note was modified in place: %v", tt.stored)
+			}
+		})
+	}
+}
+
 type mockResultIterator struct {
 	WatchChResp chan struct{}
 	NextResp    interface{}
@@ -222,3 +832,164 @@ func (m *mockResultIterator) Next() interface{} {
 	m.NextResp = nil
 	return value
 }
+
+func Test_notesRepository_NoteFlags(t *testing.T) {
+	const email = "flags@gmail.com"
+	ctx := context.Background()
+	r := NewNotesRepository(db.NewDB(), loggers.NewLogger(), nil)
+	add := func(note string) int32 {
+		t.Helper()
+		id, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Note: note})
+		if err != nil {
+			t.Fatalf("notesRepository.AddNote() error = %v", err)
+		}
+		return id
+	}
+	setFlag := func(flag string, id int32, value *bool) {
+		t.Helper()
+		if err := r.SetNoteFlag(ctx, flag, models.SetNoteFlagRequest{Email: email, Id: id, Value: value}); err != nil {
+			t.Fatalf("notesRepository.SetNoteFlag() error = %v", err)
+		}
+	}
+	list := func(request models.GetNotesRequest) []string {
+		t.Helper()
+		request.Email = email
+		notes, err := r.GetNotes(ctx, request)
+		if err != nil {
+			t.Fatalf("notesRepository.GetNotes() error = %v", err)
+		}
+		texts := make([]string, 0, len(notes))
+		for _, note := range notes {
+			texts = append(texts, note.Note)
+		}
+		return texts
+	}
+	yes := true
+
+	add("plain")
+	pinned := add("pinned")
+	archived := add("archived")
+	starred := add("starred")
+	setFlag(models.NoteFlagPinned, pinned, nil)
+	setFlag(models.NoteFlagArchived, archived, &yes)
+	setFlag(models.NoteFlagStarred, starred, &yes)
+	setFlag(models.NoteFlagStarred, archived, nil)
+
+	if got, want := list(models.GetNotesRequest{}), []string{"pinned"}; !reflect.DeepEqual(got[:1], want) || len(got) != 3 {
+		t.Errorf("GetNotes() = %v, want the pinned note first and no archived note", got)
+	}
+	if got, want := list(models.GetNotesRequest{Archived: true}), []string{"archived"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNotes() archived = %v, want %v", got, want)
+	}
+	if got, want := list(models.GetNotesRequest{Starred: true}), []string{"starred"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNotes() starred = %v, want %v", got, want)
+	}
+	if got, want := list(models.GetNotesRequest{Starred: true, Archived: true}), []string{"archived"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetNotes() starred and archived = %v, want %v", got, want)
+	}
+
+	// toggling without a value flips the flag
+	setFlag(models.NoteFlagPinned, pinned, nil)
+	if got := list(models.GetNotesRequest{}); len(got) != 3 {
+		t.Errorf("GetNotes() after unpinning = %v", got)
+	}
+	if err := r.SetNoteColor(ctx, models.SetNoteColorRequest{Email: email, Id: pinned, Color: "green"}); err != nil {
+		t.Fatalf("notesRepository.SetNoteColor() error = %v", err)
+	}
+	if err := r.SetNoteFlag(ctx, models.NoteFlagPinned, models.SetNoteFlagRequest{Email: "other@gmail.com", Id: pinned}); err == nil {
+		t.Errorf("notesRepository.SetNoteFlag() changed a note of another user")
+	}
+}
+
+func Test_notesRepository_SearchNotes(t *testing.T) {
+	mockTxn := db.MockMemDbTxn{}
+	rows := &mockSliceIterator{rows: []interface{}{
+		&models.Note{Id: 1, Title: "Groceries", Note: "eggs"},
+		&models.Note{Id: 2, Note: "buy MILK"},
+		&models.Note{Id: 3, Type: models.NoteTypeChecklist, Items: []models.ChecklistItem{{Text: "milk"}}},
+		&models.Note{Id: 4, Ciphertext: []byte("milk"), Encryption: &models.Encryption{Scheme: models.EncryptionSchemeAESGCM}},
+	}}
+	mockTxn.EXPECT().Get("notes", "workspace_owner", int32(0), "test@gmail.com").Return(rows, nil)
+	mockTxn.EXPECT().Commit()
+	mockDb := db.MockDB{}
+	mockDb.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+	r := &notesRepository{
+		db:     &mockDb,
+		logger: loggers.NewLogger(),
+	}
+	got, err := r.SearchNotes(context.Background(), models.SearchNotesRequest{Email: "test@gmail.com", Query: "Milk"})
+	if err != nil {
+		t.Fatalf("notesRepository.SearchNotes() error = %v", err)
+	}
+	ids := make([]int32, 0, len(got))
+	for _, note := range got {
+		ids = append(ids, note.Id)
+	}
+	if want := []int32{2, 3}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("notesRepository.SearchNotes() = %v, want notes %v", ids, want)
+	}
+}
+
+type mockSliceIterator struct {
+	rows []interface{}
+}
+
+func (m *mockSliceIterator) WatchCh() <-chan struct{} {
+	return make(chan struct{})
+}
+
+func (m *mockSliceIterator) Next() interface{} {
+	if len(m.rows) == 0 {
+		return nil
+	}
+	row := m.rows[0]
+	m.rows = m.rows[1:]
+	return row
+}
+
+func Test_notesRepository_Usage(t *testing.T) {
+	const email = "usage@gmail.com"
+	ctx := context.Background()
+	r := NewNotesRepository(db.NewDB(), loggers.NewLogger(), testKeyring(t, "k1"))
+	// the usage must always match the notes stored, whatever changed them
+	check := func(step string) {
+		t.Helper()
+		notes, err := r.GetNotes(ctx, models.GetNotesRequest{Email: email})
+		if err != nil {
+			t.Fatalf("notesRepository.GetNotes() error = %v", err)
+		}
+		want := models.Usage{Email: email, Notes: len(notes)}
+		for _, note := range notes {
+			if note.Size != note.ContentSize() {
+				t.Errorf("%s: note %d has size %d, want %d", step, note.Id, note.Size, note.ContentSize())
+			}
+			want.Bytes += int64(note.ContentSize())
+		}
+		if got, err := r.GetUsage(ctx, email); err != nil || got != want {
+			t.Errorf("%s: notesRepository.GetUsage() = %v, %v, want %v", step, got, err, want)
+		}
+	}
+
+	index, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Title: "Index", Note: "see [[Todo]]"})
+	if err != nil {
+		t.Fatalf("notesRepository.AddNote() error = %v", err)
+	}
+	todo, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Title: "Todo", Type: models.NoteTypeChecklist, Items: []models.ChecklistItem{{Text: "milk"}}})
+	if err != nil {
+		t.Fatalf("notesRepository.AddNote() error = %v", err)
+	}
+	check("add")
+	if _, err = r.AddChecklistItem(ctx, models.AddChecklistItemRequest{Email: email, Id: todo, Text: "eggs"}); err != nil {
+		t.Fatalf("notesRepository.AddChecklistItem() error = %v", err)
+	}
+	check("add item")
+	title := "Groceries to buy"
+	if err = r.UpdateNote(ctx, models.UpdateNoteRequest{Email: email, Id: todo, Title: &title}); err != nil {
+		t.Fatalf("notesRepository.UpdateNote() error = %v", err)
+	}
+	check("rename")
+	if err = r.DeleteNote(ctx, 0, email, index); err != nil {
+		t.Fatalf("notesRepository.DeleteNote() error = %v", err)
+	}
+	check("delete")
+}
@@ -0,0 +1,54 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+type notificationsRepository struct {
+	db     db.DB
+	logger *loggers.Logger
+}
+
+func NewNotificationsRepository(db db.DB, logger *loggers.Logger) interfaces.INotificationsRepository {
+	return &notificationsRepository{db: db, logger: logger}
+}
+
+// AddNotification - stores an in-app notification for the user
+func (r *notificationsRepository) AddNotification(ctx context.Context, notification models.Notification) error {
+	r.logger.Info(ctx, "Entering notificationsRepository.AddNotification()")
+	defer r.logger.Info(ctx, "Exiting notificationsRepository.AddNotification()")
+	txn := r.db.Txn(ctx, true)
+	notification.Id = utils.NewID()
+	err := txn.Insert("notifications", &notification)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notificationsRepository.AddNotification(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// GetNotifications - retrieves all the in-app notifications of the user
+func (r *notificationsRepository) GetNotifications(ctx context.Context, email string) ([]models.Notification, error) {
+	r.logger.Info(ctx, "Entering notificationsRepository.GetNotifications()")
+	defer r.logger.Info(ctx, "Exiting notificationsRepository.GetNotifications()")
+	notifications := make([]models.Notification, 0)
+	txn := r.db.Txn(ctx, false)
+	rows, err := txn.Get("notifications", "email", email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in notificationsRepository.GetNotifications(), error from txn.Get()", err)
+		return []models.Notification{}, err
+	}
+	txn.Commit()
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		notifications = append(notifications, *obj.(*models.Notification))
+	}
+	return notifications, nil
+}
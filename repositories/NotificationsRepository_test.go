@@ -0,0 +1,114 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_notificationsRepository_AddNotification(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in txn.Insert()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().Insert(mock.Anything, mock.Anything).Return(errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &notificationsRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			err := r.AddNotification(context.Background(), models.Notification{
+				Email:   "test@gmail.com",
+				NoteId:  123,
+				Message: "Reminder: test",
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notificationsRepository.AddNotification() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_notificationsRepository_GetNotifications(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		want    []models.Notification
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				t := mockResultIterator{
+					NextResp: &models.Notification{Id: 1, NoteId: 123, Message: "Reminder: test"},
+				}
+				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything).Return(&t, nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			want:    []models.Notification{{Id: 1, NoteId: 123, Message: "Reminder: test"}},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in txn.Get()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			want:    []models.Notification{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &notificationsRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			got, err := r.GetNotifications(context.Background(), "test@gmail.com")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notificationsRepository.GetNotifications() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("notificationsRepository.GetNotifications() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/models"
+	"notes-server/utils"
+	"time"
+)
+
+// AddOIDCLogin - stores a sign in started with an OpenID Connect provider, the expired ones are dropped
+func (r *loginRepository) AddOIDCLogin(ctx context.Context, login models.OIDCLogin) error {
+	r.logger.Info(ctx, "Entering loginRepository.AddOIDCLogin()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.AddOIDCLogin()")
+	txn := r.db.Txn(ctx, true)
+	err := deleteExpiredOIDCLogins(txn, time.Now())
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.AddOIDCLogin(), error from deleteExpiredOIDCLogins()", err)
+		return err
+	}
+	err = txn.Insert("oidc_logins", &login)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.AddOIDCLogin(), error from txn.Insert()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// TakeOIDCLogin - returns the unexpired sign in with the state hash, which can not be taken again
+func (r *loginRepository) TakeOIDCLogin(ctx context.Context, stateHash string, now time.Time) (models.OIDCLogin, error) {
+	r.logger.Info(ctx, "Entering loginRepository.TakeOIDCLogin()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.TakeOIDCLogin()")
+	txn := r.db.Txn(ctx, true)
+	row, err := txn.First("oidc_logins", "id", stateHash)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.TakeOIDCLogin(), error from txn.First()", err)
+		return models.OIDCLogin{}, err
+	}
+	login, ok := row.(*models.OIDCLogin)
+	if !ok {
+		txn.Abort()
+		return models.OIDCLogin{}, models.ErrOIDCLoginFailed
+	}
+	err = txn.Delete("oidc_logins", login)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.TakeOIDCLogin(), error from txn.Delete()", err)
+		return models.OIDCLogin{}, err
+	}
+	txn.Commit()
+	if !now.Before(login.ExpiresAt) {
+		return models.OIDCLogin{}, models.ErrOIDCLoginFailed
+	}
+	return *login, nil
+}
+
+// LoginOIDC - returns the user linked to the identity. An identity that is not linked yet is linked to the
+// account with its email address, or to a new account when there is none, as long as the provider verified it.
+// Linking an account whose address was never verified clears its password and two-factor authentication and
+// revokes its sessions and access tokens.
+// The login, and the signup of a new account or the link of an existing one, are written to the audit log.
+func (r *loginRepository) LoginOIDC(ctx context.Context, identity models.OIDCIdentity) (models.User, error) {
+	r.logger.Info(ctx, "Entering loginRepository.LoginOIDC()")
+	defer r.logger.Info(ctx, "Exiting loginRepository.LoginOIDC()")
+	txn := r.db.Txn(ctx, true)
+	row, err := txn.First("oidc_links", "id", identity.Issuer, identity.Subject)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.LoginOIDC(), error from txn.First()", err)
+		return models.User{}, err
+	}
+	if link, ok := row.(*models.OIDCLink); ok {
+		user, err := getUser(txn, link.Email)
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.LoginOIDC(), error from getUser()", err)
+			return models.User{}, err
+		}
+		err = appendAudit(ctx, txn, oidcLoginAuditEntry(user, identity))
+		if err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.LoginOIDC(), error from appendAudit()", err)
+			return models.User{}, err
+		}
+		txn.Commit()
+		return user, nil
+	}
+	if identity.Email == "" || !identity.EmailVerified {
+		txn.Abort()
+		return models.User{}, models.ErrEmailNotVerified
+	}
+	user, err := getUser(txn, identity.Email)
+	provisioned := errors.Is(err, models.ErrUserNotFound)
+	if err != nil && !provisioned {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.LoginOIDC(), error from getUser()", err)
+		return models.User{}, err
+	}
+	before := user
+	if provisioned {
+		// provisioned without a password, the user signs in with the provider or sets one with a password reset
+		name := identity.Name
+		if name == "" {
+			name = identity.Email
+		}
+		user = models.User{Id: utils.NewID(), Name: name, Email: utils.NormalizeEmail(identity.Email), Role: models.RoleUser}
+		if err = addPersonalWorkspace(txn, &user); err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.LoginOIDC(), error from addPersonalWorkspace()", err)
+			return models.User{}, err
+		}
+	} else if !user.Verified {
+		// anyone could have signed up with the address before its owner, who proves it now: the credentials,
+		// sessions and access tokens of that signup are dropped so that it can not keep access to the account
+		user.Password = ""
+		user.MFA = models.MFA{}
+		user.SessionVersion++
+		if err = deleteAccessTokens(txn, user.Email); err != nil {
+			txn.Abort()
+			r.logger.Warn(ctx, "error in loginRepository.LoginOIDC(), error from deleteAccessTokens()", err)
+			return models.User{}, err
+		}
+	}
+	user.Verified = true
+	err = txn.Insert("user", &user)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.LoginOIDC(), error from txn.Insert()", err)
+		return models.User{}, err
+	}
+	err = txn.Insert("oidc_links", &models.OIDCLink{Issuer: identity.Issuer, Subject: identity.Subject, Email: user.Email})
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.LoginOIDC(), error from txn.Insert()", err)
+		return models.User{}, err
+	}
+	if provisioned {
+		err = appendAudit(ctx, txn, models.AuditEntry{
+			Action: models.AuditSignUp,
+			Actor:  user.Email,
+			Target: models.AuditTarget("user", user.Email),
+			After:  models.AuditSummary{"name": user.Name, "role": user.Role, "issuer": identity.Issuer},
+		})
+	} else {
+		// the link of an existing account, which revoked its sessions when the address was not verified
+		entry := userAuditEntry(models.AuditOIDCLinked, before, user)
+		entry.Actor = user.Email
+		entry.After["issuer"] = identity.Issuer
+		entry.After["subject"] = identity.Subject
+		err = appendAudit(ctx, txn, entry)
+	}
+	if err == nil {
+		err = appendAudit(ctx, txn, oidcLoginAuditEntry(user, identity))
+	}
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in loginRepository.LoginOIDC(), error from appendAudit()", err)
+		return models.User{}, err
+	}
+	txn.Commit()
+	return user, nil
+}
+
+// oidcLoginAuditEntry - the login of user with identity
+func oidcLoginAuditEntry(user models.User, identity models.OIDCIdentity) models.AuditEntry {
+	entry := userLoginAuditEntry(models.AuditLoginOIDC, user, true)
+	entry.After = models.AuditSummary{"issuer": identity.Issuer, "subject": identity.Subject}
+	return entry
+}
+
+func deleteExpiredOIDCLogins(txn db.MemDbTxn, now time.Time) error {
+	rows, err := txn.Get("oidc_logins", "id")
+	if err != nil {
+		return err
+	}
+	expired := make([]models.OIDCLogin, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		if login := obj.(*models.OIDCLogin); !now.Before(login.ExpiresAt) {
+			expired = append(expired, *login)
+		}
+	}
+	for i := range expired {
+		if err = txn.Delete("oidc_logins", &expired[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getOIDCLinks(txn db.MemDbTxn, email string) ([]models.OIDCLink, error) {
+	rows, err := txn.Get("oidc_links", "email", email)
+	if err != nil {
+		return nil, err
+	}
+	links := make([]models.OIDCLink, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		links = append(links, *obj.(*models.OIDCLink))
+	}
+	return links, nil
+}
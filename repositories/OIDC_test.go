@@ -0,0 +1,120 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_loginRepository_LoginOIDC(t *testing.T) {
+	const (
+		issuer = "https://idp.example.com"
+		email  = "oidc-existing@gmail.com"
+	)
+	ctx := context.Background()
+	r := NewLoginRepository(db.NewDB(), loggers.NewLogger())
+	if err := r.SignUp(ctx, models.SignUpRequest{Email: email, Name: "existing", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	tokens := NewAccessTokensRepository(db.NewDB(), loggers.NewLogger())
+	token := models.AccessToken{Id: utils.NewID(), TokenHash: utils.HashToken("pat_oidc_existing"), Email: email}
+	if err := tokens.AddAccessToken(ctx, token); err != nil {
+		t.Fatalf("accessTokensRepository.AddAccessToken() error = %v", err)
+	}
+
+	if _, err := r.LoginOIDC(ctx, models.OIDCIdentity{Issuer: issuer, Subject: "1", Email: email}); !errors.Is(err, models.ErrEmailNotVerified) {
+		t.Errorf("loginRepository.LoginOIDC() linked an unverified email, error = %v", err)
+	}
+	linked, err := r.LoginOIDC(ctx, models.OIDCIdentity{Issuer: issuer, Subject: "1", Email: email, EmailVerified: true})
+	if err != nil || linked.Email != email || linked.Name != "existing" || !linked.Verified {
+		t.Fatalf("loginRepository.LoginOIDC() = %+v, error = %v, want the existing user", linked, err)
+	}
+	// the address was never verified, whoever signed up with it loses access to the account
+	if linked.Password != "" || linked.SessionVersion != 1 {
+		t.Errorf("loginRepository.LoginOIDC() = %+v, want the password and the sessions of the unverified signup revoked", linked)
+	}
+	if remaining, err := tokens.GetAccessTokens(ctx, email); err != nil || len(remaining) != 0 {
+		t.Errorf("accessTokensRepository.GetAccessTokens() = %+v, %v, want the tokens of the unverified signup revoked", remaining, err)
+	}
+	if entry := lastAuditEntry(t, models.AuditOIDCLinked, models.AuditTarget("user", email)); entry.Actor != email || entry.Before["has_password"] != true || entry.After["has_password"] != false || entry.After["session_version"] != 1 || entry.After["issuer"] != issuer {
+		t.Errorf("link entry = %+v", entry)
+	}
+	// the link is by subject from then on, whatever email the provider sends
+	again, err := r.LoginOIDC(ctx, models.OIDCIdentity{Issuer: issuer, Subject: "1", Email: "renamed@example.com"})
+	if err != nil || again.Id != linked.Id {
+		t.Errorf("loginRepository.LoginOIDC() = %+v, error = %v, want the linked user", again, err)
+	}
+
+	// a verified account keeps its password
+	const verified = "oidc-verified@gmail.com"
+	if err := r.SignUp(ctx, models.SignUpRequest{Email: verified, Name: "verified", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	if err := r.VerifyEmail(ctx, verified); err != nil {
+		t.Fatalf("loginRepository.VerifyEmail() error = %v", err)
+	}
+	kept, err := r.LoginOIDC(ctx, models.OIDCIdentity{Issuer: issuer, Subject: "3", Email: verified, EmailVerified: true})
+	if err != nil || kept.Password != "password" || kept.SessionVersion != 0 {
+		t.Errorf("loginRepository.LoginOIDC() = %+v, error = %v, want the verified user unchanged", kept, err)
+	}
+
+	provisioned, err := r.LoginOIDC(ctx, models.OIDCIdentity{Issuer: issuer, Subject: "2", Email: "oidc-new@gmail.com", EmailVerified: true, Name: "New"})
+	if err != nil || provisioned.Email != "oidc-new@gmail.com" || provisioned.Name != "New" || !provisioned.Verified || provisioned.Password != "" {
+		t.Fatalf("loginRepository.LoginOIDC() = %+v, error = %v, want a provisioned user", provisioned, err)
+	}
+	if _, err = r.GetUser(ctx, "oidc-new@gmail.com"); err != nil {
+		t.Errorf("loginRepository.LoginOIDC() did not store the provisioned user")
+	}
+	other, err := r.LoginOIDC(ctx, models.OIDCIdentity{Issuer: "https://other.example.com", Subject: "2", Email: "oidc-other@gmail.com", EmailVerified: true})
+	if err != nil || other.Id == provisioned.Id {
+		t.Errorf("loginRepository.LoginOIDC() = %+v, error = %v, want a user of its own for the subject of another issuer", other, err)
+	}
+}
+
+func Test_loginRepository_LoginOIDC_lookupError(t *testing.T) {
+	identity := models.OIDCIdentity{Issuer: "https://idp.example.com", Subject: "1", Email: "test@gmail.com", EmailVerified: true}
+	mockDb := db.MockDB{}
+	mockTxn := db.MockMemDbTxn{}
+	mockTxn.EXPECT().First("oidc_links", "id", identity.Issuer, identity.Subject).Return(nil, nil)
+	mockTxn.EXPECT().First("user", "email", identity.Email).Return(nil, errors.New("db error"))
+	mockTxn.EXPECT().Abort()
+	mockDb.EXPECT().Txn(mock.Anything, true).Return(&mockTxn)
+	r := &loginRepository{db: &mockDb, logger: loggers.NewLogger()}
+
+	// a failed lookup is not taken for a missing user, no account is provisioned over an existing one
+	if _, err := r.LoginOIDC(context.Background(), identity); err == nil || errors.Is(err, models.ErrUserNotFound) {
+		t.Errorf("loginRepository.LoginOIDC() error = %v, want the error of the lookup", err)
+	}
+	mockTxn.AssertExpectations(t)
+}
+
+func Test_loginRepository_TakeOIDCLogin(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now()
+	r := NewLoginRepository(db.NewDB(), loggers.NewLogger())
+	for _, login := range []models.OIDCLogin{
+		{StateHash: "take-active", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: now.Add(time.Minute)},
+		{StateHash: "take-expired", ExpiresAt: now.Add(time.Second)},
+	} {
+		if err := r.AddOIDCLogin(ctx, login); err != nil {
+			t.Fatalf("loginRepository.AddOIDCLogin() error = %v", err)
+		}
+	}
+	got, err := r.TakeOIDCLogin(ctx, "take-active", now)
+	if err != nil || got.Nonce != "nonce" || got.CodeVerifier != "verifier" {
+		t.Errorf("loginRepository.TakeOIDCLogin() = %+v, error = %v", got, err)
+	}
+	if _, err = r.TakeOIDCLogin(ctx, "take-active", now); err == nil {
+		t.Errorf("loginRepository.TakeOIDCLogin() took a sign in twice")
+	}
+	if _, err = r.TakeOIDCLogin(ctx, "take-expired", now.Add(time.Minute)); err == nil {
+		t.Errorf("loginRepository.TakeOIDCLogin() took an expired sign in")
+	}
+}
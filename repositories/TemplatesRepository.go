@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+type templatesRepository struct {
+	db     db.DB
+	logger *loggers.Logger
+}
+
+func NewTemplatesRepository(db db.DB, logger *loggers.Logger) interfaces.ITemplatesRepository {
+	return &templatesRepository{db: db, logger: logger}
+}
+
+// GetTemplates - retrieves the global templates followed by the templates of the user
+func (r *templatesRepository) GetTemplates(ctx context.Context, email string) ([]models.Template, error) {
+	r.logger.Info(ctx, "Entering templatesRepository.GetTemplates()")
+	defer r.logger.Info(ctx, "Exiting templatesRepository.GetTemplates()")
+	templates := make([]models.Template, 0)
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	for _, query := range []struct {
+		index string
+		arg   interface{}
+	}{{"global", true}, {"owner", email}} {
+		rows, err := txn.Get("templates", query.index, query.arg)
+		if err != nil {
+			r.logger.Warn(ctx, "error in templatesRepository.GetTemplates(), error from txn.Get()", err)
+			return []models.Template{}, err
+		}
+		for obj := rows.Next(); obj != nil; obj = rows.Next() {
+			templates = append(templates, *obj.(*models.Template))
+		}
+	}
+	return templates, nil
+}
+
+// GetTemplate - retrieves a global template or a template of the user
+func (r *templatesRepository) GetTemplate(ctx context.Context, email string, templateID int32) (models.Template, error) {
+	r.logger.Info(ctx, "Entering templatesRepository.GetTemplate()")
+	defer r.logger.Info(ctx, "Exiting templatesRepository.GetTemplate()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	row, err := txn.First("templates", "id", templateID)
+	if err != nil {
+		r.logger.Warn(ctx, "error in templatesRepository.GetTemplate(), error from txn.First()", err)
+		return models.Template{}, err
+	}
+	template, ok := row.(*models.Template)
+	if !ok || (!template.Global && template.Owner != email) {
+		return models.Template{}, models.ErrTemplateNotFound
+	}
+	return *template, nil
+}
+
+// AddTemplate - adds a template of the user
+func (r *templatesRepository) AddTemplate(ctx context.Context, request models.AddTemplateRequest) (int32, error) {
+	r.logger.Info(ctx, "Entering templatesRepository.AddTemplate()")
+	defer r.logger.Info(ctx, "Exiting templatesRepository.AddTemplate()")
+	template := models.Template{
+		Id:    utils.NewID(),
+		Name:  request.Name,
+		Title: request.Title,
+		Note:  request.Note,
+		Owner: request.Email,
+	}
+	txn := r.db.Txn(ctx, true)
+	err := txn.Insert("templates", &template)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in templatesRepository.AddTemplate(), error from txn.Insert()", err)
+		return 0, err
+	}
+	txn.Commit()
+	return template.Id, nil
+}
+
+// DeleteTemplate - deletes a template of the user, global templates can not be deleted
+func (r *templatesRepository) DeleteTemplate(ctx context.Context, email string, templateID int32) error {
+	r.logger.Info(ctx, "Entering templatesRepository.DeleteTemplate()")
+	defer r.logger.Info(ctx, "Exiting templatesRepository.DeleteTemplate()")
+	txn := r.db.Txn(ctx, true)
+	row, err := txn.First("templates", "id", templateID)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in templatesRepository.DeleteTemplate(), error from txn.First()", err)
+		return err
+	}
+	template, ok := row.(*models.Template)
+	if !ok || template.Global || template.Owner != email {
+		txn.Abort()
+		return models.ErrTemplateNotFound
+	}
+	err = txn.Delete("templates", template)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in templatesRepository.DeleteTemplate(), error from txn.Delete()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
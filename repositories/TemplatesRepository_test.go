@@ -0,0 +1,181 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_templatesRepository_GetTemplates(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		want    []models.Template
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().Get("templates", "global", true).Return(&mockResultIterator{
+					NextResp: &models.Template{Id: 1, Name: "Meeting notes", Global: true},
+				}, nil)
+				mockTxn.EXPECT().Get("templates", "owner", "test@gmail.com").Return(&mockResultIterator{
+					NextResp: &models.Template{Id: 2, Name: "Standup", Owner: "test@gmail.com"},
+				}, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			want: []models.Template{
+				{Id: 1, Name: "Meeting notes", Global: true},
+				{Id: 2, Name: "Standup", Owner: "test@gmail.com"},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in txn.Get()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().Get(mock.Anything, mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			want:    []models.Template{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &templatesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			got, err := r.GetTemplates(context.Background(), "test@gmail.com")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("templatesRepository.GetTemplates() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("templatesRepository.GetTemplates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_templatesRepository_GetTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		row     interface{}
+		want    models.Template
+		wantErr bool
+	}{
+		{
+			name:    "global template",
+			row:     &models.Template{Id: 1, Global: true},
+			want:    models.Template{Id: 1, Global: true},
+			wantErr: false,
+		},
+		{
+			name:    "template of the user",
+			row:     &models.Template{Id: 1, Owner: "test@gmail.com"},
+			want:    models.Template{Id: 1, Owner: "test@gmail.com"},
+			wantErr: false,
+		},
+		{
+			name:    "failure case - template of another user",
+			row:     &models.Template{Id: 1, Owner: "other@gmail.com"},
+			want:    models.Template{},
+			wantErr: true,
+		},
+		{
+			name:    "failure case - template not found",
+			row:     nil,
+			want:    models.Template{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTxn := db.MockMemDbTxn{}
+			mockTxn.EXPECT().First("templates", "id", int32(1)).Return(tt.row, nil)
+			mockTxn.EXPECT().Abort()
+			mockDb := db.MockDB{}
+			mockDb.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			r := &templatesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			got, err := r.GetTemplate(context.Background(), "test@gmail.com", 1)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("templatesRepository.GetTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("templatesRepository.GetTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_templatesRepository_DeleteTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*db.MockDB)
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("templates", "id", int32(2)).Return(&models.Template{Id: 2, Owner: "test@gmail.com"}, nil)
+				mockTxn.EXPECT().Delete("templates", mock.Anything).Return(nil)
+				mockTxn.EXPECT().Commit()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - global template",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("templates", "id", int32(2)).Return(&models.Template{Id: 2, Global: true}, nil)
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: true,
+		},
+		{
+			name: "failure case - error in txn.Delete()",
+			given: func(dab *db.MockDB) {
+				mockTxn := db.MockMemDbTxn{}
+				mockTxn.EXPECT().First("templates", "id", int32(2)).Return(&models.Template{Id: 2, Owner: "test@gmail.com"}, nil)
+				mockTxn.EXPECT().Delete("templates", mock.Anything).Return(errors.New("db error"))
+				mockTxn.EXPECT().Abort()
+				dab.EXPECT().Txn(mock.Anything, mock.Anything).Return(&mockTxn)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockDb := db.MockDB{}
+			tt.given(&mockDb)
+			r := &templatesRepository{
+				db:     &mockDb,
+				logger: loggers.NewLogger(),
+			}
+			err := r.DeleteTemplate(context.Background(), "test@gmail.com", 2)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("templatesRepository.DeleteTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,396 @@
+package repositories
+
+import (
+	"context"
+	"notes-server/db"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"sort"
+	"strings"
+	"time"
+)
+
+type workspaceRepository struct {
+	db     db.DB
+	logger *loggers.Logger
+}
+
+func NewWorkspaceRepository(db db.DB, logger *loggers.Logger) interfaces.IWorkspaceRepository {
+	return &workspaceRepository{db: db, logger: logger}
+}
+
+// CreateWorkspace - stores a new workspace owned by the user with the email
+func (r *workspaceRepository) CreateWorkspace(ctx context.Context, email string, workspace models.Workspace) error {
+	r.logger.Info(ctx, "Entering workspaceRepository.CreateWorkspace()")
+	defer r.logger.Info(ctx, "Exiting workspaceRepository.CreateWorkspace()")
+	txn := r.db.Txn(ctx, true)
+	err := insertWorkspace(txn, email, workspace)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.CreateWorkspace(), error from insertWorkspace()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// GetWorkspaces - retrieves the workspaces the user is a member of, the personal one first and the others by name
+func (r *workspaceRepository) GetWorkspaces(ctx context.Context, email string) ([]models.UserWorkspace, error) {
+	r.logger.Info(ctx, "Entering workspaceRepository.GetWorkspaces()")
+	defer r.logger.Info(ctx, "Exiting workspaceRepository.GetWorkspaces()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	memberships, err := getMemberships(txn, "email", email)
+	if err != nil {
+		r.logger.Warn(ctx, "error in workspaceRepository.GetWorkspaces(), error from getMemberships()", err)
+		return []models.UserWorkspace{}, err
+	}
+	workspaces := make([]models.UserWorkspace, 0, len(memberships))
+	for _, membership := range memberships {
+		workspace, err := getWorkspace(txn, membership.WorkspaceId)
+		if err != nil {
+			r.logger.Warn(ctx, "error in workspaceRepository.GetWorkspaces(), error from getWorkspace()", err)
+			return []models.UserWorkspace{}, err
+		}
+		workspaces = append(workspaces, models.UserWorkspace{Workspace: workspace, Role: membership.Role})
+	}
+	sort.Slice(workspaces, func(i, j int) bool {
+		if workspaces[i].Personal != workspaces[j].Personal {
+			return workspaces[i].Personal
+		}
+		return strings.ToLower(workspaces[i].Name) < strings.ToLower(workspaces[j].Name)
+	})
+	return workspaces, nil
+}
+
+// GetMembers - retrieves the members of a workspace the user is a member of, in the order they joined
+func (r *workspaceRepository) GetMembers(ctx context.Context, workspace int32, email string) ([]models.Membership, error) {
+	r.logger.Info(ctx, "Entering workspaceRepository.GetMembers()")
+	defer r.logger.Info(ctx, "Exiting workspaceRepository.GetMembers()")
+	txn := r.db.Txn(ctx, false)
+	defer txn.Abort()
+	if _, err := getMembership(txn, workspace, email); err != nil {
+		r.logger.Warn(ctx, "error in workspaceRepository.GetMembers(), error from getMembership()", err)
+		return []models.Membership{}, models.ErrWorkspaceNotFound
+	}
+	members, err := getMemberships(txn, "workspace", workspace)
+	if err != nil {
+		r.logger.Warn(ctx, "error in workspaceRepository.GetMembers(), error from getMemberships()", err)
+		return []models.Membership{}, err
+	}
+	sort.Slice(members, func(i, j int) bool { return members[i].JoinedAt.Before(members[j].JoinedAt) })
+	return members, nil
+}
+
+// AddInvitation - stores an invitation to the workspace sent by inviter, who must be allowed to manage members
+// with the role. The invitations sent to the same address before and the expired ones are dropped. Returns the
+// workspace.
+func (r *workspaceRepository) AddInvitation(ctx context.Context, inviter string, invitation models.Invitation, now time.Time) (models.Workspace, error) {
+	r.logger.Info(ctx, "Entering workspaceRepository.AddInvitation()")
+	defer r.logger.Info(ctx, "Exiting workspaceRepository.AddInvitation()")
+	txn := r.db.Txn(ctx, true)
+	workspace, err := getWorkspace(txn, invitation.WorkspaceId)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.AddInvitation(), error from getWorkspace()", err)
+		return models.Workspace{}, err
+	}
+	membership, err := getMembership(txn, workspace.Id, inviter)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.AddInvitation(), error from getMembership()", err)
+		return models.Workspace{}, models.ErrWorkspaceNotFound
+	}
+	if workspace.Personal {
+		txn.Abort()
+		return models.Workspace{}, models.ErrPersonalWorkspace
+	}
+	if !membership.CanManage(invitation.Role) {
+		txn.Abort()
+		return models.Workspace{}, models.ErrWorkspaceForbidden
+	}
+	members, err := getMemberships(txn, "workspace", workspace.Id)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.AddInvitation(), error from getMemberships()", err)
+		return models.Workspace{}, err
+	}
+	for _, member := range members {
+		if strings.EqualFold(member.Email, invitation.Email) {
+			txn.Abort()
+			return models.Workspace{}, models.ErrAlreadyMember
+		}
+	}
+	err = deleteInvitations(txn, workspace.Id, func(pending models.Invitation) bool {
+		return strings.EqualFold(pending.Email, invitation.Email) || !now.Before(pending.ExpiresAt)
+	})
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.AddInvitation(), error from deleteInvitations()", err)
+		return models.Workspace{}, err
+	}
+	err = txn.Insert("invitations", &invitation)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.AddInvitation(), error from txn.Insert()", err)
+		return models.Workspace{}, err
+	}
+	txn.Commit()
+	return workspace, nil
+}
+
+// AcceptInvitation - consumes an invitation sent to the email address of the user and makes them a member of its
+// workspace
+func (r *workspaceRepository) AcceptInvitation(ctx context.Context, tokenHash, email string, now time.Time) (models.UserWorkspace, error) {
+	r.logger.Info(ctx, "Entering workspaceRepository.AcceptInvitation()")
+	defer r.logger.Info(ctx, "Exiting workspaceRepository.AcceptInvitation()")
+	txn := r.db.Txn(ctx, true)
+	row, err := txn.First("invitations", "id", tokenHash)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.AcceptInvitation(), error from txn.First()", err)
+		return models.UserWorkspace{}, err
+	}
+	invitation, ok := row.(*models.Invitation)
+	if !ok || !now.Before(invitation.ExpiresAt) || !strings.EqualFold(invitation.Email, email) {
+		txn.Abort()
+		return models.UserWorkspace{}, models.ErrInvalidInvitation
+	}
+	workspace, err := getWorkspace(txn, invitation.WorkspaceId)
+	if err != nil {
+		txn.Abort()
+		return models.UserWorkspace{}, models.ErrInvalidInvitation
+	}
+	if _, err = getMembership(txn, workspace.Id, email); err == nil {
+		txn.Abort()
+		return models.UserWorkspace{}, models.ErrAlreadyMember
+	}
+	err = txn.Delete("invitations", invitation)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.AcceptInvitation(), error from txn.Delete()", err)
+		return models.UserWorkspace{}, err
+	}
+	err = txn.Insert("memberships", &models.Membership{WorkspaceId: workspace.Id, Email: email, Role: invitation.Role, JoinedAt: now})
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.AcceptInvitation(), error from txn.Insert()", err)
+		return models.UserWorkspace{}, err
+	}
+	txn.Commit()
+	return models.UserWorkspace{Workspace: workspace, Role: invitation.Role}, nil
+}
+
+// RemoveMember - removes the member with the email from the workspace along with their notes in it. Members can
+// remove themselves, the others are removed by a member allowed to manage their role.
+func (r *workspaceRepository) RemoveMember(ctx context.Context, workspace int32, actor, email string) error {
+	r.logger.Info(ctx, "Entering workspaceRepository.RemoveMember()")
+	defer r.logger.Info(ctx, "Exiting workspaceRepository.RemoveMember()")
+	txn := r.db.Txn(ctx, true)
+	membership, err := getMembership(txn, workspace, actor)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.RemoveMember(), error from getMembership()", err)
+		return models.ErrWorkspaceNotFound
+	}
+	member, err := getMembership(txn, workspace, email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.RemoveMember(), error from getMembership()", err)
+		return err
+	}
+	if member.Role == models.WorkspaceRoleOwner {
+		txn.Abort()
+		return models.ErrRemoveOwner
+	}
+	if member.Email != membership.Email && !membership.CanManage(member.Role) {
+		txn.Abort()
+		return models.ErrWorkspaceForbidden
+	}
+	err = txn.Delete("memberships", &member)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.RemoveMember(), error from txn.Delete()", err)
+		return err
+	}
+	err = deleteWorkspaceNotes(ctx, txn, workspace, member.Email)
+	if err != nil {
+		txn.Abort()
+		r.logger.Warn(ctx, "error in workspaceRepository.RemoveMember(), error from deleteWorkspaceNotes()", err)
+		return err
+	}
+	txn.Commit()
+	return nil
+}
+
+// addPersonalWorkspace - creates the personal workspace of a new user, which their sessions start in
+func addPersonalWorkspace(txn db.MemDbTxn, user *models.User) error {
+	workspace := models.Workspace{Id: utils.NewID(), Name: models.PersonalWorkspaceName, Personal: true}
+	if err := insertWorkspace(txn, user.Email, workspace); err != nil {
+		return err
+	}
+	user.DefaultWorkspace = workspace.Id
+	return nil
+}
+
+// insertWorkspace - stores the workspace with the user with the email as its owner
+func insertWorkspace(txn db.MemDbTxn, email string, workspace models.Workspace) error {
+	if err := txn.Insert("workspaces", &workspace); err != nil {
+		return err
+	}
+	return txn.Insert("memberships", &models.Membership{WorkspaceId: workspace.Id, Email: email, Role: models.WorkspaceRoleOwner, JoinedAt: time.Now()})
+}
+
+func getWorkspace(txn db.MemDbTxn, id int32) (models.Workspace, error) {
+	row, err := txn.First("workspaces", "id", id)
+	if err != nil {
+		return models.Workspace{}, err
+	}
+	workspace, ok := row.(*models.Workspace)
+	if !ok {
+		return models.Workspace{}, models.ErrWorkspaceNotFound
+	}
+	return *workspace, nil
+}
+
+// getMembership - the membership of the user in the workspace, ErrNotWorkspaceMember when they are not a member
+func getMembership(txn db.MemDbTxn, workspace int32, email string) (models.Membership, error) {
+	row, err := txn.First("memberships", "id", workspace, email)
+	if err != nil {
+		return models.Membership{}, err
+	}
+	membership, ok := row.(*models.Membership)
+	if !ok {
+		return models.Membership{}, models.ErrNotWorkspaceMember
+	}
+	return *membership, nil
+}
+
+// getMemberships - copies of the memberships found through index, which can be modified and inserted back
+func getMemberships(txn db.MemDbTxn, index string, args ...interface{}) ([]models.Membership, error) {
+	rows, err := txn.Get("memberships", index, args...)
+	if err != nil {
+		return nil, err
+	}
+	memberships := make([]models.Membership, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		memberships = append(memberships, *obj.(*models.Membership))
+	}
+	return memberships, nil
+}
+
+// deleteInvitations - removes the invitations to the workspace matching drop
+func deleteInvitations(txn db.MemDbTxn, workspace int32, drop func(models.Invitation) bool) error {
+	rows, err := txn.Get("invitations", "workspace", workspace)
+	if err != nil {
+		return err
+	}
+	invitations := make([]models.Invitation, 0)
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		if invitation := obj.(*models.Invitation); drop(*invitation) {
+			invitations = append(invitations, *invitation)
+		}
+	}
+	for i := range invitations {
+		if err = txn.Delete("invitations", &invitations[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteWorkspaceNotes - deletes the notes of the user in the workspace and writes their deletion to the audit
+// log, their links only ever point to notes of the same user in the same workspace
+func deleteWorkspaceNotes(ctx context.Context, txn db.MemDbTxn, workspace int32, email string) error {
+	rows, err := txn.Get("notes", "workspace_owner", workspace, email)
+	if err != nil {
+		return err
+	}
+	notes := make([]models.Note, 0)
+	var size int64
+	for obj := rows.Next(); obj != nil; obj = rows.Next() {
+		note := *obj.(*models.Note)
+		notes = append(notes, note)
+		size += int64(note.Size)
+	}
+	links, err := getOwnedLinks(txn, notes)
+	if err != nil {
+		return err
+	}
+	for i := range links {
+		if err = txn.Delete("links", &links[i]); err != nil {
+			return err
+		}
+	}
+	if err = deleteNotes(ctx, txn, notes); err != nil {
+		return err
+	}
+	if len(notes) == 0 {
+		return nil
+	}
+	return addUsage(txn, email, -len(notes), -size)
+}
+
+// moveMemberships - gives the memberships of email to newEmail
+func moveMemberships(txn db.MemDbTxn, email, newEmail string) error {
+	memberships, err := getMemberships(txn, "email", email)
+	if err != nil {
+		return err
+	}
+	for i := range memberships {
+		if err = txn.Delete("memberships", &memberships[i]); err != nil {
+			return err
+		}
+		memberships[i].Email = newEmail
+		if err = txn.Insert("memberships", &memberships[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteMemberships - removes the user with the email from all their workspaces. The workspaces they owned go to
+// the admin, or else the member, who joined first, and are deleted when no one is left.
+func deleteMemberships(txn db.MemDbTxn, email string) error {
+	memberships, err := getMemberships(txn, "email", email)
+	if err != nil {
+		return err
+	}
+	for i := range memberships {
+		if err = txn.Delete("memberships", &memberships[i]); err != nil {
+			return err
+		}
+		if memberships[i].Role != models.WorkspaceRoleOwner {
+			continue
+		}
+		if err = transferWorkspace(txn, memberships[i].WorkspaceId); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// transferWorkspace - makes the admin, or else the member, who joined first the owner of a workspace that lost
+// its owner, deletes the workspace and its invitations when it has no member left
+func transferWorkspace(txn db.MemDbTxn, workspace int32) error {
+	members, err := getMemberships(txn, "workspace", workspace)
+	if err != nil {
+		return err
+	}
+	if len(members) == 0 {
+		if err = deleteInvitations(txn, workspace, func(models.Invitation) bool { return true }); err != nil {
+			return err
+		}
+		return txn.Delete("workspaces", &models.Workspace{Id: workspace})
+	}
+	sort.Slice(members, func(i, j int) bool {
+		if (members[i].Role == models.WorkspaceRoleAdmin) != (members[j].Role == models.WorkspaceRoleAdmin) {
+			return members[i].Role == models.WorkspaceRoleAdmin
+		}
+		return members[i].JoinedAt.Before(members[j].JoinedAt)
+	})
+	members[0].Role = models.WorkspaceRoleOwner
+	return txn.Insert("memberships", &members[0])
+}
@@ -0,0 +1,321 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"testing"
+	"time"
+)
+
+// seedWorkspace - signs up the owner and the members and makes them members of a new workspace with the role.
+// Returns the workspace.
+func seedWorkspace(t *testing.T, owner string, members map[string]string) models.Workspace {
+	t.Helper()
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	loginRepository := NewLoginRepository(db.NewDB(), logger)
+	r := NewWorkspaceRepository(db.NewDB(), logger)
+	workspace := models.Workspace{Id: utils.NewID(), Name: "Team"}
+	for _, email := range append([]string{owner}, keys(members)...) {
+		if err := loginRepository.SignUp(ctx, models.SignUpRequest{Email: email, Name: "test", Password: "password"}); err != nil {
+			t.Fatalf("loginRepository.SignUp() error = %v", err)
+		}
+	}
+	if err := r.CreateWorkspace(ctx, owner, workspace); err != nil {
+		t.Fatalf("workspaceRepository.CreateWorkspace() error = %v", err)
+	}
+	now := time.Now()
+	for email, role := range members {
+		invitation := models.Invitation{TokenHash: utils.HashToken(email), WorkspaceId: workspace.Id, Email: email, Role: role, InvitedBy: owner, ExpiresAt: now.Add(time.Hour)}
+		if _, err := r.AddInvitation(ctx, owner, invitation, now); err != nil {
+			t.Fatalf("workspaceRepository.AddInvitation() error = %v", err)
+		}
+		if _, err := r.AcceptInvitation(ctx, invitation.TokenHash, email, now); err != nil {
+			t.Fatalf("workspaceRepository.AcceptInvitation() error = %v", err)
+		}
+	}
+	return workspace
+}
+
+func keys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func Test_workspaceRepository_isolation(t *testing.T) {
+	const (
+		alice = "alice@tenants.io"
+		bob   = "bob@tenants.io"
+	)
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	team := seedWorkspace(t, alice, map[string]string{bob: models.WorkspaceRoleMember})
+	user, err := NewLoginRepository(db.NewDB(), logger).GetUser(ctx, alice)
+	if err != nil {
+		t.Fatalf("loginRepository.GetUser() error = %v", err)
+	}
+	personal := user.DefaultWorkspace
+	r := NewNotesRepository(db.NewDB(), logger, nil)
+	addNote := func(workspace int32, email, title string) int32 {
+		id, err := r.AddNote(ctx, models.AddNoteRequest{Email: email, Workspace: workspace, Title: title, Note: "plan of " + email})
+		if err != nil {
+			t.Fatalf("notesRepository.AddNote() error = %v", err)
+		}
+		return id
+	}
+	// the same title can be used in every workspace
+	personalNote := addNote(personal, alice, "Plan")
+	teamNote := addNote(team.Id, alice, "Plan")
+	bobNote := addNote(team.Id, bob, "Plan")
+
+	for _, tt := range []struct {
+		workspace int32
+		email     string
+		want      int32
+	}{
+		{personal, alice, personalNote},
+		{team.Id, alice, teamNote},
+		{team.Id, bob, bobNote},
+	} {
+		notes, err := r.GetNotes(ctx, models.GetNotesRequest{Email: tt.email, Workspace: tt.workspace})
+		if err != nil || len(notes) != 1 || notes[0].Id != tt.want {
+			t.Errorf("notesRepository.GetNotes(%d, %s) = %v, %v, want note %d", tt.workspace, tt.email, notes, err, tt.want)
+		}
+		notes, err = r.SearchNotes(ctx, models.SearchNotesRequest{Email: tt.email, Workspace: tt.workspace, Query: "plan"})
+		if err != nil || len(notes) != 1 || notes[0].Id != tt.want {
+			t.Errorf("notesRepository.SearchNotes(%d, %s) = %v, %v, want note %d", tt.workspace, tt.email, notes, err, tt.want)
+		}
+		streamed := make([]int32, 0)
+		err = r.StreamNotes(ctx, tt.workspace, tt.email, func(note models.Note) error {
+			streamed = append(streamed, note.Id)
+			return nil
+		})
+		if err != nil || len(streamed) != 1 || streamed[0] != tt.want {
+			t.Errorf("notesRepository.StreamNotes(%d, %s) = %v, %v, want note %d", tt.workspace, tt.email, streamed, err, tt.want)
+		}
+	}
+
+	// a note is out of reach from another workspace, and from the other members of its workspace
+	for _, tt := range []struct {
+		workspace int32
+		email     string
+		id        int32
+	}{
+		{team.Id, alice, personalNote},
+		{personal, alice, teamNote},
+		{team.Id, bob, teamNote},
+		{personal, bob, personalNote},
+	} {
+		if _, err := r.GetNote(ctx, tt.workspace, tt.email, tt.id); err == nil {
+			t.Errorf("notesRepository.GetNote(%d, %s) returned note %d", tt.workspace, tt.email, tt.id)
+		}
+		title := "Renamed"
+		if err := r.UpdateNote(ctx, models.UpdateNoteRequest{Email: tt.email, Workspace: tt.workspace, Id: tt.id, Title: &title}); err == nil {
+			t.Errorf("notesRepository.UpdateNote(%d, %s) updated note %d", tt.workspace, tt.email, tt.id)
+		}
+		if err := r.DeleteNote(ctx, tt.workspace, tt.email, tt.id); err == nil {
+			t.Errorf("notesRepository.DeleteNote(%d, %s) deleted note %d", tt.workspace, tt.email, tt.id)
+		}
+	}
+	if note, err := r.GetNote(ctx, personal, alice, personalNote); err != nil || note.Title != "Plan" {
+		t.Errorf("notesRepository.GetNote() = %+v, %v", note, err)
+	}
+}
+
+func Test_workspaceRepository_invitations(t *testing.T) {
+	const (
+		owner   = "owner@invitations.io"
+		admin   = "admin@invitations.io"
+		member  = "member@invitations.io"
+		invitee = "invitee@invitations.io"
+	)
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	team := seedWorkspace(t, owner, map[string]string{admin: models.WorkspaceRoleAdmin, member: models.WorkspaceRoleMember})
+	if err := NewLoginRepository(db.NewDB(), logger).SignUp(ctx, models.SignUpRequest{Email: invitee, Name: "test", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	user, err := NewLoginRepository(db.NewDB(), logger).GetUser(ctx, owner)
+	if err != nil {
+		t.Fatalf("loginRepository.GetUser() error = %v", err)
+	}
+	r := NewWorkspaceRepository(db.NewDB(), logger)
+	now := time.Now()
+	invite := func(inviter string, workspace int32, role, token string, expiresAt time.Time) error {
+		_, err := r.AddInvitation(ctx, inviter, models.Invitation{TokenHash: token, WorkspaceId: workspace, Email: "Invitee@Invitations.io", Role: role, InvitedBy: inviter, ExpiresAt: expiresAt}, now)
+		return err
+	}
+
+	for _, tt := range []struct {
+		name      string
+		inviter   string
+		workspace int32
+		role      string
+		wantErr   error
+	}{
+		{"member", member, team.Id, models.WorkspaceRoleMember, models.ErrWorkspaceForbidden},
+		{"admin inviting an admin", admin, team.Id, models.WorkspaceRoleAdmin, models.ErrWorkspaceForbidden},
+		{"not a member", invitee, team.Id, models.WorkspaceRoleMember, models.ErrWorkspaceNotFound},
+		{"personal workspace", owner, user.DefaultWorkspace, models.WorkspaceRoleMember, models.ErrPersonalWorkspace},
+	} {
+		if err := invite(tt.inviter, tt.workspace, tt.role, "refused", now.Add(time.Hour)); !errors.Is(err, tt.wantErr) {
+			t.Errorf("workspaceRepository.AddInvitation() by %s error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if _, err := r.AddInvitation(ctx, owner, models.Invitation{TokenHash: "member", WorkspaceId: team.Id, Email: "MEMBER@invitations.io", Role: models.WorkspaceRoleMember, ExpiresAt: now.Add(time.Hour)}, now); !errors.Is(err, models.ErrAlreadyMember) {
+		t.Errorf("workspaceRepository.AddInvitation() of a member error = %v, want %v", err, models.ErrAlreadyMember)
+	}
+
+	if err := invite(admin, team.Id, models.WorkspaceRoleMember, "expired", now.Add(-time.Minute)); err != nil {
+		t.Fatalf("workspaceRepository.AddInvitation() error = %v", err)
+	}
+	if _, err := r.AcceptInvitation(ctx, "expired", invitee, now); !errors.Is(err, models.ErrInvalidInvitation) {
+		t.Errorf("workspaceRepository.AcceptInvitation() of an expired invitation error = %v", err)
+	}
+	if err := invite(admin, team.Id, models.WorkspaceRoleMember, "first", now.Add(time.Hour)); err != nil {
+		t.Fatalf("workspaceRepository.AddInvitation() error = %v", err)
+	}
+	if err := invite(owner, team.Id, models.WorkspaceRoleAdmin, "second", now.Add(time.Hour)); err != nil {
+		t.Fatalf("workspaceRepository.AddInvitation() error = %v", err)
+	}
+	if _, err := r.AcceptInvitation(ctx, "first", invitee, now); !errors.Is(err, models.ErrInvalidInvitation) {
+		t.Errorf("workspaceRepository.AcceptInvitation() of a replaced invitation error = %v", err)
+	}
+	if _, err := r.AcceptInvitation(ctx, "second", member, now); !errors.Is(err, models.ErrInvalidInvitation) {
+		t.Errorf("workspaceRepository.AcceptInvitation() by another user error = %v", err)
+	}
+	// the address of the invitation is matched regardless of its case
+	joined, err := r.AcceptInvitation(ctx, "second", invitee, now)
+	if err != nil || joined.Id != team.Id || joined.Role != models.WorkspaceRoleAdmin {
+		t.Fatalf("workspaceRepository.AcceptInvitation() = %+v, %v", joined, err)
+	}
+	if _, err = r.AcceptInvitation(ctx, "second", invitee, now); !errors.Is(err, models.ErrInvalidInvitation) {
+		t.Errorf("workspaceRepository.AcceptInvitation() accepted an invitation twice")
+	}
+
+	members, err := r.GetMembers(ctx, team.Id, member)
+	if err != nil || len(members) != 4 || members[0].Email != owner || members[3].Email != invitee {
+		t.Errorf("workspaceRepository.GetMembers() = %+v, %v", members, err)
+	}
+	if _, err = r.GetMembers(ctx, user.DefaultWorkspace, member); !errors.Is(err, models.ErrWorkspaceNotFound) {
+		t.Errorf("workspaceRepository.GetMembers() of another workspace error = %v", err)
+	}
+	workspaces, err := r.GetWorkspaces(ctx, invitee)
+	if err != nil || len(workspaces) != 2 || !workspaces[0].Personal || workspaces[1].Id != team.Id {
+		t.Errorf("workspaceRepository.GetWorkspaces() = %+v, %v", workspaces, err)
+	}
+}
+
+func Test_workspaceRepository_RemoveMember(t *testing.T) {
+	const (
+		owner  = "owner@removal.io"
+		admin  = "admin@removal.io"
+		member = "member@removal.io"
+		leaver = "leaver@removal.io"
+	)
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	team := seedWorkspace(t, owner, map[string]string{admin: models.WorkspaceRoleAdmin, member: models.WorkspaceRoleMember, leaver: models.WorkspaceRoleMember})
+	notesRepository := NewNotesRepository(db.NewDB(), logger, nil)
+	ids := make([]int32, 0)
+	for _, title := range []string{"Index", "see [[Index]]"} {
+		id, err := notesRepository.AddNote(ctx, models.AddNoteRequest{Email: member, Workspace: team.Id, Title: title, Note: "[[Index]]"})
+		if err != nil {
+			t.Fatalf("notesRepository.AddNote() error = %v", err)
+		}
+		ids = append(ids, id)
+	}
+	r := NewWorkspaceRepository(db.NewDB(), logger)
+
+	for _, tt := range []struct {
+		name    string
+		actor   string
+		email   string
+		wantErr error
+	}{
+		{"the owner", admin, owner, models.ErrRemoveOwner},
+		{"the owner by themselves", owner, owner, models.ErrRemoveOwner},
+		{"a user who is not a member", admin, "stranger@removal.io", models.ErrNotWorkspaceMember},
+		{"an admin by a member", member, admin, models.ErrWorkspaceForbidden},
+		{"a member by a member", leaver, member, models.ErrWorkspaceForbidden},
+		{"by a stranger", "stranger@removal.io", member, models.ErrWorkspaceNotFound},
+	} {
+		if err := r.RemoveMember(ctx, team.Id, tt.actor, tt.email); !errors.Is(err, tt.wantErr) {
+			t.Errorf("workspaceRepository.RemoveMember() of %s error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+	}
+	if err := r.RemoveMember(ctx, team.Id, leaver, leaver); err != nil {
+		t.Errorf("workspaceRepository.RemoveMember() of themselves error = %v", err)
+	}
+	if err := r.RemoveMember(ctx, team.Id, admin, member); err != nil {
+		t.Fatalf("workspaceRepository.RemoveMember() error = %v", err)
+	}
+
+	// the sessions of a removed member end, and their notes in the workspace are gone
+	loginRepository := NewLoginRepository(db.NewDB(), logger)
+	if _, err := loginRepository.ValidateUser(ctx, member, team.Id, 0); !errors.Is(err, models.ErrNotWorkspaceMember) {
+		t.Errorf("loginRepository.ValidateUser() of a removed member error = %v", err)
+	}
+	if _, _, err := loginRepository.GetMember(ctx, team.Id, member); !errors.Is(err, models.ErrNotWorkspaceMember) {
+		t.Errorf("loginRepository.GetMember() of a removed member error = %v", err)
+	}
+	if notes, err := notesRepository.GetNotes(ctx, models.GetNotesRequest{Email: member, Workspace: team.Id}); err != nil || len(notes) != 0 {
+		t.Errorf("notesRepository.GetNotes() of a removed member = %v, %v", notes, err)
+	}
+	if usage, err := notesRepository.GetUsage(ctx, member); err != nil || usage.Notes != 0 || usage.Bytes != 0 {
+		t.Errorf("notesRepository.GetUsage() of a removed member = %+v, %v", usage, err)
+	}
+	for _, id := range ids {
+		if entry := lastAuditEntry(t, models.AuditNoteDeleted, models.AuditTarget("note", id)); entry.Before["workspace"] != team.Id || entry.After != nil {
+			t.Errorf("deletion entry of note %d = %+v", id, entry)
+		}
+	}
+	members, err := r.GetMembers(ctx, team.Id, owner)
+	if err != nil || len(members) != 2 {
+		t.Errorf("workspaceRepository.GetMembers() = %+v, %v", members, err)
+	}
+}
+
+func Test_workspaceRepository_deleteOwner(t *testing.T) {
+	const (
+		owner  = "owner@ownership.io"
+		admin  = "admin@ownership.io"
+		member = "member@ownership.io"
+	)
+	ctx := context.Background()
+	logger := loggers.NewLogger()
+	team := seedWorkspace(t, owner, map[string]string{member: models.WorkspaceRoleMember, admin: models.WorkspaceRoleAdmin})
+	alone := seedWorkspace(t, "alone@ownership.io", nil)
+	accountRepository := NewAccountRepository(db.NewDB(), logger, nil)
+	r := NewWorkspaceRepository(db.NewDB(), logger)
+
+	// the admin takes the workspace over from its deleted owner, before the member who joined earlier
+	if err := accountRepository.DeleteAccount(ctx, models.DeleteAccountRequest{Email: owner, Password: "password"}); err != nil {
+		t.Fatalf("accountRepository.DeleteAccount() error = %v", err)
+	}
+	members, err := r.GetMembers(ctx, team.Id, member)
+	if err != nil || len(members) != 2 {
+		t.Fatalf("workspaceRepository.GetMembers() = %+v, %v", members, err)
+	}
+	for _, membership := range members {
+		if want := map[string]string{admin: models.WorkspaceRoleOwner, member: models.WorkspaceRoleMember}[membership.Email]; membership.Role != want {
+			t.Errorf("role of %s = %s, want %s", membership.Email, membership.Role, want)
+		}
+	}
+	// a workspace without members is deleted
+	if err = accountRepository.DeleteAccount(ctx, models.DeleteAccountRequest{Email: "alone@ownership.io", Password: "password"}); err != nil {
+		t.Fatalf("accountRepository.DeleteAccount() error = %v", err)
+	}
+	txn := db.NewDB().Txn(ctx, false)
+	defer txn.Abort()
+	if _, err = getWorkspace(txn, alone.Id); !errors.Is(err, models.ErrWorkspaceNotFound) {
+		t.Errorf("getWorkspace() of a workspace without members error = %v", err)
+	}
+}
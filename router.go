@@ -1,15 +1,19 @@
 package main
 
 import (
+	"notes-server/constants"
 	"notes-server/db"
 	"notes-server/loggers"
 	"notes-server/middlewares"
+	"notes-server/models"
 	"notes-server/repositories"
+	"strings"
 	"sync"
 
 	"github.com/go-chi/chi"
 	"github.com/go-chi/chi/middleware"
 	"github.com/go-chi/cors"
+	"github.com/spf13/viper"
 )
 
 type IChiRouter interface {
@@ -20,7 +24,17 @@ type router struct{}
 
 func (router *router) InitRouter() *chi.Mux {
 	notesController := ServiceContainer().InjectNotesController()
+	templatesController := ServiceContainer().InjectTemplatesController()
+	keysController := ServiceContainer().InjectKeysController()
 	loginController := ServiceContainer().InjectLoginController()
+	remindersController := ServiceContainer().InjectRemindersController()
+	accountController := ServiceContainer().InjectAccountController()
+	accessTokensController := ServiceContainer().InjectAccessTokensController()
+	adminController := ServiceContainer().InjectAdminController()
+	workspacesController := ServiceContainer().InjectWorkspacesController()
+	auditController := ServiceContainer().InjectAuditController()
+	signingKeysController := ServiceContainer().InjectSigningKeysController()
+	signingKeys := ServiceContainer().InjectSigningKeyring()
 
 	r := chi.NewRouter()
 	cors := cors.New(cors.Options{
@@ -30,20 +44,95 @@ func (router *router) InitRouter() *chi.Mux {
 		MaxAge:           300,
 	})
 	logger := loggers.NewLogger()
+	r.With(cors.Handler).Get("/.well-known/jwks.json", signingKeysController.GetJWKS)
 	r.Route("/v1/api", func(r chi.Router) {
 		r.Use(cors.Handler)
 		r.Group(func(r chi.Router) {
 			r.Use(middlewares.RequestID)
+			r.Use(middlewares.ClientIP(strings.Split(viper.GetString(constants.TrustedProxiesEnvKey), ",")))
+			r.Use(middlewares.UserAgent)
 			r.Use(middleware.Recoverer)
 			r.Use(middleware.Logger)
 			r.Use(cors.Handler)
 			r.Post("/signup", loginController.SignUp)
 			r.Post("/login", loginController.Login)
+			r.Post("/login/mfa", loginController.LoginMFA)
+			r.Post("/setup", adminController.Setup)
+			r.Get("/verify-email", loginController.VerifyEmail)
+			r.Post("/verify-email/resend", loginController.ResendVerificationEmail)
+			r.Post("/password/forgot", loginController.ForgotPassword)
+			r.Post("/password/reset", loginController.ResetPassword)
+			r.Get("/account/verify-email", accountController.ConfirmEmailChange)
+			r.Post("/oidc/login", loginController.StartOIDCLogin)
+			r.Get("/oidc/callback", loginController.OIDCCallback)
 			r.Route("/", func(r chi.Router) {
-				r.Use(middlewares.TokenValidation(repositories.NewLoginRepository(db.NewDB(), logger), logger))
-				r.Post("/notes", notesController.GetNotes) // need to make is post to send token in body
-				r.Post("/note", notesController.AddNote)
-				r.Delete("/note", notesController.DeleteNote)
+				r.Use(middlewares.TokenValidation(repositories.NewLoginRepository(db.NewDB(), logger), repositories.NewAccessTokensRepository(db.NewDB(), logger), signingKeys, logger))
+				// the users who must change their password can do nothing else
+				r.With(middlewares.RequireScope()).Post("/account/password", accountController.ChangePassword)
+				r.Group(func(r chi.Router) {
+					r.Use(middlewares.RequirePasswordChanged)
+					r.Group(func(r chi.Router) {
+						r.Use(middlewares.RequireScope(models.ScopeNotesRead))
+						r.Post("/notes", notesController.GetNotes) // need to make is post to send token in body
+						r.Post("/note/links", notesController.GetNoteLinks)
+						r.Post("/notes/search", notesController.SearchNotes)
+						r.Post("/notes/export", notesController.ExportNotes)
+						r.Post("/notes/usage", notesController.GetUsage)
+						r.Post("/notifications", remindersController.GetNotifications)
+					})
+					r.Group(func(r chi.Router) {
+						r.Use(middlewares.RequireScope(models.ScopeNotesWrite))
+						r.Post("/note", notesController.AddNote)
+						r.Put("/note", notesController.UpdateNote)
+						r.Delete("/note", notesController.DeleteNote)
+						r.Post("/note/from-template", notesController.CreateNoteFromTemplate)
+						r.Post("/note/pin", notesController.PinNote)
+						r.Post("/note/archive", notesController.ArchiveNote)
+						r.Post("/note/star", notesController.StarNote)
+						r.Post("/note/color", notesController.SetNoteColor)
+						r.Post("/notes/import", notesController.ImportNotes)
+						r.Post("/note/items", notesController.AddChecklistItem)
+						r.Post("/note/items/reorder", notesController.ReorderChecklistItems)
+						r.Post("/note/items/toggle", notesController.ToggleChecklistItem)
+						r.Delete("/note/items", notesController.RemoveChecklistItem)
+						r.Post("/note/reminder", remindersController.SetReminder)
+						r.Post("/note/reminder/snooze", remindersController.SnoozeReminder)
+					})
+					r.Group(func(r chi.Router) {
+						r.Use(middlewares.RequireScope())
+						r.Post("/keys", keysController.GetKeyMaterial)
+						r.Put("/keys", keysController.SetKeyMaterial)
+						r.Post("/templates", templatesController.GetTemplates)
+						r.Post("/template", templatesController.AddTemplate)
+						r.Delete("/template", templatesController.DeleteTemplate)
+						r.Post("/account", accountController.GetAccount)
+						r.Put("/account", accountController.UpdateAccount)
+						r.Delete("/account", accountController.DeleteAccount)
+						r.Post("/account/mfa/enroll", accountController.EnrollMFA)
+						r.Post("/account/mfa/confirm", accountController.ConfirmMFA)
+						r.Post("/account/mfa/recovery-codes", accountController.RegenerateRecoveryCodes)
+						r.Post("/account/mfa/disable", accountController.DisableMFA)
+						r.Post("/tokens", accessTokensController.GetAccessTokens)
+						r.Post("/token", accessTokensController.CreateAccessToken)
+						r.Delete("/token", accessTokensController.RevokeAccessToken)
+						r.Post("/workspaces", workspacesController.GetWorkspaces)
+						r.Post("/workspace", workspacesController.CreateWorkspace)
+						r.Post("/workspace/switch", workspacesController.SwitchWorkspace)
+						r.Post("/workspace/members", workspacesController.GetMembers)
+						r.Post("/workspace/invite", workspacesController.InviteMember)
+						r.Post("/workspace/join", workspacesController.JoinWorkspace)
+						r.Delete("/workspace/member", workspacesController.RemoveMember)
+					})
+					r.Group(func(r chi.Router) {
+						r.Use(middlewares.RequireScope())
+						r.Use(middlewares.RequireRole(models.RoleAdmin))
+						r.Post("/admin/users", adminController.ListUsers)
+						r.Post("/admin/user/disable", adminController.DisableUser)
+						r.Post("/admin/user/enable", adminController.EnableUser)
+						r.Post("/admin/user/password-reset", adminController.ForcePasswordReset)
+						r.Post("/admin/audit", auditController.GetAuditLog)
+					})
+				})
 			})
 		})
 	})
@@ -0,0 +1,190 @@
+package scheduler
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Frequency string
+
+const (
+	Daily   Frequency = "DAILY"
+	Weekly  Frequency = "WEEKLY"
+	Monthly Frequency = "MONTHLY"
+)
+
+// maxMonthLookahead - number of intervals searched for a month that has the day of the first occurrence
+const maxMonthLookahead = 48
+
+var weekdays = map[string]time.Weekday{
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+	"SU": time.Sunday,
+}
+
+// Recurrence - a repeat rule for reminders, either one of the "daily", "weekly" and "monthly" shorthands or
+// the FREQ, INTERVAL, BYDAY (weekly only), COUNT and UNTIL parts of an RFC 5545 RRULE
+type Recurrence struct {
+	Freq     Frequency
+	Interval int
+	ByDay    []time.Weekday
+	Count    int
+	Until    *time.Time
+}
+
+// ParseRecurrence - parses a recurrence rule, an empty rule means the reminder does not repeat and returns nil
+func ParseRecurrence(rule string) (*Recurrence, error) {
+	rule = strings.TrimSpace(rule)
+	if rule == "" {
+		return nil, nil
+	}
+	switch strings.ToLower(rule) {
+	case "daily":
+		return &Recurrence{Freq: Daily, Interval: 1}, nil
+	case "weekly":
+		return &Recurrence{Freq: Weekly, Interval: 1}, nil
+	case "monthly":
+		return &Recurrence{Freq: Monthly, Interval: 1}, nil
+	}
+	rule = strings.TrimPrefix(strings.ToUpper(rule), "RRULE:")
+	recurrence := &Recurrence{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 || kv[1] == "" {
+			return nil, fmt.Errorf("invalid recurrence part %q", part)
+		}
+		key, value := kv[0], kv[1]
+		switch key {
+		case "FREQ":
+			switch Frequency(value) {
+			case Daily, Weekly, Monthly:
+				recurrence.Freq = Frequency(value)
+			default:
+				return nil, fmt.Errorf("unsupported recurrence frequency %q", value)
+			}
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid recurrence interval %q", value)
+			}
+			recurrence.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid recurrence count %q", value)
+			}
+			recurrence.Count = count
+		case "UNTIL":
+			until, err := parseUntil(value)
+			if err != nil {
+				return nil, err
+			}
+			recurrence.Until = &until
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				weekday, ok := weekdays[day]
+				if !ok {
+					return nil, fmt.Errorf("invalid recurrence day %q", day)
+				}
+				recurrence.ByDay = append(recurrence.ByDay, weekday)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported recurrence part %q", key)
+		}
+	}
+	if recurrence.Freq == "" {
+		return nil, errors.New("recurrence is missing FREQ")
+	}
+	if len(recurrence.ByDay) > 0 && recurrence.Freq != Weekly {
+		return nil, errors.New("BYDAY is only supported with FREQ=WEEKLY")
+	}
+	if recurrence.Count > 0 && recurrence.Until != nil {
+		return nil, errors.New("recurrence can not have both COUNT and UNTIL")
+	}
+	return recurrence, nil
+}
+
+func parseUntil(value string) (time.Time, error) {
+	for _, layout := range []string{"20060102T150405Z", "20060102"} {
+		if until, err := time.Parse(layout, value); err == nil {
+			if layout == "20060102" {
+				// a date only UNTIL includes the whole day
+				until = until.Add(24*time.Hour - time.Second)
+			}
+			return until, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid recurrence until %q", value)
+}
+
+// Next - returns the first occurrence after now that follows prev, sent is the number of occurrences already
+// fired. Occurrences missed while the server was down are skipped so that only one reminder is fired for them.
+func (r *Recurrence) Next(prev time.Time, sent int, now time.Time) (time.Time, bool) {
+	if r.Count > 0 && sent >= r.Count {
+		return time.Time{}, false
+	}
+	next := r.step(prev)
+	for !next.After(now) {
+		next = r.step(next)
+	}
+	if r.Until != nil && next.After(*r.Until) {
+		return time.Time{}, false
+	}
+	return next, true
+}
+
+func (r *Recurrence) step(t time.Time) time.Time {
+	switch r.Freq {
+	case Daily:
+		return t.AddDate(0, 0, r.Interval)
+	case Weekly:
+		if len(r.ByDay) == 0 {
+			return t.AddDate(0, 0, 7*r.Interval)
+		}
+		return r.nextWeekday(t)
+	default:
+		for i := 1; i <= maxMonthLookahead; i++ {
+			next := time.Date(t.Year(), t.Month()+time.Month(i*r.Interval), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+			if next.Day() == t.Day() {
+				return next
+			}
+		}
+		return t.AddDate(0, r.Interval, 0)
+	}
+}
+
+// nextWeekday - next day listed in BYDAY, first in the rest of the week of t and then in the week interval weeks later
+func (r *Recurrence) nextWeekday(t time.Time) time.Time {
+	// weeks start on monday as in RFC 5545
+	offset := (int(t.Weekday()) + 6) % 7
+	for i := offset + 1; i < 7; i++ {
+		day := t.AddDate(0, 0, i-offset)
+		if r.matchesDay(day.Weekday()) {
+			return day
+		}
+	}
+	monday := t.AddDate(0, 0, 7*r.Interval-offset)
+	for i := 0; i < 7; i++ {
+		day := monday.AddDate(0, 0, i)
+		if r.matchesDay(day.Weekday()) {
+			return day
+		}
+	}
+	return monday
+}
+
+func (r *Recurrence) matchesDay(weekday time.Weekday) bool {
+	for _, day := range r.ByDay {
+		if day == weekday {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,136 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	tests := []struct {
+		name    string
+		rule    string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "empty rule", rule: "", wantNil: true},
+		{name: "daily shorthand", rule: "daily"},
+		{name: "weekly shorthand", rule: "Weekly"},
+		{name: "rrule with prefix", rule: "RRULE:FREQ=DAILY;INTERVAL=2"},
+		{name: "weekly by day", rule: "FREQ=WEEKLY;BYDAY=MO,WE,FR"},
+		{name: "count", rule: "FREQ=MONTHLY;COUNT=3"},
+		{name: "until", rule: "FREQ=DAILY;UNTIL=20300101T000000Z"},
+		{name: "failure case - missing freq", rule: "INTERVAL=2", wantErr: true},
+		{name: "failure case - unsupported freq", rule: "FREQ=SECONDLY", wantErr: true},
+		{name: "failure case - invalid interval", rule: "FREQ=DAILY;INTERVAL=0", wantErr: true},
+		{name: "failure case - invalid day", rule: "FREQ=WEEKLY;BYDAY=XX", wantErr: true},
+		{name: "failure case - by day with daily", rule: "FREQ=DAILY;BYDAY=MO", wantErr: true},
+		{name: "failure case - count and until", rule: "FREQ=DAILY;COUNT=2;UNTIL=20300101", wantErr: true},
+		{name: "failure case - unsupported part", rule: "FREQ=DAILY;BYHOUR=2", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRecurrence(tt.rule)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ParseRecurrence() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !tt.wantErr && (got == nil) != tt.wantNil {
+				t.Errorf("ParseRecurrence() = %v, wantNil %v", got, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestRecurrence_Next(t *testing.T) {
+	// 2024-01-01 is a monday
+	start := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		rule   string
+		prev   time.Time
+		sent   int
+		now    time.Time
+		want   time.Time
+		wantOk bool
+	}{
+		{
+			name:   "daily",
+			rule:   "daily",
+			prev:   start,
+			now:    start,
+			want:   start.AddDate(0, 0, 1),
+			wantOk: true,
+		},
+		{
+			name:   "every two weeks",
+			rule:   "FREQ=WEEKLY;INTERVAL=2",
+			prev:   start,
+			now:    start,
+			want:   start.AddDate(0, 0, 14),
+			wantOk: true,
+		},
+		{
+			name:   "weekly by day within the same week",
+			rule:   "FREQ=WEEKLY;BYDAY=MO,TH",
+			prev:   start,
+			now:    start,
+			want:   time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "weekly by day in the next interval",
+			rule:   "FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,TH",
+			prev:   time.Date(2024, 1, 4, 9, 0, 0, 0, time.UTC),
+			now:    start,
+			want:   time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "monthly skips months without the day",
+			rule:   "monthly",
+			prev:   time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+			now:    time.Date(2024, 1, 31, 9, 0, 0, 0, time.UTC),
+			want:   time.Date(2024, 3, 31, 9, 0, 0, 0, time.UTC),
+			wantOk: true,
+		},
+		{
+			name:   "missed occurrences are skipped",
+			rule:   "daily",
+			prev:   start,
+			now:    start.AddDate(0, 0, 5).Add(time.Hour),
+			want:   start.AddDate(0, 0, 6),
+			wantOk: true,
+		},
+		{
+			name:   "count reached",
+			rule:   "FREQ=DAILY;COUNT=2",
+			prev:   start,
+			sent:   2,
+			now:    start,
+			wantOk: false,
+		},
+		{
+			name:   "until reached",
+			rule:   "FREQ=DAILY;UNTIL=20240102",
+			prev:   time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+			now:    time.Date(2024, 1, 2, 9, 0, 0, 0, time.UTC),
+			wantOk: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := ParseRecurrence(tt.rule)
+			if err != nil {
+				t.Fatalf("ParseRecurrence() error = %v", err)
+			}
+			got, ok := r.Next(tt.prev, tt.sent, tt.now)
+			if ok != tt.wantOk {
+				t.Errorf("Recurrence.Next() ok = %v, want %v", ok, tt.wantOk)
+				return
+			}
+			if ok && !got.Equal(tt.want) {
+				t.Errorf("Recurrence.Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
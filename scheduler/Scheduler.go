@@ -0,0 +1,137 @@
+package scheduler
+
+import (
+	"context"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"sync"
+	"time"
+)
+
+// Scheduler - fires the reminders of the notes when they become due. It keeps no state of its own, the
+// reminders are read from the notes on every run, so a scheduler stopped and started again fires the reminders
+// that became due in between. The notes are only kept in memory: the reminders do not survive a restart of the
+// server.
+type Scheduler struct {
+	repo     interfaces.INotesRepository
+	notifier interfaces.INotifier
+	logger   *loggers.Logger
+	interval time.Duration
+	now      func() time.Time
+
+	mu   sync.Mutex
+	stop chan struct{}
+	done chan struct{}
+}
+
+func NewScheduler(logger *loggers.Logger, repo interfaces.INotesRepository, notifier interfaces.INotifier, interval time.Duration) *Scheduler {
+	return &Scheduler{
+		repo:     repo,
+		notifier: notifier,
+		logger:   logger,
+		interval: interval,
+		now:      time.Now,
+	}
+}
+
+// Start - runs the scheduler in the background every interval until Stop is called
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go s.loop(ctx, s.stop, s.done)
+}
+
+// Stop - stops the scheduler and waits for the run in progress to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+	s.stop = nil
+	s.done = nil
+}
+
+func (s *Scheduler) loop(ctx context.Context, stop <-chan struct{}, done chan<- struct{}) {
+	defer close(done)
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+	for {
+		if err := s.RunOnce(ctx); err != nil {
+			s.logger.Warn(ctx, "error in Scheduler.loop(), error from s.RunOnce()", err)
+		}
+		select {
+		case <-stop:
+			return
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunOnce - fires all the reminders that are due. A reminder whose delivery failed is retried on the next run.
+func (s *Scheduler) RunOnce(ctx context.Context) error {
+	now := s.now()
+	notes, err := s.repo.GetDueReminders(ctx, now)
+	if err != nil {
+		s.logger.Warn(ctx, "error in Scheduler.RunOnce(), error from repo.GetDueReminders()", err)
+		return err
+	}
+	for _, note := range notes {
+		firedAt := *note.NextReminderAt()
+		err = s.notifier.Notify(ctx, models.Reminder{
+			NoteId:   note.Id,
+			Email:    note.CreatedBy,
+			Title:    note.Title,
+			DueAt:    note.DueAt,
+			RemindAt: firedAt,
+		})
+		if err != nil {
+			s.logger.Warn(ctx, "error in Scheduler.RunOnce(), error from notifier.Notify()", err)
+			continue
+		}
+		err = s.repo.UpdateReminder(ctx, firedAt, s.advance(ctx, note, now))
+		if err != nil {
+			s.logger.Warn(ctx, "error in Scheduler.RunOnce(), error from repo.UpdateReminder()", err)
+		}
+	}
+	return nil
+}
+
+// advance - returns the note with the reminder that follows the one just fired
+func (s *Scheduler) advance(ctx context.Context, note models.Note, now time.Time) models.Note {
+	if note.SnoozedUntil != nil {
+		// a snoozed reminder does not move the ones that follow it
+		note.SnoozedUntil = nil
+		return note
+	}
+	note.RemindersSent++
+	recurrence, err := ParseRecurrence(note.Recurrence)
+	if err != nil {
+		s.logger.Warn(ctx, "error in Scheduler.advance(), error from ParseRecurrence()", err)
+	}
+	if recurrence == nil || note.RemindAt == nil {
+		note.RemindAt = nil
+		return note
+	}
+	next, ok := recurrence.Next(*note.RemindAt, note.RemindersSent, now)
+	if !ok {
+		note.RemindAt = nil
+		return note
+	}
+	if note.DueAt != nil {
+		dueAt := note.DueAt.Add(next.Sub(*note.RemindAt))
+		note.DueAt = &dueAt
+	}
+	note.RemindAt = &next
+	return note
+}
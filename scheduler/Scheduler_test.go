@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"notes-server/constants"
+	"notes-server/db"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/notifiers"
+	"notes-server/repositories"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func TestScheduler_RunOnce(t *testing.T) {
+	now := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	remindAt := now.Add(-time.Minute)
+	dueAt := now.Add(time.Hour)
+	snoozedUntil := now.Add(-time.Second)
+	nextDay := remindAt.AddDate(0, 0, 1)
+	nextDueAt := dueAt.AddDate(0, 0, 1)
+	tests := []struct {
+		name       string
+		given      func(*interfaces.MockINotesRepository)
+		notifyErr  error
+		wantErr    bool
+		wantNotify int
+	}{
+		{
+			name: "success case - one time reminder is cleared",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetDueReminders(mock.Anything, now).Return([]models.Note{{
+					Id: 1, Note: "test", CreatedBy: "test@gmail.com", RemindAt: &remindAt,
+				}}, nil)
+				r.EXPECT().UpdateReminder(mock.Anything, remindAt, models.Note{
+					Id: 1, Note: "test", CreatedBy: "test@gmail.com", RemindersSent: 1,
+				}).Return(nil)
+			},
+			wantNotify: 1,
+		},
+		{
+			name: "success case - recurring reminder moves to the next occurrence",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetDueReminders(mock.Anything, now).Return([]models.Note{{
+					Id: 1, Note: "test", CreatedBy: "test@gmail.com", RemindAt: &remindAt, DueAt: &dueAt, Recurrence: "daily",
+				}}, nil)
+				r.EXPECT().UpdateReminder(mock.Anything, remindAt, models.Note{
+					Id: 1, Note: "test", CreatedBy: "test@gmail.com", RemindAt: &nextDay, DueAt: &nextDueAt, Recurrence: "daily", RemindersSent: 1,
+				}).Return(nil)
+			},
+			wantNotify: 1,
+		},
+		{
+			name: "success case - snoozed reminder keeps the next occurrence",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetDueReminders(mock.Anything, now).Return([]models.Note{{
+					Id: 1, Note: "test", CreatedBy: "test@gmail.com", RemindAt: &nextDay, SnoozedUntil: &snoozedUntil, Recurrence: "daily",
+				}}, nil)
+				r.EXPECT().UpdateReminder(mock.Anything, snoozedUntil, models.Note{
+					Id: 1, Note: "test", CreatedBy: "test@gmail.com", RemindAt: &nextDay, Recurrence: "daily",
+				}).Return(nil)
+			},
+			wantNotify: 1,
+		},
+		{
+			name: "failure case - error in notifier.Notify() keeps the reminder",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetDueReminders(mock.Anything, now).Return([]models.Note{{
+					Id: 1, Note: "test", CreatedBy: "test@gmail.com", RemindAt: &remindAt,
+				}}, nil)
+			},
+			notifyErr:  errors.New("smtp error"),
+			wantNotify: 0,
+		},
+		{
+			name: "failure case - error in repo.GetDueReminders()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetDueReminders(mock.Anything, now).Return([]models.Note{}, errors.New("db error"))
+			},
+			wantErr:    true,
+			wantNotify: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			notifier := notifiers.NewFakeNotifier()
+			notifier.Err = tt.notifyErr
+			s := NewScheduler(loggers.NewLogger(), &mockRepo, notifier, time.Minute)
+			s.now = func() time.Time { return now }
+			err := s.RunOnce(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Scheduler.RunOnce() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got := len(notifier.Reminders()); got != tt.wantNotify {
+				t.Errorf("Scheduler.RunOnce() notified %d reminders, want %d", got, tt.wantNotify)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestScheduler_StopAndStart(t *testing.T) {
+	logger := loggers.NewLogger()
+	repo := repositories.NewNotesRepository(db.NewDB(), logger, nil)
+	ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "restart@gmail.com")
+	remindAt := time.Now().Add(time.Hour)
+	id, err := repo.AddNote(ctx, models.AddNoteRequest{Email: "restart@gmail.com", Title: "Restart", Note: "restart", RemindAt: &remindAt})
+	if err != nil {
+		t.Fatalf("repo.AddNote() error = %v", err)
+	}
+
+	// the first scheduler is stopped before the reminder is due
+	notifier := notifiers.NewFakeNotifier()
+	first := NewScheduler(logger, repo, notifier, time.Hour)
+	first.Start(ctx)
+	first.Stop()
+
+	// a new scheduler started after the reminder became due fires it once
+	second := NewScheduler(logger, repo, notifier, time.Hour)
+	second.now = func() time.Time { return remindAt.Add(time.Minute) }
+	if err = second.RunOnce(ctx); err != nil {
+		t.Fatalf("Scheduler.RunOnce() error = %v", err)
+	}
+	if err = second.RunOnce(ctx); err != nil {
+		t.Fatalf("Scheduler.RunOnce() error = %v", err)
+	}
+	reminders := notifier.Reminders()
+	if len(reminders) != 1 || reminders[0].NoteId != id || reminders[0].Title != "Restart" {
+		t.Errorf("Scheduler.RunOnce() notified %v, want one reminder for note %d", reminders, id)
+	}
+}
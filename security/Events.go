@@ -0,0 +1,28 @@
+package security
+
+import (
+	"context"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+)
+
+type logEvents struct {
+	logger *loggers.Logger
+}
+
+// NewLogEvents - writes the security events to the log, with their members as fields
+func NewLogEvents(logger *loggers.Logger) interfaces.ISecurityEvents {
+	return &logEvents{logger: logger}
+}
+
+func (e *logEvents) Emit(ctx context.Context, event models.SecurityEvent) {
+	e.logger.WarnWithFields(ctx, map[string]interface{}{
+		"security_event": event.Type,
+		"at":             event.At,
+		"email":          event.Email,
+		"ip":             event.IP,
+		"subject":        event.Subject,
+		"detail":         event.Detail,
+	}, "security event ", event.Type)
+}
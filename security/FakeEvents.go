@@ -0,0 +1,30 @@
+package security
+
+import (
+	"context"
+	"notes-server/models"
+	"sync"
+)
+
+// FakeEvents - keeps the security events in memory, for tests
+type FakeEvents struct {
+	mu     sync.Mutex
+	events []models.SecurityEvent
+}
+
+func NewFakeEvents() *FakeEvents {
+	return &FakeEvents{}
+}
+
+func (e *FakeEvents) Emit(ctx context.Context, event models.SecurityEvent) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.events = append(e.events, event)
+}
+
+// Events - the events emitted so far
+func (e *FakeEvents) Events() []models.SecurityEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]models.SecurityEvent{}, e.events...)
+}
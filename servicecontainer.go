@@ -1,28 +1,67 @@
 package main
 
 import (
+	"notes-server/constants"
 	"notes-server/controllers"
 	"notes-server/db"
+	"notes-server/encryption"
+	"notes-server/interfaces"
 	"notes-server/loggers"
+	"notes-server/mailers"
+	"notes-server/models"
+	"notes-server/notifiers"
+	"notes-server/oidc"
+	"notes-server/passwords"
 	"notes-server/repositories"
+	"notes-server/scheduler"
+	"notes-server/security"
 	"notes-server/services"
+	"notes-server/signing"
+	"strings"
 	"sync"
+	"time"
 
 	logrus "github.com/sirupsen/logrus"
+	"github.com/spf13/viper"
 )
 
 type IServiceContainer interface {
 	InjectNotesController() controllers.NotesController
 	InjectLoginController() controllers.LoginController
+	InjectRemindersController() controllers.RemindersController
+	InjectTemplatesController() controllers.TemplatesController
+	InjectKeysController() controllers.KeysController
+	InjectAccountController() controllers.AccountController
+	InjectAccessTokensController() controllers.AccessTokensController
+	InjectAdminController() controllers.AdminController
+	InjectAdminService() interfaces.IAdminService
+	InjectAuditController() controllers.AuditController
+	InjectAuditService() interfaces.IAuditService
+	InjectWorkspacesController() controllers.WorkspacesController
+	InjectReminderScheduler() *scheduler.Scheduler
+	InjectDataKeysRepository() interfaces.IDataKeysRepository
+	InjectSigningKeyring() *signing.Keyring
+	InjectSigningKeysController() controllers.SigningKeysController
 }
 
-type kernel struct{}
+type kernel struct {
+	keyringOnce        sync.Once
+	keyring            *encryption.Keyring
+	signingKeyringOnce sync.Once
+	signingKeyring     *signing.Keyring
+}
 
 func (k *kernel) InjectNotesController() controllers.NotesController {
 	logrus.Infof("Notes service successfully connected!")
 	logger := loggers.NewLogger()
-	notesRepository := repositories.NewNotesRepository(db.NewDB(), logger)
-	notesService := services.NewNotesService(logger, notesRepository)
+	notesRepository := repositories.NewNotesRepository(db.NewDB(), logger, k.masterKeyring())
+	templatesRepository := repositories.NewTemplatesRepository(db.NewDB(), logger)
+	quota := models.Quota{
+		MaxNoteSize: viper.GetInt(constants.QuotaMaxNoteSizeEnvKey),
+		MaxNotes:    viper.GetInt(constants.QuotaMaxNotesEnvKey),
+		MaxStorage:  viper.GetInt64(constants.QuotaMaxStorageEnvKey),
+	}
+	notesService := services.NewNotesService(logger, notesRepository, templatesRepository, quota)
 	notesController := controllers.NewNotesController(logger, notesService)
 	return notesController
 }
@@ -31,11 +70,265 @@ func (k *kernel) InjectLoginController() controllers.LoginController {
 	logrus.Infof("Login service successfully connected!")
 	logger := loggers.NewLogger()
 	loginRepository := repositories.NewLoginRepository(db.NewDB(), logger)
-	loginService := services.NewLoginService(logger, loginRepository)
+	loginService := services.NewLoginService(logger, loginRepository, newMailer(), newOIDCProvider(), k.InjectSigningKeyring(), security.NewLogEvents(logger), newLoginThrottle(), newPasswordPolicy())
 	loginController := controllers.NewLoginController(logger, loginService)
 	return loginController
 }
 
+func (k *kernel) InjectRemindersController() controllers.RemindersController {
+	logrus.Infof("Reminders service successfully connected!")
+	logger := loggers.NewLogger()
+	notesRepository := repositories.NewNotesRepository(db.NewDB(), logger, k.masterKeyring())
+	notificationsRepository := repositories.NewNotificationsRepository(db.NewDB(), logger)
+	remindersService := services.NewRemindersService(logger, notesRepository, notificationsRepository)
+	remindersController := controllers.NewRemindersController(logger, remindersService)
+	return remindersController
+}
+
+func (k *kernel) InjectTemplatesController() controllers.TemplatesController {
+	logrus.Infof("Templates service successfully connected!")
+	logger := loggers.NewLogger()
+	templatesRepository := repositories.NewTemplatesRepository(db.NewDB(), logger)
+	templatesService := services.NewTemplatesService(logger, templatesRepository)
+	templatesController := controllers.NewTemplatesController(logger, templatesService)
+	return templatesController
+}
+
+func (k *kernel) InjectKeysController() controllers.KeysController {
+	logrus.Infof("Keys service successfully connected!")
+	logger := loggers.NewLogger()
+	keysRepository := repositories.NewKeysRepository(db.NewDB(), logger)
+	keysService := services.NewKeysService(logger, keysRepository)
+	keysController := controllers.NewKeysController(logger, keysService)
+	return keysController
+}
+
+func (k *kernel) InjectAccountController() controllers.AccountController {
+	logrus.Infof("Account service successfully connected!")
+	logger := loggers.NewLogger()
+	accountRepository := repositories.NewAccountRepository(db.NewDB(), logger, k.masterKeyring())
+	loginRepository := repositories.NewLoginRepository(db.NewDB(), logger)
+	accountService := services.NewAccountService(logger, accountRepository, loginRepository, newMailer(), k.InjectSigningKeyring(), newPasswordPolicy(), security.NewLogEvents(logger), newLoginThrottle())
+	accountController := controllers.NewAccountController(logger, accountService)
+	return accountController
+}
+
+func (k *kernel) InjectAccessTokensController() controllers.AccessTokensController {
+	logrus.Infof("Access tokens service successfully connected!")
+	logger := loggers.NewLogger()
+	accessTokensRepository := repositories.NewAccessTokensRepository(db.NewDB(), logger)
+	accessTokensService := services.NewAccessTokensService(logger, accessTokensRepository)
+	accessTokensController := controllers.NewAccessTokensController(logger, accessTokensService)
+	return accessTokensController
+}
+
+func (k *kernel) InjectAdminController() controllers.AdminController {
+	logrus.Infof("Admin service successfully connected!")
+	logger := loggers.NewLogger()
+	adminController := controllers.NewAdminController(logger, k.InjectAdminService())
+	return adminController
+}
+
+func (k *kernel) InjectAdminService() interfaces.IAdminService {
+	logger := loggers.NewLogger()
+	adminRepository := repositories.NewAdminRepository(db.NewDB(), logger)
+	return services.NewAdminService(logger, adminRepository, newMailer(), security.NewLogEvents(logger), newPasswordPolicy())
+}
+
+func (k *kernel) InjectAuditController() controllers.AuditController {
+	logrus.Infof("Audit service successfully connected!")
+	logger := loggers.NewLogger()
+	auditController := controllers.NewAuditController(logger, k.InjectAuditService())
+	return auditController
+}
+
+func (k *kernel) InjectAuditService() interfaces.IAuditService {
+	logger := loggers.NewLogger()
+	auditRepository := repositories.NewAuditRepository(db.NewDB(), logger)
+	return services.NewAuditService(logger, auditRepository, viper.GetDuration(constants.AuditRetentionEnvKey))
+}
+
+func (k *kernel) InjectWorkspacesController() controllers.WorkspacesController {
+	logrus.Infof("Workspaces service successfully connected!")
+	logger := loggers.NewLogger()
+	workspaceRepository := repositories.NewWorkspaceRepository(db.NewDB(), logger)
+	loginRepository := repositories.NewLoginRepository(db.NewDB(), logger)
+	workspaceService := services.NewWorkspaceService(logger, workspaceRepository, loginRepository, newMailer(), k.InjectSigningKeyring())
+	workspacesController := controllers.NewWorkspacesController(logger, workspaceService)
+	return workspacesController
+}
+
+func (k *kernel) InjectReminderScheduler() *scheduler.Scheduler {
+	logger := loggers.NewLogger()
+	notesRepository := repositories.NewNotesRepository(db.NewDB(), logger, k.masterKeyring())
+	notifier := newReminderNotifier(logger)
+	// an interval that does not parse is read as 0
+	interval := viper.GetDuration(constants.ReminderPollIntervalEnvKey)
+	if interval <= 0 {
+		logrus.Fatalf("invalid %s: must be a positive duration", constants.ReminderPollIntervalEnvKey)
+	}
+	return scheduler.NewScheduler(logger, notesRepository, notifier, interval)
+}
+
+func (k *kernel) InjectDataKeysRepository() interfaces.IDataKeysRepository {
+	return repositories.NewDataKeysRepository(db.NewDB(), loggers.NewLogger(), k.masterKeyring())
+}
+
+// masterKeyring - loads the master keys the note bodies are encrypted at rest with, the service does not start
+// with an invalid key
+func (k *kernel) masterKeyring() *encryption.Keyring {
+	k.keyringOnce.Do(func() {
+		var previousKeys []string
+		if keys := viper.GetString(constants.EncryptionPreviousMasterKeysEnvKey); keys != "" {
+			previousKeys = strings.Split(keys, ",")
+		}
+		keyring, err := encryption.LoadKeyring(
+			viper.GetString(constants.EncryptionMasterKeyFileEnvKey),
+			viper.GetString(constants.EncryptionMasterKeyEnvKey),
+			previousKeys,
+		)
+		if err != nil {
+			logrus.Fatalf("failed to load the encryption master keys: %v", err)
+		}
+		if keyring == nil {
+			logrus.Warnf("no encryption master key configured, note bodies are stored in plaintext")
+		}
+		k.keyring = keyring
+	})
+	return k.keyring
+}
+
+// InjectSigningKeyring - loads the keys the session tokens are signed with, the service does not start with a
+// missing or weak JWT_SECRET or with invalid keys
+func (k *kernel) InjectSigningKeyring() *signing.Keyring {
+	k.signingKeyringOnce.Do(func() {
+		secret := viper.GetString(constants.JwtSecretEnvKey)
+		if err := signing.ValidateSecret(secret); err != nil {
+			logrus.Fatalf("invalid %s: %v", constants.JwtSecretEnvKey, err)
+		}
+		alg := viper.GetString(constants.JwtSigningAlgEnvKey)
+		if alg == signing.HS256 {
+			keyring, err := signing.NewHMACKeyring(secret)
+			if err != nil {
+				logrus.Fatalf("failed to load the signing keys: %v", err)
+			}
+			k.signingKeyring = keyring
+			return
+		}
+		gracePeriod := viper.GetDuration(constants.JwtKeyGracePeriodEnvKey)
+		if gracePeriod < services.SessionTTL {
+			logrus.Fatalf("%s must be at least %s, the time a session is valid for", constants.JwtKeyGracePeriodEnvKey, services.SessionTTL)
+		}
+		keyFile := viper.GetString(constants.JwtSigningKeyFileEnvKey)
+		keyring, err := signing.NewKeyring(alg, keyFile, gracePeriod)
+		if err != nil {
+			logrus.Fatalf("failed to load the signing keys: %v", err)
+		}
+		if keyFile == "" {
+			logrus.Warnf("the signing keys are generated in memory, set %s when running more than one instance", constants.JwtSigningKeyFileEnvKey)
+		}
+		k.signingKeyring = keyring
+	})
+	return k.signingKeyring
+}
+
+func (k *kernel) InjectSigningKeysController() controllers.SigningKeysController {
+	return controllers.NewSigningKeysController(loggers.NewLogger(), k.InjectSigningKeyring())
+}
+
+// newLoginThrottle - the backoff and lockout of the failed logins, the clients get the backoff of the accounts with
+// their own thresholds
+func newLoginThrottle() models.LoginThrottle {
+	account := models.LoginThrottlePolicy{
+		BackoffAfter:    viper.GetInt(constants.LoginBackoffAfterEnvKey),
+		BaseDelay:       viper.GetDuration(constants.LoginBackoffBaseEnvKey),
+		MaxDelay:        viper.GetDuration(constants.LoginBackoffMaxEnvKey),
+		LockoutAfter:    viper.GetInt(constants.LoginLockoutAfterEnvKey),
+		LockoutDuration: viper.GetDuration(constants.LoginLockoutDurationEnvKey),
+		Window:          viper.GetDuration(constants.LoginFailureWindowEnvKey),
+	}
+	client := account
+	client.BackoffAfter = viper.GetInt(constants.LoginIPBackoffAfterEnvKey)
+	client.LockoutAfter = viper.GetInt(constants.LoginIPLockoutAfterEnvKey)
+	return models.LoginThrottle{Account: account, Client: client}
+}
+
+// newPasswordPolicy - the rules of the new passwords, the service does not start with an unknown class of
+// characters or a missing directory of breached passwords
+func newPasswordPolicy() passwords.Policy {
+	var breached *passwords.BreachedList
+	if dir := viper.GetString(constants.PasswordBreachedDirEnvKey); dir != "" {
+		list, err := passwords.NewBreachedList(dir)
+		if err != nil {
+			logrus.Fatalf("invalid %s: %v", constants.PasswordBreachedDirEnvKey, err)
+		}
+		breached = list
+	}
+	policy, err := passwords.NewPolicy(
+		viper.GetInt(constants.PasswordMinLengthEnvKey),
+		strings.Split(viper.GetString(constants.PasswordRequiredClassesEnvKey), ","),
+		viper.GetBool(constants.PasswordForbidPersonalInfoEnvKey),
+		breached,
+	)
+	if err != nil {
+		logrus.Fatalf("invalid %s: %v", constants.PasswordRequiredClassesEnvKey, err)
+	}
+	return policy
+}
+
+// newReminderNotifier - builds the notifiers listed in REMINDER_NOTIFIERS, any of inapp, webhook and email
+func newReminderNotifier(logger *loggers.Logger) interfaces.INotifier {
+	reminderNotifiers := make([]interfaces.INotifier, 0)
+	for _, name := range strings.Split(viper.GetString(constants.ReminderNotifiersEnvKey), ",") {
+		switch strings.TrimSpace(name) {
+		case "inapp":
+			reminderNotifiers = append(reminderNotifiers, notifiers.NewInAppNotifier(repositories.NewNotificationsRepository(db.NewDB(), logger)))
+		case "webhook":
+			reminderNotifiers = append(reminderNotifiers, notifiers.NewWebhookNotifier(viper.GetString(constants.ReminderWebhookURLEnvKey), 10*time.Second))
+		case "email":
+			reminderNotifiers = append(reminderNotifiers, notifiers.NewEmailNotifier(newMailer()))
+		case "":
+		default:
+			logrus.Warnf("unknown reminder notifier %q", name)
+		}
+	}
+	return notifiers.NewMultiNotifier(logger, reminderNotifiers...)
+}
+
+// newMailer - sends emails through the SMTP_* server or writes them to the outbox as set by MAILER, which
+// defaults to smtp when an SMTP host is configured
+func newMailer() interfaces.IMailer {
+	mailer := viper.GetString(constants.MailerEnvKey)
+	if mailer == "smtp" || (mailer == "" && viper.GetString(constants.SMTPHostEnvKey) != "") {
+		return mailers.NewSMTPMailer(
+			viper.GetString(constants.SMTPHostEnvKey),
+			viper.GetInt(constants.SMTPPortEnvKey),
+			viper.GetString(constants.SMTPUsernameEnvKey),
+			viper.GetString(constants.SMTPPasswordEnvKey),
+			viper.GetString(constants.SMTPFromEnvKey),
+		)
+	}
+	return mailers.NewOutboxMailer(viper.GetString(constants.MailerOutboxDirEnvKey), viper.GetString(constants.SMTPFromEnvKey))
+}
+
+// newOIDCProvider - the OpenID Connect provider the users can sign in with, nil when none is configured
+func newOIDCProvider() interfaces.IOIDCProvider {
+	issuer := viper.GetString(constants.OIDCIssuerEnvKey)
+	if issuer == "" {
+		return nil
+	}
+	redirectURL := viper.GetString(constants.OIDCRedirectURLEnvKey)
+	if redirectURL == "" {
+		redirectURL = viper.GetString(constants.AppBaseURLEnvKey) + "/v1/api/oidc/callback"
+	}
+	return oidc.NewProvider(
+		issuer,
+		viper.GetString(constants.OIDCClientIDEnvKey),
+		viper.GetString(constants.OIDCClientSecretEnvKey),
+		redirectURL,
+	)
+}
+
 var (
 	k             *kernel
 	containerOnce sync.Once
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"time"
+)
+
+type accessTokensService struct {
+	repo   interfaces.IAccessTokensRepository
+	logger *loggers.Logger
+}
+
+func NewAccessTokensService(logger *loggers.Logger, repo interfaces.IAccessTokensRepository) interfaces.IAccessTokensService {
+	return &accessTokensService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// GetAccessTokens - retrieves the personal access tokens of the user
+func (s *accessTokensService) GetAccessTokens(ctx context.Context) ([]models.AccessToken, error) {
+	email := utils.GetEmailFromCtx(ctx)
+	tokens, err := s.repo.GetAccessTokens(ctx, email)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accessTokensService.GetAccessTokens(), error from repo.GetAccessTokens()")
+		return []models.AccessToken{}, err
+	}
+	return tokens, nil
+}
+
+// CreateAccessToken - creates a personal access token of the user, the token itself is only returned here
+func (s *accessTokensService) CreateAccessToken(ctx context.Context, request models.CreateAccessTokenRequest) (models.CreateAccessTokenResponse, error) {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	random, err := utils.NewToken()
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accessTokensService.CreateAccessToken(), error from utils.NewToken()")
+		return models.CreateAccessTokenResponse{}, err
+	}
+	token := models.AccessTokenPrefix + random
+	accessToken := models.AccessToken{
+		Id:        utils.NewID(),
+		TokenHash: utils.HashToken(token),
+		Email:     request.Email,
+		Workspace: request.Workspace,
+		Name:      request.Name,
+		Scopes:    request.Scopes,
+		CreatedAt: time.Now(),
+		ExpiresAt: request.ExpiresAt,
+	}
+	err = s.repo.AddAccessToken(ctx, accessToken)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accessTokensService.CreateAccessToken(), error from repo.AddAccessToken()")
+		return models.CreateAccessTokenResponse{}, err
+	}
+	return models.CreateAccessTokenResponse{Token: token, AccessToken: accessToken}, nil
+}
+
+// RevokeAccessToken - revokes a personal access token of the user
+func (s *accessTokensService) RevokeAccessToken(ctx context.Context, request models.RevokeAccessTokenRequest) error {
+	email := utils.GetEmailFromCtx(ctx)
+	err := s.repo.RevokeAccessToken(ctx, email, request.Id)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accessTokensService.RevokeAccessToken(), error from repo.RevokeAccessToken()")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_accessTokensService_CreateAccessToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockIAccessTokensRepository, *models.AccessToken)
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockIAccessTokensRepository, stored *models.AccessToken) {
+				r.EXPECT().AddAccessToken(mock.Anything, mock.Anything).Run(func(_ context.Context, token models.AccessToken) {
+					*stored = token
+				}).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.AddAccessToken()",
+			given: func(r *interfaces.MockIAccessTokensRepository, _ *models.AccessToken) {
+				r.EXPECT().AddAccessToken(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockIAccessTokensRepository{}
+			var stored models.AccessToken
+			tt.given(&mockRepo, &stored)
+			s := NewAccessTokensService(loggers.NewLogger(), &mockRepo)
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			got, err := s.CreateAccessToken(ctx, models.CreateAccessTokenRequest{Name: "cli", Scopes: []string{models.ScopeNotesRead}})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("accessTokensService.CreateAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if !strings.HasPrefix(got.Token, models.AccessTokenPrefix) {
+				t.Errorf("accessTokensService.CreateAccessToken() token = %q, want prefix %q", got.Token, models.AccessTokenPrefix)
+			}
+			if stored.TokenHash != utils.HashToken(got.Token) || strings.Contains(stored.TokenHash, got.Token) {
+				t.Errorf("accessTokensService.CreateAccessToken() stored hash %q, want the hash of %q", stored.TokenHash, got.Token)
+			}
+			if stored.Email != "test@gmail.com" || !stored.HasScope(models.ScopeNotesRead) || stored.CreatedAt.IsZero() {
+				t.Errorf("accessTokensService.CreateAccessToken() stored %+v", stored)
+			}
+		})
+	}
+}
+
+func Test_accessTokensService_RevokeAccessToken(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockIAccessTokensRepository)
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockIAccessTokensRepository) {
+				r.EXPECT().RevokeAccessToken(mock.Anything, "test@gmail.com", int32(7)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.RevokeAccessToken()",
+			given: func(r *interfaces.MockIAccessTokensRepository) {
+				r.EXPECT().RevokeAccessToken(mock.Anything, mock.Anything, mock.Anything).Return(errors.New("access token not found"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockIAccessTokensRepository{}
+			tt.given(&mockRepo)
+			s := NewAccessTokensService(loggers.NewLogger(), &mockRepo)
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			err := s.RevokeAccessToken(ctx, models.RevokeAccessTokenRequest{Id: 7})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("accessTokensService.RevokeAccessToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
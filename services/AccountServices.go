@@ -0,0 +1,165 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/passwords"
+	"notes-server/signing"
+	"notes-server/utils"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type accountService struct {
+	repo      interfaces.IAccountRepository
+	loginRepo interfaces.ILoginRepository
+	mailer    interfaces.IMailer
+	keys      *signing.Keyring
+	policy    passwords.Policy
+	// events, throttle - the wrong two-factor codes are throttled and locked out like the wrong ones of a login
+	events   interfaces.ISecurityEvents
+	throttle models.LoginThrottle
+	logger   *loggers.Logger
+}
+
+func NewAccountService(logger *loggers.Logger, repo interfaces.IAccountRepository, loginRepo interfaces.ILoginRepository, mailer interfaces.IMailer, keys *signing.Keyring, policy passwords.Policy, events interfaces.ISecurityEvents, throttle models.LoginThrottle) interfaces.IAccountService {
+	return &accountService{
+		repo:      repo,
+		loginRepo: loginRepo,
+		mailer:    mailer,
+		keys:      keys,
+		policy:    policy,
+		events:    events,
+		throttle:  throttle,
+		logger:    logger,
+	}
+}
+
+// GetAccount - retrieves the profile of the user
+func (s *accountService) GetAccount(ctx context.Context) (models.Account, error) {
+	s.logger.Info(ctx, "Entering accountService.GetAccount()")
+	defer s.logger.Info(ctx, "Exiting accountService.GetAccount()")
+	user, err := s.loginRepo.GetUser(ctx, utils.GetEmailFromCtx(ctx))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.GetAccount(), error from loginRepo.GetUser()")
+		return models.Account{}, err
+	}
+	return newAccount(user), nil
+}
+
+// UpdateAccount - renames the user right away, a new email address is only applied once the link sent to it is
+// followed
+func (s *accountService) UpdateAccount(ctx context.Context, request models.UpdateAccountRequest) (models.Account, error) {
+	s.logger.Info(ctx, "Entering accountService.UpdateAccount()")
+	defer s.logger.Info(ctx, "Exiting accountService.UpdateAccount()")
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.NewEmail = utils.NormalizeEmail(request.NewEmail)
+	user, err := s.repo.UpdateAccount(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.UpdateAccount(), error from repo.UpdateAccount()")
+		return models.Account{}, err
+	}
+	if request.NewEmail != "" && request.NewEmail == user.PendingEmail {
+		err = s.sendEmailChangeEmail(ctx, user)
+		if err != nil {
+			s.logger.Warn(ctx, "Error in accountService.UpdateAccount(), error from sendEmailChangeEmail()")
+			return models.Account{}, err
+		}
+	}
+	return newAccount(user), nil
+}
+
+// ConfirmEmailChange - moves the account to the address in an email change token, signing out all its sessions
+func (s *accountService) ConfirmEmailChange(ctx context.Context, request models.VerifyEmailRequest) error {
+	s.logger.Info(ctx, "Entering accountService.ConfirmEmailChange()")
+	defer s.logger.Info(ctx, "Exiting accountService.ConfirmEmailChange()")
+	claims, err := parseVerificationToken(emailChangeSubject, request.Token)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.ConfirmEmailChange(), error from parseVerificationToken()")
+		return err
+	}
+	err = s.repo.ChangeEmail(ctx, claims.Email, claims.NewEmail)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.ConfirmEmailChange(), error from repo.ChangeEmail()")
+		return err
+	}
+	return nil
+}
+
+// ChangePassword - sets a new password, which signs out all the sessions of the user and revokes their access
+// tokens. Returns a new session so that the client making the change stays signed in.
+func (s *accountService) ChangePassword(ctx context.Context, request models.ChangePasswordRequest) (models.LoginResponse, error) {
+	s.logger.Info(ctx, "Entering accountService.ChangePassword()")
+	defer s.logger.Info(ctx, "Exiting accountService.ChangePassword()")
+	request.Email = utils.GetEmailFromCtx(ctx)
+	// the name is only needed to check the password does not contain it
+	var name string
+	if s.policy.ForbidPersonalInfo {
+		user, err := s.loginRepo.GetUser(ctx, request.Email)
+		if err != nil {
+			s.logger.Warn(ctx, "Error in accountService.ChangePassword(), error from loginRepo.GetUser()")
+			return models.LoginResponse{}, err
+		}
+		name = user.Name
+	}
+	err := s.policy.Check("new_password", request.NewPassword, request.Email, name)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.ChangePassword(), error from policy.Check()")
+		return models.LoginResponse{}, err
+	}
+	user, err := s.repo.ChangePassword(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.ChangePassword(), error from repo.ChangePassword()")
+		return models.LoginResponse{}, err
+	}
+	token, err := generateJWTToken(s.keys, user.Email, user.Name, user.Role, utils.GetWorkspaceFromCtx(ctx), user.SessionVersion)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.ChangePassword(), error from generateJWTToken()")
+		return models.LoginResponse{}, err
+	}
+	return models.LoginResponse{SID: token}, nil
+}
+
+// DeleteAccount - deletes the user with their notes and everything else they own
+func (s *accountService) DeleteAccount(ctx context.Context, request models.DeleteAccountRequest) error {
+	s.logger.Info(ctx, "Entering accountService.DeleteAccount()")
+	defer s.logger.Info(ctx, "Exiting accountService.DeleteAccount()")
+	request.Email = utils.GetEmailFromCtx(ctx)
+	err := s.repo.DeleteAccount(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.DeleteAccount(), error from repo.DeleteAccount()")
+		return err
+	}
+	return nil
+}
+
+// sendEmailChangeEmail - sends the link confirming the change to the pending address of the user
+func (s *accountService) sendEmailChangeEmail(ctx context.Context, user models.User) error {
+	claims := models.VerificationClaims{Email: user.Email, NewEmail: user.PendingEmail}
+	token, err := newVerificationToken(emailChangeSubject, claims, time.Now().Add(viper.GetDuration(constants.EmailVerificationTTLEnvKey)))
+	if err != nil {
+		return err
+	}
+	link := viper.GetString(constants.AppBaseURLEnvKey) + "/v1/api/account/verify-email?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, models.Mail{
+		To:      user.PendingEmail,
+		Subject: "Confirm your new email address",
+		Body:    fmt.Sprintf("Hi %s,\n\nfollow this link to use this address for your account:\n\n%s\n", user.Name, link),
+	})
+}
+
+func newAccount(user models.User) models.Account {
+	return models.Account{
+		Email:        user.Email,
+		Name:         user.Name,
+		Verified:     user.Verified,
+		PendingEmail: user.PendingEmail,
+		MFAEnabled:   user.MFA.Enabled,
+	}
+}
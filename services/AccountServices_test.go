@@ -0,0 +1,257 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/mailers"
+	"notes-server/models"
+	"notes-server/passwords"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_accountService_UpdateAccount(t *testing.T) {
+	tests := []struct {
+		name     string
+		request  models.UpdateAccountRequest
+		given    func(*interfaces.MockIAccountRepository)
+		want     models.Account
+		wantSent int
+		wantErr  bool
+	}{
+		{
+			name:    "success case - rename",
+			request: models.UpdateAccountRequest{Name: "renamed"},
+			given: func(r *interfaces.MockIAccountRepository) {
+				r.EXPECT().UpdateAccount(mock.Anything, models.UpdateAccountRequest{Email: "test@gmail.com", Name: "renamed"}).
+					Return(models.User{Email: "test@gmail.com", Name: "renamed", Verified: true}, nil)
+			},
+			want: models.Account{Email: "test@gmail.com", Name: "renamed", Verified: true},
+		},
+		{
+			name:    "success case - new email is verified before being applied",
+			request: models.UpdateAccountRequest{NewEmail: "new@gmail.com"},
+			given: func(r *interfaces.MockIAccountRepository) {
+				r.EXPECT().UpdateAccount(mock.Anything, mock.Anything).
+					Return(models.User{Email: "test@gmail.com", Name: "test", Verified: true, PendingEmail: "new@gmail.com"}, nil)
+			},
+			want:     models.Account{Email: "test@gmail.com", Name: "test", Verified: true, PendingEmail: "new@gmail.com"},
+			wantSent: 1,
+		},
+		{
+			name:    "failure case - email taken",
+			request: models.UpdateAccountRequest{NewEmail: "new@gmail.com"},
+			given: func(r *interfaces.MockIAccountRepository) {
+				r.EXPECT().UpdateAccount(mock.Anything, mock.Anything).Return(models.User{}, models.ErrEmailTaken)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.EmailVerificationTTLEnvKey, time.Hour)
+			viper.Set(constants.AppBaseURLEnvKey, "http://localhost:8080")
+			mockRepo := interfaces.MockIAccountRepository{}
+			tt.given(&mockRepo)
+			mailer := mailers.NewFakeMailer()
+			s := &accountService{repo: &mockRepo, mailer: mailer, logger: loggers.NewLogger()}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			got, err := s.UpdateAccount(ctx, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("accountService.UpdateAccount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("accountService.UpdateAccount() = %+v, want %+v", got, tt.want)
+			}
+			mails := mailer.Mails()
+			if len(mails) != tt.wantSent {
+				t.Fatalf("accountService.UpdateAccount() sent %d emails, want %d", len(mails), tt.wantSent)
+			}
+			if tt.wantSent == 0 {
+				return
+			}
+			// the link sent to the new address confirms the change
+			if mails[0].To != "new@gmail.com" {
+				t.Errorf("accountService.UpdateAccount() sent the link to %s", mails[0].To)
+			}
+			link := mails[0].Body[strings.Index(mails[0].Body, "http"):]
+			parsed, err := url.Parse(strings.TrimSpace(link))
+			if err != nil {
+				t.Fatalf("url.Parse() error = %v", err)
+			}
+			mockRepo.EXPECT().ChangeEmail(mock.Anything, "test@gmail.com", "new@gmail.com").Return(nil)
+			if err = s.ConfirmEmailChange(context.Background(), models.VerifyEmailRequest{Token: parsed.Query().Get("token")}); err != nil {
+				t.Errorf("accountService.ConfirmEmailChange() error = %v", err)
+			}
+		})
+	}
+}
+
+func Test_accountService_ConfirmEmailChange(t *testing.T) {
+	valid, _ := newVerificationToken(emailChangeSubject, models.VerificationClaims{Email: "test@gmail.com", NewEmail: "new@gmail.com"}, time.Now().Add(time.Hour))
+	expired, _ := newVerificationToken(emailChangeSubject, models.VerificationClaims{Email: "test@gmail.com", NewEmail: "new@gmail.com"}, time.Now().Add(-time.Minute))
+	verification, _ := newVerificationToken(verificationSubject, models.VerificationClaims{Email: "test@gmail.com"}, time.Now().Add(time.Hour))
+	tests := []struct {
+		name    string
+		token   string
+		given   func(*interfaces.MockIAccountRepository)
+		wantErr bool
+	}{
+		{
+			name:  "success case",
+			token: valid,
+			given: func(r *interfaces.MockIAccountRepository) {
+				r.EXPECT().ChangeEmail(mock.Anything, "test@gmail.com", "new@gmail.com").Return(nil)
+			},
+		},
+		{
+			name:    "failure case - expired token",
+			token:   expired,
+			given:   func(r *interfaces.MockIAccountRepository) {},
+			wantErr: true,
+		},
+		{
+			name:    "failure case - email verification token",
+			token:   verification,
+			given:   func(r *interfaces.MockIAccountRepository) {},
+			wantErr: true,
+		},
+		{
+			name:  "failure case - error in repo.ChangeEmail()",
+			token: valid,
+			given: func(r *interfaces.MockIAccountRepository) {
+				r.EXPECT().ChangeEmail(mock.Anything, mock.Anything, mock.Anything).Return(models.ErrEmailTaken)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockIAccountRepository{}
+			tt.given(&mockRepo)
+			s := &accountService{repo: &mockRepo, logger: loggers.NewLogger()}
+			err := s.ConfirmEmailChange(context.Background(), models.VerifyEmailRequest{Token: tt.token})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("accountService.ConfirmEmailChange() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_accountService_ChangePassword(t *testing.T) {
+	tests := []struct {
+		name        string
+		policy      passwords.Policy
+		newPassword string
+		given       func(*interfaces.MockIAccountRepository, *interfaces.MockILoginRepository)
+		wantErr     bool
+	}{
+		{
+			name:        "success case",
+			newPassword: "new",
+			given: func(r *interfaces.MockIAccountRepository, l *interfaces.MockILoginRepository) {
+				r.EXPECT().ChangePassword(mock.Anything, models.ChangePasswordRequest{Email: "test@gmail.com", CurrentPassword: "old", NewPassword: "new"}).
+					Return(models.User{Email: "test@gmail.com", Name: "test", SessionVersion: 3}, nil)
+			},
+		},
+		{
+			name:        "success case - password follows the policy",
+			policy:      passwords.Policy{MinLength: 8, ForbidPersonalInfo: true},
+			newPassword: "correct horse",
+			given: func(r *interfaces.MockIAccountRepository, l *interfaces.MockILoginRepository) {
+				l.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{Email: "test@gmail.com", Name: "Jane Doe"}, nil)
+				r.EXPECT().ChangePassword(mock.Anything, mock.Anything).
+					Return(models.User{Email: "test@gmail.com", Name: "Jane Doe", SessionVersion: 3}, nil)
+			},
+		},
+		{
+			name:        "failure case - password contains the name",
+			policy:      passwords.Policy{MinLength: 8, ForbidPersonalInfo: true},
+			newPassword: "janedoe2024",
+			given: func(r *interfaces.MockIAccountRepository, l *interfaces.MockILoginRepository) {
+				l.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{Email: "test@gmail.com", Name: "Jane Doe"}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:        "failure case - password too short for the policy",
+			policy:      passwords.Policy{MinLength: 8},
+			newPassword: "new",
+			given:       func(r *interfaces.MockIAccountRepository, l *interfaces.MockILoginRepository) {},
+			wantErr:     true,
+		},
+		{
+			name:        "failure case - wrong password",
+			newPassword: "new",
+			given: func(r *interfaces.MockIAccountRepository, l *interfaces.MockILoginRepository) {
+				r.EXPECT().ChangePassword(mock.Anything, mock.Anything).Return(models.User{}, models.ErrWrongPassword)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockIAccountRepository{}
+			mockLoginRepo := interfaces.MockILoginRepository{}
+			tt.given(&mockRepo, &mockLoginRepo)
+			keys := testSigningKeys(t)
+			s := &accountService{repo: &mockRepo, loginRepo: &mockLoginRepo, keys: keys, policy: tt.policy, logger: loggers.NewLogger()}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			got, err := s.ChangePassword(ctx, models.ChangePasswordRequest{CurrentPassword: "old", NewPassword: tt.newPassword})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("accountService.ChangePassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			// the new session carries the session version the change moved to
+			claims := &models.Claims{}
+			_, err = keys.Parse(got.SID, claims)
+			if err != nil || claims.Email != "test@gmail.com" || claims.SessionVersion != 3 {
+				t.Errorf("accountService.ChangePassword() issued %+v, %v", claims, err)
+			}
+		})
+	}
+}
+
+func Test_accountService_DeleteAccount(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockIAccountRepository)
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockIAccountRepository) {
+				r.EXPECT().DeleteAccount(mock.Anything, models.DeleteAccountRequest{Email: "test@gmail.com", Password: "password"}).Return(nil)
+			},
+		},
+		{
+			name: "failure case - error in repo.DeleteAccount()",
+			given: func(r *interfaces.MockIAccountRepository) {
+				r.EXPECT().DeleteAccount(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockIAccountRepository{}
+			tt.given(&mockRepo)
+			s := &accountService{repo: &mockRepo, logger: loggers.NewLogger()}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			err := s.DeleteAccount(ctx, models.DeleteAccountRequest{Password: "password"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("accountService.DeleteAccount() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/passwords"
+	"notes-server/utils"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type adminService struct {
+	repo   interfaces.IAdminRepository
+	mailer interfaces.IMailer
+	events interfaces.ISecurityEvents
+	policy passwords.Policy
+	logger *loggers.Logger
+}
+
+func NewAdminService(logger *loggers.Logger, repo interfaces.IAdminRepository, mailer interfaces.IMailer, events interfaces.ISecurityEvents, policy passwords.Policy) interfaces.IAdminService {
+	return &adminService{
+		repo:   repo,
+		mailer: mailer,
+		events: events,
+		policy: policy,
+		logger: logger,
+	}
+}
+
+// ListUsers - service layer for POST /admin/users route, lists and searches the users
+func (s *adminService) ListUsers(ctx context.Context, request models.ListUsersRequest) (models.ListUsersResponse, error) {
+	response, err := s.repo.ListUsers(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.ListUsers(), error from repo.ListUsers()")
+		return models.ListUsersResponse{}, err
+	}
+	return response, nil
+}
+
+// DisableUser - the user can no longer log in and their sessions and access tokens are refused
+func (s *adminService) DisableUser(ctx context.Context, request models.ManageUserRequest) (models.UserSummary, error) {
+	request.Email = utils.NormalizeEmail(request.Email)
+	if request.Email == utils.GetEmailFromCtx(ctx) {
+		s.logger.Warn(ctx, "Error in adminService.DisableUser(), own account")
+		return models.UserSummary{}, models.ErrManageOwnAccount
+	}
+	user, err := s.repo.SetUserDisabled(ctx, request.Email, true)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.DisableUser(), error from repo.SetUserDisabled()")
+		return models.UserSummary{}, err
+	}
+	s.emit(ctx, models.SecurityEventUserDisabled, user.Email)
+	return user, nil
+}
+
+// EnableUser - lets a disabled user log in again, the sessions revoked by the disabling stay revoked
+func (s *adminService) EnableUser(ctx context.Context, request models.ManageUserRequest) (models.UserSummary, error) {
+	user, err := s.repo.SetUserDisabled(ctx, request.Email, false)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.EnableUser(), error from repo.SetUserDisabled()")
+		return models.UserSummary{}, err
+	}
+	s.emit(ctx, models.SecurityEventUserEnabled, user.Email)
+	return user, nil
+}
+
+// ForcePasswordReset - signs the user out everywhere and emails them a password reset token, they can not log
+// in with their password until they set a new one
+func (s *adminService) ForcePasswordReset(ctx context.Context, request models.ManageUserRequest) error {
+	token, err := utils.NewToken()
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.ForcePasswordReset(), error from utils.NewToken()", err)
+		return err
+	}
+	ttl := viper.GetDuration(constants.PasswordResetTTLEnvKey)
+	user, err := s.repo.ForcePasswordReset(ctx, models.PasswordReset{
+		TokenHash: utils.HashToken(token),
+		Email:     request.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.ForcePasswordReset(), error from repo.ForcePasswordReset()")
+		return err
+	}
+	s.emit(ctx, models.SecurityEventPasswordResetForced, user.Email)
+	// the password is removed either way, the user can ask for another token with ForgotPassword
+	err = s.mailer.Send(ctx, models.Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nan administrator asked you to choose a new password, you were signed out "+
+			"everywhere. Use this code to reset your password, it expires in %s:\n\n%s\n", user.Name, ttl, token),
+	})
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.ForcePasswordReset(), error from s.mailer.Send()", err)
+	}
+	return nil
+}
+
+// Bootstrap - creates the first admin at startup. The admin configured with ADMIN_EMAIL and ADMIN_PASSWORD must
+// change the password on first login. Without them a one-time setup token is returned, to be printed for the
+// operator to create the admin with Setup. Returns no token when there is an admin already.
+func (s *adminService) Bootstrap(ctx context.Context) (string, error) {
+	email := utils.NormalizeEmail(viper.GetString(constants.AdminEmailEnvKey))
+	if email != "" {
+		password := viper.GetString(constants.AdminPasswordEnvKey)
+		if password == "" {
+			return "", fmt.Errorf("%s is required with %s", constants.AdminPasswordEnvKey, constants.AdminEmailEnvKey)
+		}
+		err := s.repo.CreateAdmin(ctx, models.User{
+			Id:                 utils.NewID(),
+			Name:               viper.GetString(constants.AdminNameEnvKey),
+			Email:              email,
+			Password:           password,
+			Verified:           true,
+			MustChangePassword: true,
+		})
+		if errors.Is(err, models.ErrAdminExists) {
+			return "", nil
+		}
+		if err != nil {
+			s.logger.Warn(ctx, "Error in adminService.Bootstrap(), error from repo.CreateAdmin()", err)
+			return "", err
+		}
+		s.emit(ctx, models.SecurityEventAdminBootstrapped, email)
+		return "", nil
+	}
+	token, err := utils.NewToken()
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.Bootstrap(), error from utils.NewToken()", err)
+		return "", err
+	}
+	err = s.repo.AddSetupToken(ctx, models.SetupToken{TokenHash: utils.HashToken(token)})
+	if errors.Is(err, models.ErrAdminExists) {
+		return "", nil
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.Bootstrap(), error from repo.AddSetupToken()", err)
+		return "", err
+	}
+	return token, nil
+}
+
+// Setup - service layer for POST /setup route, creates the first admin with the setup token printed at startup
+func (s *adminService) Setup(ctx context.Context, request models.SetupRequest) error {
+	request.Email = utils.NormalizeEmail(request.Email)
+	err := s.policy.Check("password", request.Password, request.Email, request.Name)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.Setup(), error from policy.Check()")
+		return err
+	}
+	err = s.repo.SetupAdmin(ctx, utils.HashToken(request.Token), models.User{
+		Id:       utils.NewID(),
+		Name:     request.Name,
+		Email:    request.Email,
+		Password: request.Password,
+		Verified: true,
+	})
+	if err != nil {
+		s.logger.Warn(ctx, "Error in adminService.Setup(), error from repo.SetupAdmin()")
+		return err
+	}
+	s.emit(ctx, models.SecurityEventAdminBootstrapped, request.Email)
+	return nil
+}
+
+// emit - raises an event of the admin of the request acting on the user with the email
+func (s *adminService) emit(ctx context.Context, eventType, email string) {
+	s.events.Emit(ctx, models.SecurityEvent{
+		Type:      eventType,
+		At:        time.Now(),
+		RequestID: utils.GetRequestIDFromCtx(ctx),
+		Email:     utils.GetEmailFromCtx(ctx),
+		IP:        utils.GetClientIPFromCtx(ctx),
+		Subject:   email,
+	})
+}
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/mailers"
+	"notes-server/models"
+	"notes-server/security"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_adminService_DisableUser(t *testing.T) {
+	tests := []struct {
+		name       string
+		email      string
+		given      func(*interfaces.MockIAdminRepository)
+		wantErr    error
+		wantEvents int
+	}{
+		{
+			name:  "success case",
+			email: "test@gmail.com",
+			given: func(r *interfaces.MockIAdminRepository) {
+				r.EXPECT().SetUserDisabled(mock.Anything, "test@gmail.com", true).
+					Return(models.UserSummary{Email: "test@gmail.com", Disabled: true}, nil)
+			},
+			wantEvents: 1,
+		},
+		{
+			name:    "failure case - own account",
+			email:   "admin@gmail.com",
+			given:   func(r *interfaces.MockIAdminRepository) {},
+			wantErr: models.ErrManageOwnAccount,
+		},
+		{
+			name:  "failure case - unknown user",
+			email: "nobody@gmail.com",
+			given: func(r *interfaces.MockIAdminRepository) {
+				r.EXPECT().SetUserDisabled(mock.Anything, "nobody@gmail.com", true).Return(models.UserSummary{}, models.ErrUserNotFound)
+			},
+			wantErr: models.ErrUserNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockIAdminRepository{}
+			tt.given(&mockRepo)
+			events := security.NewFakeEvents()
+			s := &adminService{repo: &mockRepo, events: events, logger: loggers.NewLogger()}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "admin@gmail.com")
+			_, err := s.DisableUser(ctx, models.ManageUserRequest{Email: tt.email})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("adminService.DisableUser() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			got := events.Events()
+			if len(got) != tt.wantEvents {
+				t.Fatalf("adminService.DisableUser() emitted %d events, want %d", len(got), tt.wantEvents)
+			}
+			if tt.wantEvents > 0 && (got[0].Type != models.SecurityEventUserDisabled || got[0].Email != "admin@gmail.com" || got[0].Subject != tt.email) {
+				t.Errorf("adminService.DisableUser() emitted %+v", got[0])
+			}
+		})
+	}
+}
+
+func Test_adminService_ForcePasswordReset(t *testing.T) {
+	viper.Set(constants.PasswordResetTTLEnvKey, time.Hour)
+	mockRepo := interfaces.MockIAdminRepository{}
+	mockRepo.EXPECT().ForcePasswordReset(mock.Anything, mock.MatchedBy(func(reset models.PasswordReset) bool {
+		return reset.Email == "test@gmail.com" && reset.TokenHash != ""
+	})).Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+	mailer := mailers.NewFakeMailer()
+	events := security.NewFakeEvents()
+	s := &adminService{repo: &mockRepo, mailer: mailer, events: events, logger: loggers.NewLogger()}
+	ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "admin@gmail.com")
+
+	if err := s.ForcePasswordReset(ctx, models.ManageUserRequest{Email: "test@gmail.com"}); err != nil {
+		t.Fatalf("adminService.ForcePasswordReset() error = %v", err)
+	}
+	mails := mailer.Mails()
+	if len(mails) != 1 || mails[0].To != "test@gmail.com" || !strings.Contains(mails[0].Body, "administrator") {
+		t.Errorf("adminService.ForcePasswordReset() sent %+v", mails)
+	}
+	if got := events.Events(); len(got) != 1 || got[0].Type != models.SecurityEventPasswordResetForced {
+		t.Errorf("adminService.ForcePasswordReset() emitted %+v", got)
+	}
+}
+
+func Test_adminService_Bootstrap(t *testing.T) {
+	tests := []struct {
+		name      string
+		email     string
+		password  string
+		given     func(*interfaces.MockIAdminRepository)
+		wantToken bool
+		wantErr   bool
+	}{
+		{
+			name:     "success case - configured admin must change the password",
+			email:    "admin@gmail.com",
+			password: "initial",
+			given: func(r *interfaces.MockIAdminRepository) {
+				r.EXPECT().CreateAdmin(mock.Anything, mock.MatchedBy(func(user models.User) bool {
+					return user.Email == "admin@gmail.com" && user.Password == "initial" && user.Verified && user.MustChangePassword
+				})).Return(nil)
+			},
+		},
+		{
+			name:     "success case - configured admin already created",
+			email:    "admin@gmail.com",
+			password: "initial",
+			given: func(r *interfaces.MockIAdminRepository) {
+				r.EXPECT().CreateAdmin(mock.Anything, mock.Anything).Return(models.ErrAdminExists)
+			},
+		},
+		{
+			name: "success case - setup token",
+			given: func(r *interfaces.MockIAdminRepository) {
+				r.EXPECT().AddSetupToken(mock.Anything, mock.Anything).Return(nil)
+			},
+			wantToken: true,
+		},
+		{
+			name: "success case - no setup token once there is an admin",
+			given: func(r *interfaces.MockIAdminRepository) {
+				r.EXPECT().AddSetupToken(mock.Anything, mock.Anything).Return(models.ErrAdminExists)
+			},
+		},
+		{
+			name:    "failure case - email without a password",
+			email:   "admin@gmail.com",
+			given:   func(r *interfaces.MockIAdminRepository) {},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.AdminEmailEnvKey, tt.email)
+			viper.Set(constants.AdminPasswordEnvKey, tt.password)
+			defer viper.Set(constants.AdminEmailEnvKey, "")
+			defer viper.Set(constants.AdminPasswordEnvKey, "")
+			mockRepo := interfaces.MockIAdminRepository{}
+			tt.given(&mockRepo)
+			s := &adminService{repo: &mockRepo, events: security.NewFakeEvents(), logger: loggers.NewLogger()}
+			token, err := s.Bootstrap(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("adminService.Bootstrap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (token != "") != tt.wantToken {
+				t.Errorf("adminService.Bootstrap() token = %q, wantToken %v", token, tt.wantToken)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
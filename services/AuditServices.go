@@ -0,0 +1,50 @@
+package services
+
+import (
+	"context"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"time"
+)
+
+type auditService struct {
+	repo      interfaces.IAuditRepository
+	retention time.Duration
+	logger    *loggers.Logger
+	now       func() time.Time
+}
+
+// NewAuditService - the entries of the audit log are kept for retention, forever when it is 0. They are written
+// by the repositories, in the transactions of the changes they record.
+func NewAuditService(logger *loggers.Logger, repo interfaces.IAuditRepository, retention time.Duration) interfaces.IAuditService {
+	return &auditService{
+		repo:      repo,
+		retention: retention,
+		logger:    logger,
+		now:       time.Now,
+	}
+}
+
+// GetAuditLog - retrieves the entries of the audit log matching the request, newest first
+func (s *auditService) GetAuditLog(ctx context.Context, request models.GetAuditLogRequest) (models.GetAuditLogResponse, error) {
+	response, err := s.repo.GetAuditLog(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in auditService.GetAuditLog(), error from repo.GetAuditLog()")
+		return models.GetAuditLogResponse{}, err
+	}
+	return response, nil
+}
+
+// PurgeAuditLog - deletes the entries older than the retention, returns how many were deleted
+func (s *auditService) PurgeAuditLog(ctx context.Context) (int, error) {
+	if s.retention <= 0 {
+		return 0, nil
+	}
+	deleted, err := s.repo.DeleteAuditEntries(ctx, s.now().Add(-s.retention))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in auditService.PurgeAuditLog(), error from repo.DeleteAuditEntries()")
+		return 0, err
+	}
+	return deleted, nil
+}
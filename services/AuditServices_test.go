@@ -0,0 +1,59 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_auditService_PurgeAuditLog(t *testing.T) {
+	now := time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name      string
+		retention time.Duration
+		given     func(*interfaces.MockIAuditRepository)
+		want      int
+		wantErr   bool
+	}{
+		{
+			name:      "success case",
+			retention: 24 * time.Hour,
+			given: func(r *interfaces.MockIAuditRepository) {
+				r.EXPECT().DeleteAuditEntries(mock.Anything, now.Add(-24*time.Hour)).Return(3, nil)
+			},
+			want: 3,
+		},
+		{
+			name:      "success case - kept forever",
+			retention: 0,
+			given:     func(r *interfaces.MockIAuditRepository) {},
+		},
+		{
+			name:      "failure case - error in repo.DeleteAuditEntries()",
+			retention: time.Hour,
+			given: func(r *interfaces.MockIAuditRepository) {
+				r.EXPECT().DeleteAuditEntries(mock.Anything, mock.Anything).Return(0, errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockIAuditRepository{}
+			tt.given(&mockRepo)
+			s := &auditService{repo: &mockRepo, retention: tt.retention, logger: loggers.NewLogger(), now: func() time.Time { return now }}
+			got, err := s.PurgeAuditLog(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("auditService.PurgeAuditLog() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("auditService.PurgeAuditLog() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
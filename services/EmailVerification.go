@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"net/url"
+	"notes-server/constants"
+	"notes-server/models"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/spf13/viper"
+)
+
+// Subjects of the verification tokens, each is signed with its own key so that a token can never be used for
+// another purpose or as a session token
+const (
+	verificationSubject = "email-verification"
+	emailChangeSubject  = "email-change"
+)
+
+// VerifyEmail - marks the address in a verification token as verified
+func (s *loginService) VerifyEmail(ctx context.Context, request models.VerifyEmailRequest) error {
+	s.logger.Info(ctx, "Entering LoginService.VerifyEmail()")
+	defer s.logger.Info(ctx, "Exiting LoginService.VerifyEmail()")
+	claims, err := parseVerificationToken(verificationSubject, request.Token)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.VerifyEmail(), error from parseVerificationToken()")
+		return err
+	}
+	err = s.repo.VerifyEmail(ctx, claims.Email)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.VerifyEmail(), error from s.repo.VerifyEmail()")
+		return err
+	}
+	return nil
+}
+
+// ResendVerificationEmail - sends a new verification email to a user that is not verified yet. Nothing tells the
+// caller whether the address is registered.
+func (s *loginService) ResendVerificationEmail(ctx context.Context, request models.ResendVerificationRequest) error {
+	s.logger.Info(ctx, "Entering LoginService.ResendVerificationEmail()")
+	defer s.logger.Info(ctx, "Exiting LoginService.ResendVerificationEmail()")
+	user, err := s.repo.GetUser(ctx, request.Email)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.ResendVerificationEmail(), error from s.repo.GetUser()")
+		return nil
+	}
+	if user.Verified {
+		return nil
+	}
+	// a failure to send is only logged, an error would tell the caller that the address is registered
+	err = s.sendVerificationEmail(ctx, user.Email, user.Name)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.ResendVerificationEmail(), error from sendVerificationEmail()", err)
+	}
+	return nil
+}
+
+func (s *loginService) sendVerificationEmail(ctx context.Context, email, name string) error {
+	claims := models.VerificationClaims{Email: email}
+	token, err := newVerificationToken(verificationSubject, claims, time.Now().Add(viper.GetDuration(constants.EmailVerificationTTLEnvKey)))
+	if err != nil {
+		return err
+	}
+	link := viper.GetString(constants.AppBaseURLEnvKey) + "/v1/api/verify-email?token=" + url.QueryEscape(token)
+	return s.mailer.Send(ctx, models.Mail{
+		To:      email,
+		Subject: "Verify your email address",
+		Body:    fmt.Sprintf("Hi %s,\n\nfollow this link to verify your email address:\n\n%s\n", name, link),
+	})
+}
+
+func newVerificationToken(subject string, claims models.VerificationClaims, expiresAt time.Time) (string, error) {
+	claims.Subject = subject
+	claims.ExpiresAt = jwt.NewNumericDate(expiresAt)
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, &claims).SignedString(verificationKey(subject))
+}
+
+// parseVerificationToken - returns the claims of a token issued for subject
+func parseVerificationToken(subject, token string) (models.VerificationClaims, error) {
+	claims := models.VerificationClaims{}
+	_, err := jwt.ParseWithClaims(token, &claims, func(token *jwt.Token) (interface{}, error) {
+		if token.Method != jwt.SigningMethodHS256 {
+			return nil, errors.New("unexpected signing method")
+		}
+		return verificationKey(subject), nil
+	})
+	if err != nil {
+		return models.VerificationClaims{}, err
+	}
+	if claims.Subject != subject || claims.ExpiresAt == nil || claims.Email == "" {
+		return models.VerificationClaims{}, models.ErrInvalidVerificationLink
+	}
+	return claims, nil
+}
+
+func verificationKey(subject string) []byte {
+	mac := hmac.New(sha256.New, []byte(viper.GetString(constants.JwtSecretEnvKey)))
+	mac.Write([]byte(subject))
+	return mac.Sum(nil)
+}
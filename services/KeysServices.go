@@ -0,0 +1,43 @@
+package services
+
+import (
+	"context"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+)
+
+type keysService struct {
+	repo   interfaces.IKeysRepository
+	logger *loggers.Logger
+}
+
+func NewKeysService(logger *loggers.Logger, repo interfaces.IKeysRepository) interfaces.IKeysService {
+	return &keysService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// GetKeyMaterial - retrieves the wrapped note key of the user, for a client to unwrap it
+func (s *keysService) GetKeyMaterial(ctx context.Context) (models.KeyMaterial, error) {
+	email := utils.GetEmailFromCtx(ctx)
+	material, err := s.repo.GetKeyMaterial(ctx, email)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in keysService.GetKeyMaterial(), error from repo.GetKeyMaterial()")
+		return models.KeyMaterial{}, err
+	}
+	return material, nil
+}
+
+// SetKeyMaterial - stores the wrapped note key of the user, when it is first created or wrapped again with a new passphrase
+func (s *keysService) SetKeyMaterial(ctx context.Context, material models.KeyMaterial) (models.SetKeyMaterialResponse, error) {
+	material.Email = utils.GetEmailFromCtx(ctx)
+	version, err := s.repo.SetKeyMaterial(ctx, material)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in keysService.SetKeyMaterial(), error from repo.SetKeyMaterial()")
+		return models.SetKeyMaterialResponse{}, err
+	}
+	return models.SetKeyMaterialResponse{Version: version}, nil
+}
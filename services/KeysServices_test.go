@@ -0,0 +1,106 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_keysService_SetKeyMaterial(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockIKeysRepository)
+		want    models.SetKeyMaterialResponse
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockIKeysRepository) {
+				r.EXPECT().SetKeyMaterial(mock.Anything, mock.MatchedBy(func(material models.KeyMaterial) bool {
+					return material.Email == "test@gmail.com"
+				})).Return(2, nil)
+			},
+			want:    models.SetKeyMaterialResponse{Version: 2},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.SetKeyMaterial()",
+			given: func(r *interfaces.MockIKeysRepository) {
+				r.EXPECT().SetKeyMaterial(mock.Anything, mock.Anything).Return(0, errors.New("key material was changed by another client"))
+			},
+			want:    models.SetKeyMaterialResponse{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockIKeysRepository{}
+			tt.given(&mockRepo)
+			s := &keysService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			got, err := s.SetKeyMaterial(ctx, models.KeyMaterial{Version: 1})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("keysService.SetKeyMaterial() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keysService.SetKeyMaterial() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_keysService_GetKeyMaterial(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockIKeysRepository)
+		want    models.KeyMaterial
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockIKeysRepository) {
+				r.EXPECT().GetKeyMaterial(mock.Anything, "test@gmail.com").Return(models.KeyMaterial{Version: 1}, nil)
+			},
+			want:    models.KeyMaterial{Version: 1},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.GetKeyMaterial()",
+			given: func(r *interfaces.MockIKeysRepository) {
+				r.EXPECT().GetKeyMaterial(mock.Anything, mock.Anything).Return(models.KeyMaterial{}, errors.New("no key material stored"))
+			},
+			want:    models.KeyMaterial{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockIKeysRepository{}
+			tt.given(&mockRepo)
+			s := &keysService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			got, err := s.GetKeyMaterial(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("keysService.GetKeyMaterial() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("keysService.GetKeyMaterial() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
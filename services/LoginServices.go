@@ -3,25 +3,43 @@ package services
 import (
 	"context"
 	"errors"
-	"notes-server/constants"
+	"fmt"
 	"notes-server/interfaces"
 	"notes-server/loggers"
 	"notes-server/models"
+	"notes-server/passwords"
+	"notes-server/signing"
+	"notes-server/utils"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v4"
-	"github.com/spf13/viper"
 )
 
 type loginService struct {
 	repo   interfaces.ILoginRepository
+	mailer interfaces.IMailer
+	// oidc - nil when signing in with an identity provider is not configured
+	oidc     interfaces.IOIDCProvider
+	keys     *signing.Keyring
+	events   interfaces.ISecurityEvents
+	throttle models.LoginThrottle
+	policy   passwords.Policy
+	// mails - the emails being sent in the background
+	mails  sync.WaitGroup
 	logger *loggers.Logger
 }
 
-func NewLoginService(logger *loggers.Logger, repo interfaces.ILoginRepository) interfaces.ILoginService {
+func NewLoginService(logger *loggers.Logger, repo interfaces.ILoginRepository, mailer interfaces.IMailer, oidc interfaces.IOIDCProvider, keys *signing.Keyring, events interfaces.ISecurityEvents, throttle models.LoginThrottle, policy passwords.Policy) interfaces.ILoginService {
 	return &loginService{
-		repo:   repo,
-		logger: logger,
+		repo:     repo,
+		mailer:   mailer,
+		oidc:     oidc,
+		keys:     keys,
+		events:   events,
+		throttle: throttle,
+		policy:   policy,
+		logger:   logger,
 	}
 }
 
@@ -29,51 +47,96 @@ func NewLoginService(logger *loggers.Logger, repo interfaces.ILoginRepository) i
 func (s *loginService) Login(ctx context.Context, request models.LoginRequest) (models.LoginResponse, error) {
 	s.logger.Info(ctx, "Entering LoginService.Login()")
 	defer s.logger.Info(ctx, "Entering LoginService.Login()")
-	response, err := s.repo.Login(ctx, request)
+	request.Email = utils.NormalizeEmail(request.Email)
+	response, err := s.repo.Login(ctx, request, newLoginAttempt(ctx, s.throttle))
 	if err != nil {
 		s.logger.Warn(ctx, "Error in LoginService.Login(), error from s.repo.Login()")
+		emitLockouts(ctx, s.events, request.Email, err)
 		return models.LoginResponse{}, err
 	}
-	token, err := generateJWTToken(response.Email, response.Name)
+	if !response.Verified {
+		s.logger.Warn(ctx, "Error in LoginService.Login(), email address not verified")
+		return models.LoginResponse{}, models.ErrEmailNotVerified
+	}
+	if response.Disabled {
+		s.logger.Warn(ctx, "Error in LoginService.Login(), account disabled")
+		return models.LoginResponse{}, models.ErrAccountDisabled
+	}
+	if response.MFAEnabled {
+		return newMFAChallenge(response.Email)
+	}
+	token, err := generateJWTToken(s.keys, response.Email, response.Name, response.Role, response.Workspace, response.SessionVersion)
 	if err != nil {
 		s.logger.Warn(ctx, "Error in LoginService.Login(), error from generateJWTToken()")
 		return models.LoginResponse{}, err
 	}
 	return models.LoginResponse{
-		SID: token,
+		SID:                    token,
+		PasswordChangeRequired: response.MustChangePassword,
 	}, nil
 }
 
-// generateJWTToken - Creates a JWT token with HS256 signing method and signed with the secret
-func generateJWTToken(email string, name string) (string, error) {
-	expirationTime := time.Now().Add(5 * time.Minute)
+// newLoginAttempt - an attempt from the client of the request, throttled with the policies of throttle
+func newLoginAttempt(ctx context.Context, throttle models.LoginThrottle) models.LoginAttempt {
+	return models.LoginAttempt{IP: utils.GetClientIPFromCtx(ctx), At: time.Now(), Throttle: throttle}
+}
+
+// emitLockouts - raises a security event for each account or client the failed attempt locked out
+func emitLockouts(ctx context.Context, events interfaces.ISecurityEvents, email string, err error) {
+	var throttled *models.LoginThrottledError
+	if !errors.As(err, &throttled) {
+		return
+	}
+	for _, lockout := range throttled.Lockouts {
+		events.Emit(ctx, models.SecurityEvent{
+			Type:      models.SecurityEventLoginLockout,
+			At:        lockout.LastFailureAt,
+			RequestID: utils.GetRequestIDFromCtx(ctx),
+			Email:     email,
+			IP:        utils.GetClientIPFromCtx(ctx),
+			Subject:   lockout.Key,
+			Detail:    fmt.Sprintf("%d failed logins, locked out until %s", lockout.Failures, lockout.RetryAt.Format(time.RFC3339)),
+		})
+	}
+}
+
+// SessionTTL - time a session token is valid for
+const SessionTTL = 5 * time.Minute
+
+// generateJWTToken - Creates a JWT token signed with the current key of the keyring
+func generateJWTToken(keys *signing.Keyring, email string, name string, role string, workspace int32, sessionVersion int) (string, error) {
+	expirationTime := time.Now().Add(SessionTTL)
 	claims := &models.Claims{
-		Email: email,
-		Name:  name,
+		Email:          email,
+		Name:           name,
+		SessionVersion: sessionVersion,
+		Role:           role,
+		Workspace:      workspace,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expirationTime),
 		},
 	}
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString([]byte(viper.GetString(constants.JwtSecretEnvKey)))
+	return keys.Sign(claims)
 }
 
 func (s *loginService) SignUp(ctx context.Context, request models.SignUpRequest) error {
 	s.logger.Info(ctx, "Entering LoginService.SignUp()")
 	defer s.logger.Info(ctx, "Entering LoginService.SignUp()")
-	isUserExists, err := s.repo.CheckIfUserExists(ctx, request.Email)
+	request.Email = utils.NormalizeEmail(request.Email)
+	err := s.policy.Check("password", request.Password, request.Email, request.Name)
 	if err != nil {
-		s.logger.Warn(ctx, "Error in LoginService.SignUp(), error from s.repo.CheckIfUserExists()")
+		s.logger.Warn(ctx, "Error in LoginService.SignUp(), error from s.policy.Check()")
 		return err
 	}
-	if isUserExists {
-		s.logger.Warn(ctx, "Error in LoginService.SignUp(), user already exists")
-		return errors.New("user already exists")
-	}
 	err = s.repo.SignUp(ctx, request)
 	if err != nil {
 		s.logger.Warn(ctx, "Error in LoginService.SignUp(), error from s.repo.SignUp()")
 		return err
 	}
+	// the user is created either way, a failed email can be sent again with ResendVerificationEmail
+	err = s.sendVerificationEmail(ctx, request.Email, request.Name)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.SignUp(), error from sendVerificationEmail()")
+	}
 	return nil
 }
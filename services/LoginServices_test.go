@@ -3,14 +3,34 @@ package services
 import (
 	"context"
 	"errors"
+	"net/url"
+	"notes-server/constants"
 	"notes-server/interfaces"
 	"notes-server/loggers"
+	"notes-server/mailers"
 	"notes-server/models"
+	"notes-server/passwords"
+	"notes-server/security"
+	"notes-server/signing"
+	"notes-server/utils"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/spf13/viper"
 	"github.com/stretchr/testify/mock"
 )
 
+// testSigningKeys - a keyring signing with a generated EdDSA key
+func testSigningKeys(t *testing.T) *signing.Keyring {
+	t.Helper()
+	keys, err := signing.NewKeyring(signing.EdDSA, "", time.Hour)
+	if err != nil {
+		t.Fatalf("signing.NewKeyring() error = %v", err)
+	}
+	return keys
+}
+
 func Test_loginService_Login(t *testing.T) {
 	type args struct {
 		ctx     context.Context
@@ -32,12 +52,44 @@ func Test_loginService_Login(t *testing.T) {
 				},
 			},
 			given: func(r *interfaces.MockILoginRepository) {
-				r.EXPECT().Login(mock.Anything, mock.Anything).Return(models.LoginRepoResponse{
+				r.EXPECT().Login(mock.Anything, mock.Anything, mock.Anything).Return(models.LoginRepoResponse{
+					Email:    "test@gmail.com",
+					Name:     "test",
+					Verified: true,
+				}, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - email address not verified",
+			args: args{
+				ctx: context.Background(),
+				request: models.LoginRequest{
+					Email:    "test@gmail.com",
+					Password: "testpassword",
+				},
+			},
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().Login(mock.Anything, mock.Anything, mock.Anything).Return(models.LoginRepoResponse{
 					Email: "test@gmail.com",
 					Name:  "test",
 				}, nil)
 			},
-			wantErr: false,
+			wantErr: true,
+		},
+		{
+			name: "failure case - invalid credentials",
+			args: args{
+				ctx: context.Background(),
+				request: models.LoginRequest{
+					Email:    "test@gmail.com",
+					Password: "wrongpassword",
+				},
+			},
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().Login(mock.Anything, mock.Anything, mock.Anything).Return(models.LoginRepoResponse{}, models.ErrInvalidCredentials)
+			},
+			wantErr: true,
 		},
 		{
 			name: "failure case - error in repo.Login()",
@@ -49,7 +101,7 @@ func Test_loginService_Login(t *testing.T) {
 				},
 			},
 			given: func(r *interfaces.MockILoginRepository) {
-				r.EXPECT().Login(mock.Anything, mock.Anything).Return(models.LoginRepoResponse{}, errors.New("db error"))
+				r.EXPECT().Login(mock.Anything, mock.Anything, mock.Anything).Return(models.LoginRepoResponse{}, errors.New("db error"))
 			},
 			wantErr: true,
 		},
@@ -60,6 +112,8 @@ func Test_loginService_Login(t *testing.T) {
 			tt.given(&mockRepo)
 			s := &loginService{
 				repo:   &mockRepo,
+				keys:   testSigningKeys(t),
+				events: security.NewFakeEvents(),
 				logger: loggers.NewLogger(),
 			}
 			_, err := s.Login(tt.args.ctx, tt.args.request)
@@ -71,6 +125,32 @@ func Test_loginService_Login(t *testing.T) {
 	}
 }
 
+func Test_loginService_Login_lockout(t *testing.T) {
+	throttle := models.LoginThrottle{Account: models.LoginThrottlePolicy{LockoutAfter: 10, LockoutDuration: time.Minute}}
+	retryAt := time.Now().Add(time.Minute)
+	lockout := models.LoginFailures{Key: models.AccountThrottleKey("test@gmail.com"), Failures: 10, RetryAt: retryAt, LockedOut: true}
+	mockRepo := interfaces.MockILoginRepository{}
+	mockRepo.EXPECT().Login(mock.Anything, mock.Anything, mock.MatchedBy(func(attempt models.LoginAttempt) bool {
+		return attempt.IP == "203.0.113.7" && attempt.Throttle == throttle
+	})).Return(models.LoginRepoResponse{}, &models.LoginThrottledError{RetryAt: retryAt, Lockouts: []models.LoginFailures{lockout}}).Once()
+	mockRepo.EXPECT().Login(mock.Anything, mock.Anything, mock.Anything).Return(models.LoginRepoResponse{}, &models.LoginThrottledError{RetryAt: retryAt})
+	events := security.NewFakeEvents()
+	s := &loginService{repo: &mockRepo, events: events, throttle: throttle, logger: loggers.NewLogger()}
+	ctx := context.WithValue(context.Background(), constants.ClientIPCtxKey, "203.0.113.7")
+	request := models.LoginRequest{Email: "test@gmail.com", Password: "wrongpassword"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := s.Login(ctx, request); !errors.Is(err, models.ErrTooManyLoginAttempts) {
+			t.Fatalf("loginService.Login() error = %v, want %v", err, models.ErrTooManyLoginAttempts)
+		}
+	}
+	// the event is raised once, by the failure that locked the account out
+	got := events.Events()
+	if len(got) != 1 || got[0].Type != models.SecurityEventLoginLockout || got[0].Subject != lockout.Key || got[0].Email != "test@gmail.com" || got[0].IP != "203.0.113.7" {
+		t.Errorf("security events = %+v, want one lockout of %s", got, lockout.Key)
+	}
+}
+
 func Test_loginService_SignUp(t *testing.T) {
 	type args struct {
 		ctx     context.Context
@@ -78,6 +158,7 @@ func Test_loginService_SignUp(t *testing.T) {
 	}
 	tests := []struct {
 		name    string
+		policy  passwords.Policy
 		args    args
 		given   func(*interfaces.MockILoginRepository)
 		wantErr bool
@@ -94,23 +175,36 @@ func Test_loginService_SignUp(t *testing.T) {
 			},
 			given: func(r *interfaces.MockILoginRepository) {
 				r.EXPECT().SignUp(mock.Anything, mock.Anything).Return(nil)
-				r.EXPECT().CheckIfUserExists(mock.Anything, mock.Anything).Return(false, nil)
 			},
 			wantErr: false,
 		},
 		{
-			name: "failure case - error in repo.CheckIfUserExists",
+			name: "success case - email normalized",
 			args: args{
 				ctx: context.Background(),
 				request: models.SignUpRequest{
-					Email:    "test@gmail.com",
+					Email:    " Test@Gmail.com",
 					Password: "testpassword",
 					Name:     "test",
 				},
 			},
 			given: func(r *interfaces.MockILoginRepository) {
-				r.EXPECT().CheckIfUserExists(mock.Anything, mock.Anything).Return(false, errors.New("db error"))
+				r.EXPECT().SignUp(mock.Anything, models.SignUpRequest{Email: "test@gmail.com", Password: "testpassword", Name: "test"}).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:   "failure case - password does not follow the policy",
+			policy: passwords.Policy{MinLength: 8, RequiredClasses: []string{passwords.ClassDigit}},
+			args: args{
+				ctx: context.Background(),
+				request: models.SignUpRequest{
+					Email:    "test@gmail.com",
+					Password: "testpassword",
+					Name:     "test",
+				},
 			},
+			given:   func(r *interfaces.MockILoginRepository) {},
 			wantErr: true,
 		},
 		{
@@ -124,8 +218,7 @@ func Test_loginService_SignUp(t *testing.T) {
 				},
 			},
 			given: func(r *interfaces.MockILoginRepository) {
-				r.EXPECT().SignUp(mock.Anything, mock.Anything).Return(nil)
-				r.EXPECT().CheckIfUserExists(mock.Anything, mock.Anything).Return(true, nil)
+				r.EXPECT().SignUp(mock.Anything, mock.Anything).Return(models.ErrEmailTaken)
 			},
 			wantErr: true,
 		},
@@ -140,7 +233,6 @@ func Test_loginService_SignUp(t *testing.T) {
 				},
 			},
 			given: func(r *interfaces.MockILoginRepository) {
-				r.EXPECT().CheckIfUserExists(mock.Anything, mock.Anything).Return(false, nil)
 				r.EXPECT().SignUp(mock.Anything, mock.Anything).Return(errors.New("db error"))
 			},
 			wantErr: true,
@@ -150,8 +242,11 @@ func Test_loginService_SignUp(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			mockRepo := interfaces.MockILoginRepository{}
 			tt.given(&mockRepo)
+			mailer := mailers.NewFakeMailer()
 			s := &loginService{
 				repo:   &mockRepo,
+				mailer: mailer,
+				policy: tt.policy,
 				logger: loggers.NewLogger(),
 			}
 			err := s.SignUp(tt.args.ctx, tt.args.request)
@@ -159,6 +254,257 @@ func Test_loginService_SignUp(t *testing.T) {
 				t.Errorf("loginService.SignUp() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
+			if sent := len(mailer.Mails()); (sent == 1) == tt.wantErr {
+				t.Errorf("loginService.SignUp() sent %d verification emails", sent)
+			}
+		})
+	}
+}
+
+func Test_loginService_VerifyEmail(t *testing.T) {
+	viper.Set(constants.EmailVerificationTTLEnvKey, time.Hour)
+	mailer := mailers.NewFakeMailer()
+	mockRepo := interfaces.MockILoginRepository{}
+	mockRepo.EXPECT().SignUp(mock.Anything, mock.Anything).Return(nil)
+	mockRepo.EXPECT().VerifyEmail(mock.Anything, "test@gmail.com").Return(nil).Once()
+	s := &loginService{repo: &mockRepo, mailer: mailer, logger: loggers.NewLogger()}
+	err := s.SignUp(context.Background(), models.SignUpRequest{Email: "test@gmail.com", Password: "testpassword", Name: "test"})
+	if err != nil {
+		t.Fatalf("loginService.SignUp() error = %v", err)
+	}
+
+	// the link of the email verifies the address it was sent to
+	mails := mailer.Mails()
+	if len(mails) != 1 || mails[0].To != "test@gmail.com" {
+		t.Fatalf("loginService.SignUp() sent %v", mails)
+	}
+	start := strings.Index(mails[0].Body, "?token=")
+	if start < 0 {
+		t.Fatalf("no verification link in %q", mails[0].Body)
+	}
+	token, err := url.QueryUnescape(strings.Fields(mails[0].Body[start+len("?token="):])[0])
+	if err != nil {
+		t.Fatalf("invalid verification link: %v", err)
+	}
+	if err = s.VerifyEmail(context.Background(), models.VerifyEmailRequest{Token: token}); err != nil {
+		t.Errorf("loginService.VerifyEmail() error = %v", err)
+	}
+
+	// expired, tampered and session tokens are refused
+	expired, _ := newVerificationToken(verificationSubject, models.VerificationClaims{Email: "test@gmail.com"}, time.Now().Add(-time.Minute))
+	session, _ := generateJWTToken(testSigningKeys(t), "test@gmail.com", "test", models.RoleUser, 0, 0)
+	for name, token := range map[string]string{"expired": expired, "tampered": token[:len(token)-2] + "xx", "session": session} {
+		if err = s.VerifyEmail(context.Background(), models.VerifyEmailRequest{Token: token}); err == nil {
+			t.Errorf("loginService.VerifyEmail() accepted a %s token", name)
+		}
+	}
+	mockRepo.AssertExpectations(t)
+}
+
+func Test_loginService_ResendVerificationEmail(t *testing.T) {
+	tests := []struct {
+		name     string
+		given    func(*interfaces.MockILoginRepository)
+		mailErr  error
+		wantSent int
+	}{
+		{
+			name: "success case - user not verified",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+			},
+			wantSent: 1,
+		},
+		{
+			name: "success case - user already verified",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{Email: "test@gmail.com", Verified: true}, nil)
+			},
+		},
+		{
+			name: "success case - unknown user",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{}, errors.New("user not found"))
+			},
+		},
+		{
+			name: "success case - send failure answered like an unknown user",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+			},
+			mailErr: errors.New("smtp down"),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockILoginRepository{}
+			tt.given(&mockRepo)
+			mailer := mailers.NewFakeMailer()
+			mailer.Err = tt.mailErr
+			s := &loginService{repo: &mockRepo, mailer: mailer, logger: loggers.NewLogger()}
+			err := s.ResendVerificationEmail(context.Background(), models.ResendVerificationRequest{Email: "test@gmail.com"})
+			if err != nil {
+				t.Errorf("loginService.ResendVerificationEmail() error = %v", err)
+			}
+			if sent := len(mailer.Mails()); sent != tt.wantSent {
+				t.Errorf("loginService.ResendVerificationEmail() sent %d emails, want %d", sent, tt.wantSent)
+			}
+		})
+	}
+}
+
+func Test_loginService_ForgotPassword(t *testing.T) {
+	tests := []struct {
+		name      string
+		given     func(*interfaces.MockILoginRepository, *mailers.FakeMailer)
+		wantSent  int
+		wantReset bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockILoginRepository, m *mailers.FakeMailer) {
+				r.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+				r.EXPECT().AddPasswordReset(mock.Anything, mock.Anything).Return(nil)
+			},
+			wantSent:  1,
+			wantReset: true,
+		},
+		{
+			name: "success case - unknown user",
+			given: func(r *interfaces.MockILoginRepository, m *mailers.FakeMailer) {
+				r.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{}, errors.New("user not found"))
+			},
+		},
+		{
+			name: "success case - error in s.repo.AddPasswordReset() is not returned",
+			given: func(r *interfaces.MockILoginRepository, m *mailers.FakeMailer) {
+				r.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+				r.EXPECT().AddPasswordReset(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+		},
+		{
+			name: "success case - error in s.mailer.Send() is not returned",
+			given: func(r *interfaces.MockILoginRepository, m *mailers.FakeMailer) {
+				r.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+				r.EXPECT().AddPasswordReset(mock.Anything, mock.Anything).Return(nil)
+				m.Err = errors.New("smtp down")
+			},
+			wantReset: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			viper.Set(constants.PasswordResetTTLEnvKey, time.Hour)
+			mockRepo := interfaces.MockILoginRepository{}
+			mailer := mailers.NewFakeMailer()
+			tt.given(&mockRepo, mailer)
+			s := &loginService{repo: &mockRepo, mailer: mailer, logger: loggers.NewLogger()}
+			err := s.ForgotPassword(context.Background(), models.ForgotPasswordRequest{Email: "test@gmail.com"})
+			if err != nil {
+				t.Errorf("loginService.ForgotPassword() error = %v", err)
+			}
+			s.mails.Wait()
+			mails := mailer.Mails()
+			if len(mails) != tt.wantSent {
+				t.Fatalf("loginService.ForgotPassword() sent %d emails, want %d", len(mails), tt.wantSent)
+			}
+			if !tt.wantReset {
+				return
+			}
+			reset := mockRepo.Calls[len(mockRepo.Calls)-1].Arguments.Get(1).(models.PasswordReset)
+			if reset.Email != "test@gmail.com" || time.Until(reset.ExpiresAt) <= 0 {
+				t.Errorf("loginService.ForgotPassword() stored %+v", reset)
+			}
+			if len(mails) == 1 {
+				// the email carries the token, only its hash is stored
+				found := false
+				for _, field := range strings.Fields(mails[0].Body) {
+					if utils.HashToken(field) == reset.TokenHash {
+						found = true
+					}
+				}
+				if !found {
+					t.Errorf("loginService.ForgotPassword() sent no token matching the stored hash: %q", mails[0].Body)
+				}
+			}
+		})
+	}
+}
+
+// the response does not wait for the email, it would take longer for a registered address than for an unknown one
+func Test_loginService_ForgotPassword_background(t *testing.T) {
+	viper.Set(constants.PasswordResetTTLEnvKey, time.Hour)
+	mockRepo := interfaces.MockILoginRepository{}
+	mockRepo.EXPECT().GetUser(mock.Anything, "test@gmail.com").Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+	mockRepo.EXPECT().AddPasswordReset(mock.Anything, mock.Anything).Return(nil)
+	delivered := make(chan struct{})
+	mailer := interfaces.MockIMailer{}
+	mailer.EXPECT().Send(mock.Anything, mock.Anything).Run(func(ctx context.Context, mail models.Mail) {
+		<-delivered
+	}).Return(nil)
+	s := &loginService{repo: &mockRepo, mailer: &mailer, logger: loggers.NewLogger()}
+
+	returned := make(chan struct{})
+	go func() {
+		_ = s.ForgotPassword(context.Background(), models.ForgotPasswordRequest{Email: "test@gmail.com"})
+		close(returned)
+	}()
+	select {
+	case <-returned:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("loginService.ForgotPassword() waited for the email to be sent")
+	}
+	close(delivered)
+	s.mails.Wait()
+	mailer.AssertExpectations(t)
+}
+
+func Test_loginService_ResetPassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockILoginRepository)
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetPasswordReset(mock.Anything, utils.HashToken("token"), mock.Anything).Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+				r.EXPECT().ResetPassword(mock.Anything, utils.HashToken("token"), "new password", mock.Anything).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - password contains the name of the user",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetPasswordReset(mock.Anything, mock.Anything, mock.Anything).Return(models.User{Email: "test@gmail.com", Name: "New Password"}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "failure case - error in s.repo.GetPasswordReset()",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetPasswordReset(mock.Anything, mock.Anything, mock.Anything).Return(models.User{}, models.ErrInvalidResetToken)
+			},
+			wantErr: true,
+		},
+		{
+			name: "failure case - error in s.repo.ResetPassword()",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetPasswordReset(mock.Anything, mock.Anything, mock.Anything).Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+				r.EXPECT().ResetPassword(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(models.ErrInvalidResetToken)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockILoginRepository{}
+			tt.given(&mockRepo)
+			s := &loginService{repo: &mockRepo, policy: passwords.Policy{ForbidPersonalInfo: true}, logger: loggers.NewLogger()}
+			err := s.ResetPassword(context.Background(), models.ResetPasswordRequest{Token: "token", Password: "new password"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("loginService.ResetPassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
 		})
 	}
 }
@@ -0,0 +1,159 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"notes-server/constants"
+	"notes-server/models"
+	"notes-server/totp"
+	"notes-server/utils"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+const (
+	// mfaChallengeSubject - subject of the token returned by the first step of a login with two-factor
+	// authentication, see verificationSubject
+	mfaChallengeSubject = "mfa-challenge"
+	mfaChallengeTTL     = 5 * time.Minute
+	recoveryCodeCount   = 10
+)
+
+// newMFAChallenge - the login response of a user with two-factor authentication, whose session is only issued by
+// LoginMFA. The ID of the token lets it be exchanged only once.
+func newMFAChallenge(email string) (models.LoginResponse, error) {
+	id, err := utils.NewToken()
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	claims := models.VerificationClaims{Email: email}
+	claims.ID = id
+	token, err := newVerificationToken(mfaChallengeSubject, claims, time.Now().Add(mfaChallengeTTL))
+	if err != nil {
+		return models.LoginResponse{}, err
+	}
+	return models.LoginResponse{MFARequired: true, MFAToken: token}, nil
+}
+
+// LoginMFA - second step of the login of a user with two-factor authentication, exchanges the challenge token and
+// a TOTP or recovery code for a session
+func (s *loginService) LoginMFA(ctx context.Context, request models.LoginMFARequest) (models.LoginResponse, error) {
+	s.logger.Info(ctx, "Entering LoginService.LoginMFA()")
+	defer s.logger.Info(ctx, "Exiting LoginService.LoginMFA()")
+	claims, err := parseVerificationToken(mfaChallengeSubject, request.MFAToken)
+	if err != nil || claims.ID == "" {
+		s.logger.Warn(ctx, "Error in LoginService.LoginMFA(), error from parseVerificationToken()")
+		return models.LoginResponse{}, models.ErrInvalidMFACode
+	}
+	challenge := models.MFAChallenge{ID: claims.ID, ExpiresAt: claims.ExpiresAt.Time}
+	user, err := s.repo.UseMFACode(ctx, claims.Email, request.Code, challenge, newLoginAttempt(ctx, s.throttle))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.LoginMFA(), error from s.repo.UseMFACode()")
+		emitLockouts(ctx, s.events, claims.Email, err)
+		return models.LoginResponse{}, err
+	}
+	if user.Disabled {
+		s.logger.Warn(ctx, "Error in LoginService.LoginMFA(), account disabled")
+		return models.LoginResponse{}, models.ErrAccountDisabled
+	}
+	token, err := generateJWTToken(s.keys, user.Email, user.Name, user.Role, user.DefaultWorkspace, user.SessionVersion)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.LoginMFA(), error from generateJWTToken()")
+		return models.LoginResponse{}, err
+	}
+	return models.LoginResponse{SID: token, PasswordChangeRequired: user.MustChangePassword}, nil
+}
+
+// EnrollMFA - generates the TOTP secret to add to an authenticator app, two-factor authentication is enabled
+// by ConfirmMFA
+func (s *accountService) EnrollMFA(ctx context.Context, request models.EnrollMFARequest) (models.MFAEnrollment, error) {
+	s.logger.Info(ctx, "Entering accountService.EnrollMFA()")
+	defer s.logger.Info(ctx, "Exiting accountService.EnrollMFA()")
+	request.Email = utils.GetEmailFromCtx(ctx)
+	secret, err := totp.NewSecret()
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.EnrollMFA(), error from totp.NewSecret()")
+		return models.MFAEnrollment{}, err
+	}
+	err = s.loginRepo.StartMFAEnrollment(ctx, request.Email, request.Code, secret, newLoginAttempt(ctx, s.throttle))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.EnrollMFA(), error from loginRepo.StartMFAEnrollment()")
+		emitLockouts(ctx, s.events, request.Email, err)
+		return models.MFAEnrollment{}, err
+	}
+	return models.MFAEnrollment{
+		Secret: secret,
+		URI:    totp.URI(viper.GetString(constants.MFAIssuerEnvKey), request.Email, secret),
+	}, nil
+}
+
+// ConfirmMFA - enables two-factor authentication with the first code of the enrolled authenticator app. The
+// recovery codes are only ever returned here.
+func (s *accountService) ConfirmMFA(ctx context.Context, request models.MFACodeRequest) (models.RecoveryCodesResponse, error) {
+	s.logger.Info(ctx, "Entering accountService.ConfirmMFA()")
+	defer s.logger.Info(ctx, "Exiting accountService.ConfirmMFA()")
+	request.Email = utils.GetEmailFromCtx(ctx)
+	recoveryCodes, err := newRecoveryCodes()
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.ConfirmMFA(), error from newRecoveryCodes()")
+		return models.RecoveryCodesResponse{}, err
+	}
+	err = s.loginRepo.ConfirmMFAEnrollment(ctx, request.Email, request.Code, recoveryCodes, newLoginAttempt(ctx, s.throttle))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.ConfirmMFA(), error from loginRepo.ConfirmMFAEnrollment()")
+		emitLockouts(ctx, s.events, request.Email, err)
+		return models.RecoveryCodesResponse{}, err
+	}
+	return models.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// RegenerateRecoveryCodes - replaces the recovery codes, the ones left are no longer accepted
+func (s *accountService) RegenerateRecoveryCodes(ctx context.Context, request models.MFACodeRequest) (models.RecoveryCodesResponse, error) {
+	s.logger.Info(ctx, "Entering accountService.RegenerateRecoveryCodes()")
+	defer s.logger.Info(ctx, "Exiting accountService.RegenerateRecoveryCodes()")
+	request.Email = utils.GetEmailFromCtx(ctx)
+	recoveryCodes, err := newRecoveryCodes()
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.RegenerateRecoveryCodes(), error from newRecoveryCodes()")
+		return models.RecoveryCodesResponse{}, err
+	}
+	err = s.loginRepo.ReplaceRecoveryCodes(ctx, request.Email, request.Code, recoveryCodes, newLoginAttempt(ctx, s.throttle))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.RegenerateRecoveryCodes(), error from loginRepo.ReplaceRecoveryCodes()")
+		emitLockouts(ctx, s.events, request.Email, err)
+		return models.RecoveryCodesResponse{}, err
+	}
+	return models.RecoveryCodesResponse{RecoveryCodes: recoveryCodes}, nil
+}
+
+// DisableMFA - turns two-factor authentication off, with the password and a TOTP or recovery code
+func (s *accountService) DisableMFA(ctx context.Context, request models.DisableMFARequest) error {
+	s.logger.Info(ctx, "Entering accountService.DisableMFA()")
+	defer s.logger.Info(ctx, "Exiting accountService.DisableMFA()")
+	request.Email = utils.GetEmailFromCtx(ctx)
+	err := s.loginRepo.DisableMFA(ctx, request, newLoginAttempt(ctx, s.throttle))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in accountService.DisableMFA(), error from loginRepo.DisableMFA()")
+		emitLockouts(ctx, s.events, request.Email, err)
+		return err
+	}
+	return nil
+}
+
+// newRecoveryCodes - returns recoveryCodeCount random codes formatted as xxxxx-xxxxx
+func newRecoveryCodes() ([]string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		random := make([]byte, 7)
+		if _, err := rand.Read(random); err != nil {
+			return nil, err
+		}
+		code := strings.ToLower(encoding.EncodeToString(random))[:10]
+		codes = append(codes, code[:5]+"-"+code[5:])
+	}
+	return codes, nil
+}
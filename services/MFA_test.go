@@ -0,0 +1,140 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/security"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_loginService_LoginMFA(t *testing.T) {
+	mockRepo := interfaces.MockILoginRepository{}
+	mockRepo.EXPECT().Login(mock.Anything, mock.Anything, mock.Anything).Return(models.LoginRepoResponse{
+		Email:      "test@gmail.com",
+		Name:       "test",
+		Verified:   true,
+		MFAEnabled: true,
+	}, nil)
+	s := &loginService{repo: &mockRepo, logger: loggers.NewLogger()}
+	challenge, err := s.Login(context.Background(), models.LoginRequest{Email: "test@gmail.com", Password: "testpassword"})
+	if err != nil {
+		t.Fatalf("loginService.Login() error = %v", err)
+	}
+	if challenge.SID != "" || !challenge.MFARequired || challenge.MFAToken == "" {
+		t.Fatalf("loginService.Login() = %+v, want an mfa_required challenge", challenge)
+	}
+	// the challenge is no session token
+	if _, err = parseVerificationToken(verificationSubject, challenge.MFAToken); err == nil {
+		t.Errorf("the challenge token is accepted as a verification token")
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		given   func(*interfaces.MockILoginRepository)
+		wantErr error
+	}{
+		{
+			name:  "success case",
+			token: challenge.MFAToken,
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().UseMFACode(mock.Anything, "test@gmail.com", "123456", mock.MatchedBy(func(c models.MFAChallenge) bool {
+					return c.ID != "" && c.ExpiresAt.After(time.Now())
+				}), mock.Anything).Return(models.User{Email: "test@gmail.com", Name: "test"}, nil)
+			},
+		},
+		{
+			name:    "failure case - invalid challenge",
+			token:   "not a token",
+			given:   func(r *interfaces.MockILoginRepository) {},
+			wantErr: models.ErrInvalidMFACode,
+		},
+		{
+			name:  "failure case - invalid code",
+			token: challenge.MFAToken,
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().UseMFACode(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(models.User{}, models.ErrInvalidMFACode)
+			},
+			wantErr: models.ErrInvalidMFACode,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockILoginRepository{}
+			tt.given(&mockRepo)
+			s := &loginService{repo: &mockRepo, keys: testSigningKeys(t), logger: loggers.NewLogger()}
+			got, err := s.LoginMFA(context.Background(), models.LoginMFARequest{MFAToken: tt.token, Code: "123456"})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("loginService.LoginMFA() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got.SID == "" {
+				t.Errorf("loginService.LoginMFA() issued no session")
+			}
+		})
+	}
+}
+
+func Test_accountService_MFA(t *testing.T) {
+	viper.Set(constants.MFAIssuerEnvKey, "Notes")
+	ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+	mockRepo := interfaces.MockILoginRepository{}
+	events := security.NewFakeEvents()
+	s := &accountService{loginRepo: &mockRepo, events: events, logger: loggers.NewLogger()}
+
+	mockRepo.EXPECT().StartMFAEnrollment(mock.Anything, "test@gmail.com", "", mock.Anything, mock.Anything).Return(nil).Once()
+	enrollment, err := s.EnrollMFA(ctx, models.EnrollMFARequest{})
+	if err != nil {
+		t.Fatalf("accountService.EnrollMFA() error = %v", err)
+	}
+	if enrollment.Secret == "" || !strings.HasPrefix(enrollment.URI, "otpauth://totp/Notes:test@gmail.com?") {
+		t.Errorf("accountService.EnrollMFA() = %+v", enrollment)
+	}
+	if stored := mockRepo.Calls[0].Arguments.String(3); stored != enrollment.Secret {
+		t.Errorf("accountService.EnrollMFA() stored %s, returned %s", stored, enrollment.Secret)
+	}
+
+	mockRepo.EXPECT().ConfirmMFAEnrollment(mock.Anything, "test@gmail.com", "123456", mock.Anything, mock.Anything).Return(nil).Once()
+	response, err := s.ConfirmMFA(ctx, models.MFACodeRequest{Code: "123456"})
+	if err != nil {
+		t.Fatalf("accountService.ConfirmMFA() error = %v", err)
+	}
+	seen := make(map[string]bool)
+	for _, code := range response.RecoveryCodes {
+		if len(code) != 11 || code[5] != '-' || seen[code] {
+			t.Errorf("accountService.ConfirmMFA() returned the recovery code %q", code)
+		}
+		seen[code] = true
+	}
+	if len(seen) != recoveryCodeCount {
+		t.Errorf("accountService.ConfirmMFA() returned %d recovery codes, want %d", len(seen), recoveryCodeCount)
+	}
+
+	mockRepo.EXPECT().ConfirmMFAEnrollment(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(models.ErrInvalidMFACode).Once()
+	if _, err = s.ConfirmMFA(ctx, models.MFACodeRequest{Code: "000000"}); !errors.Is(err, models.ErrInvalidMFACode) {
+		t.Errorf("accountService.ConfirmMFA() error = %v, want %v", err, models.ErrInvalidMFACode)
+	}
+
+	mockRepo.EXPECT().DisableMFA(mock.Anything, models.DisableMFARequest{Email: "test@gmail.com", Password: "password", Code: "123456"}, mock.Anything).Return(nil).Once()
+	if err = s.DisableMFA(ctx, models.DisableMFARequest{Password: "password", Code: "123456"}); err != nil {
+		t.Errorf("accountService.DisableMFA() error = %v", err)
+	}
+
+	// the wrong code that locks the account out raises the same event as a login
+	lockout := models.LoginFailures{Key: models.AccountThrottleKey("test@gmail.com"), Failures: 10, LockedOut: true}
+	mockRepo.EXPECT().DisableMFA(mock.Anything, mock.Anything, mock.Anything).Return(&models.LoginThrottledError{RetryAt: time.Now(), Lockouts: []models.LoginFailures{lockout}}).Once()
+	if err = s.DisableMFA(ctx, models.DisableMFARequest{Password: "password", Code: "000000"}); !errors.Is(err, models.ErrTooManyLoginAttempts) {
+		t.Errorf("accountService.DisableMFA() error = %v, want %v", err, models.ErrTooManyLoginAttempts)
+	}
+	if got := events.Events(); len(got) != 1 || got[0].Type != models.SecurityEventLoginLockout || got[0].Subject != lockout.Key {
+		t.Errorf("security events = %+v, want one lockout of %s", got, lockout.Key)
+	}
+}
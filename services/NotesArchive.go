@@ -0,0 +1,282 @@
+package services
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"notes-server/models"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	archiveFormat       = "notes-server/export"
+	archiveVersion      = 1
+	archiveManifestFile = "manifest.json"
+	archiveNotesDir     = "notes/"
+	// archiveMaxFileSize - upper bound for a single decompressed file while importing
+	archiveMaxFileSize = 10 << 20
+)
+
+const frontMatterDelimiter = "---"
+
+type archiveManifest struct {
+	Format     string                 `json:"format"`
+	Version    int                    `json:"version"`
+	ExportedAt time.Time              `json:"exported_at"`
+	Notes      []archiveManifestEntry `json:"notes"`
+}
+
+type archiveManifestEntry struct {
+	Id   int32  `json:"id"`
+	File string `json:"file"`
+}
+
+// noteFrontMatter - the YAML front-matter written on top of every exported note
+type noteFrontMatter struct {
+	Id       int32  `yaml:"id"`
+	Type     string `yaml:"type,omitempty"`
+	Title    string `yaml:"title,omitempty"`
+	Pinned   bool   `yaml:"pinned,omitempty"`
+	Archived bool   `yaml:"archived,omitempty"`
+	Starred  bool   `yaml:"starred,omitempty"`
+	Color    string `yaml:"color,omitempty"`
+	// Encryption - set for end-to-end encrypted notes, whose body is the base64 encoded ciphertext
+	Encryption *encryptionFrontMatter `yaml:"encryption,omitempty"`
+	Items      []checklistFrontMatter `yaml:"items,omitempty"`
+	DueAt      *time.Time             `yaml:"due_at,omitempty"`
+	RemindAt   *time.Time             `yaml:"remind_at,omitempty"`
+	Recurrence string                 `yaml:"recurrence,omitempty"`
+}
+
+type encryptionFrontMatter struct {
+	Scheme     string `yaml:"scheme"`
+	Nonce      string `yaml:"nonce"`
+	KeyVersion int    `yaml:"key_version"`
+}
+
+type checklistFrontMatter struct {
+	Text string `yaml:"text"`
+	Done bool   `yaml:"done"`
+}
+
+// archiveWriter - writes notes into a zip archive one at a time, the manifest is written on close
+type archiveWriter struct {
+	zw       *zip.Writer
+	manifest archiveManifest
+}
+
+func newArchiveWriter(w io.Writer) *archiveWriter {
+	return &archiveWriter{
+		zw: zip.NewWriter(w),
+		manifest: archiveManifest{
+			Format:     archiveFormat,
+			Version:    archiveVersion,
+			ExportedAt: time.Now().UTC(),
+			Notes:      []archiveManifestEntry{},
+		},
+	}
+}
+
+// WriteNote - adds a note to the archive as a markdown file with YAML front-matter
+func (a *archiveWriter) WriteNote(note models.Note) error {
+	name := fmt.Sprintf("%s%d.md", archiveNotesDir, note.Id)
+	content, err := encodeNoteMarkdown(note)
+	if err != nil {
+		return err
+	}
+	f, err := a.create(name)
+	if err != nil {
+		return err
+	}
+	if _, err = f.Write(content); err != nil {
+		return err
+	}
+	a.manifest.Notes = append(a.manifest.Notes, archiveManifestEntry{Id: note.Id, File: name})
+	return nil
+}
+
+// Close - writes the manifest and finishes the zip archive
+func (a *archiveWriter) Close() error {
+	f, err := a.create(archiveManifestFile)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+	if err = encoder.Encode(a.manifest); err != nil {
+		return err
+	}
+	return a.zw.Close()
+}
+
+func (a *archiveWriter) create(name string) (io.Writer, error) {
+	return a.zw.CreateHeader(&zip.FileHeader{
+		Name:     name,
+		Method:   zip.Deflate,
+		Modified: a.manifest.ExportedAt,
+	})
+}
+
+func encodeNoteMarkdown(note models.Note) ([]byte, error) {
+	items := make([]checklistFrontMatter, 0, len(note.Items))
+	for _, item := range note.Items {
+		items = append(items, checklistFrontMatter{Text: item.Text, Done: item.Done})
+	}
+	body := note.Note
+	var encryption *encryptionFrontMatter
+	if note.IsEncrypted() {
+		body = base64.StdEncoding.EncodeToString(note.Ciphertext)
+		encryption = &encryptionFrontMatter{
+			Scheme:     note.Encryption.Scheme,
+			Nonce:      base64.StdEncoding.EncodeToString(note.Encryption.Nonce),
+			KeyVersion: note.Encryption.KeyVersion,
+		}
+	}
+	frontMatter, err := yaml.Marshal(noteFrontMatter{
+		Id:         note.Id,
+		Type:       note.Type,
+		Title:      note.Title,
+		Pinned:     note.Pinned,
+		Archived:   note.Archived,
+		Starred:    note.Starred,
+		Color:      note.Color,
+		Encryption: encryption,
+		Items:      items,
+		DueAt:      note.DueAt,
+		RemindAt:   note.RemindAt,
+		Recurrence: note.Recurrence,
+	})
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.Write(frontMatter)
+	buf.WriteString(frontMatterDelimiter + "\n")
+	buf.WriteString(body)
+	return buf.Bytes(), nil
+}
+
+func decodeNoteMarkdown(content []byte) (noteFrontMatter, string, error) {
+	var frontMatter noteFrontMatter
+	text := strings.ReplaceAll(string(content), "\r\n", "\n")
+	if !strings.HasPrefix(text, frontMatterDelimiter+"\n") {
+		return frontMatter, "", errors.New("missing front-matter")
+	}
+	rest := text[len(frontMatterDelimiter)+1:]
+	end := strings.Index(rest, "\n"+frontMatterDelimiter+"\n")
+	if end < 0 {
+		return frontMatter, "", errors.New("unterminated front-matter")
+	}
+	if err := yaml.Unmarshal([]byte(rest[:end+1]), &frontMatter); err != nil {
+		return frontMatter, "", err
+	}
+	return frontMatter, rest[end+len(frontMatterDelimiter)+2:], nil
+}
+
+// readNotesArchive - reads the notes listed in the manifest of an archive produced by archiveWriter
+func readNotesArchive(data []byte) ([]models.Note, error) {
+	zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]*zip.File, len(zr.File))
+	for _, f := range zr.File {
+		files[f.Name] = f
+	}
+	manifestFile, ok := files[archiveManifestFile]
+	if !ok {
+		return nil, errors.New("archive has no manifest")
+	}
+	content, err := readArchiveFile(manifestFile)
+	if err != nil {
+		return nil, err
+	}
+	var manifest archiveManifest
+	if err = json.Unmarshal(content, &manifest); err != nil {
+		return nil, err
+	}
+	if manifest.Format != archiveFormat || manifest.Version > archiveVersion {
+		return nil, fmt.Errorf("unsupported archive format %q version %d", manifest.Format, manifest.Version)
+	}
+	notes := make([]models.Note, 0, len(manifest.Notes))
+	for _, entry := range manifest.Notes {
+		f, ok := files[entry.File]
+		if !ok {
+			return nil, fmt.Errorf("archive is missing %s", entry.File)
+		}
+		content, err := readArchiveFile(f)
+		if err != nil {
+			return nil, err
+		}
+		frontMatter, body, err := decodeNoteMarkdown(content)
+		if err != nil {
+			return nil, fmt.Errorf("invalid note %s: %w", entry.File, err)
+		}
+		var items []models.ChecklistItem
+		for _, item := range frontMatter.Items {
+			items = append(items, models.ChecklistItem{Text: item.Text, Done: item.Done})
+		}
+		var ciphertext []byte
+		var encryption *models.Encryption
+		if frontMatter.Encryption != nil {
+			ciphertext, encryption, err = decodeEncryptedBody(frontMatter.Encryption, body)
+			if err != nil {
+				return nil, fmt.Errorf("invalid note %s: %w", entry.File, err)
+			}
+			body = ""
+		}
+		notes = append(notes, models.Note{
+			Id:         frontMatter.Id,
+			Type:       frontMatter.Type,
+			Title:      frontMatter.Title,
+			Pinned:     frontMatter.Pinned,
+			Archived:   frontMatter.Archived,
+			Starred:    frontMatter.Starred,
+			Color:      frontMatter.Color,
+			Ciphertext: ciphertext,
+			Encryption: encryption,
+			Note:       body,
+			Items:      items,
+			DueAt:      frontMatter.DueAt,
+			RemindAt:   frontMatter.RemindAt,
+			Recurrence: frontMatter.Recurrence,
+		})
+	}
+	return notes, nil
+}
+
+func decodeEncryptedBody(frontMatter *encryptionFrontMatter, body string) ([]byte, *models.Encryption, error) {
+	ciphertext, err := base64.StdEncoding.DecodeString(strings.TrimSpace(body))
+	if err != nil {
+		return nil, nil, err
+	}
+	nonce, err := base64.StdEncoding.DecodeString(frontMatter.Nonce)
+	if err != nil {
+		return nil, nil, err
+	}
+	return ciphertext, &models.Encryption{Scheme: frontMatter.Scheme, Nonce: nonce, KeyVersion: frontMatter.KeyVersion}, nil
+}
+
+func readArchiveFile(f *zip.File) ([]byte, error) {
+	rc, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	content, err := io.ReadAll(io.LimitReader(rc, archiveMaxFileSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(content) > archiveMaxFileSize {
+		return nil, fmt.Errorf("%s is too large", f.Name)
+	}
+	return content, nil
+}
@@ -2,32 +2,48 @@ package services
 
 import (
 	"context"
+	"encoding/base64"
+	"io"
 	"notes-server/interfaces"
 	"notes-server/loggers"
 	"notes-server/models"
 	"notes-server/utils"
+	"strings"
+	"time"
 )
 
 type notesService struct {
-	repo   interfaces.INotesRepository
-	logger *loggers.Logger
+	repo          interfaces.INotesRepository
+	templatesRepo interfaces.ITemplatesRepository
+	logger        *loggers.Logger
+	now           func() time.Time
+	quota         models.Quota
 }
 
-func NewNotesService(logger *loggers.Logger, repo interfaces.INotesRepository) interfaces.INotesService {
+func NewNotesService(logger *loggers.Logger, repo interfaces.INotesRepository, templatesRepo interfaces.ITemplatesRepository, quota models.Quota) interfaces.INotesService {
 	return &notesService{
-		repo:   repo,
-		logger: logger,
+		repo:          repo,
+		templatesRepo: templatesRepo,
+		logger:        logger,
+		now:           time.Now,
+		quota:         quota,
 	}
 }
 
-// GetNotes - retrieves all the notes of the user
-func (s *notesService) GetNotes(ctx context.Context) ([]models.Note, error) {
-	email := utils.GetEmailFromCtx(ctx)
-	notes, err := s.repo.GetNotes(ctx, email)
+// GetNotes - retrieves the notes of the user, pinned notes first and archived notes only when requested
+func (s *notesService) GetNotes(ctx context.Context, request models.GetNotesRequest) ([]models.Note, error) {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	notes, err := s.repo.GetNotes(ctx, request)
 	if err != nil {
 		s.logger.Warn(ctx, "Error in notesService.GetNotes(), error from repo.GetNotes()")
 		return []models.Note{}, err
 	}
+	for i := range notes {
+		if notes[i].Type == models.NoteTypeChecklist {
+			notes[i].Progress = checklistProgress(notes[i].Items)
+		}
+	}
 	return notes, nil
 }
 
@@ -35,6 +51,18 @@ func (s *notesService) GetNotes(ctx context.Context) ([]models.Note, error) {
 func (s *notesService) AddNote(ctx context.Context, request models.AddNoteRequest) (models.AddNoteResponse, error) {
 	email := utils.GetEmailFromCtx(ctx)
 	request.Email = email
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	request.Title = strings.TrimSpace(request.Title)
+	err := validateNote(request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.AddNote(), error from validateNote()")
+		return models.AddNoteResponse{}, err
+	}
+	err = s.checkNewNotes(ctx, email, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.AddNote(), error from checkNewNotes()")
+		return models.AddNoteResponse{}, err
+	}
 	id, err := s.repo.AddNote(ctx, request)
 	if err != nil {
 		s.logger.Warn(ctx, "Error in notesService.AddNote(), error from repo.AddNote()")
@@ -43,12 +71,367 @@ func (s *notesService) AddNote(ctx context.Context, request models.AddNoteReques
 	return models.AddNoteResponse{Id: id}, nil
 }
 
+// SearchNotes - retrieves the notes of the user containing the query, encrypted notes are never matched
+func (s *notesService) SearchNotes(ctx context.Context, request models.SearchNotesRequest) ([]models.Note, error) {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	notes, err := s.repo.SearchNotes(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.SearchNotes(), error from repo.SearchNotes()")
+		return []models.Note{}, err
+	}
+	for i := range notes {
+		if notes[i].Type == models.NoteTypeChecklist {
+			notes[i].Progress = checklistProgress(notes[i].Items)
+		}
+	}
+	return notes, nil
+}
+
+// CreateNoteFromTemplate - renders a template with the variables of the request and adds the result as a new note
+func (s *notesService) CreateNoteFromTemplate(ctx context.Context, request models.CreateNoteFromTemplateRequest) (models.AddNoteResponse, error) {
+	email := utils.GetEmailFromCtx(ctx)
+	template, err := s.templatesRepo.GetTemplate(ctx, email, request.TemplateId)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.CreateNoteFromTemplate(), error from templatesRepo.GetTemplate()")
+		return models.AddNoteResponse{}, err
+	}
+	builtins := templateVariables(ctx, s.now())
+	err = validateTemplateVariables(builtins, request.Variables)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.CreateNoteFromTemplate(), error from validateTemplateVariables()")
+		return models.AddNoteResponse{}, err
+	}
+	title, err := renderTemplate(template.Title, builtins, request.Variables)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.CreateNoteFromTemplate(), error from renderTemplate()")
+		return models.AddNoteResponse{}, err
+	}
+	note, err := renderTemplate(template.Note, builtins, request.Variables)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.CreateNoteFromTemplate(), error from renderTemplate()")
+		return models.AddNoteResponse{}, err
+	}
+	return s.AddNote(ctx, models.AddNoteRequest{Title: title, Note: note})
+}
+
+// UpdateNote - changes the title or the body of a note
+func (s *notesService) UpdateNote(ctx context.Context, request models.UpdateNoteRequest) error {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	if (request.Encryption == nil) != (request.Ciphertext == nil) {
+		err := models.ErrIncompleteEncryption
+		s.logger.Warn(ctx, "Error in notesService.UpdateNote(), invalid encrypted note")
+		return err
+	}
+	if request.Title != nil {
+		title := strings.TrimSpace(*request.Title)
+		request.Title = &title
+		err := validateTitle(title)
+		if err != nil {
+			s.logger.Warn(ctx, "Error in notesService.UpdateNote(), error from validateTitle()")
+			return err
+		}
+	}
+	if s.quota.MaxNoteSize > 0 || s.quota.MaxStorage > 0 {
+		note, err := s.repo.GetNote(ctx, request.Workspace, request.Email, request.Id)
+		if err != nil {
+			s.logger.Warn(ctx, "Error in notesService.UpdateNote(), error from repo.GetNote()")
+			return err
+		}
+		updated := note
+		request.Apply(&updated)
+		err = s.checkResize(ctx, request.Email, note, updated)
+		if err != nil {
+			s.logger.Warn(ctx, "Error in notesService.UpdateNote(), error from checkResize()")
+			return err
+		}
+	}
+	err := s.repo.UpdateNote(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.UpdateNote(), error from repo.UpdateNote()")
+		return err
+	}
+	return nil
+}
+
 // DeleteNote - delete a note
 func (s *notesService) DeleteNote(ctx context.Context, request models.DeleteNoteRequest) error {
-	err := s.repo.DeleteNote(ctx, request.Id)
+	email := utils.GetEmailFromCtx(ctx)
+	err := s.repo.DeleteNote(ctx, utils.GetWorkspaceFromCtx(ctx), email, request.Id)
 	if err != nil {
 		s.logger.Warn(ctx, "Error in notesService.DeleteNote(), error from repo.DeleteNote()")
 		return err
 	}
 	return nil
 }
+
+// SetNoteFlag - pins, archives or stars a note, or undoes it
+func (s *notesService) SetNoteFlag(ctx context.Context, flag string, request models.SetNoteFlagRequest) error {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	err := s.repo.SetNoteFlag(ctx, flag, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.SetNoteFlag(), error from repo.SetNoteFlag()")
+		return err
+	}
+	return nil
+}
+
+// SetNoteColor - sets the color label of a note
+func (s *notesService) SetNoteColor(ctx context.Context, request models.SetNoteColorRequest) error {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	err := s.repo.SetNoteColor(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.SetNoteColor(), error from repo.SetNoteColor()")
+		return err
+	}
+	return nil
+}
+
+// GetNoteLinks - retrieves the notes a note links to and the notes linking to it
+func (s *notesService) GetNoteLinks(ctx context.Context, request models.GetNoteLinksRequest) (models.NoteLinksResponse, error) {
+	email := utils.GetEmailFromCtx(ctx)
+	response, err := s.repo.GetNoteLinks(ctx, utils.GetWorkspaceFromCtx(ctx), email, request.Id)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.GetNoteLinks(), error from repo.GetNoteLinks()")
+		return models.NoteLinksResponse{}, err
+	}
+	return response, nil
+}
+
+// ExportNotes - streams a zip archive of all the notes of the user to w
+func (s *notesService) ExportNotes(ctx context.Context, w io.Writer) error {
+	email := utils.GetEmailFromCtx(ctx)
+	archive := newArchiveWriter(w)
+	err := s.repo.StreamNotes(ctx, utils.GetWorkspaceFromCtx(ctx), email, archive.WriteNote)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.ExportNotes(), error from repo.StreamNotes()")
+		return err
+	}
+	err = archive.Close()
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.ExportNotes(), error from archive.Close()")
+		return err
+	}
+	return nil
+}
+
+// ImportNotes - adds all the notes of an archive created by ExportNotes as new notes of the user
+func (s *notesService) ImportNotes(ctx context.Context, request models.ImportNotesRequest) (models.ImportNotesResponse, error) {
+	email := utils.GetEmailFromCtx(ctx)
+	data, err := base64.StdEncoding.DecodeString(request.Archive)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.ImportNotes(), archive is not base64 encoded")
+		return models.ImportNotesResponse{}, models.ErrInvalidArchive.Wrap(err)
+	}
+	notes, err := readNotesArchive(data)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.ImportNotes(), error from readNotesArchive()", err)
+		return models.ImportNotesResponse{}, models.ErrInvalidArchive.Wrap(err)
+	}
+	workspace := utils.GetWorkspaceFromCtx(ctx)
+	requests := make([]models.AddNoteRequest, 0, len(notes))
+	for _, note := range notes {
+		request := models.AddNoteRequest{
+			Email:      email,
+			Workspace:  workspace,
+			Type:       note.Type,
+			Title:      strings.TrimSpace(note.Title),
+			Pinned:     note.Pinned,
+			Archived:   note.Archived,
+			Starred:    note.Starred,
+			Color:      note.Color,
+			Ciphertext: note.Ciphertext,
+			Encryption: note.Encryption,
+			Note:       note.Note,
+			Items:      note.Items,
+			DueAt:      note.DueAt,
+			RemindAt:   note.RemindAt,
+			Recurrence: note.Recurrence,
+		}
+		err = validateNote(request)
+		if err != nil {
+			s.logger.Warn(ctx, "Error in notesService.ImportNotes(), error from validateNote()")
+			return models.ImportNotesResponse{}, err
+		}
+		requests = append(requests, request)
+	}
+	err = s.checkNewNotes(ctx, email, requests...)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.ImportNotes(), error from checkNewNotes()")
+		return models.ImportNotesResponse{}, err
+	}
+	ids, err := s.repo.AddNotes(ctx, requests)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.ImportNotes(), error from repo.AddNotes()")
+		return models.ImportNotesResponse{}, err
+	}
+	return models.ImportNotesResponse{Ids: ids}, nil
+}
+
+// AddChecklistItem - adds an item to a checklist note
+func (s *notesService) AddChecklistItem(ctx context.Context, request models.AddChecklistItemRequest) (models.AddChecklistItemResponse, error) {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	if s.quota.MaxNoteSize > 0 || s.quota.MaxStorage > 0 {
+		note, err := s.repo.GetNote(ctx, request.Workspace, request.Email, request.Id)
+		if err != nil {
+			s.logger.Warn(ctx, "Error in notesService.AddChecklistItem(), error from repo.GetNote()")
+			return models.AddChecklistItemResponse{}, err
+		}
+		updated := note
+		updated.Items = append(append([]models.ChecklistItem{}, note.Items...), models.ChecklistItem{Text: request.Text})
+		err = s.checkResize(ctx, request.Email, note, updated)
+		if err != nil {
+			s.logger.Warn(ctx, "Error in notesService.AddChecklistItem(), error from checkResize()")
+			return models.AddChecklistItemResponse{}, err
+		}
+	}
+	itemID, err := s.repo.AddChecklistItem(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.AddChecklistItem(), error from repo.AddChecklistItem()")
+		return models.AddChecklistItemResponse{}, err
+	}
+	return models.AddChecklistItemResponse{ItemId: itemID}, nil
+}
+
+// ReorderChecklistItems - changes the order of the items of a checklist note
+func (s *notesService) ReorderChecklistItems(ctx context.Context, request models.ReorderChecklistItemsRequest) error {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	err := s.repo.ReorderChecklistItems(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.ReorderChecklistItems(), error from repo.ReorderChecklistItems()")
+		return err
+	}
+	return nil
+}
+
+// ToggleChecklistItem - marks an item of a checklist note as done or not done
+func (s *notesService) ToggleChecklistItem(ctx context.Context, request models.ToggleChecklistItemRequest) error {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	err := s.repo.ToggleChecklistItem(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.ToggleChecklistItem(), error from repo.ToggleChecklistItem()")
+		return err
+	}
+	return nil
+}
+
+// RemoveChecklistItem - removes an item from a checklist note
+func (s *notesService) RemoveChecklistItem(ctx context.Context, request models.RemoveChecklistItemRequest) error {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	err := s.repo.RemoveChecklistItem(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.RemoveChecklistItem(), error from repo.RemoveChecklistItem()")
+		return err
+	}
+	return nil
+}
+
+// GetUsage - reports the notes and storage used by the user along with the quota
+func (s *notesService) GetUsage(ctx context.Context) (models.UsageResponse, error) {
+	email := utils.GetEmailFromCtx(ctx)
+	usage, err := s.repo.GetUsage(ctx, email)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in notesService.GetUsage(), error from repo.GetUsage()")
+		return models.UsageResponse{}, err
+	}
+	return models.UsageResponse{Usage: usage, Quota: s.quota}, nil
+}
+
+// checkNewNotes - checks that adding the notes keeps the user within the quota. The usage is read before the
+// notes are added, so concurrent requests of the same user can go over it by the size of one request.
+func (s *notesService) checkNewNotes(ctx context.Context, email string, requests ...models.AddNoteRequest) error {
+	var size int64
+	for _, request := range requests {
+		noteSize := models.Note{Title: request.Title, Note: request.Note, Ciphertext: request.Ciphertext, Items: request.Items}.ContentSize()
+		if s.quota.MaxNoteSize > 0 && noteSize > s.quota.MaxNoteSize {
+			return models.QuotaExceeded(models.ErrNoteTooLarge, int64(s.quota.MaxNoteSize))
+		}
+		size += int64(noteSize)
+	}
+	if s.quota.MaxNotes == 0 && s.quota.MaxStorage == 0 {
+		return nil
+	}
+	usage, err := s.repo.GetUsage(ctx, email)
+	if err != nil {
+		return err
+	}
+	if s.quota.MaxNotes > 0 && usage.Notes+len(requests) > s.quota.MaxNotes {
+		return models.QuotaExceeded(models.ErrNoteLimitReached, int64(s.quota.MaxNotes))
+	}
+	if s.quota.MaxStorage > 0 && usage.Bytes+size > s.quota.MaxStorage {
+		return models.QuotaExceeded(models.ErrStorageLimitReached, s.quota.MaxStorage)
+	}
+	return nil
+}
+
+// checkResize - checks that changing note to updated keeps the user within the quota, a note can always shrink
+func (s *notesService) checkResize(ctx context.Context, email string, note, updated models.Note) error {
+	growth := updated.ContentSize() - note.ContentSize()
+	if growth <= 0 {
+		return nil
+	}
+	if s.quota.MaxNoteSize > 0 && updated.ContentSize() > s.quota.MaxNoteSize {
+		return models.QuotaExceeded(models.ErrNoteTooLarge, int64(s.quota.MaxNoteSize))
+	}
+	if s.quota.MaxStorage == 0 {
+		return nil
+	}
+	usage, err := s.repo.GetUsage(ctx, email)
+	if err != nil {
+		return err
+	}
+	if usage.Bytes+int64(growth) > s.quota.MaxStorage {
+		return models.QuotaExceeded(models.ErrStorageLimitReached, s.quota.MaxStorage)
+	}
+	return nil
+}
+
+// validateNote - checks the parts of a new note that the request validation can not express
+func validateNote(request models.AddNoteRequest) error {
+	if len(request.Items) > 0 && request.Type != models.NoteTypeChecklist {
+		return models.ErrItemsNotChecklist
+	}
+	if err := validateEncryptedNote(request); err != nil {
+		return err
+	}
+	if err := validateTitle(request.Title); err != nil {
+		return err
+	}
+	return validateReminder(request.RemindAt, request.Recurrence)
+}
+
+// validateEncryptedNote - an encrypted note only has a ciphertext, its title and items would be readable by the server
+func validateEncryptedNote(request models.AddNoteRequest) error {
+	if (request.Encryption == nil) != (request.Ciphertext == nil) {
+		return models.ErrIncompleteEncryption
+	}
+	if request.Encryption != nil && (request.Note != "" || request.Title != "" || (request.Type != "" && request.Type != models.NoteTypeText)) {
+		return models.ErrInvalidEncryptedNote
+	}
+	return nil
+}
+
+// validateTitle - titles are the targets of [[Title]] links, so they can not contain the link syntax
+func validateTitle(title string) error {
+	if strings.ContainsAny(title, "[]|\r\n") {
+		return models.ErrInvalidTitle
+	}
+	return nil
+}
+
+func checklistProgress(items []models.ChecklistItem) *models.ChecklistProgress {
+	progress := &models.ChecklistProgress{Total: len(items)}
+	for _, item := range items {
+		if item.Done {
+			progress.Done++
+		}
+	}
+	return progress
+}
@@ -1,13 +1,17 @@
 package services
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
+	"notes-server/constants"
 	"notes-server/interfaces"
 	"notes-server/loggers"
 	"notes-server/models"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -42,6 +46,26 @@ func Test_notesService_GetNotes(t *testing.T) {
 			}},
 			wantErr: false,
 		},
+		{
+			name: "success case - checklist progress",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetNotes(mock.Anything, mock.Anything).Return([]models.Note{{
+					Id:    1,
+					Type:  models.NoteTypeChecklist,
+					Items: []models.ChecklistItem{{Id: 1, Text: "a", Done: true}, {Id: 2, Text: "b"}},
+				}}, nil)
+			},
+			args: args{
+				ctx: context.Background(),
+			},
+			want: []models.Note{{
+				Id:       1,
+				Type:     models.NoteTypeChecklist,
+				Items:    []models.ChecklistItem{{Id: 1, Text: "a", Done: true}, {Id: 2, Text: "b"}},
+				Progress: &models.ChecklistProgress{Done: 1, Total: 2},
+			}},
+			wantErr: false,
+		},
 		{
 			name: "failure case - error in repo.GetNotes()",
 			given: func(r *interfaces.MockINotesRepository) {
@@ -62,7 +86,7 @@ func Test_notesService_GetNotes(t *testing.T) {
 				repo:   &mockRepo,
 				logger: loggers.NewLogger(),
 			}
-			got, err := s.GetNotes(tt.args.ctx)
+			got, err := s.GetNotes(tt.args.ctx, models.GetNotesRequest{})
 			if (err != nil) != tt.wantErr {
 				t.Errorf("notesService.GetNotes() error = %v, wantErr %v", err, tt.wantErr)
 				return
@@ -103,6 +127,66 @@ func Test_notesService_AddNote(t *testing.T) {
 			},
 			wantErr: false,
 		},
+		{
+			name: "failure case - items on a text note",
+			given: func(r *interfaces.MockINotesRepository) {
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.AddNoteRequest{
+					Email: "test@gmail.com",
+					Note:  "test note",
+					Items: []models.ChecklistItem{{Text: "item"}},
+				},
+			},
+			want:    models.AddNoteResponse{},
+			wantErr: true,
+		},
+		{
+			name: "success case - encrypted note",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().AddNote(mock.Anything, mock.Anything).Return(123, nil)
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.AddNoteRequest{
+					Ciphertext: []byte{1, 2, 3},
+					Encryption: &models.Encryption{Scheme: models.EncryptionSchemeAESGCM, Nonce: []byte{1}},
+				},
+			},
+			want: models.AddNoteResponse{
+				Id: 123,
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - encrypted note with a plaintext title",
+			given: func(r *interfaces.MockINotesRepository) {
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.AddNoteRequest{
+					Title:      "Passwords",
+					Ciphertext: []byte{1, 2, 3},
+					Encryption: &models.Encryption{Scheme: models.EncryptionSchemeAESGCM, Nonce: []byte{1}},
+				},
+			},
+			want:    models.AddNoteResponse{},
+			wantErr: true,
+		},
+		{
+			name: "failure case - ciphertext without encryption",
+			given: func(r *interfaces.MockINotesRepository) {
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.AddNoteRequest{
+					Ciphertext: []byte{1, 2, 3},
+				},
+			},
+			want:    models.AddNoteResponse{},
+			wantErr: true,
+		},
 		{
 			name: "failure case - error in repo.AddNote()",
 			given: func(r *interfaces.MockINotesRepository) {
@@ -153,7 +237,7 @@ func Test_notesService_DeleteNote(t *testing.T) {
 		{
 			name: "success case",
 			given: func(r *interfaces.MockINotesRepository) {
-				r.EXPECT().DeleteNote(mock.Anything, mock.Anything).Return(nil)
+				r.EXPECT().DeleteNote(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(nil)
 			},
 			args: args{
 				ctx: context.Background(),
@@ -166,7 +250,7 @@ func Test_notesService_DeleteNote(t *testing.T) {
 		{
 			name: "failure case - error in repo.DeleteNote()",
 			given: func(r *interfaces.MockINotesRepository) {
-				r.EXPECT().DeleteNote(mock.Anything, mock.Anything).Return(errors.New("db error"))
+				r.EXPECT().DeleteNote(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db error"))
 			},
 			args: args{
 				ctx: context.Background(),
@@ -193,3 +277,701 @@ func Test_notesService_DeleteNote(t *testing.T) {
 		})
 	}
 }
+
+func Test_notesService_SetNoteFlag(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		request models.SetNoteFlagRequest
+	}
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().SetNoteFlag(mock.Anything, models.NoteFlagStarred, mock.Anything).Return(nil)
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetNoteFlagRequest{
+					Id: 123,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.SetNoteFlag()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().SetNoteFlag(mock.Anything, models.NoteFlagStarred, mock.Anything).Return(errors.New("db error"))
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetNoteFlagRequest{
+					Id: 123,
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &notesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			err := s.SetNoteFlag(tt.args.ctx, models.NoteFlagStarred, tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesService.SetNoteFlag() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_notesService_SetNoteColor(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		request models.SetNoteColorRequest
+	}
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().SetNoteColor(mock.Anything, mock.Anything).Return(nil)
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetNoteColorRequest{
+					Id: 123,
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.SetNoteColor()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().SetNoteColor(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetNoteColorRequest{
+					Id: 123,
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &notesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			err := s.SetNoteColor(tt.args.ctx, tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesService.SetNoteColor() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_notesService_CreateNoteFromTemplate(t *testing.T) {
+	template := models.Template{
+		Id:     1,
+		Name:   "Meeting notes",
+		Title:  "Meeting {{date}} - {{topic}}",
+		Note:   "Author: {{user.name}} <{{user.email}}>\nAt {{time}}",
+		Global: true,
+	}
+	tests := []struct {
+		name      string
+		given     func(*interfaces.MockINotesRepository, *interfaces.MockITemplatesRepository)
+		variables map[string]string
+		want      models.AddNoteResponse
+		wantErr   bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository, tr *interfaces.MockITemplatesRepository) {
+				tr.EXPECT().GetTemplate(mock.Anything, "test@gmail.com", int32(1)).Return(template, nil)
+				r.EXPECT().AddNote(mock.Anything, models.AddNoteRequest{
+					Email: "test@gmail.com",
+					Title: "Meeting 2024-03-01 - planning",
+					Note:  "Author: Test <test@gmail.com>\nAt 09:30",
+				}).Return(123, nil)
+			},
+			variables: map[string]string{"topic": "planning"},
+			want:      models.AddNoteResponse{Id: 123},
+			wantErr:   false,
+		},
+		{
+			name: "failure case - missing variable",
+			given: func(r *interfaces.MockINotesRepository, tr *interfaces.MockITemplatesRepository) {
+				tr.EXPECT().GetTemplate(mock.Anything, mock.Anything, mock.Anything).Return(template, nil)
+			},
+			want:    models.AddNoteResponse{},
+			wantErr: true,
+		},
+		{
+			name: "failure case - reserved variable",
+			given: func(r *interfaces.MockINotesRepository, tr *interfaces.MockITemplatesRepository) {
+				tr.EXPECT().GetTemplate(mock.Anything, mock.Anything, mock.Anything).Return(template, nil)
+			},
+			variables: map[string]string{"topic": "planning", "user.name": "Someone else"},
+			want:      models.AddNoteResponse{},
+			wantErr:   true,
+		},
+		{
+			name: "failure case - error in templatesRepo.GetTemplate()",
+			given: func(r *interfaces.MockINotesRepository, tr *interfaces.MockITemplatesRepository) {
+				tr.EXPECT().GetTemplate(mock.Anything, mock.Anything, mock.Anything).Return(models.Template{}, errors.New("template not found"))
+			},
+			want:    models.AddNoteResponse{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			mockTemplatesRepo := interfaces.MockITemplatesRepository{}
+			tt.given(&mockRepo, &mockTemplatesRepo)
+			s := &notesService{
+				repo:          &mockRepo,
+				templatesRepo: &mockTemplatesRepo,
+				logger:        loggers.NewLogger(),
+				now:           func() time.Time { return time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC) },
+			}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			ctx = context.WithValue(ctx, constants.NameCtxKey, "Test")
+			got, err := s.CreateNoteFromTemplate(ctx, models.CreateNoteFromTemplateRequest{TemplateId: 1, Variables: tt.variables})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesService.CreateNoteFromTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("notesService.CreateNoteFromTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_notesService_UpdateNote(t *testing.T) {
+	title := " Groceries "
+	invalidTitle := "[[Groceries]]"
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		request models.UpdateNoteRequest
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().UpdateNote(mock.Anything, mock.MatchedBy(func(request models.UpdateNoteRequest) bool {
+					return request.Email == "test@gmail.com" && *request.Title == "Groceries"
+				})).Return(nil)
+			},
+			request: models.UpdateNoteRequest{Id: 123, Title: &title},
+			wantErr: false,
+		},
+		{
+			name: "failure case - title with link syntax",
+			given: func(r *interfaces.MockINotesRepository) {
+			},
+			request: models.UpdateNoteRequest{Id: 123, Title: &invalidTitle},
+			wantErr: true,
+		},
+		{
+			name: "failure case - error in repo.UpdateNote()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().UpdateNote(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			request: models.UpdateNoteRequest{Id: 123, Title: &title},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &notesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			err := s.UpdateNote(ctx, tt.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesService.UpdateNote() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_notesService_GetNoteLinks(t *testing.T) {
+	response := models.NoteLinksResponse{
+		Links:     []models.LinkedNote{{Title: "Missing", Dangling: true}},
+		Backlinks: []models.LinkedNote{{Id: 2, Title: "Index"}},
+	}
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		want    models.NoteLinksResponse
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetNoteLinks(mock.Anything, int32(0), "test@gmail.com", int32(123)).Return(response, nil)
+			},
+			want:    response,
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.GetNoteLinks()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetNoteLinks(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(models.NoteLinksResponse{}, errors.New("note not found"))
+			},
+			want:    models.NoteLinksResponse{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &notesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			got, err := s.GetNoteLinks(ctx, models.GetNoteLinksRequest{Id: 123})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesService.GetNoteLinks() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("notesService.GetNoteLinks() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_notesService_ExportNotes(t *testing.T) {
+	notes := []models.Note{
+		{Id: 1, Title: "First", Pinned: true, Starred: true, Color: "green", Note: "first note, see [[Second]]"},
+		{Id: 2, Note: "---\nsecond note\n---\nwith a fake front-matter"},
+		{Id: 3, Type: models.NoteTypeChecklist, Note: "groceries", Items: []models.ChecklistItem{{Text: "milk", Done: true}, {Text: "eggs"}}},
+	}
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		want    []models.Note
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().StreamNotes(mock.Anything, mock.Anything, mock.Anything, mock.Anything).RunAndReturn(func(ctx context.Context, workspace int32, email string, fn func(models.Note) error) error {
+					for _, note := range notes {
+						if err := fn(note); err != nil {
+							return err
+						}
+					}
+					return nil
+				})
+			},
+			want:    notes,
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.StreamNotes()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().StreamNotes(mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &notesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			var buf bytes.Buffer
+			err := s.ExportNotes(context.Background(), &buf)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesService.ExportNotes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			got, err := readNotesArchive(buf.Bytes())
+			if err != nil {
+				t.Errorf("readNotesArchive() error = %v", err)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("notesService.ExportNotes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_notesService_ImportNotes(t *testing.T) {
+	var archive bytes.Buffer
+	writer := newArchiveWriter(&archive)
+	writer.WriteNote(models.Note{Id: 1, Note: "first note"})
+	writer.WriteNote(models.Note{Id: 2, Note: "second note"})
+	writer.Close()
+	encoded := base64.StdEncoding.EncodeToString(archive.Bytes())
+
+	type args struct {
+		ctx     context.Context
+		request models.ImportNotesRequest
+	}
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		args    args
+		want    models.ImportNotesResponse
+		wantErr bool
+		// wantInvalid - the error is ErrInvalidArchive, a validation error rather than an internal one
+		wantInvalid bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().AddNotes(mock.Anything, []models.AddNoteRequest{
+					{Email: "test@gmail.com", Note: "first note"},
+					{Email: "test@gmail.com", Note: "second note"},
+				}).Return([]int32{10, 20}, nil)
+			},
+			args: args{
+				ctx:     context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com"),
+				request: models.ImportNotesRequest{Archive: encoded},
+			},
+			want:    models.ImportNotesResponse{Ids: []int32{10, 20}},
+			wantErr: false,
+		},
+		{
+			name: "failure case - archive not base64",
+			given: func(r *interfaces.MockINotesRepository) {
+			},
+			args: args{
+				ctx:     context.Background(),
+				request: models.ImportNotesRequest{Archive: "%%%"},
+			},
+			want:        models.ImportNotesResponse{},
+			wantErr:     true,
+			wantInvalid: true,
+		},
+		{
+			name: "failure case - not a zip archive",
+			given: func(r *interfaces.MockINotesRepository) {
+			},
+			args: args{
+				ctx:     context.Background(),
+				request: models.ImportNotesRequest{Archive: base64.StdEncoding.EncodeToString([]byte("test"))},
+			},
+			want:        models.ImportNotesResponse{},
+			wantErr:     true,
+			wantInvalid: true,
+		},
+		{
+			name: "failure case - error in repo.AddNotes()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().AddNotes(mock.Anything, mock.Anything).Return([]int32{}, errors.New("db error"))
+			},
+			args: args{
+				ctx:     context.Background(),
+				request: models.ImportNotesRequest{Archive: encoded},
+			},
+			want:    models.ImportNotesResponse{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &notesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			got, err := s.ImportNotes(tt.args.ctx, tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesService.ImportNotes() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if errors.Is(err, models.ErrInvalidArchive) != tt.wantInvalid {
+				t.Errorf("notesService.ImportNotes() error = %v, want ErrInvalidArchive %v", err, tt.wantInvalid)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("notesService.ImportNotes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_notesService_AddChecklistItem(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		want    models.AddChecklistItemResponse
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().AddChecklistItem(mock.Anything, mock.Anything).Return(1, nil)
+			},
+			want:    models.AddChecklistItemResponse{ItemId: 1},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.AddChecklistItem()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().AddChecklistItem(mock.Anything, mock.Anything).Return(0, errors.New("db error"))
+			},
+			want:    models.AddChecklistItemResponse{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &notesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			got, err := s.AddChecklistItem(context.Background(), models.AddChecklistItemRequest{Id: 123, Text: "milk"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesService.AddChecklistItem() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("notesService.AddChecklistItem() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_notesService_ChecklistItems(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		call    func(*notesService) error
+		wantErr bool
+	}{
+		{
+			name: "success case - reorder",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().ReorderChecklistItems(mock.Anything, mock.Anything).Return(nil)
+			},
+			call: func(s *notesService) error {
+				return s.ReorderChecklistItems(context.Background(), models.ReorderChecklistItemsRequest{Id: 123, ItemIds: []int32{2, 1}})
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.ReorderChecklistItems()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().ReorderChecklistItems(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			call: func(s *notesService) error {
+				return s.ReorderChecklistItems(context.Background(), models.ReorderChecklistItemsRequest{Id: 123, ItemIds: []int32{2, 1}})
+			},
+			wantErr: true,
+		},
+		{
+			name: "success case - toggle",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().ToggleChecklistItem(mock.Anything, mock.Anything).Return(nil)
+			},
+			call: func(s *notesService) error {
+				return s.ToggleChecklistItem(context.Background(), models.ToggleChecklistItemRequest{Id: 123, ItemId: 1})
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.ToggleChecklistItem()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().ToggleChecklistItem(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			call: func(s *notesService) error {
+				return s.ToggleChecklistItem(context.Background(), models.ToggleChecklistItemRequest{Id: 123, ItemId: 1})
+			},
+			wantErr: true,
+		},
+		{
+			name: "success case - remove",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().RemoveChecklistItem(mock.Anything, mock.Anything).Return(nil)
+			},
+			call: func(s *notesService) error {
+				return s.RemoveChecklistItem(context.Background(), models.RemoveChecklistItemRequest{Id: 123, ItemId: 1})
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.RemoveChecklistItem()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().RemoveChecklistItem(mock.Anything, mock.Anything).Return(errors.New("db error"))
+			},
+			call: func(s *notesService) error {
+				return s.RemoveChecklistItem(context.Background(), models.RemoveChecklistItemRequest{Id: 123, ItemId: 1})
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &notesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			err := tt.call(s)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("notesService error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_notesService_Quota(t *testing.T) {
+	ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+	quota := models.Quota{MaxNoteSize: 10, MaxNotes: 2, MaxStorage: 20}
+	body := func(s string) *string { return &s }
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		call    func(*notesService) error
+		wantErr error
+	}{
+		{
+			name: "success case - add within the quota",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetUsage(mock.Anything, "test@gmail.com").Return(models.Usage{Notes: 1, Bytes: 10}, nil)
+				r.EXPECT().AddNote(mock.Anything, mock.Anything).Return(123, nil)
+			},
+			call: func(s *notesService) error {
+				_, err := s.AddNote(ctx, models.AddNoteRequest{Note: "0123456789"})
+				return err
+			},
+		},
+		{
+			name:  "failure case - note too large",
+			given: func(r *interfaces.MockINotesRepository) {},
+			call: func(s *notesService) error {
+				_, err := s.AddNote(ctx, models.AddNoteRequest{Title: "title", Note: "body!!"})
+				return err
+			},
+			wantErr: models.ErrNoteTooLarge,
+		},
+		{
+			name: "failure case - note limit reached",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetUsage(mock.Anything, "test@gmail.com").Return(models.Usage{Notes: 2, Bytes: 2}, nil)
+			},
+			call: func(s *notesService) error {
+				_, err := s.AddNote(ctx, models.AddNoteRequest{Note: "a"})
+				return err
+			},
+			wantErr: models.ErrNoteLimitReached,
+		},
+		{
+			name: "failure case - import over the storage limit",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetUsage(mock.Anything, "test@gmail.com").Return(models.Usage{Notes: 0, Bytes: 15}, nil)
+			},
+			call: func(s *notesService) error {
+				return s.checkNewNotes(ctx, "test@gmail.com", models.AddNoteRequest{Note: "abc"}, models.AddNoteRequest{Note: "abc"})
+			},
+			wantErr: models.ErrStorageLimitReached,
+		},
+		{
+			name: "success case - update shrinking a note",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetNote(mock.Anything, int32(0), "test@gmail.com", int32(123)).Return(models.Note{Id: 123, Note: "0123456789", Size: 10}, nil)
+				r.EXPECT().UpdateNote(mock.Anything, mock.Anything).Return(nil)
+			},
+			call: func(s *notesService) error {
+				return s.UpdateNote(ctx, models.UpdateNoteRequest{Id: 123, Note: body("short")})
+			},
+		},
+		{
+			name: "failure case - update over the storage limit",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetNote(mock.Anything, int32(0), "test@gmail.com", int32(123)).Return(models.Note{Id: 123, Note: "abc", Size: 3}, nil)
+				r.EXPECT().GetUsage(mock.Anything, "test@gmail.com").Return(models.Usage{Notes: 2, Bytes: 18}, nil)
+			},
+			call: func(s *notesService) error {
+				return s.UpdateNote(ctx, models.UpdateNoteRequest{Id: 123, Note: body("abcdef")})
+			},
+			wantErr: models.ErrStorageLimitReached,
+		},
+		{
+			name: "failure case - checklist item making the note too large",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().GetNote(mock.Anything, int32(0), "test@gmail.com", int32(123)).Return(models.Note{
+					Id:    123,
+					Type:  models.NoteTypeChecklist,
+					Items: []models.ChecklistItem{{Id: 1, Text: "milk"}},
+				}, nil)
+			},
+			call: func(s *notesService) error {
+				_, err := s.AddChecklistItem(ctx, models.AddChecklistItemRequest{Id: 123, Text: "more eggs"})
+				return err
+			},
+			wantErr: models.ErrNoteTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &notesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+				quota:  quota,
+			}
+			err := tt.call(s)
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("notesService error = %v, want %v", err, tt.wantErr)
+			}
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func Test_notesService_GetUsage(t *testing.T) {
+	ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+	mockRepo := interfaces.MockINotesRepository{}
+	mockRepo.EXPECT().GetUsage(mock.Anything, "test@gmail.com").Return(models.Usage{Notes: 3, Bytes: 42}, nil)
+	quota := models.Quota{MaxNoteSize: 10, MaxNotes: 5, MaxStorage: 100}
+	s := &notesService{repo: &mockRepo, logger: loggers.NewLogger(), quota: quota}
+	got, err := s.GetUsage(ctx)
+	want := models.UsageResponse{Usage: models.Usage{Notes: 3, Bytes: 42}, Quota: quota}
+	if err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("notesService.GetUsage() = %v, %v, want %v", got, err, want)
+	}
+}
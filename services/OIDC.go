@@ -0,0 +1,81 @@
+package services
+
+import (
+	"context"
+	"notes-server/models"
+	"notes-server/oidc"
+	"notes-server/utils"
+	"time"
+)
+
+// StartOIDCLogin - starts a sign in with the identity provider, returns the URL of the provider to send the user
+// to
+func (s *loginService) StartOIDCLogin(ctx context.Context) (models.OIDCLoginResponse, error) {
+	s.logger.Info(ctx, "Entering LoginService.StartOIDCLogin()")
+	defer s.logger.Info(ctx, "Exiting LoginService.StartOIDCLogin()")
+	if s.oidc == nil {
+		return models.OIDCLoginResponse{}, models.ErrOIDCNotConfigured
+	}
+	login := models.OIDCLogin{ExpiresAt: time.Now().Add(models.OIDCLoginTTL)}
+	state, err := utils.NewToken()
+	if err == nil {
+		login.Nonce, err = utils.NewToken()
+	}
+	if err == nil {
+		login.CodeVerifier, err = utils.NewToken()
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.StartOIDCLogin(), error from utils.NewToken()", err)
+		return models.OIDCLoginResponse{}, err
+	}
+	login.StateHash = utils.HashToken(state)
+	err = s.repo.AddOIDCLogin(ctx, login)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.StartOIDCLogin(), error from s.repo.AddOIDCLogin()", err)
+		return models.OIDCLoginResponse{}, err
+	}
+	authorizationURL, err := s.oidc.AuthCodeURL(ctx, state, login.Nonce, oidc.CodeChallenge(login.CodeVerifier))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.StartOIDCLogin(), error from s.oidc.AuthCodeURL()", err)
+		return models.OIDCLoginResponse{}, err
+	}
+	return models.OIDCLoginResponse{AuthorizationURL: authorizationURL, State: state}, nil
+}
+
+// FinishOIDCLogin - completes a sign in once the identity provider redirected back with a code, the user is
+// linked or provisioned on their first sign in. Users with two-factor authentication still get a challenge.
+func (s *loginService) FinishOIDCLogin(ctx context.Context, request models.OIDCCallbackRequest) (models.LoginResponse, error) {
+	s.logger.Info(ctx, "Entering LoginService.FinishOIDCLogin()")
+	defer s.logger.Info(ctx, "Exiting LoginService.FinishOIDCLogin()")
+	if s.oidc == nil {
+		return models.LoginResponse{}, models.ErrOIDCNotConfigured
+	}
+	login, err := s.repo.TakeOIDCLogin(ctx, utils.HashToken(request.State), time.Now())
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.FinishOIDCLogin(), error from s.repo.TakeOIDCLogin()")
+		return models.LoginResponse{}, err
+	}
+	identity, err := s.oidc.Exchange(ctx, request.Code, login.CodeVerifier, login.Nonce)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.FinishOIDCLogin(), error from s.oidc.Exchange()", err)
+		return models.LoginResponse{}, err
+	}
+	user, err := s.repo.LoginOIDC(ctx, identity)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.FinishOIDCLogin(), error from s.repo.LoginOIDC()")
+		return models.LoginResponse{}, err
+	}
+	if user.Disabled {
+		s.logger.Warn(ctx, "Error in LoginService.FinishOIDCLogin(), account disabled")
+		return models.LoginResponse{}, models.ErrAccountDisabled
+	}
+	if user.MFA.Enabled {
+		return newMFAChallenge(user.Email)
+	}
+	token, err := generateJWTToken(s.keys, user.Email, user.Name, user.Role, user.DefaultWorkspace, user.SessionVersion)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.FinishOIDCLogin(), error from generateJWTToken()")
+		return models.LoginResponse{}, err
+	}
+	return models.LoginResponse{SID: token}, nil
+}
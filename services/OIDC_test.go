@@ -0,0 +1,108 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notes-server/db"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/mailers"
+	"notes-server/models"
+	"notes-server/oidc"
+	"notes-server/oidc/oidctest"
+	"notes-server/passwords"
+	"notes-server/repositories"
+	"notes-server/security"
+	"testing"
+)
+
+// Test_loginService_OIDC - signs in through the fake identity provider end to end, with the users stored in the
+// db
+func Test_loginService_OIDC(t *testing.T) {
+	keys := testSigningKeys(t)
+	fake := oidctest.NewFakeProvider("notes")
+	defer fake.Close()
+	logger := loggers.NewLogger()
+	repo := repositories.NewLoginRepository(db.NewDB(), logger)
+	ctx := context.Background()
+	if err := repo.SignUp(ctx, models.SignUpRequest{Email: "oidc-service-existing@gmail.com", Name: "Existing", Password: "password"}); err != nil {
+		t.Fatalf("loginRepository.SignUp() error = %v", err)
+	}
+	if err := repo.VerifyEmail(ctx, "oidc-service-existing@gmail.com"); err != nil {
+		t.Fatalf("loginRepository.VerifyEmail() error = %v", err)
+	}
+	s := NewLoginService(logger, repo, mailers.NewFakeMailer(),
+		oidc.NewProvider(fake.Issuer(), "notes", "secret", "http://localhost:8080/v1/api/oidc/callback"), keys, security.NewFakeEvents(), models.LoginThrottle{}, passwords.Policy{})
+	signIn := func(t *testing.T) (models.LoginResponse, error) {
+		t.Helper()
+		started, err := s.StartOIDCLogin(ctx)
+		if err != nil {
+			t.Fatalf("loginService.StartOIDCLogin() error = %v", err)
+		}
+		code, state, err := fake.Authorize(started.AuthorizationURL)
+		if err != nil {
+			t.Fatalf("FakeProvider.Authorize() error = %v", err)
+		}
+		return s.FinishOIDCLogin(ctx, models.OIDCCallbackRequest{Code: code, State: state})
+	}
+	sessionEmail := func(t *testing.T, response models.LoginResponse) string {
+		t.Helper()
+		claims := &models.Claims{}
+		if _, err := keys.Parse(response.SID, claims); err != nil {
+			t.Fatalf("the session is invalid, error = %v", err)
+		}
+		return claims.Email
+	}
+
+	fake.SetUser(oidctest.FakeUser{Subject: "sub-new", Email: "oidc-service-new@gmail.com", EmailVerified: true, Name: "New"})
+	response, err := signIn(t)
+	if err != nil {
+		t.Fatalf("loginService.FinishOIDCLogin() error = %v", err)
+	}
+	if email := sessionEmail(t, response); email != "oidc-service-new@gmail.com" {
+		t.Errorf("loginService.FinishOIDCLogin() signed in %s, want the provisioned user", email)
+	}
+
+	fake.SetUser(oidctest.FakeUser{Subject: "sub-existing", Email: "oidc-service-existing@gmail.com", EmailVerified: true})
+	response, err = signIn(t)
+	if err != nil {
+		t.Fatalf("loginService.FinishOIDCLogin() error = %v", err)
+	}
+	if email := sessionEmail(t, response); email != "oidc-service-existing@gmail.com" {
+		t.Errorf("loginService.FinishOIDCLogin() signed in %s, want the linked user", email)
+	}
+
+	fake.SetUser(oidctest.FakeUser{Subject: "sub-unverified", Email: "oidc-service-unverified@gmail.com"})
+	if _, err = signIn(t); !errors.Is(err, models.ErrEmailNotVerified) {
+		t.Errorf("loginService.FinishOIDCLogin() error = %v, want %v", err, models.ErrEmailNotVerified)
+	}
+
+	fake.SetNonce("replayed")
+	fake.SetUser(oidctest.FakeUser{Subject: "sub-new", Email: "oidc-service-new@gmail.com", EmailVerified: true})
+	if _, err = signIn(t); err == nil {
+		t.Errorf("loginService.FinishOIDCLogin() accepted an ID token with another nonce")
+	}
+	fake.SetNonce("")
+
+	started, _ := s.StartOIDCLogin(ctx)
+	code, state, _ := fake.Authorize(started.AuthorizationURL)
+	if _, err = s.FinishOIDCLogin(ctx, models.OIDCCallbackRequest{Code: code, State: "forged"}); err == nil {
+		t.Errorf("loginService.FinishOIDCLogin() accepted a state it did not issue")
+	}
+	if _, err = s.FinishOIDCLogin(ctx, models.OIDCCallbackRequest{Code: code, State: state}); err != nil {
+		t.Errorf("loginService.FinishOIDCLogin() error = %v", err)
+	}
+	if _, err = s.FinishOIDCLogin(ctx, models.OIDCCallbackRequest{Code: code, State: state}); err == nil {
+		t.Errorf("loginService.FinishOIDCLogin() accepted a state twice")
+	}
+}
+
+func Test_loginService_OIDCNotConfigured(t *testing.T) {
+	s := &loginService{repo: &interfaces.MockILoginRepository{}, logger: loggers.NewLogger()}
+	if _, err := s.StartOIDCLogin(context.Background()); !errors.Is(err, models.ErrOIDCNotConfigured) {
+		t.Errorf("loginService.StartOIDCLogin() error = %v, want %v", err, models.ErrOIDCNotConfigured)
+	}
+	if _, err := s.FinishOIDCLogin(context.Background(), models.OIDCCallbackRequest{Code: "code", State: "state"}); !errors.Is(err, models.ErrOIDCNotConfigured) {
+		t.Errorf("loginService.FinishOIDCLogin() error = %v, want %v", err, models.ErrOIDCNotConfigured)
+	}
+}
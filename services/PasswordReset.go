@@ -0,0 +1,82 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notes-server/constants"
+	"notes-server/models"
+	"notes-server/utils"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// ForgotPassword - emails a single use password reset token to the user. Nothing tells the caller whether the
+// address is registered, failures are only logged and the email is sent in the background so that the time of the
+// response does not depend on it either.
+func (s *loginService) ForgotPassword(ctx context.Context, request models.ForgotPasswordRequest) error {
+	s.logger.Info(ctx, "Entering LoginService.ForgotPassword()")
+	defer s.logger.Info(ctx, "Exiting LoginService.ForgotPassword()")
+	user, err := s.repo.GetUser(ctx, request.Email)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.ForgotPassword(), error from s.repo.GetUser()")
+		return nil
+	}
+	token, err := utils.NewToken()
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.ForgotPassword(), error from utils.NewToken()", err)
+		return nil
+	}
+	ttl := viper.GetDuration(constants.PasswordResetTTLEnvKey)
+	err = s.repo.AddPasswordReset(ctx, models.PasswordReset{
+		TokenHash: utils.HashToken(token),
+		Email:     user.Email,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.ForgotPassword(), error from s.repo.AddPasswordReset()", err)
+		return nil
+	}
+	mail := models.Mail{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Hi %s,\n\nuse this code to reset your password, it expires in %s:\n\n%s\n\n"+
+			"If you did not ask for it you can ignore this email.\n", user.Name, ttl, token),
+	}
+	// the request is over by the time the email is sent, only its id is kept for the logs
+	mailCtx := context.WithValue(context.Background(), constants.RequestIDCtxKey, utils.GetRequestIDFromCtx(ctx))
+	s.mails.Add(1)
+	go func() {
+		defer s.mails.Done()
+		err := s.mailer.Send(mailCtx, mail)
+		if err != nil {
+			s.logger.Warn(mailCtx, "Error in LoginService.ForgotPassword(), error from s.mailer.Send()", err)
+		}
+	}()
+	return nil
+}
+
+// ResetPassword - sets the password of the user a reset token was issued to, which signs out all their sessions
+func (s *loginService) ResetPassword(ctx context.Context, request models.ResetPasswordRequest) error {
+	s.logger.Info(ctx, "Entering LoginService.ResetPassword()")
+	defer s.logger.Info(ctx, "Exiting LoginService.ResetPassword()")
+	tokenHash := utils.HashToken(request.Token)
+	user, err := s.repo.GetPasswordReset(ctx, tokenHash, time.Now())
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.ResetPassword(), error from s.repo.GetPasswordReset()")
+		return err
+	}
+	// the policy is only checked once the token is known to be valid, with the email address and the name of its
+	// user, and outside of any transaction as it may read the breached passwords
+	err = s.policy.Check("password", request.Password, user.Email, user.Name)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.ResetPassword(), error from s.policy.Check()", err)
+		return err
+	}
+	err = s.repo.ResetPassword(ctx, tokenHash, request.Password, time.Now())
+	if err != nil {
+		s.logger.Warn(ctx, "Error in LoginService.ResetPassword(), error from s.repo.ResetPassword()")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,79 @@
+package services
+
+import (
+	"context"
+	"notes-server/apperrors"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/scheduler"
+	"notes-server/utils"
+	"time"
+)
+
+type remindersService struct {
+	notesRepo         interfaces.INotesRepository
+	notificationsRepo interfaces.INotificationsRepository
+	logger            *loggers.Logger
+}
+
+func NewRemindersService(logger *loggers.Logger, notesRepo interfaces.INotesRepository, notificationsRepo interfaces.INotificationsRepository) interfaces.IRemindersService {
+	return &remindersService{
+		notesRepo:         notesRepo,
+		notificationsRepo: notificationsRepo,
+		logger:            logger,
+	}
+}
+
+// SetReminder - sets or clears the due date, reminder and recurrence of a note
+func (s *remindersService) SetReminder(ctx context.Context, request models.SetReminderRequest) error {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	request.Workspace = utils.GetWorkspaceFromCtx(ctx)
+	err := validateReminder(request.RemindAt, request.Recurrence)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in remindersService.SetReminder(), error from validateReminder()")
+		return err
+	}
+	err = s.notesRepo.SetReminder(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in remindersService.SetReminder(), error from notesRepo.SetReminder()")
+		return err
+	}
+	return nil
+}
+
+// SnoozeReminder - fires the reminder of a note again after the given number of minutes
+func (s *remindersService) SnoozeReminder(ctx context.Context, request models.SnoozeReminderRequest) error {
+	email := utils.GetEmailFromCtx(ctx)
+	until := time.Now().Add(time.Duration(request.Minutes) * time.Minute)
+	err := s.notesRepo.SnoozeReminder(ctx, utils.GetWorkspaceFromCtx(ctx), email, request.Id, until)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in remindersService.SnoozeReminder(), error from notesRepo.SnoozeReminder()")
+		return err
+	}
+	return nil
+}
+
+// GetNotifications - retrieves the in-app notifications of the user
+func (s *remindersService) GetNotifications(ctx context.Context) ([]models.Notification, error) {
+	email := utils.GetEmailFromCtx(ctx)
+	notifications, err := s.notificationsRepo.GetNotifications(ctx, email)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in remindersService.GetNotifications(), error from notificationsRepo.GetNotifications()")
+		return []models.Notification{}, err
+	}
+	return notifications, nil
+}
+
+// validateReminder - checks that the recurrence rule is supported and is only used along with a reminder
+func validateReminder(remindAt *time.Time, recurrence string) error {
+	_, err := scheduler.ParseRecurrence(recurrence)
+	if err != nil {
+		return apperrors.Validation("invalid_recurrence", err.Error(),
+			apperrors.FieldError{Field: "recurrence", Rule: "rrule", Message: err.Error()})
+	}
+	if recurrence != "" && remindAt == nil {
+		return models.ErrRecurrenceWithoutReminder
+	}
+	return nil
+}
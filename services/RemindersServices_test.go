@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_remindersService_SetReminder(t *testing.T) {
+	remindAt := time.Now().Add(time.Hour)
+	type args struct {
+		ctx     context.Context
+		request models.SetReminderRequest
+	}
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().SetReminder(mock.Anything, mock.Anything).Return(nil)
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetReminderRequest{
+					Id:         123,
+					RemindAt:   &remindAt,
+					Recurrence: "FREQ=WEEKLY;BYDAY=MO",
+				},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - invalid recurrence",
+			given: func(r *interfaces.MockINotesRepository) {
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetReminderRequest{
+					Id:         123,
+					RemindAt:   &remindAt,
+					Recurrence: "FREQ=YEARLY",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "failure case - recurrence without reminder",
+			given: func(r *interfaces.MockINotesRepository) {
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetReminderRequest{
+					Id:         123,
+					Recurrence: "daily",
+				},
+			},
+			wantErr: true,
+		},
+		{
+			name: "failure case - error in notesRepo.SetReminder()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().SetReminder(mock.Anything, mock.Anything).Return(errors.New("note not found"))
+			},
+			args: args{
+				ctx: context.Background(),
+				request: models.SetReminderRequest{
+					Id:       123,
+					RemindAt: &remindAt,
+				},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &remindersService{
+				notesRepo: &mockRepo,
+				logger:    loggers.NewLogger(),
+			}
+			err := s.SetReminder(tt.args.ctx, tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("remindersService.SetReminder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_remindersService_SnoozeReminder(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		request models.SnoozeReminderRequest
+	}
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotesRepository)
+		args    args
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().SnoozeReminder(mock.Anything, int32(0), mock.Anything, int32(123), mock.Anything).Return(nil)
+			},
+			args: args{
+				ctx:     context.Background(),
+				request: models.SnoozeReminderRequest{Id: 123, Minutes: 10},
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in notesRepo.SnoozeReminder()",
+			given: func(r *interfaces.MockINotesRepository) {
+				r.EXPECT().SnoozeReminder(mock.Anything, int32(0), mock.Anything, int32(123), mock.Anything).Return(errors.New("db error"))
+			},
+			args: args{
+				ctx:     context.Background(),
+				request: models.SnoozeReminderRequest{Id: 123, Minutes: 10},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotesRepository{}
+			tt.given(&mockRepo)
+			s := &remindersService{
+				notesRepo: &mockRepo,
+				logger:    loggers.NewLogger(),
+			}
+			err := s.SnoozeReminder(tt.args.ctx, tt.args.request)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("remindersService.SnoozeReminder() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+		})
+	}
+}
+
+func Test_remindersService_GetNotifications(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockINotificationsRepository)
+		want    []models.Notification
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockINotificationsRepository) {
+				r.EXPECT().GetNotifications(mock.Anything, mock.Anything).Return([]models.Notification{{
+					Id: 1, NoteId: 123, Message: "Reminder: test",
+				}}, nil)
+			},
+			want: []models.Notification{{
+				Id: 1, NoteId: 123, Message: "Reminder: test",
+			}},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in notificationsRepo.GetNotifications()",
+			given: func(r *interfaces.MockINotificationsRepository) {
+				r.EXPECT().GetNotifications(mock.Anything, mock.Anything).Return([]models.Notification{}, errors.New("db error"))
+			},
+			want:    []models.Notification{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockINotificationsRepository{}
+			tt.given(&mockRepo)
+			s := &remindersService{
+				notificationsRepo: &mockRepo,
+				logger:            loggers.NewLogger(),
+			}
+			got, err := s.GetNotifications(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Errorf("remindersService.GetNotifications() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("remindersService.GetNotifications() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
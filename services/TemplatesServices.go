@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"notes-server/apperrors"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/utils"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// placeholderPattern - matches {{name}} placeholders, spaces inside the braces are ignored
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([A-Za-z][A-Za-z0-9_.-]*)\s*\}\}`)
+
+type templatesService struct {
+	repo   interfaces.ITemplatesRepository
+	logger *loggers.Logger
+}
+
+func NewTemplatesService(logger *loggers.Logger, repo interfaces.ITemplatesRepository) interfaces.ITemplatesService {
+	return &templatesService{
+		repo:   repo,
+		logger: logger,
+	}
+}
+
+// GetTemplates - retrieves the global templates and the templates of the user
+func (s *templatesService) GetTemplates(ctx context.Context) ([]models.Template, error) {
+	email := utils.GetEmailFromCtx(ctx)
+	templates, err := s.repo.GetTemplates(ctx, email)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in templatesService.GetTemplates(), error from repo.GetTemplates()")
+		return []models.Template{}, err
+	}
+	return templates, nil
+}
+
+// AddTemplate - adds a template of the user
+func (s *templatesService) AddTemplate(ctx context.Context, request models.AddTemplateRequest) (models.AddTemplateResponse, error) {
+	request.Email = utils.GetEmailFromCtx(ctx)
+	id, err := s.repo.AddTemplate(ctx, request)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in templatesService.AddTemplate(), error from repo.AddTemplate()")
+		return models.AddTemplateResponse{}, err
+	}
+	return models.AddTemplateResponse{Id: id}, nil
+}
+
+// DeleteTemplate - deletes a template of the user
+func (s *templatesService) DeleteTemplate(ctx context.Context, request models.DeleteTemplateRequest) error {
+	email := utils.GetEmailFromCtx(ctx)
+	err := s.repo.DeleteTemplate(ctx, email, request.Id)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in templatesService.DeleteTemplate(), error from repo.DeleteTemplate()")
+		return err
+	}
+	return nil
+}
+
+// templateVariables - the built-in variables available to every template, custom variables can not redefine them
+func templateVariables(ctx context.Context, now time.Time) map[string]string {
+	return map[string]string{
+		"date":       now.Format("2006-01-02"),
+		"time":       now.Format("15:04"),
+		"datetime":   now.Format("2006-01-02 15:04"),
+		"user.name":  utils.GetNameFromCtx(ctx),
+		"user.email": utils.GetEmailFromCtx(ctx),
+	}
+}
+
+// renderTemplate - replaces the placeholders of text with the built-in and custom variables. Every placeholder
+// must have a value, the missing ones are reported together.
+func renderTemplate(text string, builtins, custom map[string]string) (string, error) {
+	missing := make([]string, 0)
+	rendered := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+		if value, ok := builtins[name]; ok {
+			return value
+		}
+		if value, ok := custom[name]; ok {
+			return value
+		}
+		if !containsString(missing, name) {
+			missing = append(missing, name)
+		}
+		return placeholder
+	})
+	if len(missing) > 0 {
+		message := "missing template variables: " + strings.Join(missing, ", ")
+		return "", apperrors.Validation("missing_template_variables", message,
+			apperrors.FieldError{Field: "variables", Rule: "required", Message: message})
+	}
+	return rendered, nil
+}
+
+// validateTemplateVariables - custom variables can not shadow the built-in ones
+func validateTemplateVariables(builtins, custom map[string]string) error {
+	for name := range custom {
+		if _, ok := builtins[name]; ok || strings.HasPrefix(name, "user.") {
+			message := fmt.Sprintf("template variable %q is reserved", name)
+			return apperrors.Validation("reserved_template_variable", message,
+				apperrors.FieldError{Field: "variables", Rule: "reserved", Message: message})
+		}
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
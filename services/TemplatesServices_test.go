@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_renderTemplate(t *testing.T) {
+	builtins := map[string]string{"date": "2024-03-01", "user.name": "Admin"}
+	tests := []struct {
+		name    string
+		text    string
+		custom  map[string]string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:    "built-in and custom variables",
+			text:    "{{date}} {{ topic }} by {{user.name}}",
+			custom:  map[string]string{"topic": "planning"},
+			want:    "2024-03-01 planning by Admin",
+			wantErr: false,
+		},
+		{
+			name:    "text without placeholders",
+			text:    "{{ not a placeholder }} {x}",
+			want:    "{{ not a placeholder }} {x}",
+			wantErr: false,
+		},
+		{
+			name:    "missing variables",
+			text:    "{{topic}} {{severity}} {{topic}}",
+			want:    "",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := renderTemplate(tt.text, builtins, tt.custom)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("renderTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("renderTemplate() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_templatesService_GetTemplates(t *testing.T) {
+	templates := []models.Template{{Id: 1, Name: "Meeting notes", Note: "{{date}}", Global: true}}
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockITemplatesRepository)
+		want    []models.Template
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockITemplatesRepository) {
+				r.EXPECT().GetTemplates(mock.Anything, "test@gmail.com").Return(templates, nil)
+			},
+			want:    templates,
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.GetTemplates()",
+			given: func(r *interfaces.MockITemplatesRepository) {
+				r.EXPECT().GetTemplates(mock.Anything, mock.Anything).Return(nil, errors.New("db error"))
+			},
+			want:    []models.Template{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockITemplatesRepository{}
+			tt.given(&mockRepo)
+			s := &templatesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			got, err := s.GetTemplates(ctx)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("templatesService.GetTemplates() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("templatesService.GetTemplates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_templatesService_AddTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockITemplatesRepository)
+		want    models.AddTemplateResponse
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockITemplatesRepository) {
+				r.EXPECT().AddTemplate(mock.Anything, mock.MatchedBy(func(request models.AddTemplateRequest) bool {
+					return request.Email == "test@gmail.com"
+				})).Return(123, nil)
+			},
+			want:    models.AddTemplateResponse{Id: 123},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.AddTemplate()",
+			given: func(r *interfaces.MockITemplatesRepository) {
+				r.EXPECT().AddTemplate(mock.Anything, mock.Anything).Return(0, errors.New("db error"))
+			},
+			want:    models.AddTemplateResponse{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockITemplatesRepository{}
+			tt.given(&mockRepo)
+			s := &templatesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			got, err := s.AddTemplate(ctx, models.AddTemplateRequest{Name: "Standup", Note: "{{date}}"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("templatesService.AddTemplate() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("templatesService.AddTemplate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_templatesService_DeleteTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockITemplatesRepository)
+		wantErr bool
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockITemplatesRepository) {
+				r.EXPECT().DeleteTemplate(mock.Anything, "test@gmail.com", int32(123)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "failure case - error in repo.DeleteTemplate()",
+			given: func(r *interfaces.MockITemplatesRepository) {
+				r.EXPECT().DeleteTemplate(mock.Anything, mock.Anything, mock.Anything).Return(errors.New("template not found"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockITemplatesRepository{}
+			tt.given(&mockRepo)
+			s := &templatesService{
+				repo:   &mockRepo,
+				logger: loggers.NewLogger(),
+			}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			err := s.DeleteTemplate(ctx, models.DeleteTemplateRequest{Id: 123})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("templatesService.DeleteTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
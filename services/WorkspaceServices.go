@@ -0,0 +1,146 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/models"
+	"notes-server/signing"
+	"notes-server/utils"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+type workspaceService struct {
+	repo      interfaces.IWorkspaceRepository
+	loginRepo interfaces.ILoginRepository
+	mailer    interfaces.IMailer
+	keys      *signing.Keyring
+	logger    *loggers.Logger
+}
+
+func NewWorkspaceService(logger *loggers.Logger, repo interfaces.IWorkspaceRepository, loginRepo interfaces.ILoginRepository, mailer interfaces.IMailer, keys *signing.Keyring) interfaces.IWorkspaceService {
+	return &workspaceService{
+		repo:      repo,
+		loginRepo: loginRepo,
+		mailer:    mailer,
+		keys:      keys,
+		logger:    logger,
+	}
+}
+
+// GetWorkspaces - retrieves the workspaces of the user, marking the one of the session
+func (s *workspaceService) GetWorkspaces(ctx context.Context) ([]models.UserWorkspace, error) {
+	workspaces, err := s.repo.GetWorkspaces(ctx, utils.GetEmailFromCtx(ctx))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.GetWorkspaces(), error from repo.GetWorkspaces()")
+		return []models.UserWorkspace{}, err
+	}
+	current := utils.GetWorkspaceFromCtx(ctx)
+	for i := range workspaces {
+		workspaces[i].Current = workspaces[i].Id == current
+	}
+	return workspaces, nil
+}
+
+// CreateWorkspace - creates a workspace owned by the user, the session stays in its workspace until switched
+func (s *workspaceService) CreateWorkspace(ctx context.Context, request models.CreateWorkspaceRequest) (models.Workspace, error) {
+	workspace := models.Workspace{Id: utils.NewID(), Name: strings.TrimSpace(request.Name)}
+	err := s.repo.CreateWorkspace(ctx, utils.GetEmailFromCtx(ctx), workspace)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.CreateWorkspace(), error from repo.CreateWorkspace()")
+		return models.Workspace{}, err
+	}
+	return workspace, nil
+}
+
+// SwitchWorkspace - returns a session for another workspace of the user
+func (s *workspaceService) SwitchWorkspace(ctx context.Context, request models.SwitchWorkspaceRequest) (models.LoginResponse, error) {
+	user, _, err := s.loginRepo.GetMember(ctx, request.Id, utils.GetEmailFromCtx(ctx))
+	if errors.Is(err, models.ErrNotWorkspaceMember) {
+		return models.LoginResponse{}, models.ErrWorkspaceNotFound
+	}
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.SwitchWorkspace(), error from loginRepo.GetMember()")
+		return models.LoginResponse{}, err
+	}
+	token, err := generateJWTToken(s.keys, user.Email, user.Name, user.Role, request.Id, user.SessionVersion)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.SwitchWorkspace(), error from generateJWTToken()")
+		return models.LoginResponse{}, err
+	}
+	return models.LoginResponse{SID: token}, nil
+}
+
+// GetMembers - retrieves the members of the workspace of the session
+func (s *workspaceService) GetMembers(ctx context.Context) ([]models.Membership, error) {
+	members, err := s.repo.GetMembers(ctx, utils.GetWorkspaceFromCtx(ctx), utils.GetEmailFromCtx(ctx))
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.GetMembers(), error from repo.GetMembers()")
+		return []models.Membership{}, err
+	}
+	return members, nil
+}
+
+// InviteMember - emails an invitation to join the workspace of the session, accepted with JoinWorkspace by the
+// user with the address. Inviting an address again replaces the earlier invitation.
+func (s *workspaceService) InviteMember(ctx context.Context, request models.InviteMemberRequest) error {
+	token, err := utils.NewToken()
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.InviteMember(), error from utils.NewToken()", err)
+		return err
+	}
+	now := time.Now()
+	ttl := viper.GetDuration(constants.WorkspaceInvitationTTLEnvKey)
+	inviter := utils.GetEmailFromCtx(ctx)
+	email := utils.NormalizeEmail(request.Email)
+	workspace, err := s.repo.AddInvitation(ctx, inviter, models.Invitation{
+		TokenHash:   utils.HashToken(token),
+		WorkspaceId: utils.GetWorkspaceFromCtx(ctx),
+		Email:       email,
+		Role:        request.Role,
+		InvitedBy:   inviter,
+		ExpiresAt:   now.Add(ttl),
+	}, now)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.InviteMember(), error from repo.AddInvitation()")
+		return err
+	}
+	err = s.mailer.Send(ctx, models.Mail{
+		To:      email,
+		Subject: fmt.Sprintf("Join %s", workspace.Name),
+		Body: fmt.Sprintf("Hi,\n\n%s invited you to join the workspace %s as %s. Sign up or log in with this "+
+			"address and use this code to join it, it expires in %s:\n\n%s\n", utils.GetNameFromCtx(ctx), workspace.Name, request.Role, ttl, token),
+	})
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.InviteMember(), error from s.mailer.Send()", err)
+		return err
+	}
+	return nil
+}
+
+// JoinWorkspace - makes the user a member of the workspace of an invitation sent to their address
+func (s *workspaceService) JoinWorkspace(ctx context.Context, request models.JoinWorkspaceRequest) (models.UserWorkspace, error) {
+	workspace, err := s.repo.AcceptInvitation(ctx, utils.HashToken(request.Token), utils.GetEmailFromCtx(ctx), time.Now())
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.JoinWorkspace(), error from repo.AcceptInvitation()")
+		return models.UserWorkspace{}, err
+	}
+	return workspace, nil
+}
+
+// RemoveMember - removes a member from the workspace of the session along with their notes in it, the user
+// leaves the workspace when removing themselves
+func (s *workspaceService) RemoveMember(ctx context.Context, request models.RemoveMemberRequest) error {
+	err := s.repo.RemoveMember(ctx, utils.GetWorkspaceFromCtx(ctx), utils.GetEmailFromCtx(ctx), request.Email)
+	if err != nil {
+		s.logger.Warn(ctx, "Error in workspaceService.RemoveMember(), error from repo.RemoveMember()")
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"notes-server/constants"
+	"notes-server/interfaces"
+	"notes-server/loggers"
+	"notes-server/mailers"
+	"notes-server/models"
+	"notes-server/utils"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+	"github.com/stretchr/testify/mock"
+)
+
+func Test_workspaceService_SwitchWorkspace(t *testing.T) {
+	tests := []struct {
+		name    string
+		given   func(*interfaces.MockILoginRepository)
+		wantErr error
+	}{
+		{
+			name: "success case",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetMember(mock.Anything, int32(7), "test@gmail.com").Return(models.User{Email: "test@gmail.com", Name: "test", Role: models.RoleUser, SessionVersion: 2}, models.Membership{WorkspaceId: 7, Role: models.WorkspaceRoleMember}, nil)
+			},
+		},
+		{
+			name: "failure case - not a member",
+			given: func(r *interfaces.MockILoginRepository) {
+				r.EXPECT().GetMember(mock.Anything, int32(7), "test@gmail.com").Return(models.User{}, models.Membership{}, models.ErrNotWorkspaceMember)
+			},
+			wantErr: models.ErrWorkspaceNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := interfaces.MockILoginRepository{}
+			tt.given(&mockRepo)
+			keys := testSigningKeys(t)
+			s := &workspaceService{loginRepo: &mockRepo, keys: keys, logger: loggers.NewLogger()}
+			ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "test@gmail.com")
+			ctx = context.WithValue(ctx, constants.WorkspaceCtxKey, int32(3))
+			got, err := s.SwitchWorkspace(ctx, models.SwitchWorkspaceRequest{Id: 7})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("workspaceService.SwitchWorkspace() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			claims := &models.Claims{}
+			if _, err = keys.Parse(got.SID, claims); err != nil {
+				t.Fatalf("keys.Parse() error = %v", err)
+			}
+			if claims.Workspace != 7 || claims.Email != "test@gmail.com" || claims.SessionVersion != 2 {
+				t.Errorf("workspaceService.SwitchWorkspace() claims = %+v", claims)
+			}
+		})
+	}
+}
+
+func Test_workspaceService_InviteMember(t *testing.T) {
+	viper.Set(constants.WorkspaceInvitationTTLEnvKey, time.Hour)
+	var tokenHash string
+	mockRepo := interfaces.MockIWorkspaceRepository{}
+	mockRepo.EXPECT().AddInvitation(mock.Anything, "owner@gmail.com", mock.MatchedBy(func(invitation models.Invitation) bool {
+		tokenHash = invitation.TokenHash
+		return invitation.WorkspaceId == 3 && invitation.Email == "test@gmail.com" && invitation.Role == models.WorkspaceRoleAdmin
+	}), mock.Anything).Return(models.Workspace{Id: 3, Name: "Team"}, nil)
+	mailer := mailers.NewFakeMailer()
+	s := &workspaceService{repo: &mockRepo, mailer: mailer, logger: loggers.NewLogger()}
+	ctx := context.WithValue(context.Background(), constants.EmailCtxKey, "owner@gmail.com")
+	ctx = context.WithValue(ctx, constants.WorkspaceCtxKey, int32(3))
+
+	// the invitation is stored and sent to the normalized address
+	if err := s.InviteMember(ctx, models.InviteMemberRequest{Email: "Test@Gmail.com", Role: models.WorkspaceRoleAdmin}); err != nil {
+		t.Fatalf("workspaceService.InviteMember() error = %v", err)
+	}
+	mails := mailer.Mails()
+	if len(mails) != 1 || mails[0].To != "test@gmail.com" || !strings.Contains(mails[0].Body, "Team") {
+		t.Fatalf("workspaceService.InviteMember() sent %+v", mails)
+	}
+	// only the hash of the token in the email is stored
+	fields := strings.Fields(mails[0].Body)
+	if utils.HashToken(fields[len(fields)-1]) != tokenHash {
+		t.Errorf("workspaceService.InviteMember() stored the hash %q of another token", tokenHash)
+	}
+}
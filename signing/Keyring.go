@@ -0,0 +1,370 @@
+package signing
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Algorithms the session tokens can be signed with
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	EdDSA = "EdDSA"
+)
+
+// MinSecretLength - the shared secret must have at least this many bytes, 256 bits like the HS256 output
+const MinSecretLength = 32
+
+// rsaKeySize - size of the RSA keys that are generated
+const rsaKeySize = 2048
+
+// Keyring - the keys the session tokens are signed with. Tokens are always signed with the current key, the
+// keys rotated out keep verifying the tokens they signed until their grace period is over. Tokens carry the kid
+// of the key that signed them.
+//
+// Generated keys only live in the memory of one instance: another instance can not verify the tokens they sign,
+// and they are lost on a restart. Instances sharing the sessions load their keys from the same file.
+type Keyring struct {
+	method      jwt.SigningMethod
+	gracePeriod time.Duration
+	// fromFile - the keys were loaded from a file, they are rotated by replacing the file
+	fromFile bool
+
+	mu   sync.RWMutex
+	keys []*key
+	stop chan struct{}
+	done chan struct{}
+}
+
+type key struct {
+	id         string
+	signingKey interface{}
+	verifyKey  interface{}
+	// retiresAt - zero for the current key and the keys loaded from a file
+	retiresAt time.Time
+}
+
+// JSONWebKey - the public part of a signing key, as published in the JWKS
+type JSONWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+type JSONWebKeySet struct {
+	Keys []JSONWebKey `json:"keys"`
+}
+
+// ValidateSecret - the shared secret must be set and long and varied enough not to be guessed
+func ValidateSecret(secret string) error {
+	if secret == "" {
+		return errors.New("secret is missing")
+	}
+	if len(secret) < MinSecretLength {
+		return fmt.Errorf("secret is too weak, it must have at least %d bytes", MinSecretLength)
+	}
+	distinct := make(map[rune]bool)
+	for _, c := range secret {
+		distinct[c] = true
+	}
+	if len(distinct) < 8 {
+		return errors.New("secret is too weak, it repeats too few characters")
+	}
+	return nil
+}
+
+// NewHMACKeyring - signs with HS256 and the shared secret, which can not be rotated or published
+func NewHMACKeyring(secret string) (*Keyring, error) {
+	if err := ValidateSecret(secret); err != nil {
+		return nil, err
+	}
+	return &Keyring{
+		method: jwt.SigningMethodHS256,
+		keys:   []*key{{signingKey: []byte(secret), verifyKey: []byte(secret)}},
+	}, nil
+}
+
+// NewKeyring - signs with alg, RS256 or EdDSA. The keys are read from the PEM file when one is given, the first
+// one is current, otherwise a key is generated. gracePeriod is how long a rotated key keeps verifying tokens, it
+// must be longer than the tokens are valid.
+func NewKeyring(alg, keyFile string, gracePeriod time.Duration) (*Keyring, error) {
+	keyring := &Keyring{gracePeriod: gracePeriod}
+	switch alg {
+	case RS256:
+		keyring.method = jwt.SigningMethodRS256
+	case EdDSA:
+		keyring.method = jwt.SigningMethodEdDSA
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+	if keyFile == "" {
+		if err := keyring.Rotate(time.Now()); err != nil {
+			return nil, err
+		}
+		return keyring, nil
+	}
+	content, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	keyring.fromFile = true
+	for block, rest := pem.Decode(content); block != nil; block, rest = pem.Decode(rest) {
+		k, err := keyring.parseKey(block)
+		if err != nil {
+			return nil, err
+		}
+		keyring.keys = append(keyring.keys, k)
+	}
+	if len(keyring.keys) == 0 {
+		return nil, errors.New("no private key in " + keyFile)
+	}
+	return keyring, nil
+}
+
+// Algorithm - the algorithm the tokens are signed with
+func (k *Keyring) Algorithm() string {
+	return k.method.Alg()
+}
+
+// CurrentKeyID - kid of the key new tokens are signed with
+func (k *Keyring) CurrentKeyID() string {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.keys[0].id
+}
+
+// Sign - signs the claims with the current key
+func (k *Keyring) Sign(claims jwt.Claims) (string, error) {
+	k.mu.RLock()
+	current := k.keys[0]
+	k.mu.RUnlock()
+	token := jwt.NewWithClaims(k.method, claims)
+	if current.id != "" {
+		token.Header["kid"] = current.id
+	}
+	return token.SignedString(current.signingKey)
+}
+
+// Parse - verifies a token signed by one of the keys that are not retired yet and reads its claims
+func (k *Keyring) Parse(token string, claims jwt.Claims) (*jwt.Token, error) {
+	parser := jwt.NewParser(jwt.WithValidMethods([]string{k.method.Alg()}))
+	return parser.ParseWithClaims(token, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		now := time.Now()
+		k.mu.RLock()
+		defer k.mu.RUnlock()
+		for _, key := range k.keys {
+			if key.id == kid && (key.retiresAt.IsZero() || now.Before(key.retiresAt)) {
+				return key.verifyKey, nil
+			}
+		}
+		return nil, errors.New("unknown signing key " + kid)
+	})
+}
+
+// Rotate - signs with a new key from now on, the previous keys retire once the grace period is over. The shared
+// secret of HS256 and the keys of a file are never rotated.
+func (k *Keyring) Rotate(now time.Time) error {
+	if k.method == jwt.SigningMethodHS256 || k.fromFile {
+		return nil
+	}
+	next, err := k.generateKey()
+	if err != nil {
+		return err
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	keys := []*key{next}
+	for _, previous := range k.keys {
+		if previous.retiresAt.IsZero() {
+			retired := *previous
+			retired.retiresAt = now.Add(k.gracePeriod)
+			previous = &retired
+		}
+		if now.Before(previous.retiresAt) {
+			keys = append(keys, previous)
+		}
+	}
+	k.keys = keys
+	return nil
+}
+
+// JWKS - the public keys that are not retired yet, for other services to verify the tokens with
+func (k *Keyring) JWKS() JSONWebKeySet {
+	set := JSONWebKeySet{Keys: make([]JSONWebKey, 0)}
+	now := time.Now()
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	for _, key := range k.keys {
+		if !key.retiresAt.IsZero() && !now.Before(key.retiresAt) {
+			continue
+		}
+		if jwk, ok := k.jsonWebKey(key); ok {
+			set.Keys = append(set.Keys, jwk)
+		}
+	}
+	return set
+}
+
+// StartRotation - rotates the keys in the background every interval until StopRotation is called, unless they
+// are never rotated, see Rotate
+func (k *Keyring) StartRotation(interval time.Duration, onError func(error)) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.stop != nil || interval <= 0 || k.method == jwt.SigningMethodHS256 || k.fromFile {
+		return
+	}
+	k.stop = make(chan struct{})
+	k.done = make(chan struct{})
+	go func(stop <-chan struct{}, done chan<- struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-stop:
+				return
+			case now := <-ticker.C:
+				if err := k.Rotate(now); err != nil {
+					onError(err)
+				}
+			}
+		}
+	}(k.stop, k.done)
+}
+
+// StopRotation - stops the rotation started with StartRotation
+func (k *Keyring) StopRotation() {
+	k.mu.Lock()
+	stop, done := k.stop, k.done
+	k.stop, k.done = nil, nil
+	k.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
+
+func (k *Keyring) generateKey() (*key, error) {
+	var private crypto.Signer
+	var err error
+	if k.method == jwt.SigningMethodRS256 {
+		private, err = rsa.GenerateKey(rand.Reader, rsaKeySize)
+	} else {
+		_, private, err = ed25519.GenerateKey(rand.Reader)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return k.newKey(private)
+}
+
+func (k *Keyring) parseKey(block *pem.Block) (*key, error) {
+	var parsed interface{}
+	var err error
+	if block.Type == "RSA PRIVATE KEY" {
+		parsed, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	} else {
+		parsed, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+	private, ok := parsed.(crypto.Signer)
+	if !ok {
+		return nil, errors.New("unsupported private key")
+	}
+	return k.newKey(private)
+}
+
+// newKey - the kid of a key is its RFC 7638 thumbprint
+func (k *Keyring) newKey(private crypto.Signer) (*key, error) {
+	switch private := private.(type) {
+	case *rsa.PrivateKey:
+		if k.method != jwt.SigningMethodRS256 {
+			return nil, errors.New("an RSA key can not sign with " + k.method.Alg())
+		}
+		if private.N.BitLen() < rsaKeySize {
+			return nil, fmt.Errorf("RSA keys must have at least %d bits", rsaKeySize)
+		}
+	case ed25519.PrivateKey:
+		if k.method != jwt.SigningMethodEdDSA {
+			return nil, errors.New("an Ed25519 key can not sign with " + k.method.Alg())
+		}
+	default:
+		return nil, errors.New("unsupported private key")
+	}
+	public := private.Public()
+	jwk, _ := publicJWK(public)
+	id, err := thumbprint(jwk)
+	if err != nil {
+		return nil, err
+	}
+	return &key{id: id, signingKey: private, verifyKey: public}, nil
+}
+
+func (k *Keyring) jsonWebKey(key *key) (JSONWebKey, bool) {
+	jwk, ok := publicJWK(key.verifyKey)
+	jwk.Kid, jwk.Alg, jwk.Use = key.id, k.method.Alg(), "sig"
+	return jwk, ok
+}
+
+// publicJWK - the members of a public key in a JWK, false for the shared secret
+func publicJWK(public interface{}) (JSONWebKey, bool) {
+	switch public := public.(type) {
+	case *rsa.PublicKey:
+		return JSONWebKey{
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(public.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(public.E)).Bytes()),
+		}, true
+	case ed25519.PublicKey:
+		return JSONWebKey{Kty: "OKP", Crv: "Ed25519", X: base64.RawURLEncoding.EncodeToString(public)}, true
+	}
+	return JSONWebKey{}, false
+}
+
+// thumbprint - hash of the required members of the key in lexicographic order
+func thumbprint(jwk JSONWebKey) (string, error) {
+	var members interface{}
+	if jwk.Kty == "RSA" {
+		members = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{jwk.E, jwk.Kty, jwk.N}
+	} else {
+		members = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{jwk.Crv, jwk.Kty, jwk.X}
+	}
+	canonical, err := json.Marshal(members)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(canonical)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,174 @@
+package signing
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+func sign(t *testing.T, keys *Keyring) string {
+	t.Helper()
+	token, err := keys.Sign(&jwt.RegisteredClaims{Subject: "test@gmail.com", ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute))})
+	if err != nil {
+		t.Fatalf("Keyring.Sign() error = %v", err)
+	}
+	return token
+}
+
+func verifies(keys *Keyring, token string) bool {
+	_, err := keys.Parse(token, &jwt.RegisteredClaims{})
+	return err == nil
+}
+
+func TestValidateSecret(t *testing.T) {
+	tests := []struct {
+		name    string
+		secret  string
+		wantErr bool
+	}{
+		{name: "strong secret", secret: "q8Vd2kP0xZ7mWb4Rt1Ls9Nc6Hy3Ja5Ue"},
+		{name: "missing", secret: "", wantErr: true},
+		{name: "short", secret: "x", wantErr: true},
+		{name: "repeated", secret: strings.Repeat("ab", 32), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := ValidateSecret(tt.secret); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateSecret() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	for _, alg := range []string{EdDSA, RS256} {
+		t.Run(alg, func(t *testing.T) {
+			keys, err := NewKeyring(alg, "", time.Hour)
+			if err != nil {
+				t.Fatalf("NewKeyring() error = %v", err)
+			}
+			first := keys.CurrentKeyID()
+			before := sign(t, keys)
+			header, _, _ := new(jwt.Parser).ParseUnverified(before, &jwt.RegisteredClaims{})
+			if header.Header["kid"] != first || header.Header["alg"] != alg {
+				t.Errorf("Keyring.Sign() header = %v, want kid %s and alg %s", header.Header, first, alg)
+			}
+
+			now := time.Now()
+			if err = keys.Rotate(now); err != nil {
+				t.Fatalf("Keyring.Rotate() error = %v", err)
+			}
+			if keys.CurrentKeyID() == first {
+				t.Fatalf("Keyring.Rotate() kept the current key")
+			}
+			after := sign(t, keys)
+			if !verifies(keys, before) || !verifies(keys, after) {
+				t.Errorf("Keyring.Parse() refused a token within the grace period")
+			}
+			if jwks := keys.JWKS(); len(jwks.Keys) != 2 {
+				t.Errorf("Keyring.JWKS() = %+v, want the current and the rotated key", jwks)
+			}
+
+			// the first key retires with the rotation after its grace period
+			if err = keys.Rotate(now.Add(time.Hour)); err != nil {
+				t.Fatalf("Keyring.Rotate() error = %v", err)
+			}
+			if verifies(keys, before) {
+				t.Errorf("Keyring.Parse() accepted a token signed with a retired key")
+			}
+			if !verifies(keys, after) {
+				t.Errorf("Keyring.Parse() refused a token signed with the previous key")
+			}
+			for _, jwk := range keys.JWKS().Keys {
+				if jwk.Kid == first {
+					t.Errorf("Keyring.JWKS() publishes the retired key %s", first)
+				}
+			}
+		})
+	}
+}
+
+func TestKeyring_Parse(t *testing.T) {
+	keys, _ := NewKeyring(EdDSA, "", time.Hour)
+	other, _ := NewKeyring(EdDSA, "", time.Hour)
+	if verifies(keys, sign(t, other)) {
+		t.Errorf("Keyring.Parse() accepted a token signed by another keyring")
+	}
+	// a token signed with HS256 and the public key as the secret must not verify
+	hmac, _ := NewHMACKeyring("q8Vd2kP0xZ7mWb4Rt1Ls9Nc6Hy3Ja5Ue")
+	if verifies(keys, sign(t, hmac)) {
+		t.Errorf("Keyring.Parse() accepted a token signed with HS256")
+	}
+	unsigned, _ := jwt.NewWithClaims(jwt.SigningMethodNone, &jwt.RegisteredClaims{}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if verifies(keys, unsigned) {
+		t.Errorf("Keyring.Parse() accepted an unsigned token")
+	}
+	if !verifies(hmac, sign(t, hmac)) {
+		t.Errorf("Keyring.Parse() refused a token signed with the shared secret")
+	}
+	if len(hmac.JWKS().Keys) != 0 {
+		t.Errorf("Keyring.JWKS() publishes the shared secret")
+	}
+}
+
+func TestNewKeyring_keyFile(t *testing.T) {
+	dir := t.TempDir()
+	writeKeys := func(name string, keys ...interface{}) string {
+		t.Helper()
+		var content []byte
+		for _, key := range keys {
+			der, err := x509.MarshalPKCS8PrivateKey(key)
+			if err != nil {
+				t.Fatal(err)
+			}
+			content = append(content, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})...)
+		}
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, content, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	_, current, _ := ed25519.GenerateKey(rand.Reader)
+	_, previous, _ := ed25519.GenerateKey(rand.Reader)
+	keyFile := writeKeys("ed25519.pem", current, previous)
+
+	keys, err := NewKeyring(EdDSA, keyFile, time.Hour)
+	if err != nil {
+		t.Fatalf("NewKeyring() error = %v", err)
+	}
+	again, _ := NewKeyring(EdDSA, keyFile, time.Hour)
+	if keys.CurrentKeyID() != again.CurrentKeyID() || len(keys.JWKS().Keys) != 2 {
+		t.Errorf("NewKeyring() kid %s then %s, JWKS %+v", keys.CurrentKeyID(), again.CurrentKeyID(), keys.JWKS())
+	}
+	if !verifies(again, sign(t, keys)) {
+		t.Errorf("Keyring.Parse() refused a token signed with the same key file")
+	}
+	// the keys of a file stay the ones of the file, every instance loading it signs with the same key
+	if err = keys.Rotate(time.Now()); err != nil || keys.CurrentKeyID() != again.CurrentKeyID() {
+		t.Errorf("Keyring.Rotate() replaced a key of the file, kid %s, error = %v", keys.CurrentKeyID(), err)
+	}
+
+	if _, err = NewKeyring(RS256, keyFile, time.Hour); err == nil {
+		t.Errorf("NewKeyring() accepted Ed25519 keys for RS256")
+	}
+	weak, _ := rsa.GenerateKey(rand.Reader, 1024)
+	if _, err = NewKeyring(RS256, writeKeys("weak.pem", weak), time.Hour); err == nil {
+		t.Errorf("NewKeyring() accepted a 1024 bit RSA key")
+	}
+	if _, err = NewKeyring(RS256, writeKeys("empty.pem"), time.Hour); err == nil {
+		t.Errorf("NewKeyring() accepted a file without keys")
+	}
+	if _, err = NewKeyring("none", "", time.Hour); err == nil {
+		t.Errorf("NewKeyring() accepted the none algorithm")
+	}
+}
@@ -0,0 +1,80 @@
+package totp
+
+import (
+	"encoding/base32"
+	"net/url"
+	"testing"
+	"time"
+)
+
+// rfcSecret - the SHA1 key of the test vectors of RFC 6238
+var rfcSecret = base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+func TestCode(t *testing.T) {
+	// the last 6 digits of the 8 digit codes of RFC 6238, appendix B
+	tests := []struct {
+		unix int64
+		want string
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+	for _, tt := range tests {
+		got, err := Code(rfcSecret, Step(time.Unix(tt.unix, 0)))
+		if err != nil || got != tt.want {
+			t.Errorf("Code(%d) = %s, %v, want %s", tt.unix, got, err, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	step := Step(now)
+	code := func(step int64) string {
+		c, _ := Code(rfcSecret, step)
+		return c
+	}
+	tests := []struct {
+		name     string
+		code     string
+		lastStep int64
+		want     bool
+	}{
+		{name: "current step", code: code(step), want: true},
+		{name: "spaces are ignored", code: code(step)[:3] + " " + code(step)[3:], want: true},
+		{name: "previous step", code: code(step - 1), want: true},
+		{name: "next step", code: code(step + 1), want: true},
+		{name: "too old", code: code(step - 2), want: false},
+		{name: "already used", code: code(step), lastStep: step, want: false},
+		{name: "wrong code", code: "000000", want: false},
+		{name: "wrong length", code: "12345", want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, got := Validate(rfcSecret, tt.code, now, tt.lastStep); got != tt.want {
+				t.Errorf("Validate() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURI(t *testing.T) {
+	secret, err := NewSecret()
+	if err != nil {
+		t.Fatalf("NewSecret() error = %v", err)
+	}
+	uri, err := url.Parse(URI("Notes", "test@gmail.com", secret))
+	if err != nil {
+		t.Fatalf("url.Parse() error = %v", err)
+	}
+	if uri.Scheme != "otpauth" || uri.Host != "totp" || uri.Path != "/Notes:test@gmail.com" {
+		t.Errorf("URI() = %s", uri)
+	}
+	if uri.Query().Get("secret") != secret || uri.Query().Get("issuer") != "Notes" {
+		t.Errorf("URI() query = %v", uri.Query())
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"notes-server/apperrors"
+	"notes-server/validation"
+	"strings"
 
-	"github.com/go-playground/validator"
 	"github.com/sirupsen/logrus"
 )
 
@@ -24,14 +26,17 @@ func respondWithJSON(w http.ResponseWriter, code int, payload interface{}) {
 	}
 }
 
-// GetBodyParams- get the parameters from the request body
+// ErrMalformedRequest - the request body is not JSON or does not match the fields of the request
+var ErrMalformedRequest = apperrors.Validation("malformed_request", "malformed request body")
+
+// GetBodyParams- get the parameters from the request body, the messages of the fields that fail validation are in
+// the language of the Accept-Language header
 func GetBodyParams(r *http.Request, data interface{}) error {
 	err := json.NewDecoder(r.Body).Decode(&data)
 	if err != nil {
-		return err
+		return ErrMalformedRequest.Wrap(err)
 	}
-	validator := validator.New()
-	return validator.Struct(data)
+	return validation.Struct(data, validation.Languages(r.Header.Get("Accept-Language"))...)
 }
 
 type Response struct {
@@ -40,8 +45,12 @@ type Response struct {
 	StatusCode string      `json:"status"`
 }
 
+// Error - Code is stable for the clients to act on, Description is meant to be shown to the users and Fields
+// lists the fields of the request that failed validation
 type Error struct {
-	Description string `json:"description"`
+	Code        string                 `json:"code"`
+	Description string                 `json:"description"`
+	Fields      []apperrors.FieldError `json:"fields,omitempty"`
 }
 
 func WriteHttpSuccess(w http.ResponseWriter, code int, payload interface{}) {
@@ -52,10 +61,54 @@ func WriteHttpSuccess(w http.ResponseWriter, code int, payload interface{}) {
 	respondWithJSON(w, code, response)
 }
 
+// WriteHttpFailure - writes err with the status code. The code and the message of a domain error are sent, other
+// errors get a code and a message named after the status: their message is for the logs only, it may hold what
+// the caller sent. Nothing is told about a server error.
 func WriteHttpFailure(w http.ResponseWriter, code int, err error) {
+	failure := &Error{Code: statusErrorCode(code), Description: http.StatusText(code)}
+	if domainErr := apperrors.As(err); domainErr != nil {
+		failure = &Error{Code: domainErr.Code, Description: domainErr.Message, Fields: domainErr.Fields}
+	}
+	if code >= http.StatusInternalServerError {
+		failure = &Error{Code: statusErrorCode(http.StatusInternalServerError), Description: "internal server error"}
+	}
 	response := Response{
 		StatusCode: fmt.Sprint(code),
-		Error:      &Error{Description: err.Error()},
+		Error:      failure,
 	}
 	respondWithJSON(w, code, response)
 }
+
+// WriteHttpError - writes err with the status code of its kind, see ErrorStatus
+func WriteHttpError(w http.ResponseWriter, err error) {
+	WriteHttpFailure(w, ErrorStatus(err), err)
+}
+
+// ErrorStatus - status code of the kind of a domain error, 500 for any other error
+func ErrorStatus(err error) int {
+	switch apperrors.KindOf(err) {
+	case apperrors.KindNotFound:
+		return http.StatusNotFound
+	case apperrors.KindConflict:
+		return http.StatusConflict
+	case apperrors.KindUnauthorized:
+		return http.StatusUnauthorized
+	case apperrors.KindForbidden:
+		return http.StatusForbidden
+	case apperrors.KindValidation:
+		return http.StatusBadRequest
+	case apperrors.KindTooManyRequests:
+		return http.StatusTooManyRequests
+	case apperrors.KindTooLarge:
+		return http.StatusRequestEntityTooLarge
+	}
+	return http.StatusInternalServerError
+}
+
+// statusErrorCode - error code of a status, like not_found
+func statusErrorCode(code int) string {
+	if code == http.StatusInternalServerError {
+		return "internal_error"
+	}
+	return strings.ReplaceAll(strings.ToLower(http.StatusText(code)), " ", "_")
+}
@@ -2,7 +2,12 @@ package utils
 
 import (
 	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"notes-server/constants"
+	"strings"
 
 	"github.com/google/uuid"
 )
@@ -21,6 +26,69 @@ func GetEmailFromCtx(ctx context.Context) string {
 	return ""
 }
 
+func GetNameFromCtx(ctx context.Context) string {
+	if name, ok := ctx.Value(constants.NameCtxKey).(string); ok {
+		return name
+	}
+	return ""
+}
+
+func GetRoleFromCtx(ctx context.Context) string {
+	if role, ok := ctx.Value(constants.RoleCtxKey).(string); ok {
+		return role
+	}
+	return ""
+}
+
+// GetPasswordChangeRequiredFromCtx - whether the user of the request must change their password first
+func GetPasswordChangeRequiredFromCtx(ctx context.Context) bool {
+	required, _ := ctx.Value(constants.PasswordChangeRequiredCtxKey).(bool)
+	return required
+}
+
+// GetWorkspaceFromCtx - workspace the request operates in, 0 when unknown
+func GetWorkspaceFromCtx(ctx context.Context) int32 {
+	workspace, _ := ctx.Value(constants.WorkspaceCtxKey).(int32)
+	return workspace
+}
+
+// GetClientIPFromCtx - address of the client, empty when unknown
+func GetClientIPFromCtx(ctx context.Context) string {
+	if ip, ok := ctx.Value(constants.ClientIPCtxKey).(string); ok {
+		return ip
+	}
+	return ""
+}
+
+// GetUserAgentFromCtx - User-Agent of the client, empty when unknown
+func GetUserAgentFromCtx(ctx context.Context) string {
+	if userAgent, ok := ctx.Value(constants.UserAgentCtxKey).(string); ok {
+		return userAgent
+	}
+	return ""
+}
+
+// NormalizeEmail - the form the email addresses are stored and compared in, addresses differing only by case
+// or surrounding spaces are the same account
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// NewToken - returns a random URL safe token of 256 bits
+func NewToken() (string, error) {
+	token := make([]byte, 32)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(token), nil
+}
+
+// HashToken - tokens are stored hashed so that a leaked table can not be used to authenticate
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
 func NewID() int32 {
 	u, _ := uuid.NewRandom()
 	return int32(u.ID())
@@ -0,0 +1,96 @@
+package validation
+
+import (
+	"reflect"
+	"strconv"
+	"time"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/fr"
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator"
+)
+
+// messages - the messages of the rules by language, {0} is the field and {1} the parameter of the rule. The
+// rules on lengths and bounds have a message per kind of value, keyed rule-string, rule-items and rule-number,
+// and the rules comparing to now one keyed rule-time. A rule without a message gets the invalid message.
+var messages = map[string]map[string]string{
+	"en": {
+		"invalid":              "{0} is invalid",
+		"required":             "{0} is required",
+		"required_without_all": "{0} is required",
+		"email":                "{0} must be a valid email address",
+		"oneof":                "{0} must be one of {1}",
+		"password":             "{0} must be at most " + strconv.Itoa(PasswordMaxLength) + " characters long and not only spaces",
+		"max-string":           "{0} must be at most {1} characters long",
+		"max-items":            "{0} must have at most {1} items",
+		"max-number":           "{0} must be {1} or less",
+		"min-string":           "{0} must be at least {1} characters long",
+		"min-items":            "{0} must have at least {1} items",
+		"min-number":           "{0} must be {1} or more",
+		"gt-time":              "{0} must be in the future",
+		"gt-number":            "{0} must be greater than {1}",
+	},
+	"fr": {
+		"invalid":              "{0} n'est pas valide",
+		"required":             "{0} est obligatoire",
+		"required_without_all": "{0} est obligatoire",
+		"email":                "{0} doit être une adresse e-mail valide",
+		"oneof":                "{0} doit être l'une des valeurs {1}",
+		"password":             "{0} doit contenir au plus " + strconv.Itoa(PasswordMaxLength) + " caractères, pas seulement des espaces",
+		"max-string":           "{0} doit contenir au plus {1} caractères",
+		"max-items":            "{0} doit contenir au plus {1} éléments",
+		"max-number":           "{0} doit être inférieur ou égal à {1}",
+		"min-string":           "{0} doit contenir au moins {1} caractères",
+		"min-items":            "{0} doit contenir au moins {1} éléments",
+		"min-number":           "{0} doit être supérieur ou égal à {1}",
+		"gt-time":              "{0} doit être dans le futur",
+		"gt-number":            "{0} doit être supérieur à {1}",
+	},
+}
+
+var translators = newTranslators()
+
+// newTranslators - English is used when the client accepts none of the languages of messages
+func newTranslators() *ut.UniversalTranslator {
+	translators := ut.New(en.New(), en.New(), fr.New())
+	for language, texts := range messages {
+		trans, _ := translators.GetTranslator(language)
+		for key, text := range texts {
+			if err := trans.Add(key, text, false); err != nil {
+				panic(err)
+			}
+		}
+	}
+	return translators
+}
+
+func findTranslator(languages []string) ut.Translator {
+	trans, _ := translators.FindTranslator(languages...)
+	return trans
+}
+
+// message - the message of the rule a field failed
+func message(trans ut.Translator, fieldErr validator.FieldError) string {
+	for _, key := range []string{fieldErr.Tag() + "-" + valueKind(fieldErr), fieldErr.Tag()} {
+		if text, err := trans.T(key, fieldErr.Field(), fieldErr.Param()); err == nil {
+			return text
+		}
+	}
+	text, _ := trans.T("invalid", fieldErr.Field())
+	return text
+}
+
+// valueKind - the kind of value a rule on lengths and bounds applies to
+func valueKind(fieldErr validator.FieldError) string {
+	if fieldErr.Type() == reflect.TypeOf(time.Time{}) {
+		return "time"
+	}
+	switch fieldErr.Kind() {
+	case reflect.String:
+		return "string"
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return "items"
+	}
+	return "number"
+}
@@ -0,0 +1,88 @@
+// Package validation - checks the requests against the rules declared in their validate tags. The fields at
+// fault are reported by their JSON names, with a message in the language the client accepts.
+package validation
+
+import (
+	"errors"
+	"notes-server/apperrors"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-playground/validator"
+)
+
+// PasswordMaxLength - characters of the longest password accepted by the password rule. The minimum is up to the
+// password policy, see passwords.Policy.
+const PasswordMaxLength = 128
+
+// ErrInvalidRequest - fields of the request failed validation, they are listed in the details of the error
+var ErrInvalidRequest = apperrors.Validation("invalid_request", "invalid request")
+
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+	v := validator.New()
+	v.RegisterTagNameFunc(jsonName)
+	// the rules only fail on values that can be told from the request, so registering them can not fail
+	_ = v.RegisterValidation("password", isPassword)
+	return v
+}
+
+// jsonName - the name of a field in the JSON body, fields left out of it keep their Go name
+func jsonName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+// isPassword - the password rule, a password of at most PasswordMaxLength characters that is not only spaces
+func isPassword(fl validator.FieldLevel) bool {
+	password := fl.Field().String()
+	return utf8.RuneCountInString(password) <= PasswordMaxLength && strings.TrimSpace(password) != ""
+}
+
+// Struct - validates data, the messages of the fields at fault are in the first of languages that is supported
+func Struct(data interface{}, languages ...string) error {
+	err := validate.Struct(data)
+	var fieldErrs validator.ValidationErrors
+	if !errors.As(err, &fieldErrs) {
+		return err
+	}
+	trans := findTranslator(languages)
+	fields := make([]apperrors.FieldError, 0, len(fieldErrs))
+	for _, fieldErr := range fieldErrs {
+		fields = append(fields, apperrors.FieldError{
+			Field:   fieldPath(fieldErr),
+			Rule:    fieldErr.Tag(),
+			Message: message(trans, fieldErr),
+		})
+	}
+	return ErrInvalidRequest.WithFields(fields...).Wrap(err)
+}
+
+// fieldPath - the JSON path of the field from the root of the request, like items[0].text
+func fieldPath(fieldErr validator.FieldError) string {
+	parts := strings.SplitN(fieldErr.Namespace(), ".", 2)
+	return parts[len(parts)-1]
+}
+
+// Languages - the languages of an Accept-Language header in order, a regional language is followed by its base
+// language
+func Languages(acceptLanguage string) []string {
+	var languages []string
+	for _, entry := range strings.Split(acceptLanguage, ",") {
+		language := strings.TrimSpace(strings.SplitN(entry, ";", 2)[0])
+		if language == "" || language == "*" {
+			continue
+		}
+		language = strings.ReplaceAll(language, "-", "_")
+		languages = append(languages, language)
+		if base := strings.SplitN(language, "_", 2)[0]; base != language {
+			languages = append(languages, base)
+		}
+	}
+	return languages
+}
@@ -0,0 +1,117 @@
+package validation
+
+import (
+	"errors"
+	"notes-server/apperrors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type testItem struct {
+	Text string `json:"text" validate:"required,max=5"`
+}
+
+type testRequest struct {
+	Email    string     `json:"email" validate:"required,email"`
+	Password string     `json:"password" validate:"omitempty,password"`
+	Tags     []string   `json:"tags" validate:"max=1"`
+	Items    []testItem `json:"items" validate:"dive"`
+	Untagged string     `validate:"required"`
+}
+
+func TestStruct(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   testRequest
+		languages []string
+		want      []apperrors.FieldError
+	}{
+		{
+			name:    "valid request",
+			request: testRequest{Email: "test@gmail.com", Password: "testpassword", Untagged: "x"},
+		},
+		{
+			name:    "fields by their JSON names",
+			request: testRequest{Email: "test", Tags: []string{"a", "b"}},
+			want: []apperrors.FieldError{
+				{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+				{Field: "tags", Rule: "max", Message: "tags must have at most 1 items"},
+				{Field: "Untagged", Rule: "required", Message: "Untagged is required"},
+			},
+		},
+		{
+			name:    "nested fields by their path",
+			request: testRequest{Email: "test@gmail.com", Items: []testItem{{Text: "ok"}, {Text: "too long"}}, Untagged: "x"},
+			want: []apperrors.FieldError{
+				{Field: "items[1].text", Rule: "max", Message: "text must be at most 5 characters long"},
+			},
+		},
+		{
+			name:    "password rule",
+			request: testRequest{Email: "test@gmail.com", Password: "        ", Untagged: "x"},
+			want: []apperrors.FieldError{
+				{Field: "password", Rule: "password", Message: "password must be at most 128 characters long and not only spaces"},
+			},
+		},
+		{
+			name:      "messages in the accepted language",
+			request:   testRequest{Email: "test@gmail.com", Password: strings.Repeat("a", 129), Untagged: "x"},
+			languages: []string{"fr_CA", "fr"},
+			want: []apperrors.FieldError{
+				{Field: "password", Rule: "password", Message: "password doit contenir au plus 128 caractères, pas seulement des espaces"},
+			},
+		},
+		{
+			name:      "English when no language is supported",
+			request:   testRequest{Email: "", Untagged: "x"},
+			languages: []string{"de"},
+			want: []apperrors.FieldError{
+				{Field: "email", Rule: "required", Message: "email is required"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Struct(tt.request, tt.languages...)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Struct() error = %v", err)
+				}
+				return
+			}
+			if !errors.Is(err, ErrInvalidRequest) {
+				t.Fatalf("Struct() error = %v, want %v", err, ErrInvalidRequest)
+			}
+			if got := apperrors.As(err).Fields; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Struct() fields = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMessages(t *testing.T) {
+	for language, texts := range messages {
+		for key := range messages["en"] {
+			if _, ok := texts[key]; !ok {
+				t.Errorf("%s has no message for %s", language, key)
+			}
+		}
+		for key, text := range texts {
+			if !strings.Contains(text, "{0}") {
+				t.Errorf("%s message for %s does not name the field", language, key)
+			}
+		}
+	}
+}
+
+func TestLanguages(t *testing.T) {
+	got := Languages("fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5")
+	want := []string{"fr_CH", "fr", "fr", "en"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Languages() = %v, want %v", got, want)
+	}
+	if got := Languages(""); len(got) != 0 {
+		t.Errorf("Languages() = %v, want none", got)
+	}
+}